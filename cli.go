@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/tructn/racket/internal/di"
+	"github.com/tructn/racket/internal/domain"
+	"github.com/tructn/racket/internal/dto"
+	"github.com/tructn/racket/internal/service"
+	"github.com/tructn/racket/pkg/scopes"
+	"gorm.io/gorm"
+)
+
+// runCommand runs the command line tools, usage:
+//
+//	racket import-bookings -file bookings.ics [-commit] [-timezone Europe/London] [-tenant default] [-allow-closed] [-allow-conflicts]
+func runCommand(args []string) error {
+	switch args[0] {
+	case "import-bookings":
+		return importBookings(args[1:])
+	default:
+		return fmt.Errorf("unknown command %s", args[0])
+	}
+}
+
+func importBookings(args []string) error {
+	cmd := flag.NewFlagSet("import-bookings", flag.ExitOnError)
+	file := cmd.String("file", "", "ICS or CSV file exported by the sport center")
+	commit := cmd.Bool("commit", false, "create the matches, otherwise only preview them")
+	timezone := cmd.String("timezone", "", "timezone of the booking times without offset, e.g. Europe/London")
+	costPerSection := cmd.Float64("cost-per-section", 0, "cost per section of new sport centers")
+	minutePerSection := cmd.Uint("minute-per-section", 0, "minutes per section of new sport centers")
+	tenantSlug := cmd.String("tenant", domain.DefaultTenantSlug, "slug of the club or group the matches belong to")
+	allowClosed := cmd.Bool("allow-closed", false, "import bookings outside opening hours or on closed dates")
+	allowConflicts := cmd.Bool("allow-conflicts", false, "import bookings of courts already booked by other matches")
+	cmd.Parse(args)
+
+	if *file == "" {
+		cmd.Usage()
+		return fmt.Errorf("file is mandatory")
+	}
+
+	f, err := os.Open(*file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	opts := dto.BookingImportOptions{
+		Timezone:                *timezone,
+		DefaultCostPerSection:   *costPerSection,
+		DefaultMinutePerSection: *minutePerSection,
+		AllowClosed:             *allowClosed,
+		AllowConflicts:          *allowConflicts,
+	}
+
+	var res *dto.BookingImportResultDto
+	err = di.Register().Invoke(func(db *gorm.DB, svc *service.BookingImportService) error {
+		tenant := domain.Tenant{}
+		if err := db.Where("slug = ?", *tenantSlug).First(&tenant).Error; err != nil {
+			return fmt.Errorf("tenant %s: %w", *tenantSlug, err)
+		}
+
+		var err error
+		svc = svc.WithContext(scopes.WithTenant(context.Background(), tenant.ID))
+		if *commit {
+			res, err = svc.Import(*file, f, opts)
+		} else {
+			res, err = svc.Preview(*file, f, opts)
+		}
+		return err
+	})
+
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "REFERENCE\tSPORT CENTER\tCOURT\tSTART\tEND\tCOST\tSTATUS")
+	for _, item := range res.Items {
+		status := "new"
+		switch {
+		case item.ValidationErrorMsg != "":
+			status = "invalid: " + item.ValidationErrorMsg
+		case item.IsDuplicate:
+			status = "duplicate"
+		case item.CreatedMatchId != nil:
+			status = fmt.Sprintf("created #%d", *item.CreatedMatchId)
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%.2f\t%s\n",
+			item.Reference,
+			item.SportCenterName,
+			item.Court,
+			item.Start.Format("02/01/2006 15:04"),
+			item.End.Format("15:04"),
+			item.Cost,
+			status,
+		)
+	}
+	w.Flush()
+
+	fmt.Printf("\n%d new, %d duplicate, %d invalid, total cost %.2f\n", res.NewCount, res.DuplicateCount, res.InvalidCount, res.TotalCost)
+	if len(res.NewSportCenters) > 0 {
+		fmt.Printf("New sport centers: %v\n", res.NewSportCenters)
+	}
+	if !res.Committed {
+		fmt.Println("Preview only, run again with -commit to create the matches")
+	}
+
+	return nil
+}
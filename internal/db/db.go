@@ -1,12 +1,14 @@
 package db
 
 import (
+	"context"
 	"log"
 	"os"
 	"sync"
 	"time"
 
 	"github.com/tructn/racket/internal/domain"
+	"github.com/tructn/racket/pkg/scopes"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
@@ -16,6 +18,62 @@ var (
 	db   *gorm.DB
 )
 
+var models = []any{
+	&domain.Tenant{},
+	&domain.Player{},
+	&domain.Match{},
+	&domain.Registration{},
+	&domain.AdditionalCost{},
+	&domain.SportCenter{},
+	&domain.Settings{},
+	&domain.Activity{},
+	&domain.ShareCode{},
+	&domain.Team{},
+	&domain.TeamMember{},
+	&domain.Wallet{},
+	&domain.CalendarFeed{},
+	&domain.CourtBooking{},
+	&domain.PricingRule{},
+	&domain.SportCenterPrice{},
+	&domain.OpeningHours{},
+	&domain.Closure{},
+	&domain.Notification{},
+	&domain.LateCancellationFee{},
+	&domain.Guest{},
+	&domain.Rotation{},
+	&domain.RotationRound{},
+	&domain.RotationGame{},
+	&domain.RotationSitOut{},
+	&domain.Game{},
+	&domain.GamePlayer{},
+	&domain.GameSet{},
+	&domain.GameRevision{},
+	&domain.PlayerRating{},
+	&domain.RatingChange{},
+	&domain.Tournament{},
+	&domain.TournamentEntry{},
+	&domain.TournamentEntryPlayer{},
+	&domain.BracketMatch{},
+	&domain.Season{},
+	&domain.League{},
+	&domain.Fixture{},
+	&domain.Ladder{},
+	&domain.LadderRung{},
+	&domain.LadderChallenge{},
+	&domain.LadderPenalty{},
+	&domain.Poll{},
+	&domain.PollSlot{},
+	&domain.PollResponse{},
+	&domain.MatchInvitation{},
+	&domain.StandingRegistration{},
+	&domain.BallotDraw{},
+}
+
+// Migrate creates and updates the tables of the domain models
+func Migrate(db *gorm.DB) error {
+	return db.AutoMigrate(models...)
+}
+
 func NewDatabase() *gorm.DB {
 
 	once.Do(func() {
@@ -31,19 +89,29 @@ func NewDatabase() *gorm.DB {
 			log.Fatalln(err)
 		}
 
-		dbCtx.AutoMigrate(
-			&domain.Player{},
-			&domain.Match{},
-			&domain.Registration{},
-			&domain.AdditionalCost{},
-			&domain.SportCenter{},
-			&domain.Settings{},
-			&domain.Activity{},
-			&domain.ShareCode{},
-			&domain.Team{},
-			&domain.TeamMember{},
-			&domain.Wallet{},
-		)
+		if err := scopes.RegisterTenantScope(dbCtx); err != nil {
+			log.Fatalln(err)
+		}
+
+		// Migrations move the data of every tenant
+		migrationDb := dbCtx.WithContext(scopes.AllTenants(context.Background()))
+		Migrate(migrationDb)
+
+		if err := migrateTenants(migrationDb); err != nil {
+			log.Fatalln(err)
+		}
+
+		if err := migrateCourtBookings(migrationDb); err != nil {
+			log.Fatalln(err)
+		}
+
+		if err := migrateMatchStates(migrationDb); err != nil {
+			log.Fatalln(err)
+		}
+
+		if err := migrateInvitations(migrationDb); err != nil {
+			log.Fatalln(err)
+		}
 
 		db = dbCtx.Debug()
 	})
@@ -0,0 +1,72 @@
+// Package dbtest opens an in-memory database with the schema of the domain models for tests
+package dbtest
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	gosqlite "github.com/glebarez/go-sqlite"
+	"github.com/glebarez/sqlite"
+	"github.com/tructn/racket/internal/db"
+	"github.com/tructn/racket/pkg/scopes"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// The raw queries of the services are written for Postgres
+func init() {
+	gosqlite.MustRegisterScalarFunction("concat", -1, func(_ *gosqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+		var sb strings.Builder
+		for _, arg := range args {
+			if arg != nil {
+				sb.WriteString(fmt.Sprint(arg))
+			}
+		}
+		return sb.String(), nil
+	})
+
+	gosqlite.MustRegisterScalarFunction("now", 0, func(_ *gosqlite.FunctionContext, _ []driver.Value) (driver.Value, error) {
+		return time.Now().UTC().Format("2006-01-02 15:04:05.999999999-07:00"), nil
+	})
+}
+
+// Open returns an empty migrated database with the tenant scope registered, it is closed when the test ends.
+// Like in production, tenant entities can only be read within WithTenant or AllTenants.
+func Open(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	conn, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Every connection of an in-memory database is a new database
+	sqlDB, err := conn.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	if err := scopes.RegisterTenantScope(conn); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := db.Migrate(conn.WithContext(scopes.AllTenants(context.Background()))); err != nil {
+		t.Fatal(err)
+	}
+
+	return conn
+}
+
+// TenantId is the tenant of the database returned by OpenTenant
+const TenantId uint = 1
+
+// OpenTenant returns an empty migrated database scoped to TenantId, the rows created with it belong to the tenant
+func OpenTenant(t *testing.T) *gorm.DB {
+	return Open(t).WithContext(scopes.WithTenant(context.Background(), TenantId))
+}
@@ -0,0 +1,6 @@
+package db
+
+var (
+	MigrateTenants       = migrateTenants
+	MigrateCourtBookings = migrateCourtBookings
+)
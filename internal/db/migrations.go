@@ -0,0 +1,116 @@
+package db
+
+import (
+	"log"
+
+	"github.com/tructn/racket/internal/domain"
+	"gorm.io/gorm"
+)
+
+// migrateCourtBookings converts the free text court of existing matches into court bookings,
+// it only touches matches without bookings so it is safe to run on every start
+func migrateCourtBookings(db *gorm.DB) error {
+	var matches []domain.Match
+	if err := db.Unscoped().
+		Where("NOT EXISTS (SELECT 1 FROM court_bookings cb WHERE cb.match_id = matches.id)").
+		Find(&matches).Error; err != nil {
+		return err
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, m := range matches {
+			bookings := m.LegacyCourtBookings()
+			if err := tx.Create(&bookings).Error; err != nil {
+				return err
+			}
+		}
+
+		if len(matches) > 0 {
+			log.Printf("migrated court bookings of %d matches", len(matches))
+		}
+
+		// Legacy bookings carry a split of the match cost and no rate, price changes must not touch them
+		return tx.Exec(`
+			UPDATE court_bookings SET price_source = ?
+			WHERE price_source = ? AND minute_per_section = 0
+		`, domain.PriceSourceLegacy, domain.PriceSourceRules).Error
+	})
+}
+
+// migrateMatchStates gives a state to matches created before the lifecycle existed.
+// Deleted matches are restored as cancelled so that they stay in history.
+func migrateMatchStates(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(`
+			UPDATE matches SET state = ?, deleted_at = NULL
+			WHERE (state IS NULL OR state = '') AND deleted_at IS NOT NULL
+		`, domain.MatchCancelled).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Exec(`
+			UPDATE matches SET state = ?
+			WHERE (state IS NULL OR state = '') AND "end" < NOW()
+		`, domain.MatchPlayed).Error; err != nil {
+			return err
+		}
+
+		return tx.Exec(`
+			UPDATE matches SET state = ?
+			WHERE state IS NULL OR state = ''
+		`, domain.MatchOpen).Error
+	})
+}
+
+// migrateInvitations adds the match invitation of the players organizers listed as invited before invitations existed,
+// the invited registrations are kept and it only adds missing invitations so it is safe to run on every start
+func migrateInvitations(db *gorm.DB) error {
+	res := db.Exec(`
+		INSERT INTO match_invitations (tenant_id, created_by_id, created_at, updated_at, match_id, player_id)
+		SELECT r.tenant_id, r.created_by_id, r.created_at, NOW(), r.match_id, r.player_id
+		FROM registrations r
+		WHERE r.rsvp = 'invited' AND r.deleted_at IS NULL
+		AND NOT EXISTS (
+			SELECT 1 FROM match_invitations mi
+			WHERE mi.match_id = r.match_id AND mi.player_id = r.player_id AND mi.deleted_at IS NULL
+		)
+	`)
+	if res.Error != nil {
+		return res.Error
+	}
+
+	if res.RowsAffected > 0 {
+		log.Printf("added %d match invitations for invited registrations", res.RowsAffected)
+	}
+	return nil
+}
+
+// migrateTenants creates the default tenant and moves the data created before tenants existed to it,
+// it only touches rows without a tenant so it is safe to run on every start
+func migrateTenants(db *gorm.DB) error {
+	tenant := domain.Tenant{}
+	if err := db.
+		Where(domain.Tenant{Slug: domain.DefaultTenantSlug}).
+		Attrs(domain.Tenant{Name: "Default"}).
+		FirstOrCreate(&tenant).Error; err != nil {
+		return err
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, model := range models {
+			if !tx.Migrator().HasColumn(model, "tenant_id") {
+				continue
+			}
+
+			res := tx.Unscoped().Model(model).Where("tenant_id IS NULL OR tenant_id = 0").UpdateColumn("tenant_id", tenant.ID)
+			if res.Error != nil {
+				return res.Error
+			}
+
+			if res.RowsAffected > 0 {
+				log.Printf("migrated %d rows of %T to the default tenant", res.RowsAffected, model)
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,44 @@
+package db_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tructn/racket/internal/db"
+	"github.com/tructn/racket/internal/db/dbtest"
+	"github.com/tructn/racket/internal/domain"
+	"github.com/tructn/racket/pkg/scopes"
+)
+
+func TestMigratedCourtBookingsBelongToTheTenantOfTheMatch(t *testing.T) {
+	conn := dbtest.Open(t).WithContext(scopes.AllTenants(context.Background()))
+
+	// A match created before tenants and court bookings existed
+	start := time.Date(2024, 5, 1, 19, 0, 0, 0, time.UTC)
+	require.NoError(t, conn.Exec(`INSERT INTO matches (start, "end", court, cost, state) VALUES (?, ?, ?, ?, ?)`,
+		start, start.Add(2*time.Hour), "1 & 2", 30, domain.MatchOpen).Error)
+
+	require.NoError(t, db.MigrateTenants(conn))
+	require.NoError(t, db.MigrateCourtBookings(conn))
+
+	tenant := domain.Tenant{}
+	require.NoError(t, conn.Where("slug = ?", domain.DefaultTenantSlug).First(&tenant).Error)
+
+	var bookings []domain.CourtBooking
+	require.NoError(t, conn.Find(&bookings).Error)
+	require.Len(t, bookings, 2)
+	for _, b := range bookings {
+		assert.Equal(t, tenant.ID, b.TenantId)
+		// Established sessions keep having no player limit
+		assert.Zero(t, b.Capacity)
+	}
+
+	// The tenant scope still finds the courts of the match
+	ctx := scopes.WithTenant(context.Background(), tenant.ID)
+	match := domain.Match{}
+	require.NoError(t, conn.WithContext(ctx).Preload("CourtBookings").First(&match).Error)
+	assert.Len(t, match.CourtBookings, 2)
+}
@@ -30,6 +30,20 @@ func Register() *dig.Container {
 	c.Provide(handler.NewUserHandler)
 	c.Provide(handler.NewMeHandler)
 	c.Provide(handler.NewAnonymousHandler)
+	c.Provide(handler.NewCalendarHandler)
+	c.Provide(handler.NewBookingImportHandler)
+	c.Provide(handler.NewAttendanceHandler)
+	c.Provide(handler.NewRotationHandler)
+	c.Provide(handler.NewGameHandler)
+	c.Provide(handler.NewRatingHandler)
+	c.Provide(handler.NewTeamSplitHandler)
+	c.Provide(handler.NewTournamentHandler)
+	c.Provide(handler.NewLeagueHandler)
+	c.Provide(handler.NewLadderHandler)
+	c.Provide(handler.NewPollHandler)
+	c.Provide(handler.NewStandingRegistrationHandler)
+	c.Provide(handler.NewBallotHandler)
+	c.Provide(handler.NewTenantHandler)
 
 	// Services
 	c.Provide(service.NewSportCenterService)
@@ -42,6 +56,21 @@ func Register() *dig.Container {
 	c.Provide(service.NewUserService)
 	c.Provide(service.NewRegistrationService)
 	c.Provide(service.NewMeService)
+	c.Provide(service.NewCalendarService)
+	c.Provide(service.NewBookingImportService)
+	c.Provide(service.NewAttendanceService)
+	c.Provide(service.NewRotationService)
+	c.Provide(service.NewGameService)
+	c.Provide(service.NewRatingService)
+	c.Provide(service.NewTeamSplitService)
+	c.Provide(service.NewTournamentService)
+	c.Provide(service.NewLeagueService)
+	c.Provide(service.NewLadderService)
+	c.Provide(service.NewPollService)
+	c.Provide(service.NewStandingRegistrationService)
+	c.Provide(service.NewBallotService)
+	c.Provide(service.NewSchedulerService)
+	c.Provide(service.NewTenantService)
 
 	// Features
 	c.Provide(wallet.NewWalletHandler)
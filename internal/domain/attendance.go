@@ -0,0 +1,158 @@
+package domain
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"time"
+)
+
+type Attendance = string
+
+const (
+	AttendanceUnknown  Attendance = ""
+	AttendanceAttended Attendance = "attended"
+	AttendanceLate     Attendance = "late"
+	AttendanceNoShow   Attendance = "no_show"
+)
+
+const (
+	// CheckInCodePeriod is how often the check-in QR code rotates
+	CheckInCodePeriod = time.Minute
+	// CheckInGracePeriod is how long after the start a player is still on time
+	CheckInGracePeriod = 10 * time.Minute
+	// CheckInOpensBefore is how early players can check in before the start
+	CheckInOpensBefore = 30 * time.Minute
+
+	LowWaitlistPriority = -1
+)
+
+var (
+	ErrCheckInClosed      = errors.New("check-in is not open for this match")
+	ErrInvalidCheckInCode = errors.New("check-in code is invalid or expired")
+)
+
+// NoShowPolicy lowers the waitlist priority of players with repeated no-shows, a zero threshold disables it
+type NoShowPolicy struct {
+	Threshold    uint
+	LookbackDays uint
+}
+
+func ParseAttendance(value string) (Attendance, error) {
+	switch value {
+	case AttendanceUnknown, AttendanceAttended, AttendanceLate, AttendanceNoShow:
+		return value, nil
+	default:
+		return "", fmt.Errorf("unknown attendance %s", value)
+	}
+}
+
+// MarkAttendance is used by the organizer from the roster, no-shows still share the cost
+func (r *Registration) MarkAttendance(attendance Attendance, now time.Time) {
+	r.Attendance = attendance
+	if attendance == AttendanceNoShow || attendance == AttendanceUnknown {
+		r.CheckedInAt = nil
+		return
+	}
+
+	if r.CheckedInAt == nil {
+		r.CheckedInAt = &now
+	}
+}
+
+// CheckIn records the player arrival, arriving after the grace period is late
+func (r *Registration) CheckIn(m *Match, now time.Time) error {
+	if !m.IsCheckInOpen(now) {
+		return ErrCheckInClosed
+	}
+
+	if r.CheckedInAt != nil {
+		return nil
+	}
+
+	r.CheckedInAt = &now
+	if now.After(m.Start.Add(CheckInGracePeriod)) {
+		r.Attendance = AttendanceLate
+	} else {
+		r.Attendance = AttendanceAttended
+	}
+	return nil
+}
+
+func (m *Match) IsCheckInOpen(now time.Time) bool {
+	if m.IsCancelled() || m.GetState() == MatchFinalized {
+		return false
+	}
+	return !now.Before(m.Start.Add(-CheckInOpensBefore)) && now.Before(m.End)
+}
+
+// EnsureCheckInSecret creates the secret the rotating check-in codes are derived from
+func (m *Match) EnsureCheckInSecret(generator Generator) error {
+	if m.CheckInSecret != "" {
+		return nil
+	}
+
+	secret, err := generator.Gen(32)
+	if err != nil {
+		return err
+	}
+	m.CheckInSecret = secret
+	return nil
+}
+
+// CheckInCode is the code shown as a QR code at the venue, it changes every CheckInCodePeriod
+func (m *Match) CheckInCode(at time.Time) (string, time.Time) {
+	window := at.Unix() / int64(CheckInCodePeriod.Seconds())
+	expiresAt := time.Unix((window+1)*int64(CheckInCodePeriod.Seconds()), 0).UTC()
+	return m.checkInCodeFor(window), expiresAt
+}
+
+// VerifyCheckInCode accepts the current and the previous code, a scan can straddle the rotation
+func (m *Match) VerifyCheckInCode(code string, at time.Time) bool {
+	if m.CheckInSecret == "" {
+		return false
+	}
+
+	window := at.Unix() / int64(CheckInCodePeriod.Seconds())
+	for _, w := range []int64{window, window - 1} {
+		if hmac.Equal([]byte(code), []byte(m.checkInCodeFor(w))) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *Match) checkInCodeFor(window int64) string {
+	mac := hmac.New(sha256.New, []byte(m.CheckInSecret))
+	fmt.Fprintf(mac, "%d:%d", m.ID, window)
+	return base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(mac.Sum(nil))[:10]
+}
+
+func (p NoShowPolicy) IsEnabled() bool {
+	return p.Threshold > 0
+}
+
+// WaitlistPriority lowers the priority of players who reached the no-show threshold
+func (p NoShowPolicy) WaitlistPriority(noShows int) int {
+	if p.IsEnabled() && noShows >= int(p.Threshold) {
+		return LowWaitlistPriority
+	}
+	return 0
+}
+
+func (p NoShowPolicy) Since(now time.Time) time.Time {
+	lookback := p.LookbackDays
+	if lookback == 0 {
+		lookback = 90
+	}
+	return now.AddDate(0, 0, -int(lookback))
+}
+
+func (s *Settings) GetNoShowPolicy() NoShowPolicy {
+	return NoShowPolicy{
+		Threshold:    s.NoShowThreshold,
+		LookbackDays: s.NoShowLookbackDays,
+	}
+}
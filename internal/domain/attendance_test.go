@@ -0,0 +1,87 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckIn(t *testing.T) {
+	start := time.Date(2024, 5, 1, 18, 0, 0, 0, time.UTC)
+	m := NewMatch(start, start.Add(2*time.Hour), 1, NewFlatPricing(10, 60), "1", nil)
+
+	tests := []struct {
+		name       string
+		now        time.Time
+		attendance Attendance
+		err        error
+	}{
+		{"too early", start.Add(-time.Hour), AttendanceUnknown, ErrCheckInClosed},
+		{"before the start", start.Add(-10 * time.Minute), AttendanceAttended, nil},
+		{"within the grace period", start.Add(CheckInGracePeriod), AttendanceAttended, nil},
+		{"after the grace period", start.Add(30 * time.Minute), AttendanceLate, nil},
+		{"after the end", start.Add(2 * time.Hour), AttendanceUnknown, ErrCheckInClosed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewRegistration(1, m.ID)
+			err := r.CheckIn(m, tt.now)
+			assert.Equal(t, tt.err, err)
+			assert.Equal(t, tt.attendance, r.Attendance)
+		})
+	}
+}
+
+func TestCheckInRefusesCancelledMatch(t *testing.T) {
+	start := time.Date(2024, 5, 1, 18, 0, 0, 0, time.UTC)
+	m := NewMatch(start, start.Add(2*time.Hour), 1, NewFlatPricing(10, 60), "1", nil)
+	assert.Nil(t, m.Cancel("rain"))
+
+	r := NewRegistration(1, m.ID)
+	assert.Equal(t, ErrCheckInClosed, r.CheckIn(m, start))
+}
+
+func TestMarkAttendance(t *testing.T) {
+	now := time.Date(2024, 5, 1, 18, 0, 0, 0, time.UTC)
+	r := NewRegistration(1, 1)
+
+	r.MarkAttendance(AttendanceAttended, now)
+	assert.Equal(t, &now, r.CheckedInAt)
+
+	r.MarkAttendance(AttendanceNoShow, now)
+	assert.Equal(t, AttendanceNoShow, r.Attendance)
+	assert.Nil(t, r.CheckedInAt)
+}
+
+func TestVerifyCheckInCode(t *testing.T) {
+	now := time.Date(2024, 5, 1, 18, 0, 30, 0, time.UTC)
+	m := &Match{CheckInSecret: "secret"}
+	m.ID = 1
+
+	code, expiresAt := m.CheckInCode(now)
+	assert.Len(t, code, 10)
+	assert.Equal(t, time.Date(2024, 5, 1, 18, 1, 0, 0, time.UTC), expiresAt)
+
+	assert.True(t, m.VerifyCheckInCode(code, now))
+	assert.True(t, m.VerifyCheckInCode(code, now.Add(CheckInCodePeriod)))
+	assert.False(t, m.VerifyCheckInCode(code, now.Add(2*CheckInCodePeriod)))
+	assert.False(t, m.VerifyCheckInCode("ABCDEFGHIJ", now))
+
+	other := &Match{CheckInSecret: "secret"}
+	other.ID = 2
+	assert.False(t, other.VerifyCheckInCode(code, now))
+}
+
+func TestNoShowPolicy(t *testing.T) {
+	assert.Equal(t, 0, NoShowPolicy{}.WaitlistPriority(10))
+
+	policy := NoShowPolicy{Threshold: 2}
+	assert.Equal(t, 0, policy.WaitlistPriority(1))
+	assert.Equal(t, LowWaitlistPriority, policy.WaitlistPriority(2))
+
+	now := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, now.AddDate(0, 0, -90), policy.Since(now))
+	assert.Equal(t, now.AddDate(0, 0, -30), NoShowPolicy{Threshold: 2, LookbackDays: 30}.Since(now))
+}
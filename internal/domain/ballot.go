@@ -0,0 +1,203 @@
+package domain
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"math"
+	mathrand "math/rand"
+	"sort"
+	"time"
+
+	"github.com/samber/lo"
+)
+
+// UnlimitedSeats is drawn for matches without a known capacity, every entry wins
+const UnlimitedSeats = -1
+
+const (
+	// BallotRecentDays is how far back sessions played lower the chance of a player
+	BallotRecentDays = 28
+	memberWeight     = 2.0
+	guestWeight      = 1.0
+)
+
+var (
+	ErrNoBallot      = errors.New("match has no ballot")
+	ErrBallotDrawn   = errors.New("ballot is already drawn")
+	ErrBallotNotDue  = errors.New("ballot can not be drawn before the draw time")
+	ErrBallotTooLate = errors.New("ballot must be drawn before the match starts and registration closes")
+)
+
+// BallotDraw records the seed and the inputs of a draw so that anyone can replay it
+type BallotDraw struct {
+	BaseModel
+	MatchId uint          `gorm:"uniqueIndex" json:"matchId"`
+	Seed    int64         `json:"seed"`
+	Seats   int           `json:"seats"`
+	DrawnAt time.Time     `json:"drawnAt"`
+	Entries []BallotEntry `gorm:"serializer:json" json:"entries"`
+}
+
+// BallotEntry holds the weighting inputs of a registration and, once drawn, its outcome.
+// Members are players with an account, guests were added by an organizer without one.
+type BallotEntry struct {
+	RegistrationId uint    `json:"registrationId"`
+	PlayerId       uint    `json:"playerId"`
+	Seats          uint    `json:"seats"`
+	IsMember       bool    `json:"isMember"`
+	RecentSessions int     `json:"recentSessions"`
+	Reliability    float64 `json:"reliability"`
+	Weight         float64 `json:"weight"`
+	Position       int     `json:"position"`
+	Won            bool    `json:"won"`
+}
+
+func NewBallotSeed() (int64, error) {
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return 0, err
+	}
+	return int64(binary.BigEndian.Uint64(b[:]) & math.MaxInt64), nil
+}
+
+func NewBallotEntry(registration Registration, isMember bool, recentSessions int, reliability float64) BallotEntry {
+	return BallotEntry{
+		RegistrationId: registration.ID,
+		PlayerId:       registration.PlayerId,
+		Seats:          registration.TotalPlayerPaidFor,
+		IsMember:       isMember,
+		RecentSessions: recentSessions,
+		Reliability:    reliability,
+	}
+}
+
+// CalcWeight favours members, players with fewer recent sessions and reliable players
+func (e BallotEntry) CalcWeight() float64 {
+	weight := lo.Ternary(e.IsMember, memberWeight, guestWeight)
+	weight /= float64(1 + max(e.RecentSessions, 0))
+	return weight * (0.5 + 0.5*math.Min(math.Max(e.Reliability, 0), 1))
+}
+
+// DrawBallot orders the entries with a weighted lottery seeded by seed, then confirms them in that order
+// while seats remain. The outcome only depends on the seed and the entry inputs so it can be replayed.
+func DrawBallot(seed int64, seats int, entries []BallotEntry) []BallotEntry {
+	drawn := append([]BallotEntry{}, entries...)
+	sort.SliceStable(drawn, func(i, j int) bool { return drawn[i].RegistrationId < drawn[j].RegistrationId })
+
+	rng := mathrand.New(mathrand.NewSource(seed))
+	keys := map[uint]float64{}
+	for i := range drawn {
+		drawn[i].Weight = drawn[i].CalcWeight()
+		keys[drawn[i].RegistrationId] = math.Pow(rng.Float64(), 1/drawn[i].Weight)
+	}
+
+	sort.SliceStable(drawn, func(i, j int) bool { return keys[drawn[i].RegistrationId] > keys[drawn[j].RegistrationId] })
+
+	remaining := seats
+	for i := range drawn {
+		drawn[i].Position = i + 1
+		drawn[i].Won = seats == UnlimitedSeats || int(drawn[i].Seats) <= remaining
+		if drawn[i].Won && seats != UnlimitedSeats {
+			remaining -= int(drawn[i].Seats)
+		}
+	}
+	return drawn
+}
+
+// Verify replays the draw from the stored seed and inputs
+func (d *BallotDraw) Verify() bool {
+	replay := DrawBallot(d.Seed, d.Seats, d.Entries)
+	return lo.EveryBy(lo.Zip2(d.Entries, replay), func(t lo.Tuple2[BallotEntry, BallotEntry]) bool {
+		return t.A.RegistrationId == t.B.RegistrationId && t.A.Position == t.B.Position && t.A.Won == t.B.Won
+	})
+}
+
+// SetBallot collects registrations until the draw time instead of confirming them first-come-first-served,
+// a nil draw time turns the ballot off
+func (m *Match) SetBallot(drawAt *time.Time) error {
+	if m.BallotDrawnAt != nil {
+		return ErrBallotDrawn
+	}
+
+	if drawAt != nil && (!drawAt.Before(m.Start) || (m.RegistrationClosesAt != nil && drawAt.After(*m.RegistrationClosesAt))) {
+		return ErrBallotTooLate
+	}
+
+	m.BallotDrawAt = drawAt
+	return nil
+}
+
+// UpdateBallot sets the draw time of an edited match, a drawn ballot only fails when its draw time changes
+func (m *Match) UpdateBallot(drawAt *time.Time) error {
+	unchanged := (drawAt == nil && m.BallotDrawAt == nil) ||
+		(drawAt != nil && m.BallotDrawAt != nil && drawAt.Equal(*m.BallotDrawAt))
+	if unchanged && m.BallotDrawnAt != nil {
+		return nil
+	}
+
+	return m.SetBallot(drawAt)
+}
+
+func (m *Match) IsBallotPending() bool {
+	return m.BallotDrawAt != nil && m.BallotDrawnAt == nil
+}
+
+func (m *Match) IsBallotDue(now time.Time) bool {
+	return m.IsBallotPending() && !now.Before(*m.BallotDrawAt)
+}
+
+// ShouldWaitlist tells whether a new registration waits, registrations wait for the draw while the ballot is pending
+func (m *Match) ShouldWaitlist(count uint) bool {
+	return m.IsBallotPending() || !m.HasFreeSpot(count)
+}
+
+// IsDrawn tells whether the registration took part in a ballot draw
+func (reg *Registration) IsDrawn() bool {
+	return reg.BallotPosition > 0
+}
+
+// GetBallotEntrants are the registrations waiting for the draw
+func (m *Match) GetBallotEntrants() []Registration {
+	return lo.Filter(m.Registrations, func(r Registration, _ int) bool { return r.IsGoing() && r.IsWaitlisted })
+}
+
+// DrawBallot runs the lottery over the entrants for the seats left, winners are confirmed
+// and the others stay on the waitlist in draw order
+func (m *Match) DrawBallot(seed int64, entries []BallotEntry, now time.Time) (*BallotDraw, error) {
+	if m.BallotDrawAt == nil {
+		return nil, ErrNoBallot
+	}
+
+	if m.BallotDrawnAt != nil {
+		return nil, ErrBallotDrawn
+	}
+
+	if !m.IsBallotDue(now) {
+		return nil, ErrBallotNotDue
+	}
+
+	seats := UnlimitedSeats
+	if capacity := m.CalcCapacity(); capacity > 0 {
+		seats = max(capacity-m.CalcPlayerCount(), 0)
+	}
+
+	drawn := DrawBallot(seed, seats, entries)
+	outcomes := lo.KeyBy(drawn, func(e BallotEntry) uint { return e.RegistrationId })
+	for i := range m.Registrations {
+		reg := &m.Registrations[i]
+		if outcome, ok := outcomes[reg.ID]; ok {
+			reg.IsWaitlisted = !outcome.Won
+			reg.BallotPosition = outcome.Position
+		}
+	}
+
+	m.BallotDrawnAt = &now
+	return &BallotDraw{
+		MatchId: m.ID,
+		Seed:    seed,
+		Seats:   seats,
+		DrawnAt: now,
+		Entries: drawn,
+	}, nil
+}
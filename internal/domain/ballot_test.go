@@ -0,0 +1,171 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+)
+
+func newBallotMatch(drawAt time.Time, capacity uint) *Match {
+	m := &Match{
+		BaseModel:     BaseModel{ID: 1},
+		State:         MatchOpen,
+		Start:         drawAt.Add(48 * time.Hour),
+		CourtBookings: []CourtBooking{{Capacity: capacity}},
+	}
+	m.SetBallot(&drawAt)
+	return m
+}
+
+func TestBallotEntryWeight(t *testing.T) {
+	member := BallotEntry{IsMember: true, Reliability: 1}
+	guest := BallotEntry{Reliability: 1}
+	regular := BallotEntry{IsMember: true, RecentSessions: 3, Reliability: 1}
+	unreliable := BallotEntry{IsMember: true, Reliability: 0}
+
+	assert.Greater(t, member.CalcWeight(), guest.CalcWeight())
+	assert.Greater(t, member.CalcWeight(), regular.CalcWeight())
+	assert.Greater(t, member.CalcWeight(), unreliable.CalcWeight())
+	assert.Greater(t, unreliable.CalcWeight(), 0.0)
+}
+
+func TestDrawBallotIsReproducible(t *testing.T) {
+	entries := lo.Map([]uint{1, 2, 3, 4, 5, 6}, func(id uint, _ int) BallotEntry {
+		return BallotEntry{RegistrationId: id, PlayerId: id, Seats: 1, IsMember: id%2 == 0, RecentSessions: int(id % 3), Reliability: 1}
+	})
+
+	first := DrawBallot(42, 3, entries)
+	// The input order does not matter, only the seed and the inputs
+	second := DrawBallot(42, 3, lo.Reverse(append([]BallotEntry{}, entries...)))
+	assert.Equal(t, first, second)
+	assert.Equal(t, 3, lo.CountBy(first, func(e BallotEntry) bool { return e.Won }))
+	assert.Equal(t, []int{1, 2, 3, 4, 5, 6}, lo.Map(first, func(e BallotEntry, _ int) int { return e.Position }))
+
+	draw := &BallotDraw{Seed: 42, Seats: 3, Entries: first}
+	assert.True(t, draw.Verify())
+
+	draw.Entries[0].Won, draw.Entries[len(first)-1].Won = false, true
+	assert.False(t, draw.Verify())
+}
+
+func TestDrawBallotSeats(t *testing.T) {
+	entries := []BallotEntry{
+		{RegistrationId: 1, Seats: 2, Reliability: 1},
+		{RegistrationId: 2, Seats: 2, Reliability: 1},
+		{RegistrationId: 3, Seats: 1, Reliability: 1},
+	}
+
+	// An entry bringing guests only wins when all of its seats fit
+	drawn := DrawBallot(7, 3, entries)
+	assert.Equal(t, 3, int(lo.SumBy(drawn, func(e BallotEntry) uint { return lo.Ternary(e.Won, e.Seats, 0) })))
+
+	drawn = DrawBallot(7, UnlimitedSeats, entries)
+	assert.True(t, lo.EveryBy(drawn, func(e BallotEntry) bool { return e.Won }))
+}
+
+func TestSetBallot(t *testing.T) {
+	drawAt := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	m := &Match{Start: drawAt.Add(time.Hour)}
+	assert.Nil(t, m.SetBallot(&drawAt))
+
+	late := m.Start
+	assert.ErrorIs(t, m.SetBallot(&late), ErrBallotTooLate)
+
+	closesAt := drawAt.Add(-time.Minute)
+	m.RegistrationClosesAt = &closesAt
+	assert.ErrorIs(t, m.SetBallot(&drawAt), ErrBallotTooLate)
+
+	m.BallotDrawnAt = &drawAt
+	assert.ErrorIs(t, m.SetBallot(nil), ErrBallotDrawn)
+}
+
+func TestUpdateBallot(t *testing.T) {
+	drawAt := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	m := &Match{Start: drawAt.Add(time.Hour)}
+	assert.Nil(t, m.UpdateBallot(&drawAt))
+
+	later := drawAt.Add(30 * time.Minute)
+	assert.Nil(t, m.UpdateBallot(&later))
+	assert.Equal(t, later, *m.BallotDrawAt)
+
+	// Editing a match after its draw keeps the draw time
+	m.BallotDrawnAt = &later
+	same := later
+	assert.Nil(t, m.UpdateBallot(&same))
+	assert.ErrorIs(t, m.UpdateBallot(&drawAt), ErrBallotDrawn)
+	assert.ErrorIs(t, m.UpdateBallot(nil), ErrBallotDrawn)
+}
+
+func TestMatchDrawBallot(t *testing.T) {
+	drawAt := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	m := newBallotMatch(drawAt, 2)
+	m.Registrations = []Registration{
+		{BaseModel: BaseModel{ID: 1}, PlayerId: 1, TotalPlayerPaidFor: 1, Rsvp: RsvpGoing},
+		{BaseModel: BaseModel{ID: 2}, PlayerId: 2, TotalPlayerPaidFor: 1, Rsvp: RsvpGoing, IsWaitlisted: true},
+		{BaseModel: BaseModel{ID: 3}, PlayerId: 3, TotalPlayerPaidFor: 1, Rsvp: RsvpGoing, IsWaitlisted: true},
+		{BaseModel: BaseModel{ID: 4}, PlayerId: 4, TotalPlayerPaidFor: 1, Rsvp: RsvpMaybe},
+	}
+
+	// Registrations wait for the draw and nobody is promoted before it
+	assert.True(t, m.ShouldWaitlist(1))
+	assert.Empty(t, m.PromoteWaitlist())
+	assert.Len(t, m.GetBallotEntrants(), 2)
+
+	entries := lo.Map(m.GetBallotEntrants(), func(r Registration, _ int) BallotEntry { return NewBallotEntry(r, true, 0, 1) })
+	_, err := m.DrawBallot(1, entries, drawAt.Add(-time.Minute))
+	assert.ErrorIs(t, err, ErrBallotNotDue)
+
+	draw, err := m.DrawBallot(1, entries, drawAt)
+	assert.Nil(t, err)
+
+	// One seat is left next to the player confirmed by an organizer
+	assert.Equal(t, 1, draw.Seats)
+	assert.Equal(t, 2, m.CalcPlayerCount())
+	loser, _ := lo.Find(draw.Entries, func(e BallotEntry) bool { return !e.Won })
+	reg, _ := lo.Find(m.Registrations, func(r Registration) bool { return r.ID == loser.RegistrationId })
+	assert.True(t, reg.IsWaitlisted)
+	assert.Equal(t, loser.Position, reg.BallotPosition)
+
+	assert.False(t, m.IsBallotPending())
+	_, err = m.DrawBallot(1, entries, drawAt)
+	assert.ErrorIs(t, err, ErrBallotDrawn)
+}
+
+func TestBallotLosersArePromotedInDrawOrder(t *testing.T) {
+	drawAt := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	m := newBallotMatch(drawAt, 1)
+	m.Registrations = []Registration{
+		{BaseModel: BaseModel{ID: 1}, PlayerId: 1, TotalPlayerPaidFor: 1, Rsvp: RsvpGoing, IsWaitlisted: true},
+		{BaseModel: BaseModel{ID: 2}, PlayerId: 2, TotalPlayerPaidFor: 1, Rsvp: RsvpGoing, IsWaitlisted: true, WaitlistPriority: LowWaitlistPriority},
+		{BaseModel: BaseModel{ID: 3}, PlayerId: 3, TotalPlayerPaidFor: 1, Rsvp: RsvpGoing, IsWaitlisted: true},
+	}
+
+	entries := lo.Map(m.GetBallotEntrants(), func(r Registration, _ int) BallotEntry { return NewBallotEntry(r, true, 0, 1) })
+	draw, err := m.DrawBallot(1, entries, drawAt)
+	assert.Nil(t, err)
+
+	losers := lo.Filter(draw.Entries, func(e BallotEntry, _ int) bool { return !e.Won })
+	assert.Len(t, losers, 2)
+
+	// A player registering after the draw waits behind the ballot losers
+	latecomer := NewRegistration(4, m.ID)
+	latecomer.ID = 4
+	latecomer.IsWaitlisted = m.ShouldWaitlist(1)
+	assert.True(t, latecomer.IsWaitlisted)
+	m.Registrations = append(m.Registrations, *latecomer)
+
+	// Spots free up one at a time, the loser with a no-show penalty still goes before the latecomer
+	for _, loser := range losers {
+		m.Registrations = lo.Reject(m.Registrations, func(r Registration, _ int) bool { return r.IsConfirmed() })
+		promoted := m.PromoteWaitlist()
+		assert.Len(t, promoted, 1)
+		assert.Equal(t, loser.RegistrationId, promoted[0].ID)
+	}
+
+	m.Registrations = lo.Reject(m.Registrations, func(r Registration, _ int) bool { return r.IsConfirmed() })
+	promoted := m.PromoteWaitlist()
+	assert.Len(t, promoted, 1)
+	assert.Equal(t, latecomer.ID, promoted[0].ID)
+}
@@ -10,6 +10,7 @@ type (
 	BaseModel struct {
 		*gorm.Model
 		ID          uint           `gorm:"primarykey" json:"id"`
+		TenantId    uint           `json:"tenantId" gorm:"index"`
 		CreatedByID string         `json:"createdById" gorm:"index"`
 		UpdatedByID *string        `json:"updatedById" gorm:"index"`
 		CreatedAt   time.Time      `json:"createdAt" gorm:"autoCreateTime"`
@@ -22,6 +23,9 @@ func (b *BaseModel) BeforeCreate(tx *gorm.DB) (err error) {
 	if userId, ok := tx.Statement.Context.Value("user_id").(string); ok {
 		b.CreatedByID = userId
 	}
+	if tenantId, ok := tx.Statement.Context.Value(TenantContextKey).(uint); ok && b.TenantId == 0 {
+		b.TenantId = tenantId
+	}
 	return
 }
 
@@ -0,0 +1,82 @@
+package domain
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/samber/lo"
+)
+
+var courtPattern = regexp.MustCompile(`(?i)\bcourts?\s*(?:no\.?|number|#|:)?\s*((?:[0-9]+|[a-z])\b(?:\s*(?:&|and|,|-)\s*[0-9]+\b)*)`)
+
+// Booking is a court booking read from a sport center confirmation (ICS attachment, CSV export).
+// It is not persisted, it becomes a Match once imported.
+type Booking struct {
+	Reference string    `json:"reference"`
+	Venue     string    `json:"venue"`
+	Address   string    `json:"address"`
+	Court     string    `json:"court"`
+	Start     time.Time `json:"start"`
+	End       time.Time `json:"end"`
+}
+
+func NewBooking(reference, venue, address, court string, start, end time.Time) (*Booking, error) {
+	venue = strings.TrimSpace(venue)
+	if len(venue) == 0 {
+		return nil, errors.New("venue is mandatory")
+	}
+
+	if start.IsZero() || end.IsZero() {
+		return nil, errors.New("start and end are mandatory")
+	}
+
+	if !end.After(start) {
+		return nil, errors.New("end must be after start")
+	}
+
+	if err := CheckBookingDuration(start, end); err != nil {
+		return nil, err
+	}
+
+	return &Booking{
+		Reference: reference,
+		Venue:     venue,
+		Address:   strings.TrimSpace(address),
+		Court:     strings.TrimSpace(court),
+		Start:     start.UTC(),
+		End:       end.UTC(),
+	}, nil
+}
+
+// ExtractCourt finds the court in free text such as "Badminton - Court 3" or "Courts 1 & 2"
+func ExtractCourt(texts ...string) string {
+	for _, text := range texts {
+		if m := courtPattern.FindStringSubmatch(text); m != nil {
+			return strings.TrimSpace(m[1])
+		}
+	}
+	return ""
+}
+
+// NormalizeName is used to match imported venue names with existing sport centers
+func NormalizeName(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
+
+func (sc *SportCenter) IsNamed(name string) bool {
+	return NormalizeName(sc.Name) == NormalizeName(name)
+}
+
+// IsSameBooking tells whether the match already covers the booking, bookings are identified by time and the set of courts
+// so that "Court 1" matches "1" and "1, 2" matches "1 & 2"
+func (m *Match) IsSameBooking(sportCenterId uint, start, end time.Time, court string) bool {
+	if m.SportCenterId != sportCenterId || !m.Start.Equal(start) || !m.End.Equal(end) {
+		return false
+	}
+
+	courts := lo.Uniq(lo.FlatMap(m.courtSlots(), func(b CourtBooking, _ int) []string { return ParseCourts(b.Court) }))
+	booked := ParseCourts(court)
+	return len(courts) == len(booked) && len(lo.Intersect(courts, booked)) == len(booked)
+}
@@ -0,0 +1,80 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtractCourt(t *testing.T) {
+	tests := []struct {
+		name     string
+		texts    []string
+		expected string
+	}{
+		{name: "Court with number", texts: []string{"Badminton - Court 3"}, expected: "3"},
+		{name: "Court with letter", texts: []string{"Court: A"}, expected: "A"},
+		{name: "Multiple courts", texts: []string{"Courts 1 & 2"}, expected: "1 & 2"},
+		{name: "Court number", texts: []string{"Court No. 5, Main hall"}, expected: "5"},
+		{name: "Falls back to next text", texts: []string{"Badminton booking", "court 4"}, expected: "4"},
+		{name: "No court", texts: []string{"Court booking confirmed"}, expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, ExtractCourt(tt.texts...))
+		})
+	}
+}
+
+func TestNewBookingRequiresValidTimes(t *testing.T) {
+	start := time.Date(2024, 5, 1, 19, 0, 0, 0, time.UTC)
+
+	_, err := NewBooking("", "Venue", "", "1", start, start)
+	assert.Error(t, err)
+
+	_, err = NewBooking("", " ", "", "1", start, start.Add(time.Hour))
+	assert.Error(t, err)
+
+	b, err := NewBooking("ref", " Venue ", "", " 1 ", start, start.Add(time.Hour))
+	assert.Nil(t, err)
+	assert.Equal(t, "Venue", b.Venue)
+	assert.Equal(t, "1", b.Court)
+}
+
+func TestIsSameBooking(t *testing.T) {
+	start := time.Date(2024, 5, 1, 19, 0, 0, 0, time.UTC)
+	m := Match{SportCenterId: 1, Start: start, End: start.Add(2 * time.Hour), Court: "Court 1"}
+
+	assert.True(t, m.IsSameBooking(1, start, start.Add(2*time.Hour), "court  1"))
+	assert.False(t, m.IsSameBooking(2, start, start.Add(2*time.Hour), "Court 1"))
+	assert.False(t, m.IsSameBooking(1, start, start.Add(time.Hour), "Court 1"))
+	assert.False(t, m.IsSameBooking(1, start, start.Add(2*time.Hour), "Court 2"))
+}
+
+func TestIsSameBookingAsImportedCourts(t *testing.T) {
+	start := time.Date(2024, 5, 1, 19, 0, 0, 0, time.UTC)
+	end := start.Add(2 * time.Hour)
+	pricing := Pricing{DefaultCostPerSection: 10, MinutePerSection: 60}
+
+	tests := []struct {
+		name     string
+		court    string
+		imported string
+		expected bool
+	}{
+		{name: "Court name and number", court: "Court 1", imported: ExtractCourt("Badminton - Court 1"), expected: true},
+		{name: "Several courts", court: "1, 2", imported: ExtractCourt("Courts 1 & 2"), expected: true},
+		{name: "Courts in another order", court: "2 & 1", imported: "1 and 2", expected: true},
+		{name: "Fewer courts", court: "1, 2", imported: "1", expected: false},
+		{name: "Other court", court: "Court 1", imported: "2", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMatch(start, end, 1, pricing, tt.court, nil)
+			assert.Equal(t, tt.expected, m.IsSameBooking(1, start, end, tt.imported))
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package domain
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/samber/lo"
+)
+
+var (
+	csvDateTimeLayouts = []string{
+		time.RFC3339,
+		"2006-01-02 15:04:05",
+		"2006-01-02 15:04",
+		"2006-01-02T15:04",
+		"02/01/2006 15:04",
+		"02/01/2006 15:04:05",
+	}
+	csvDateLayouts = []string{"2006-01-02", "02/01/2006"}
+	csvTimeLayouts = []string{"15:04", "15:04:05", "3:04 PM", "3:04PM"}
+)
+
+// ParsedBooking is an event or a row of a booking file, Err tells why it is not a valid booking
+type ParsedBooking struct {
+	Reference string
+	Booking   *Booking
+	Err       error
+}
+
+// ParseCsvBookings reads exports with a header row, columns are matched by name case insensitively.
+// Start/end can be full date times, or a date column combined with start/end time columns.
+func ParseCsvBookings(content []byte, loc *time.Location) ([]ParsedBooking, error) {
+	reader := csv.NewReader(bytes.NewReader(content))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rows) == 0 {
+		return []ParsedBooking{}, nil
+	}
+
+	header := lo.Map(rows[0], func(h string, _ int) string { return NormalizeName(h) })
+	col := func(names ...string) int {
+		for _, name := range names {
+			if idx := lo.IndexOf(header, name); idx >= 0 {
+				return idx
+			}
+		}
+		return -1
+	}
+
+	venueCol := col("venue", "sport center", "sport centre", "sportcenter", "facility", "centre", "center")
+	addressCol := col("address", "location")
+	courtCol := col("court", "resource", "activity")
+	refCol := col("reference", "booking reference", "booking ref", "booking id", "id")
+	dateCol := col("date", "booking date")
+	startCol := col("start", "start time", "from")
+	endCol := col("end", "end time", "to")
+
+	if venueCol < 0 || startCol < 0 || endCol < 0 {
+		return nil, errors.New("csv must have venue, start and end columns")
+	}
+
+	get := func(row []string, idx int) string {
+		if idx < 0 || idx >= len(row) {
+			return ""
+		}
+		return strings.TrimSpace(row[idx])
+	}
+
+	result := []ParsedBooking{}
+	for i, row := range rows[1:] {
+		reference := get(row, refCol)
+		if reference == "" {
+			reference = fmt.Sprintf("row %d", i+2)
+		}
+
+		start, startErr := parseCsvTime(get(row, dateCol), get(row, startCol), loc)
+		end, endErr := parseCsvTime(get(row, dateCol), get(row, endCol), loc)
+		if err := errors.Join(startErr, endErr); err != nil {
+			result = append(result, ParsedBooking{Reference: reference, Err: err})
+			continue
+		}
+
+		court := get(row, courtCol)
+		if extracted := ExtractCourt(court); extracted != "" {
+			court = extracted
+		}
+
+		booking, err := NewBooking(reference, get(row, venueCol), get(row, addressCol), court, start, end)
+		result = append(result, ParsedBooking{Reference: reference, Booking: booking, Err: err})
+	}
+
+	return result, nil
+}
+
+func parseCsvTime(date, value string, loc *time.Location) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, errors.New("missing time")
+	}
+
+	if date == "" {
+		for _, layout := range csvDateTimeLayouts {
+			if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+				return t, nil
+			}
+		}
+		return time.Time{}, fmt.Errorf("unrecognized date time %q", value)
+	}
+
+	for _, dateLayout := range csvDateLayouts {
+		for _, timeLayout := range csvTimeLayouts {
+			if t, err := time.ParseInLocation(dateLayout+" "+timeLayout, date+" "+value, loc); err == nil {
+				return t, nil
+			}
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("unrecognized date %q and time %q", date, value)
+}
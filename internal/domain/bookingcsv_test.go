@@ -0,0 +1,66 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCsvBookings(t *testing.T) {
+	london, _ := time.LoadLocation("Europe/London")
+	start := time.Date(2024, 5, 1, 19, 0, 0, 0, london)
+
+	tests := []struct {
+		name      string
+		content   string
+		reference string
+		court     string
+	}{
+		{
+			name:      "Date time columns",
+			content:   "Venue,Court,Start,End,Reference\nSports Hall,Court 3,2024-05-01 19:00,2024-05-01 21:00,B1\n",
+			reference: "B1",
+			court:     "3",
+		},
+		{
+			name:      "Date and time columns",
+			content:   "Sport Centre,Resource,Booking Date,Start Time,End Time\nSports Hall,Courts 1 & 2,01/05/2024,7:00 PM,9:00 PM\n",
+			reference: "row 2",
+			court:     "1 & 2",
+		},
+		{
+			name:      "Header case and spacing",
+			content:   "  FACILITY ,Booking  Ref,From,To\nSports Hall,B2,01/05/2024 19:00,01/05/2024 21:00\n",
+			reference: "B2",
+			court:     "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bookings, err := ParseCsvBookings([]byte(tt.content), london)
+			assert.Nil(t, err)
+			assert.Len(t, bookings, 1)
+			assert.Nil(t, bookings[0].Err)
+			assert.Equal(t, tt.reference, bookings[0].Reference)
+			assert.Equal(t, "Sports Hall", bookings[0].Booking.Venue)
+			assert.Equal(t, tt.court, bookings[0].Booking.Court)
+			assert.True(t, start.Equal(bookings[0].Booking.Start))
+			assert.True(t, start.Add(2*time.Hour).Equal(bookings[0].Booking.End))
+		})
+	}
+}
+
+func TestParseCsvBookingsReportsInvalidRows(t *testing.T) {
+	content := "Venue,Start,End\nSports Hall,yesterday,2024-05-01 21:00\nSports Hall,2024-05-01 21:00,2024-05-01 19:00\n"
+	bookings, err := ParseCsvBookings([]byte(content), time.UTC)
+	assert.Nil(t, err)
+	assert.Len(t, bookings, 2)
+	assert.Equal(t, "row 2", bookings[0].Reference)
+	assert.Error(t, bookings[0].Err)
+	assert.Error(t, bookings[1].Err)
+
+	_, err = ParseCsvBookings([]byte("Venue,Court\nSports Hall,1\n"), time.UTC)
+	assert.Error(t, err)
+}
@@ -0,0 +1,612 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"math/bits"
+	"sort"
+	"time"
+
+	"github.com/samber/lo"
+)
+
+type BracketStage = string
+
+const (
+	StageGroup      BracketStage = "group"
+	StageWinners    BracketStage = "winners"
+	StageLosers     BracketStage = "losers"
+	StageGrandFinal BracketStage = "grand_final"
+
+	grandFinalCode = "GF"
+)
+
+var (
+	ErrBracketMatchNotFound = errors.New("bracket match not found")
+	ErrBracketMatchNotReady = errors.New("both entries of the bracket match are not known yet")
+	ErrBracketMatchPlayed   = errors.New("bracket match already has a result")
+	ErrNotEnoughSlots       = errors.New("not enough court slots in the sessions to schedule the bracket")
+)
+
+// BracketMatch is a match of the draw, the code (e.g. W2-1, L3-0, G1-4, GF) tells where the winner
+// and, in double elimination, the loser go next. A bye is finished without being played.
+type BracketMatch struct {
+	BaseModel
+	TournamentId  uint         `gorm:"index" json:"tournamentId"`
+	Code          string       `json:"code"`
+	Stage         BracketStage `json:"stage"`
+	Group         int          `json:"group"`
+	Round         int          `json:"round"`
+	Position      int          `json:"position"`
+	EntryAId      *uint        `json:"entryAId"`
+	EntryBId      *uint        `json:"entryBId"`
+	WinnerId      *uint        `json:"winnerId"`
+	IsFinished    bool         `json:"isFinished"`
+	IsBye         bool         `json:"isBye"`
+	Sets          [][2]int     `gorm:"serializer:json" json:"sets"`
+	NextCode      string       `json:"nextCode"`
+	NextSlot      GameSide     `json:"nextSlot"`
+	LoserNextCode string       `json:"loserNextCode"`
+	LoserNextSlot GameSide     `json:"loserNextSlot"`
+	SessionId     *uint        `gorm:"index" json:"sessionId"`
+	Court         string       `json:"court"`
+	StartAt       *time.Time   `json:"startAt"`
+}
+
+// GroupStanding is the record of an entry in its group, ranked by wins, then set and point difference, then seed
+type GroupStanding struct {
+	EntryId       uint `json:"entryId"`
+	Group         int  `json:"group"`
+	Rank          int  `json:"rank"`
+	Played        int  `json:"played"`
+	Won           int  `json:"won"`
+	SetsFor       int  `json:"setsFor"`
+	SetsAgainst   int  `json:"setsAgainst"`
+	PointsFor     int  `json:"pointsFor"`
+	PointsAgainst int  `json:"pointsAgainst"`
+}
+
+// BracketSlot is a court for the slot length of the tournament during one of its sessions
+type BracketSlot struct {
+	SessionId uint
+	Court     string
+	Start     time.Time
+}
+
+// GenerateBracket draws the bracket from the seeded entries, seed 1 and 2 can only meet in the final.
+// Byes go to the best seeds when the number of entries is not a power of two.
+func (t *Tournament) GenerateBracket() error {
+	if t.State != TournamentDraft {
+		return ErrTournamentStarted
+	}
+
+	needed := lo.Ternary(t.Format == FormatDoubleElimination, 3, 2)
+	if len(t.Entries) < needed {
+		return fmt.Errorf("at least %d entries are needed", needed)
+	}
+
+	entries := append([]TournamentEntry{}, t.Entries...)
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Seed < entries[j].Seed })
+	ids := lo.Map(entries, func(e TournamentEntry, _ int) uint { return e.ID })
+
+	switch t.Format {
+	case FormatGroupsKnockout:
+		matches, err := t.buildGroupStage(ids)
+		if err != nil {
+			return err
+		}
+		t.BracketMatches = matches
+	default:
+		t.BracketMatches = buildElimination(ids, t.Format == FormatDoubleElimination)
+	}
+
+	t.State = TournamentInProgress
+	t.resolveByes()
+	return nil
+}
+
+// RecordResult validates the score with the scoring format of the tournament and moves the winner on,
+// the loser drops to the losers bracket in double elimination. The knockout is drawn once every group match is played.
+func (t *Tournament) RecordResult(bracketMatchId uint, scores [][2]int) (*BracketMatch, error) {
+	if t.State == TournamentDraft {
+		return nil, ErrTournamentNotStarted
+	}
+
+	m, found := lo.Find(t.BracketMatches, func(m BracketMatch) bool { return m.ID == bracketMatchId })
+	if !found {
+		return nil, ErrBracketMatchNotFound
+	}
+
+	if m.IsFinished {
+		return nil, ErrBracketMatchPlayed
+	}
+
+	if m.EntryAId == nil || m.EntryBId == nil {
+		return nil, ErrBracketMatchNotReady
+	}
+
+	game := &Game{Format: t.Scoring}
+	if err := game.RecordScores(scores); err != nil {
+		return nil, err
+	}
+
+	match := t.findBracketMatch(m.Code)
+	match.Sets = scores
+	if game.Winner == SideA {
+		t.finishBracketMatch(match, m.EntryAId, m.EntryBId)
+	} else {
+		t.finishBracketMatch(match, m.EntryBId, m.EntryAId)
+	}
+	t.resolveByes()
+
+	if err := t.drawKnockout(); err != nil {
+		return nil, err
+	}
+
+	return t.findBracketMatch(m.Code), nil
+}
+
+// GroupStandings ranks the entries of every group, groups are numbered from 1
+func (t *Tournament) GroupStandings() []GroupStanding {
+	standings := map[uint]*GroupStanding{}
+	for _, e := range t.Entries {
+		if e.Group > 0 {
+			standings[e.ID] = &GroupStanding{EntryId: e.ID, Group: e.Group}
+		}
+	}
+
+	for _, m := range t.BracketMatches {
+		if m.Stage != StageGroup || !m.IsFinished {
+			continue
+		}
+
+		a, b := standings[*m.EntryAId], standings[*m.EntryBId]
+		if a == nil || b == nil {
+			continue
+		}
+
+		a.Played++
+		b.Played++
+		if *m.WinnerId == a.EntryId {
+			a.Won++
+		} else {
+			b.Won++
+		}
+
+		for _, s := range m.Sets {
+			a.PointsFor, a.PointsAgainst = a.PointsFor+s[0], a.PointsAgainst+s[1]
+			b.PointsFor, b.PointsAgainst = b.PointsFor+s[1], b.PointsAgainst+s[0]
+			if s[0] > s[1] {
+				a.SetsFor++
+				b.SetsAgainst++
+			} else {
+				b.SetsFor++
+				a.SetsAgainst++
+			}
+		}
+	}
+
+	result := lo.Map(lo.Values(standings), func(s *GroupStanding, _ int) GroupStanding { return *s })
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Group != result[j].Group {
+			return result[i].Group < result[j].Group
+		}
+		return t.ranksAbove(result[i], result[j])
+	})
+
+	for i := range result {
+		result[i].Rank = 1
+		if i > 0 && result[i].Group == result[i-1].Group {
+			result[i].Rank = result[i-1].Rank + 1
+		}
+	}
+	return result
+}
+
+// BuildSlots cuts every court booked for the sessions in slots, a session without bookings uses its courts for the whole session
+func (t *Tournament) BuildSlots() []BracketSlot {
+	duration := time.Duration(t.SlotMinutes) * time.Minute
+	slots := []BracketSlot{}
+	if duration <= 0 {
+		return slots
+	}
+
+	for _, s := range t.Sessions {
+		bookings := s.CourtBookings
+		if len(bookings) == 0 {
+			courts := lo.Ternary(len(SplitCourts(s.Court)) > 0, SplitCourts(s.Court), []string{""})
+			bookings = lo.Map(courts, func(c string, _ int) CourtBooking { return CourtBooking{Court: c, Start: s.Start, End: s.End} })
+		}
+
+		for _, b := range bookings {
+			for start := b.Start; !start.Add(duration).After(b.End); start = start.Add(duration) {
+				slots = append(slots, BracketSlot{SessionId: s.ID, Court: b.Court, Start: start})
+			}
+		}
+	}
+
+	sort.SliceStable(slots, func(i, j int) bool {
+		if !slots[i].Start.Equal(slots[j].Start) {
+			return slots[i].Start.Before(slots[j].Start)
+		}
+		return slots[i].Court < slots[j].Court
+	})
+	return slots
+}
+
+// Schedule puts every match still to be played in the earliest free slot once the matches feeding it
+// and the previous matches of its entries are over. Played matches keep their slot.
+func (t *Tournament) Schedule() error {
+	if t.State == TournamentDraft {
+		return ErrTournamentNotStarted
+	}
+
+	if t.SlotMinutes <= 0 {
+		return errors.New("slot length must be positive")
+	}
+
+	taken := map[string]bool{}
+	for i := range t.BracketMatches {
+		m := &t.BracketMatches[i]
+		if m.IsFinished && m.StartAt != nil {
+			taken[slotKey(*m.SessionId, m.Court, *m.StartAt)] = true
+			continue
+		}
+
+		if !m.IsFinished {
+			m.SessionId, m.Court, m.StartAt = nil, "", nil
+		}
+	}
+
+	slots := t.BuildSlots()
+	for _, code := range t.scheduleOrder() {
+		m := t.findBracketMatch(code)
+		readyAt := t.readyAt(m)
+		slot, found := lo.Find(slots, func(s BracketSlot) bool {
+			return !taken[slotKey(s.SessionId, s.Court, s.Start)] && !s.Start.Before(readyAt)
+		})
+		if !found {
+			return ErrNotEnoughSlots
+		}
+
+		taken[slotKey(slot.SessionId, slot.Court, slot.Start)] = true
+		m.SessionId, m.Court, m.StartAt = &slot.SessionId, slot.Court, &slot.Start
+	}
+	return nil
+}
+
+// buildElimination lays out the winners bracket and, for double elimination, the losers bracket and the grand final.
+// Losers of a winners round after the first drop in reverse order so that rematches come as late as possible.
+func buildElimination(ids []uint, double bool) []BracketMatch {
+	size := 2
+	for size < len(ids) {
+		size *= 2
+	}
+	rounds := bits.Len(uint(size)) - 1
+	order := seedOrder(size)
+
+	matches := []BracketMatch{}
+	for r := 1; r <= rounds; r++ {
+		count := size >> r
+		for p := 0; p < count; p++ {
+			m := BracketMatch{Code: bracketCode("W", r, p), Stage: StageWinners, Round: r, Position: p}
+			if r < rounds {
+				m.NextCode, m.NextSlot = bracketCode("W", r+1, p/2), slotOf(p)
+			} else if double {
+				m.NextCode, m.NextSlot = grandFinalCode, SideA
+			}
+
+			if r == 1 {
+				m.EntryAId, m.EntryBId = seededEntry(ids, order[2*p]), seededEntry(ids, order[2*p+1])
+			}
+
+			if double && r == 1 {
+				m.LoserNextCode, m.LoserNextSlot = bracketCode("L", 1, p/2), slotOf(p)
+			} else if double {
+				m.LoserNextCode, m.LoserNextSlot = bracketCode("L", 2*(r-1), count-1-p), SideB
+			}
+			matches = append(matches, m)
+		}
+	}
+
+	if !double {
+		return matches
+	}
+
+	losersRounds := 2 * (rounds - 1)
+	for r := 1; r <= losersRounds; r++ {
+		count := size >> ((r+1)/2 + 1)
+		for p := 0; p < count; p++ {
+			m := BracketMatch{Code: bracketCode("L", r, p), Stage: StageLosers, Round: r, Position: p}
+			switch {
+			case r == losersRounds:
+				m.NextCode, m.NextSlot = grandFinalCode, SideB
+			case r%2 == 1:
+				m.NextCode, m.NextSlot = bracketCode("L", r+1, p), SideA
+			default:
+				m.NextCode, m.NextSlot = bracketCode("L", r+1, p/2), slotOf(p)
+			}
+			matches = append(matches, m)
+		}
+	}
+
+	// A single grand final, the winners bracket champion does not get a second chance
+	return append(matches, BracketMatch{Code: grandFinalCode, Stage: StageGrandFinal, Round: 1})
+}
+
+// buildGroupStage snakes the seeds over the groups and plays a round robin in every group
+func (t *Tournament) buildGroupStage(ids []uint) ([]BracketMatch, error) {
+	groups := make([][]uint, t.GroupCount)
+	for i, id := range ids {
+		row, col := i/t.GroupCount, i%t.GroupCount
+		g := lo.Ternary(row%2 == 0, col, t.GroupCount-1-col)
+		groups[g] = append(groups[g], id)
+	}
+
+	for _, group := range groups {
+		if len(group) < max(2, t.QualifiersPerGroup) {
+			return nil, errors.New("every group needs at least two entries and more than the number of qualifiers")
+		}
+	}
+
+	matches := []BracketMatch{}
+	for g, group := range groups {
+		for _, id := range group {
+			t.findEntry(id).Group = g + 1
+		}
+
+		count := 0
+		for r, pairs := range roundRobin(group) {
+			for p, pair := range pairs {
+				a, b := pair[0], pair[1]
+				matches = append(matches, BracketMatch{
+					Code:     fmt.Sprintf("G%d-%d", g+1, count),
+					Stage:    StageGroup,
+					Group:    g + 1,
+					Round:    r + 1,
+					Position: p,
+					EntryAId: &a,
+					EntryBId: &b,
+				})
+				count++
+			}
+		}
+	}
+	return matches, nil
+}
+
+// drawKnockout seeds the qualifiers by their place in the group, then by their record, once every group match is played
+func (t *Tournament) drawKnockout() error {
+	if t.Format != FormatGroupsKnockout {
+		return nil
+	}
+
+	groupMatches := lo.Filter(t.BracketMatches, func(m BracketMatch, _ int) bool { return m.Stage == StageGroup })
+	if len(groupMatches) != len(t.BracketMatches) || !lo.EveryBy(groupMatches, func(m BracketMatch) bool { return m.IsFinished }) {
+		return nil
+	}
+
+	standings := t.GroupStandings()
+	qualifiers := []uint{}
+	for rank := 1; rank <= t.QualifiersPerGroup; rank++ {
+		placed := lo.Filter(standings, func(s GroupStanding, _ int) bool { return s.Rank == rank })
+		sort.SliceStable(placed, func(i, j int) bool { return t.ranksAbove(placed[i], placed[j]) })
+		qualifiers = append(qualifiers, lo.Map(placed, func(s GroupStanding, _ int) uint { return s.EntryId })...)
+	}
+
+	if len(qualifiers) < 2 {
+		return errors.New("at least two entries must qualify for the knockout")
+	}
+
+	t.BracketMatches = append(t.BracketMatches, buildElimination(qualifiers, false)...)
+	t.resolveByes()
+	return nil
+}
+
+func (t *Tournament) ranksAbove(a, b GroupStanding) bool {
+	if a.Won != b.Won {
+		return a.Won > b.Won
+	}
+	if diffA, diffB := a.SetsFor-a.SetsAgainst, b.SetsFor-b.SetsAgainst; diffA != diffB {
+		return diffA > diffB
+	}
+	if diffA, diffB := a.PointsFor-a.PointsAgainst, b.PointsFor-b.PointsAgainst; diffA != diffB {
+		return diffA > diffB
+	}
+	return t.findEntry(a.EntryId).Seed < t.findEntry(b.EntryId).Seed
+}
+
+func (t *Tournament) finishBracketMatch(m *BracketMatch, winner, loser *uint) {
+	m.IsFinished = true
+	m.WinnerId = winner
+	if winner != nil {
+		t.placeEntry(m.NextCode, m.NextSlot, *winner)
+	}
+	if loser != nil {
+		t.placeEntry(m.LoserNextCode, m.LoserNextSlot, *loser)
+	}
+
+	if m.NextCode == "" && m.Stage != StageGroup {
+		t.WinnerEntryId = winner
+		t.State = TournamentFinished
+	}
+}
+
+// resolveByes moves an entry on without playing when nobody can come to the other side of its match
+func (t *Tournament) resolveByes() {
+	for changed := true; changed; {
+		changed = false
+		for i := range t.BracketMatches {
+			m := &t.BracketMatches[i]
+			if m.IsFinished || m.Stage == StageGroup || (m.EntryAId != nil && m.EntryBId != nil) {
+				continue
+			}
+
+			if !t.isSlotSettled(m, SideA) || !t.isSlotSettled(m, SideB) {
+				continue
+			}
+
+			m.IsBye = true
+			t.finishBracketMatch(m, lo.Ternary(m.EntryAId != nil, m.EntryAId, m.EntryBId), nil)
+			changed = true
+		}
+	}
+}
+
+// isSlotSettled tells whether the entry of a side is known, or known to never come
+func (t *Tournament) isSlotSettled(m *BracketMatch, side GameSide) bool {
+	if lo.Ternary(side == SideA, m.EntryAId, m.EntryBId) != nil {
+		return true
+	}
+	return lo.EveryBy(t.feeders(m.Code, side), func(f BracketMatch) bool { return f.IsFinished })
+}
+
+// isPendingBye tells whether the match will be a bye whatever the pending results are
+func (t *Tournament) isPendingBye(m *BracketMatch) bool {
+	return (m.EntryAId == nil && t.isSlotSettled(m, SideA)) || (m.EntryBId == nil && t.isSlotSettled(m, SideB))
+}
+
+func (t *Tournament) feeders(code string, side GameSide) []BracketMatch {
+	return lo.Filter(t.BracketMatches, func(f BracketMatch, _ int) bool {
+		return (f.NextCode == code && f.NextSlot == side) || (f.LoserNextCode == code && f.LoserNextSlot == side)
+	})
+}
+
+func (t *Tournament) placeEntry(code string, slot GameSide, entryId uint) {
+	if m := t.findBracketMatch(code); m != nil {
+		if slot == SideA {
+			m.EntryAId = &entryId
+		} else {
+			m.EntryBId = &entryId
+		}
+	}
+}
+
+// scheduleOrder lists the matches to play, a match always comes after the matches feeding it
+func (t *Tournament) scheduleOrder() []string {
+	depths := map[string]int{}
+	var depth func(m BracketMatch) int
+	depth = func(m BracketMatch) int {
+		if d, found := depths[m.Code]; found {
+			return d
+		}
+		d := m.Round
+		if m.Stage != StageGroup {
+			feeders := append(t.feeders(m.Code, SideA), t.feeders(m.Code, SideB)...)
+			d = lo.Max(lo.Map(feeders, func(f BracketMatch, _ int) int { return depth(f) })) + 1
+		}
+		depths[m.Code] = d
+		return d
+	}
+
+	matches := lo.Filter(t.BracketMatches, func(m BracketMatch, _ int) bool { return !m.IsFinished && !t.isPendingBye(&m) })
+	sort.SliceStable(matches, func(i, j int) bool {
+		if di, dj := depth(matches[i]), depth(matches[j]); di != dj {
+			return di < dj
+		}
+		return matches[i].Stage == StageWinners && matches[j].Stage == StageLosers
+	})
+	return lo.Map(matches, func(m BracketMatch, _ int) string { return m.Code })
+}
+
+// readyAt is when the entries of the match are known and rested from their previous match,
+// a feeder which will be a bye is ready when its own feeders are
+func (t *Tournament) readyAt(m *BracketMatch) time.Time {
+	duration := time.Duration(t.SlotMinutes) * time.Minute
+	readyAt := time.Time{}
+	later := func(at time.Time) {
+		if at.After(readyAt) {
+			readyAt = at
+		}
+	}
+
+	for _, f := range append(t.feeders(m.Code, SideA), t.feeders(m.Code, SideB)...) {
+		if f.StartAt != nil {
+			later(f.StartAt.Add(duration))
+		} else if !f.IsFinished {
+			later(t.readyAt(&f))
+		}
+	}
+
+	entries := lo.Compact([]uint{lo.FromPtr(m.EntryAId), lo.FromPtr(m.EntryBId)})
+	for _, other := range t.BracketMatches {
+		plays := lo.Contains(entries, lo.FromPtr(other.EntryAId)) || lo.Contains(entries, lo.FromPtr(other.EntryBId))
+		if other.StartAt != nil && other.Code != m.Code && plays {
+			later(other.StartAt.Add(duration))
+		}
+	}
+	return readyAt
+}
+
+func (t *Tournament) findBracketMatch(code string) *BracketMatch {
+	for i := range t.BracketMatches {
+		if t.BracketMatches[i].Code == code {
+			return &t.BracketMatches[i]
+		}
+	}
+	return nil
+}
+
+func (t *Tournament) findEntry(entryId uint) *TournamentEntry {
+	for i := range t.Entries {
+		if t.Entries[i].ID == entryId {
+			return &t.Entries[i]
+		}
+	}
+	return &TournamentEntry{}
+}
+
+// seedOrder places the seeds in the first round so that the best seeds meet as late as possible, e.g. 1 8 4 5 2 7 3 6
+func seedOrder(size int) []int {
+	order := []int{1}
+	for len(order) < size {
+		next := make([]int, 0, len(order)*2)
+		for _, seed := range order {
+			next = append(next, seed, len(order)*2+1-seed)
+		}
+		order = next
+	}
+	return order
+}
+
+// roundRobin pairs every entry with every other one using the circle method, an odd entry rests each round
+func roundRobin(ids []uint) [][][2]uint {
+	list := append([]uint{}, ids...)
+	if len(list)%2 == 1 {
+		list = append(list, 0)
+	}
+
+	n := len(list)
+	rounds := [][][2]uint{}
+	for r := 0; r < n-1; r++ {
+		pairs := [][2]uint{}
+		for i := 0; i < n/2; i++ {
+			if a, b := list[i], list[n-1-i]; a != 0 && b != 0 {
+				pairs = append(pairs, [2]uint{a, b})
+			}
+		}
+		rounds = append(rounds, pairs)
+		list = append([]uint{list[0], list[n-1]}, list[1:n-1]...)
+	}
+	return rounds
+}
+
+func seededEntry(ids []uint, seed int) *uint {
+	if seed > len(ids) {
+		return nil
+	}
+	id := ids[seed-1]
+	return &id
+}
+
+func bracketCode(prefix string, round, position int) string {
+	return fmt.Sprintf("%s%d-%d", prefix, round, position)
+}
+
+func slotOf(position int) GameSide {
+	return lo.Ternary(position%2 == 0, SideA, SideB)
+}
+
+func slotKey(sessionId uint, court string, start time.Time) string {
+	return fmt.Sprintf("%d|%s|%d", sessionId, court, start.Unix())
+}
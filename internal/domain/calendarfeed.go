@@ -0,0 +1,70 @@
+package domain
+
+import (
+	"errors"
+	"time"
+
+	"github.com/samber/lo"
+)
+
+// CalendarFeed is a secret token that lets calendar clients (Google, Apple, ...)
+// subscribe to an .ics feed without going through the Auth0 login flow.
+// A feed belongs either to a player (matches they registered for) or to a team (all upcoming matches).
+type CalendarFeed struct {
+	BaseModel
+	Token    string `gorm:"uniqueIndex" json:"token"`
+	PlayerId *uint  `gorm:"index" json:"playerId"`
+	TeamId   *uint  `gorm:"index" json:"teamId"`
+}
+
+func NewPlayerCalendarFeed(playerId uint, generator Generator) (*CalendarFeed, error) {
+	if playerId == 0 {
+		return nil, errors.New("player is mandatory")
+	}
+
+	token, err := generator.Gen(24)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CalendarFeed{
+		Token:    token,
+		PlayerId: &playerId,
+	}, nil
+}
+
+func NewTeamCalendarFeed(teamId uint, generator Generator) (*CalendarFeed, error) {
+	if teamId == 0 {
+		return nil, errors.New("team is mandatory")
+	}
+
+	token, err := generator.Gen(24)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CalendarFeed{
+		Token:  token,
+		TeamId: &teamId,
+	}, nil
+}
+
+func (f *CalendarFeed) IsTeamFeed() bool {
+	return f.TeamId != nil
+}
+
+// GetFeedAttendance picks the registration the player attends the match with among all of their registrations,
+// deleted ones included. Without one the player withdrew, withdrawnAt is when the last registration changed.
+func GetFeedAttendance(registrations []Registration) (registration Registration, attending bool, withdrawnAt time.Time) {
+	registration, attending = lo.Find(registrations, func(r Registration) bool {
+		return !r.DeletedAt.Valid && (r.IsGoing() || r.Rsvp == RsvpMaybe)
+	})
+
+	for _, r := range registrations {
+		changedAt := lo.Ternary(r.DeletedAt.Valid, r.DeletedAt.Time, r.UpdatedAt)
+		if changedAt.After(withdrawnAt) {
+			withdrawnAt = changedAt
+		}
+	}
+	return registration, attending, withdrawnAt
+}
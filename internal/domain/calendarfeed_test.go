@@ -0,0 +1,40 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestGetFeedAttendance(t *testing.T) {
+	updatedAt := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	deletedAt := updatedAt.Add(time.Hour)
+	going := Registration{BaseModel: BaseModel{ID: 1, UpdatedAt: updatedAt}, Rsvp: RsvpGoing}
+	unregistered := Registration{BaseModel: BaseModel{ID: 2, UpdatedAt: updatedAt, DeletedAt: gorm.DeletedAt{Time: deletedAt, Valid: true}}, Rsvp: RsvpGoing}
+	notGoing := Registration{BaseModel: BaseModel{ID: 3, UpdatedAt: deletedAt}, Rsvp: RsvpNotGoing}
+	maybe := Registration{BaseModel: BaseModel{ID: 4, UpdatedAt: updatedAt}, Rsvp: RsvpMaybe}
+
+	tests := []struct {
+		name          string
+		registrations []Registration
+		attendingId   uint
+		withdrawnAt   time.Time
+	}{
+		{"going", []Registration{going}, 1, updatedAt},
+		{"maybe", []Registration{maybe}, 4, updatedAt},
+		{"unregistered", []Registration{unregistered}, 0, deletedAt},
+		{"not going any more", []Registration{notGoing}, 0, deletedAt},
+		{"registered again", []Registration{unregistered, going}, 1, deletedAt},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reg, attending, withdrawnAt := GetFeedAttendance(tt.registrations)
+			assert.Equal(t, tt.attendingId != 0, attending)
+			assert.Equal(t, tt.attendingId, reg.ID)
+			assert.Equal(t, tt.withdrawnAt, withdrawnAt)
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package domain
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/samber/lo"
+)
+
+const (
+	CourtConflict  = "court"
+	PlayerConflict = "player"
+)
+
+var courtSeparator = regexp.MustCompile(`(?i)\s*(?:,|&|/|\+|\band\b)\s*`)
+
+// ConflictError is returned when a booking or a registration overlaps with existing matches.
+// It can be bypassed with an explicit override for legitimate cases.
+type ConflictError struct {
+	Kind    string
+	Matches []Match
+}
+
+func (e *ConflictError) Error() string {
+	ids := lo.Map(e.Matches, func(m Match, _ int) string { return fmt.Sprintf("#%d", m.ID) })
+	if e.Kind == PlayerConflict {
+		return fmt.Sprintf("player is already registered for overlapping matches %s", strings.Join(ids, ", "))
+	}
+	return fmt.Sprintf("court is already booked by overlapping matches %s", strings.Join(ids, ", "))
+}
+
+// SplitCourts splits a court description such as "Court 1 & 2" or "1, 2" into court identifiers
+func SplitCourts(court string) []string {
+	court = strings.TrimSpace(court)
+	if extracted := ExtractCourt(court); extracted != "" {
+		court = extracted
+	}
+
+	return lo.Uniq(lo.FilterMap(courtSeparator.Split(court, -1), func(c string, _ int) (string, bool) {
+		c = strings.TrimSpace(c)
+		return c, c != ""
+	}))
+}
+
+// ParseCourts returns the normalized court identifiers used for comparison
+func ParseCourts(court string) []string {
+	courts := lo.Uniq(lo.Map(SplitCourts(court), func(c string, _ int) string { return NormalizeName(c) }))
+	if len(courts) == 0 {
+		// An unspecified court is considered as one and the same court
+		return []string{""}
+	}
+
+	return courts
+}
+
+func timeOverlaps(startA, endA, startB, endB time.Time) bool {
+	return startA.Before(endB) && startB.Before(endA)
+}
+
+// OverlapsInTime tells whether both matches are played at the same time, regardless of the place
+func (m *Match) OverlapsInTime(other *Match) bool {
+	return timeOverlaps(m.Start, m.End, other.Start, other.End)
+}
+
+// ConflictsWith tells whether both matches book the same court of the same sport center at the same time
+func (m *Match) ConflictsWith(other *Match) bool {
+	if m.ID != 0 && m.ID == other.ID {
+		return false
+	}
+
+	if m.SportCenterId != other.SportCenterId || !m.OverlapsInTime(other) {
+		return false
+	}
+
+	for _, a := range m.courtSlots() {
+		for _, b := range other.courtSlots() {
+			if timeOverlaps(a.Start, a.End, b.Start, b.End) && len(lo.Intersect(ParseCourts(a.Court), ParseCourts(b.Court))) > 0 {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// FindConflicts returns the candidates booking the same court at the same time
+func (m *Match) FindConflicts(candidates []Match) []Match {
+	return lo.Filter(candidates, func(c Match, _ int) bool { return m.ConflictsWith(&c) })
+}
+
+// FindOverlaps returns the candidates played at the same time, used for player double bookings
+func (m *Match) FindOverlaps(candidates []Match) []Match {
+	return lo.Filter(candidates, func(c Match, _ int) bool {
+		return c.ID != m.ID && m.OverlapsInTime(&c)
+	})
+}
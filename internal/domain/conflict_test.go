@@ -0,0 +1,71 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCourts(t *testing.T) {
+	assert.Equal(t, []string{"1", "2"}, ParseCourts("Courts 1 & 2"))
+	assert.Equal(t, []string{"1", "2", "3"}, ParseCourts("1, 2 and 3"))
+	assert.Equal(t, []string{"a"}, ParseCourts("A"))
+	assert.Equal(t, []string{""}, ParseCourts(" "))
+}
+
+func TestConflictsWith(t *testing.T) {
+	start := time.Date(2024, 5, 1, 19, 0, 0, 0, time.UTC)
+	base := Match{BaseModel: BaseModel{ID: 1}, SportCenterId: 1, Start: start, End: start.Add(2 * time.Hour), Court: "1 & 2"}
+
+	tests := []struct {
+		name     string
+		other    Match
+		expected bool
+	}{
+		{
+			name:     "Same court overlapping time",
+			other:    Match{BaseModel: BaseModel{ID: 2}, SportCenterId: 1, Start: start.Add(time.Hour), End: start.Add(3 * time.Hour), Court: "2"},
+			expected: true,
+		},
+		{
+			name:     "Back to back",
+			other:    Match{BaseModel: BaseModel{ID: 2}, SportCenterId: 1, Start: start.Add(2 * time.Hour), End: start.Add(3 * time.Hour), Court: "1"},
+			expected: false,
+		},
+		{
+			name:     "Different court",
+			other:    Match{BaseModel: BaseModel{ID: 2}, SportCenterId: 1, Start: start, End: start.Add(2 * time.Hour), Court: "3"},
+			expected: false,
+		},
+		{
+			name:     "Different sport center",
+			other:    Match{BaseModel: BaseModel{ID: 2}, SportCenterId: 2, Start: start, End: start.Add(2 * time.Hour), Court: "1"},
+			expected: false,
+		},
+		{
+			name:     "Same match",
+			other:    base,
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, base.ConflictsWith(&tt.other))
+		})
+	}
+}
+
+func TestFindOverlaps(t *testing.T) {
+	start := time.Date(2024, 5, 1, 19, 0, 0, 0, time.UTC)
+	m := Match{BaseModel: BaseModel{ID: 1}, SportCenterId: 1, Start: start, End: start.Add(2 * time.Hour)}
+	candidates := []Match{
+		{BaseModel: BaseModel{ID: 2}, SportCenterId: 2, Start: start.Add(-time.Hour), End: start.Add(time.Hour)},
+		{BaseModel: BaseModel{ID: 3}, SportCenterId: 1, Start: start.Add(3 * time.Hour), End: start.Add(4 * time.Hour)},
+	}
+
+	overlaps := m.FindOverlaps(candidates)
+	assert.Len(t, overlaps, 1)
+	assert.Equal(t, uint(2), overlaps[0].ID)
+}
@@ -0,0 +1,112 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/samber/lo"
+)
+
+type CostSplit = string
+
+const (
+	// CostSplitEven shares the cost between every confirmed player, it is the default
+	CostSplitEven CostSplit = "even"
+	// CostSplitMinutes shares the cost by player-minutes, players leaving early pay less
+	CostSplitMinutes CostSplit = "minutes"
+)
+
+var (
+	ErrCostSettled       = errors.New("match cost is settled and can not be split differently")
+	ErrInvalidPlayedTime = errors.New("played time must be a range within the match")
+)
+
+func ParseCostSplit(value string) (CostSplit, error) {
+	switch value {
+	case "", CostSplitEven:
+		return CostSplitEven, nil
+	case CostSplitMinutes:
+		return CostSplitMinutes, nil
+	default:
+		return "", fmt.Errorf("unknown cost split %s", value)
+	}
+}
+
+// SetCostSplit configures how the court cost and the additional costs are shared
+func (m *Match) SetCostSplit(courtCost, additionalCost CostSplit) error {
+	if !m.AcceptsChanges() {
+		return ErrCostSettled
+	}
+
+	m.CostSplit = courtCost
+	m.AdditionalCostSplit = additionalCost
+	return nil
+}
+
+// SetPlayedTime records the part of the session the player actually played, nil means from the start or until the end
+func (reg *Registration) SetPlayedTime(m *Match, from, to *time.Time) error {
+	if !m.AcceptsChanges() || reg.IsPaid {
+		return ErrCostSettled
+	}
+
+	start := lo.FromPtrOr(from, m.Start)
+	end := lo.FromPtrOr(to, m.End)
+	if start.Before(m.Start) || end.After(m.End) || !end.After(start) {
+		return ErrInvalidPlayedTime
+	}
+
+	reg.PlayedFrom = from
+	reg.PlayedTo = to
+	return nil
+}
+
+// PlayedMinutes is the time played by each player of the registration, guests stay as long as their host
+func (reg *Registration) PlayedMinutes(m *Match) float64 {
+	start := lo.FromPtrOr(reg.PlayedFrom, m.Start)
+	if start.Before(m.Start) {
+		start = m.Start
+	}
+
+	end := lo.FromPtrOr(reg.PlayedTo, m.End)
+	if end.After(m.End) {
+		end = m.End
+	}
+
+	return max(end.Sub(start).Minutes(), 0)
+}
+
+// CalcPlayerMinutes sums the minutes played by every confirmed player
+func (m *Match) CalcPlayerMinutes() float64 {
+	return lo.SumBy(m.Registrations, func(r Registration) float64 {
+		return lo.Ternary(r.IsConfirmed(), r.PlayedMinutes(m)*float64(r.TotalPlayerPaidFor), 0)
+	})
+}
+
+// CalcIndividualCost is the amount owed by the registration, its guests included
+func (m *Match) CalcIndividualCost(reg Registration) float64 {
+	additionalCost := m.CalcAdditionalCost()
+	if m.CostSplit == m.AdditionalCostSplit {
+		return m.calcShare(m.Cost+additionalCost, m.CostSplit, reg)
+	}
+	return m.calcShare(m.Cost, m.CostSplit, reg) + m.calcShare(additionalCost, m.AdditionalCostSplit, reg)
+}
+
+// CalcFullSessionCost is the amount owed by a single player staying for the whole session
+func (m *Match) CalcFullSessionCost() float64 {
+	return m.CalcIndividualCost(*NewRegistration(0, m.ID))
+}
+
+func (m *Match) calcShare(amount float64, split CostSplit, reg Registration) float64 {
+	count := float64(reg.TotalPlayerPaidFor)
+	if split == CostSplitMinutes {
+		if total := m.CalcPlayerMinutes(); total > 0 {
+			return amount * reg.PlayedMinutes(m) * count / total
+		}
+	}
+
+	if playerCount := m.CalcPlayerCount(); playerCount > 0 {
+		return amount * count / float64(playerCount)
+	}
+	return 0
+}
@@ -0,0 +1,84 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalcIndividualCostByMinutes(t *testing.T) {
+	start := time.Date(2024, 5, 1, 18, 0, 0, 0, time.UTC)
+	firstHourEnd := start.Add(time.Hour)
+	m := &Match{
+		Start:           start,
+		End:             start.Add(2 * time.Hour),
+		Cost:            100,
+		AdditionalCosts: []AdditionalCost{{Amount: 30}},
+		CostSplit:       CostSplitMinutes,
+		Registrations: []Registration{
+			{BaseModel: BaseModel{ID: 1}, TotalPlayerPaidFor: 1},
+			{BaseModel: BaseModel{ID: 2}, TotalPlayerPaidFor: 1},
+			{BaseModel: BaseModel{ID: 3}, TotalPlayerPaidFor: 1, PlayedTo: &firstHourEnd},
+			{BaseModel: BaseModel{ID: 4}, TotalPlayerPaidFor: 1, IsWaitlisted: true},
+		},
+	}
+
+	// 120 + 120 + 60 player-minutes, shuttles are still shared evenly
+	assert.Equal(t, 300.0, m.CalcPlayerMinutes())
+	assert.InDelta(t, 40+10, m.CalcIndividualCost(m.Registrations[0]), 0.0001)
+	assert.InDelta(t, 20+10, m.CalcIndividualCost(m.Registrations[2]), 0.0001)
+
+	assert.Nil(t, m.SetCostSplit(CostSplitMinutes, CostSplitMinutes))
+	assert.InDelta(t, 20+6, m.CalcIndividualCost(m.Registrations[2]), 0.0001)
+
+	total := m.CalcIndividualCost(m.Registrations[0]) + m.CalcIndividualCost(m.Registrations[1]) + m.CalcIndividualCost(m.Registrations[2])
+	assert.InDelta(t, 130, total, 0.0001)
+}
+
+func TestCalcIndividualCostEvenIgnoresPlayedTime(t *testing.T) {
+	start := time.Date(2024, 5, 1, 18, 0, 0, 0, time.UTC)
+	firstHourEnd := start.Add(time.Hour)
+	m := &Match{
+		Start: start,
+		End:   start.Add(2 * time.Hour),
+		Cost:  90,
+		Registrations: []Registration{
+			{TotalPlayerPaidFor: 2},
+			{TotalPlayerPaidFor: 1, PlayedTo: &firstHourEnd},
+		},
+	}
+
+	assert.Equal(t, 60.0, m.CalcIndividualCost(m.Registrations[0]))
+	assert.Equal(t, 30.0, m.CalcIndividualCost(m.Registrations[1]))
+	assert.Equal(t, 30.0, m.CalcFullSessionCost())
+}
+
+func TestSetPlayedTime(t *testing.T) {
+	start := time.Date(2024, 5, 1, 18, 0, 0, 0, time.UTC)
+	m := &Match{Start: start, End: start.Add(2 * time.Hour), State: MatchPlayed}
+	before, after, middle := start.Add(-time.Minute), start.Add(3*time.Hour), start.Add(time.Hour)
+
+	tests := []struct {
+		name string
+		from *time.Time
+		to   *time.Time
+		err  error
+	}{
+		{"first hour", nil, &middle, nil},
+		{"second hour", &middle, nil, nil},
+		{"before the start", &before, nil, ErrInvalidPlayedTime},
+		{"after the end", nil, &after, ErrInvalidPlayedTime},
+		{"empty range", &middle, &middle, ErrInvalidPlayedTime},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reg := &Registration{TotalPlayerPaidFor: 1}
+			assert.Equal(t, tt.err, reg.SetPlayedTime(m, tt.from, tt.to))
+		})
+	}
+
+	reg := &Registration{IsPaid: true}
+	assert.Equal(t, ErrCostSettled, reg.SetPlayedTime(m, nil, &middle))
+}
@@ -0,0 +1,241 @@
+package domain
+
+import (
+	"errors"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/samber/lo"
+)
+
+// Four players on court and two waiting for the next game, bookings made before capacities existed keep an unknown capacity
+const DefaultCourtCapacity uint = 6
+
+// PriceSource tells where the cost of a court booking comes from, only bookings
+// priced from the sport center rules follow its price changes
+type PriceSource string
+
+const (
+	PriceSourceRules      PriceSource = "rules"
+	PriceSourceCustomRate PriceSource = "custom_rate"
+	PriceSourceLegacy     PriceSource = "legacy"
+)
+
+// CourtBooking is one court booked for a match, a session often books several courts
+// and sometimes for different durations. The rate is kept so that past costs can be explained.
+type CourtBooking struct {
+	BaseModel
+	MatchId          uint        `gorm:"index" json:"matchId"`
+	Court            string      `json:"court"`
+	Start            time.Time   `json:"start"`
+	End              time.Time   `json:"end"`
+	CostPerSection   float64     `json:"costPerSection"`
+	MinutePerSection float64     `json:"minutePerSection"`
+	CustomSection    *float64    `gorm:"default:null" json:"customSection"`
+	Cost             float64     `json:"cost"`
+	CostItems        []CostItem  `gorm:"serializer:json" json:"costItems"`
+	MemberRate       bool        `json:"memberRate"`
+	Capacity         uint        `json:"capacity"`
+	PriceSource      PriceSource `gorm:"default:rules" json:"priceSource"`
+}
+
+func NewCourtBooking(
+	court string,
+	start,
+	end time.Time,
+	pricing Pricing,
+	customSection *float64,
+) (*CourtBooking, error) {
+	if err := CheckBookingDuration(start, end); err != nil {
+		return nil, err
+	}
+
+	booking := &CourtBooking{
+		Court:         strings.TrimSpace(court),
+		Start:         start,
+		End:           end,
+		CustomSection: customSection,
+		Capacity:      DefaultCourtCapacity,
+	}
+	booking.ApplyPricing(pricing)
+	return booking, nil
+}
+
+func (b *CourtBooking) SetCapacity(capacity uint) {
+	if capacity == 0 {
+		capacity = DefaultCourtCapacity
+	}
+	b.Capacity = capacity
+}
+
+func (b *CourtBooking) Minutes() float64 {
+	return math.Abs(b.End.Sub(b.Start).Minutes())
+}
+
+// ApplyPricing computes the itemized cost of the booking, a booking crossing
+// a pricing window boundary is split into several items
+func (b *CourtBooking) ApplyPricing(pricing Pricing) float64 {
+	b.CostPerSection = pricing.DefaultCostPerSection
+	b.MinutePerSection = pricing.MinutePerSection
+	b.MemberRate = pricing.Member
+	b.CostItems, b.Cost = pricing.Price(b.Start, b.End, b.CustomSection)
+	b.PriceSource = PriceSourceRules
+	return b.Cost
+}
+
+// ApplyCustomRate prices the booking at a flat rate given for the court, it overrides the sport center rules
+func (b *CourtBooking) ApplyCustomRate(pricing Pricing, costPerSection float64) float64 {
+	pricing.DefaultCostPerSection = costPerSection
+	pricing.Rules = nil
+	b.ApplyPricing(pricing)
+	b.PriceSource = PriceSourceCustomRate
+	return b.Cost
+}
+
+// Reprice recomputes the court costs with the sport center prices effective at each booking,
+// pricing rules and price history must be loaded. A cost set manually on the match and bookings
+// with a custom rate or a legacy cost are kept, it tells whether the cost was recomputed.
+func (m *Match) Reprice(sc *SportCenter) bool {
+	if m.CostOverridden {
+		return false
+	}
+
+	repriced := false
+	for i := range m.CourtBookings {
+		b := &m.CourtBookings[i]
+		if b.PriceSource != PriceSourceRules {
+			continue
+		}
+		b.ApplyPricing(sc.GetPricing(b.Start, b.MemberRate))
+		repriced = true
+	}
+
+	if repriced {
+		m.syncFromCourtBookings()
+	}
+	return repriced
+}
+
+// SetCourtBookings replaces the bookings of the match, the match time span,
+// court summary and total cost are derived from them unless the cost was entered manually
+// and the same courts are booked
+func (m *Match) SetCourtBookings(bookings []CourtBooking) error {
+	if len(bookings) == 0 {
+		return errors.New("at least one court booking is required")
+	}
+
+	for _, b := range bookings {
+		if err := CheckBookingDuration(b.Start, b.End); err != nil {
+			return err
+		}
+	}
+
+	for i := range bookings {
+		bookings[i].ID = 0
+		bookings[i].MatchId = m.ID
+	}
+
+	sort.SliceStable(bookings, func(i, j int) bool { return bookings[i].Start.Before(bookings[j].Start) })
+
+	// A cost entered manually is kept when the match is edited without changing what is booked
+	keepCost := m.CostOverridden && m.isSameBooked(bookings)
+	cost := m.Cost
+
+	m.CourtBookings = bookings
+	m.syncFromCourtBookings()
+	if keepCost {
+		m.Cost = cost
+	} else {
+		m.CostOverridden = false
+	}
+	return nil
+}
+
+// isSameBooked tells whether the bookings book the same courts, times and rates as the current ones
+func (m *Match) isSameBooked(bookings []CourtBooking) bool {
+	if len(m.CourtBookings) != len(bookings) {
+		return false
+	}
+
+	byStartAndCourt := func(list []CourtBooking) []CourtBooking {
+		sorted := slices.Clone(list)
+		sort.SliceStable(sorted, func(i, j int) bool {
+			if !sorted[i].Start.Equal(sorted[j].Start) {
+				return sorted[i].Start.Before(sorted[j].Start)
+			}
+			return NormalizeName(sorted[i].Court) < NormalizeName(sorted[j].Court)
+		})
+		return sorted
+	}
+
+	current, updated := byStartAndCourt(m.CourtBookings), byStartAndCourt(bookings)
+	for i, a := range current {
+		b := updated[i]
+		sameRate := a.PriceSource == b.PriceSource &&
+			a.MemberRate == b.MemberRate &&
+			lo.FromPtr(a.CustomSection) == lo.FromPtr(b.CustomSection) &&
+			(a.PriceSource != PriceSourceCustomRate || a.CostPerSection == b.CostPerSection)
+		if !sameRate || !a.Start.Equal(b.Start) || !a.End.Equal(b.End) || NormalizeName(a.Court) != NormalizeName(b.Court) {
+			return false
+		}
+	}
+	return true
+}
+
+func (m *Match) syncFromCourtBookings() {
+	if len(m.CourtBookings) == 0 {
+		return
+	}
+
+	m.Start = lo.MinBy(m.CourtBookings, func(a, b CourtBooking) bool { return a.Start.Before(b.Start) }).Start
+	m.End = lo.MaxBy(m.CourtBookings, func(a, b CourtBooking) bool { return a.End.After(b.End) }).End
+	m.Court = strings.Join(lo.Uniq(lo.FilterMap(m.CourtBookings, func(b CourtBooking, _ int) (string, bool) {
+		return b.Court, b.Court != ""
+	})), ", ")
+	m.Cost = lo.SumBy(m.CourtBookings, func(b CourtBooking) float64 { return b.Cost })
+}
+
+// CalcCapacity is the number of players the booked courts can take, 0 means unknown
+func (m *Match) CalcCapacity() int {
+	return int(lo.SumBy(m.CourtBookings, func(b CourtBooking) uint { return b.Capacity }))
+}
+
+// HasUnknownCapacity tells whether the courts were booked before capacities existed, such a match has no player limit
+func (m *Match) HasUnknownCapacity() bool {
+	return len(m.CourtBookings) > 0 && m.CalcCapacity() == 0
+}
+
+// LegacyCourtBookings converts the free text court of matches created before court bookings existed.
+// The stored cost is split evenly so that the match total does not change, the bookings belong to the tenant of the match.
+// Their capacity is unknown so that established sessions do not start waitlisting players.
+func (m *Match) LegacyCourtBookings() []CourtBooking {
+	courts := SplitCourts(m.Court)
+	if len(courts) == 0 {
+		courts = []string{""}
+	}
+	costPerCourt := m.Cost / float64(len(courts))
+
+	return lo.Map(courts, func(court string, _ int) CourtBooking {
+		return CourtBooking{
+			BaseModel:     BaseModel{TenantId: m.TenantId},
+			MatchId:       m.ID,
+			Court:         court,
+			Start:         m.Start,
+			End:           m.End,
+			CustomSection: m.CustomSection,
+			Cost:          costPerCourt,
+			PriceSource:   PriceSourceLegacy,
+		}
+	})
+}
+
+// courtSlots are the bookings of the match, or a single slot built from the legacy court field
+func (m *Match) courtSlots() []CourtBooking {
+	if len(m.CourtBookings) > 0 {
+		return m.CourtBookings
+	}
+	return []CourtBooking{{Court: m.Court, Start: m.Start, End: m.End}}
+}
@@ -0,0 +1,114 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchCostIsSumOfCourtBookings(t *testing.T) {
+	start := time.Date(2024, 5, 1, 19, 0, 0, 0, time.UTC)
+	court1, _ := NewCourtBooking("1", start, start.Add(2*time.Hour), NewFlatPricing(10, 60), nil)
+	court2, _ := NewCourtBooking("2", start.Add(time.Hour), start.Add(2*time.Hour), NewFlatPricing(12, 60), nil)
+
+	m, err := NewMatchWithCourtBookings(1, []CourtBooking{*court2, *court1})
+
+	assert.Nil(t, err)
+	assert.Equal(t, float64(32), m.Cost)
+	assert.Equal(t, start, m.Start)
+	assert.Equal(t, start.Add(2*time.Hour), m.End)
+	assert.Equal(t, "1, 2", m.Court)
+	assert.Equal(t, 12, m.CalcCapacity())
+}
+
+func TestNewMatchBooksEveryCourt(t *testing.T) {
+	start := time.Date(2024, 5, 1, 19, 0, 0, 0, time.UTC)
+
+	single := NewMatch(start, start.Add(2*time.Hour), 1, NewFlatPricing(10, 60), "Court 3", nil)
+	assert.Len(t, single.CourtBookings, 1)
+	assert.Equal(t, "Court 3", single.Court)
+	assert.Equal(t, float64(20), single.Cost)
+
+	multi := NewMatch(start, start.Add(2*time.Hour), 1, NewFlatPricing(10, 60), "1 & 2", nil)
+	assert.Len(t, multi.CourtBookings, 2)
+	assert.Equal(t, float64(40), multi.Cost)
+}
+
+func TestNewMatchWithoutCourtBookings(t *testing.T) {
+	_, err := NewMatchWithCourtBookings(1, []CourtBooking{})
+	assert.Error(t, err)
+}
+
+func TestLegacyCourtBookingsKeepTotalCost(t *testing.T) {
+	start := time.Date(2024, 5, 1, 19, 0, 0, 0, time.UTC)
+	m := Match{BaseModel: BaseModel{ID: 5, TenantId: 3}, Start: start, End: start.Add(2 * time.Hour), Court: "Courts 1 & 2", Cost: 30}
+
+	bookings := m.LegacyCourtBookings()
+
+	assert.Len(t, bookings, 2)
+	assert.Equal(t, "1", bookings[0].Court)
+	assert.Equal(t, "2", bookings[1].Court)
+	assert.Equal(t, float64(15), bookings[0].Cost)
+	assert.Equal(t, uint(5), bookings[1].MatchId)
+	assert.Equal(t, uint(3), bookings[1].TenantId)
+}
+
+func TestLegacyMatchHasNoPlayerLimit(t *testing.T) {
+	start := time.Date(2024, 5, 1, 19, 0, 0, 0, time.UTC)
+	m := Match{Start: start, End: start.Add(2 * time.Hour), Court: "1", Cost: 30}
+	m.CourtBookings = m.LegacyCourtBookings()
+	for i := uint(1); i <= 10; i++ {
+		m.Registrations = append(m.Registrations, Registration{PlayerId: i, Rsvp: RsvpGoing, TotalPlayerPaidFor: 1})
+	}
+
+	assert.True(t, m.HasUnknownCapacity())
+	assert.True(t, m.HasFreeSpot(1))
+
+	// Editing the session keeps it unlimited, new matches get the default capacity
+	assert.Nil(t, m.UpdateMatch(1, start, start.Add(time.Hour), NewFlatPricing(10, 60), "1 & 2", nil))
+	assert.Equal(t, 0, m.CalcCapacity())
+
+	created := NewMatch(start, start.Add(2*time.Hour), 1, NewFlatPricing(10, 60), "1", nil)
+	assert.Equal(t, int(DefaultCourtCapacity), created.CalcCapacity())
+}
+
+func TestCloneIsRepricedOneOffMatch(t *testing.T) {
+	sc := NewSportCenter("Center", "Somewhere", 10, 60)
+	start := time.Date(2024, 6, 5, 18, 0, 0, 0, time.UTC)
+	m := NewMatch(start, start.Add(2*time.Hour), 1, sc.GetPricing(start, false), "1", nil)
+	m.ID = 3
+	assert.Nil(t, m.UpdateCost(15, "Discount"))
+
+	clone := m.Clone()
+	assert.Nil(t, clone.SeriesId)
+	assert.False(t, clone.CostOverridden)
+	assert.Equal(t, float64(20), clone.Cost)
+	assert.Equal(t, start.AddDate(0, 0, 7), clone.CourtBookings[0].CostItems[0].Start)
+
+	// The clone follows the price effective at its own date
+	_, err := sc.ChangePrice(12, 60, start.AddDate(0, 0, 1))
+	assert.Nil(t, err)
+	assert.True(t, clone.Reprice(sc))
+	assert.Equal(t, float64(24), clone.Cost)
+
+	repeated := m.RepeatWeeksLater(2)
+	assert.Equal(t, uint(3), *repeated.SeriesId)
+	assert.Equal(t, start.AddDate(0, 0, 14), repeated.Start)
+}
+
+func TestUpdateMatchKeepsManualCost(t *testing.T) {
+	start := time.Date(2024, 5, 1, 19, 0, 0, 0, time.UTC)
+	pricing := NewFlatPricing(10, 60)
+	m := NewMatch(start, start.Add(2*time.Hour), 1, pricing, "1 & 2", nil)
+	assert.Nil(t, m.UpdateCost(25, "Discount"))
+
+	// Same courts and times in another order, e.g. only the registration window was edited
+	assert.Nil(t, m.UpdateMatch(1, start, start.Add(2*time.Hour), pricing, "2, 1", nil))
+	assert.True(t, m.CostOverridden)
+	assert.Equal(t, float64(25), m.Cost)
+
+	assert.Nil(t, m.UpdateMatch(1, start, start.Add(3*time.Hour), pricing, "1 & 2", nil))
+	assert.False(t, m.CostOverridden)
+	assert.Equal(t, float64(60), m.Cost)
+}
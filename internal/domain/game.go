@@ -0,0 +1,262 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/samber/lo"
+)
+
+type GameSide = string
+
+const (
+	SideA GameSide = "a"
+	SideB GameSide = "b"
+)
+
+var ErrInvalidGamePlayers = errors.New("each side needs one or two players and a player can only be on one side")
+
+// ScoringFormat is the rally scoring of a game, badminton is 21 points, win by 2, capped at 30, best of 3 sets
+type ScoringFormat struct {
+	Points int `json:"points"`
+	WinBy  int `json:"winBy"`
+	Cap    int `json:"cap"`
+	BestOf int `json:"bestOf"`
+}
+
+var BadmintonScoring = ScoringFormat{Points: 21, WinBy: 2, Cap: 30, BestOf: 3}
+
+// Game is the result of a game played during a match session, singles or doubles
+type Game struct {
+	BaseModel
+	MatchId uint           `gorm:"index" json:"matchId"`
+	Court   string         `json:"court"`
+	Format  ScoringFormat  `gorm:"embedded;embeddedPrefix:format_" json:"format"`
+	Players []GamePlayer   `json:"players"`
+	Sets    []GameSet      `json:"sets"`
+	Winner  GameSide       `json:"winner"`
+	History []GameRevision `json:"history"`
+}
+
+type GamePlayer struct {
+	BaseModel
+	GameId   uint     `gorm:"index" json:"gameId"`
+	PlayerId uint     `gorm:"index" json:"playerId"`
+	Side     GameSide `json:"side"`
+}
+
+type GameSet struct {
+	BaseModel
+	GameId uint `gorm:"index" json:"gameId"`
+	Number int  `json:"number"`
+	ScoreA int  `json:"scoreA"`
+	ScoreB int  `json:"scoreB"`
+}
+
+// GameRevision keeps every version of a result, who entered it and what it was
+type GameRevision struct {
+	BaseModel
+	GameId   uint   `gorm:"index" json:"gameId"`
+	EditedBy string `json:"editedBy"`
+	Players  string `json:"players"`
+	Scores   string `json:"scores"`
+	Winner   string `json:"winner"`
+}
+
+func NewGame(matchId uint, court string, format ScoringFormat) (*Game, error) {
+	if format == (ScoringFormat{}) {
+		format = BadmintonScoring
+	}
+
+	if err := format.validate(); err != nil {
+		return nil, err
+	}
+
+	return &Game{MatchId: matchId, Court: court, Format: format}, nil
+}
+
+func (f ScoringFormat) validate() error {
+	if f.Points < 1 || f.WinBy < 1 || f.BestOf < 1 || f.BestOf%2 == 0 {
+		return errors.New("scoring format needs points, a win margin and an odd number of sets")
+	}
+
+	if f.Cap != 0 && f.Cap < f.Points {
+		return errors.New("score cap must not be below the points of a set")
+	}
+	return nil
+}
+
+// ValidateSet checks the final score of a set, e.g. 21-19, 22-20 and 30-29 are valid but 21-20 and 31-29 are not
+func (f ScoringFormat) ValidateSet(a, b int) error {
+	won, lost := max(a, b), min(a, b)
+	invalid := fmt.Errorf("%d-%d is not a valid final score", a, b)
+	switch {
+	case lost < 0 || won == lost:
+		return invalid
+	case f.Cap != 0 && won > f.Cap:
+		return invalid
+	case won == f.Points:
+		if won-lost < f.WinBy && !(won == f.Cap && lost == f.Cap-1) {
+			return invalid
+		}
+	case won > f.Points:
+		// Extended sets end as soon as the margin is reached or the cap is hit
+		if won-lost != f.WinBy && !(won == f.Cap && lost == f.Cap-1) {
+			return invalid
+		}
+	default:
+		return invalid
+	}
+	return nil
+}
+
+// SetPlayers puts the players on their side, both sides must have as many players
+func (g *Game) SetPlayers(sideA, sideB []uint) error {
+	all := append(append([]uint{}, sideA...), sideB...)
+	if len(sideA) < 1 || len(sideA) > 2 || len(sideA) != len(sideB) || len(lo.Uniq(all)) != len(all) {
+		return ErrInvalidGamePlayers
+	}
+
+	g.Players = append(
+		lo.Map(sideA, func(id uint, _ int) GamePlayer { return GamePlayer{GameId: g.ID, PlayerId: id, Side: SideA} }),
+		lo.Map(sideB, func(id uint, _ int) GamePlayer { return GamePlayer{GameId: g.ID, PlayerId: id, Side: SideB} })...,
+	)
+	return nil
+}
+
+// RecordScores validates every set and decides the winner, the game must be complete and stop once decided
+func (g *Game) RecordScores(scores [][2]int) error {
+	needed := g.Format.BestOf/2 + 1
+	winsA, winsB := 0, 0
+	sets := []GameSet{}
+	for i, s := range scores {
+		if winsA == needed || winsB == needed {
+			return errors.New("no more sets can be played once the game is won")
+		}
+
+		if err := g.Format.ValidateSet(s[0], s[1]); err != nil {
+			return fmt.Errorf("set %d: %w", i+1, err)
+		}
+
+		if s[0] > s[1] {
+			winsA++
+		} else {
+			winsB++
+		}
+		sets = append(sets, GameSet{GameId: g.ID, Number: i + 1, ScoreA: s[0], ScoreB: s[1]})
+	}
+
+	if winsA < needed && winsB < needed {
+		return fmt.Errorf("a side must win %d sets", needed)
+	}
+
+	g.Sets = sets
+	g.Winner = lo.Ternary(winsA > winsB, SideA, SideB)
+	return nil
+}
+
+// Revise records the current result in the history of the game
+func (g *Game) Revise(editedBy string) GameRevision {
+	revision := GameRevision{
+		GameId:   g.ID,
+		EditedBy: editedBy,
+		Players:  g.describePlayers(),
+		Scores:   g.ScoreSummary(),
+		Winner:   g.Winner,
+	}
+	g.History = append(g.History, revision)
+	return revision
+}
+
+func (g *Game) GetSide(side GameSide) []uint {
+	return lo.FilterMap(g.Players, func(p GamePlayer, _ int) (uint, bool) { return p.PlayerId, p.Side == side })
+}
+
+func (g *Game) ScoreSummary() string {
+	sets := append([]GameSet{}, g.Sets...)
+	sort.Slice(sets, func(i, j int) bool { return sets[i].Number < sets[j].Number })
+	return strings.Join(lo.Map(sets, func(s GameSet, _ int) string { return fmt.Sprintf("%d-%d", s.ScoreA, s.ScoreB) }), ", ")
+}
+
+func (g *Game) describePlayers() string {
+	side := func(side GameSide) string {
+		return strings.Join(lo.Map(g.GetSide(side), func(id uint, _ int) string { return fmt.Sprint(id) }), "+")
+	}
+	return fmt.Sprintf("%s vs %s", side(SideA), side(SideB))
+}
+
+// PlayerGameStats sums the results of a player
+type PlayerGameStats struct {
+	PlayerId      uint `json:"playerId"`
+	Played        int  `json:"played"`
+	Won           int  `json:"won"`
+	PointsFor     int  `json:"pointsFor"`
+	PointsAgainst int  `json:"pointsAgainst"`
+}
+
+func (s PlayerGameStats) WinRate() float64 {
+	if s.Played == 0 {
+		return 0
+	}
+	return float64(s.Won) / float64(s.Played)
+}
+
+// CalcPlayerGameStats ranks players by win rate, then wins, then point difference
+func CalcPlayerGameStats(games []Game) []PlayerGameStats {
+	stats := map[uint]*PlayerGameStats{}
+	for _, g := range games {
+		pointsA := lo.SumBy(g.Sets, func(s GameSet) int { return s.ScoreA })
+		pointsB := lo.SumBy(g.Sets, func(s GameSet) int { return s.ScoreB })
+		for _, p := range g.Players {
+			s, found := stats[p.PlayerId]
+			if !found {
+				s = &PlayerGameStats{PlayerId: p.PlayerId}
+				stats[p.PlayerId] = s
+			}
+
+			s.Played++
+			s.Won += lo.Ternary(p.Side == g.Winner, 1, 0)
+			s.PointsFor += lo.Ternary(p.Side == SideA, pointsA, pointsB)
+			s.PointsAgainst += lo.Ternary(p.Side == SideA, pointsB, pointsA)
+		}
+	}
+
+	result := lo.Map(lo.Values(stats), func(s *PlayerGameStats, _ int) PlayerGameStats { return *s })
+	sort.Slice(result, func(i, j int) bool {
+		a, b := result[i], result[j]
+		if a.WinRate() != b.WinRate() {
+			return a.WinRate() > b.WinRate()
+		}
+		if a.Won != b.Won {
+			return a.Won > b.Won
+		}
+		if diffA, diffB := a.PointsFor-a.PointsAgainst, b.PointsFor-b.PointsAgainst; diffA != diffB {
+			return diffA > diffB
+		}
+		return a.PlayerId < b.PlayerId
+	})
+	return result
+}
+
+var ErrPlayerNotInMatch = errors.New("every player of the game must play in the match")
+
+// EnsurePlayersInMatch checks that the game players are confirmed for the match or guests known as players
+func (m *Match) EnsurePlayersInMatch(g *Game) error {
+	if m.IsCancelled() || m.GetState() == MatchDraft {
+		return fmt.Errorf("games can not be recorded for a %s match", m.GetState())
+	}
+
+	for _, p := range g.Players {
+		playing := lo.ContainsBy(m.Registrations, func(r Registration) bool {
+			return r.IsConfirmed() && (r.PlayerId == p.PlayerId || lo.ContainsBy(r.Guests, func(guest Guest) bool {
+				return guest.PlayerId != nil && *guest.PlayerId == p.PlayerId
+			}))
+		})
+		if !playing {
+			return ErrPlayerNotInMatch
+		}
+	}
+	return nil
+}
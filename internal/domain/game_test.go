@@ -0,0 +1,132 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateSet(t *testing.T) {
+	tests := []struct {
+		a, b  int
+		valid bool
+	}{
+		{21, 0, true},
+		{21, 19, true},
+		{19, 21, true},
+		{21, 20, false},
+		{22, 20, true},
+		{23, 20, false},
+		{29, 27, true},
+		{30, 28, true},
+		{30, 29, true},
+		{31, 29, false},
+		{20, 18, false},
+		{21, 21, false},
+	}
+
+	for _, tt := range tests {
+		err := BadmintonScoring.ValidateSet(tt.a, tt.b)
+		assert.Equal(t, tt.valid, err == nil, "%d-%d", tt.a, tt.b)
+	}
+}
+
+func TestValidateSetWithCustomFormat(t *testing.T) {
+	// Short sets to 15 capped at 15
+	format := ScoringFormat{Points: 15, WinBy: 2, Cap: 15, BestOf: 1}
+	assert.Nil(t, format.ValidateSet(15, 14))
+	assert.Nil(t, format.ValidateSet(15, 13))
+	assert.NotNil(t, format.ValidateSet(16, 14))
+
+	// No cap
+	format = ScoringFormat{Points: 11, WinBy: 2, BestOf: 5}
+	assert.Nil(t, format.ValidateSet(35, 33))
+}
+
+func TestRecordScores(t *testing.T) {
+	game, err := NewGame(1, "1", ScoringFormat{})
+	assert.Nil(t, err)
+	assert.Equal(t, BadmintonScoring, game.Format)
+
+	assert.Nil(t, game.RecordScores([][2]int{{21, 19}, {18, 21}, {30, 29}}))
+	assert.Equal(t, SideA, game.Winner)
+	assert.Len(t, game.Sets, 3)
+	assert.Equal(t, "21-19, 18-21, 30-29", game.ScoreSummary())
+
+	assert.NotNil(t, game.RecordScores([][2]int{{21, 19}}), "game is not complete")
+	assert.NotNil(t, game.RecordScores([][2]int{{21, 19}, {21, 10}, {10, 21}}), "game was already won")
+	assert.NotNil(t, game.RecordScores([][2]int{{21, 19}, {21, 20}}), "invalid set")
+
+	_, err = NewGame(1, "1", ScoringFormat{Points: 21, WinBy: 2, BestOf: 2})
+	assert.NotNil(t, err)
+}
+
+func TestSetPlayers(t *testing.T) {
+	game, _ := NewGame(1, "1", BadmintonScoring)
+	assert.Nil(t, game.SetPlayers([]uint{1, 2}, []uint{3, 4}))
+	assert.Equal(t, []uint{3, 4}, game.GetSide(SideB))
+
+	assert.Equal(t, ErrInvalidGamePlayers, game.SetPlayers([]uint{1, 2}, []uint{3}))
+	assert.Equal(t, ErrInvalidGamePlayers, game.SetPlayers([]uint{1, 2}, []uint{2, 3}))
+	assert.Equal(t, ErrInvalidGamePlayers, game.SetPlayers(nil, nil))
+}
+
+func TestReviseKeepsHistory(t *testing.T) {
+	game, _ := NewGame(1, "1", BadmintonScoring)
+	game.SetPlayers([]uint{1}, []uint{2})
+	game.RecordScores([][2]int{{21, 10}, {21, 10}})
+	game.Revise("auth0|a")
+
+	game.RecordScores([][2]int{{10, 21}, {10, 21}})
+	revision := game.Revise("auth0|b")
+
+	assert.Len(t, game.History, 2)
+	assert.Equal(t, "1 vs 2", revision.Players)
+	assert.Equal(t, "21-10, 21-10", game.History[0].Scores)
+	assert.Equal(t, SideB, revision.Winner)
+}
+
+func TestEnsurePlayersInMatch(t *testing.T) {
+	guestPlayerId := uint(5)
+	m := &Match{
+		State: MatchPlayed,
+		Registrations: []Registration{
+			{PlayerId: 1, Guests: []Guest{{PlayerId: &guestPlayerId}}},
+			{PlayerId: 2},
+			{PlayerId: 3, IsWaitlisted: true},
+		},
+	}
+
+	game, _ := NewGame(1, "1", BadmintonScoring)
+	game.SetPlayers([]uint{1}, []uint{5})
+	assert.Nil(t, m.EnsurePlayersInMatch(game))
+
+	game.SetPlayers([]uint{1}, []uint{3})
+	assert.Equal(t, ErrPlayerNotInMatch, m.EnsurePlayersInMatch(game))
+
+	m.State = MatchCancelled
+	game.SetPlayers([]uint{1}, []uint{2})
+	assert.NotNil(t, m.EnsurePlayersInMatch(game))
+}
+
+func TestCalcPlayerGameStats(t *testing.T) {
+	games := []Game{}
+	for _, result := range [][3]uint{{1, 2, 1}, {1, 3, 1}, {2, 3, 2}, {3, 1, 3}} {
+		game, _ := NewGame(1, "1", ScoringFormat{Points: 21, WinBy: 2, Cap: 30, BestOf: 1})
+		game.SetPlayers([]uint{result[0]}, []uint{result[1]})
+		score := [2]int{21, 15}
+		if result[2] != result[0] {
+			score = [2]int{15, 21}
+		}
+		game.RecordScores([][2]int{score})
+		games = append(games, *game)
+	}
+
+	stats := CalcPlayerGameStats(games)
+	assert.Len(t, stats, 3)
+
+	// Player 1 won 2 of 3, player 2 won 1 of 2 and player 3 won 1 of 3
+	assert.Equal(t, []uint{1, 2, 3}, []uint{stats[0].PlayerId, stats[1].PlayerId, stats[2].PlayerId})
+	assert.Equal(t, 2, stats[0].Won)
+	assert.Equal(t, 3, stats[0].Played)
+}
@@ -0,0 +1,139 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/samber/lo"
+)
+
+var (
+	ErrNoFreeSpot           = errors.New("match is full")
+	ErrGuestNotFound        = errors.New("guest not found")
+	ErrRegistrationNotFound = errors.New("registration not found")
+	ErrAlreadyRegistered    = errors.New("player already registered for this match")
+	ErrGuestNameRequired    = errors.New("guest name is required")
+)
+
+// Guest is a named +1 brought by a registered player, the host pays for them.
+// A guest can be linked to an existing player, e.g. a regular coming as someone's +1.
+type Guest struct {
+	BaseModel
+	RegistrationId uint   `gorm:"index" json:"registrationId"`
+	Name           string `json:"name"`
+	PlayerId       *uint  `gorm:"index" json:"playerId"`
+}
+
+func NewGuest(name string, playerId *uint) (*Guest, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, ErrGuestNameRequired
+	}
+
+	return &Guest{
+		Name:     name,
+		PlayerId: playerId,
+	}, nil
+}
+
+// UnnamedGuestCount is the number of +1s paid for without a name
+func (reg *Registration) UnnamedGuestCount() uint {
+	named := uint(len(reg.Guests)) + 1
+	return lo.Ternary(reg.TotalPlayerPaidFor > named, reg.TotalPlayerPaidFor-named, 0)
+}
+
+// AddGuest names one of the unnamed +1s of the registration or brings one more player.
+// Guests of a confirmed registration take a spot, guests of a waitlisted or undecided one wait with their host.
+func (m *Match) AddGuest(registrationId uint, guest *Guest) (*Registration, error) {
+	reg := m.findRegistration(registrationId)
+	if reg == nil {
+		return nil, ErrRegistrationNotFound
+	}
+
+	if guest.PlayerId != nil && m.isPlayerAttending(*guest.PlayerId, 0) {
+		return nil, ErrAlreadyRegistered
+	}
+
+	if reg.UnnamedGuestCount() == 0 {
+		if reg.IsConfirmed() && !m.HasFreeSpot(1) {
+			return nil, ErrNoFreeSpot
+		}
+		reg.TotalPlayerPaidFor++
+	}
+
+	guest.RegistrationId = reg.ID
+	reg.Guests = append(reg.Guests, *guest)
+	return reg, nil
+}
+
+// ChangePlayerPaidForCount sets how many players the registration pays for, named guests are kept.
+// Extra players of a confirmed registration take spots like guests, they are refused when the match is full.
+func (m *Match) ChangePlayerPaidForCount(registrationId uint, count uint) (*Registration, error) {
+	reg := m.findRegistration(registrationId)
+	if reg == nil {
+		return nil, ErrRegistrationNotFound
+	}
+
+	if reg.IsConfirmed() && count > reg.TotalPlayerPaidFor && !m.HasFreeSpot(count-reg.TotalPlayerPaidFor) {
+		return nil, ErrNoFreeSpot
+	}
+
+	reg.UpdatePlayerPaidForCount(count)
+	return reg, nil
+}
+
+// RemoveGuest frees the spot taken by the guest
+func (reg *Registration) RemoveGuest(guestId uint) (*Guest, error) {
+	guest, found := lo.Find(reg.Guests, func(g Guest) bool { return g.ID == guestId })
+	if !found {
+		return nil, ErrGuestNotFound
+	}
+
+	reg.Guests = lo.Filter(reg.Guests, func(g Guest, _ int) bool { return g.ID != guestId })
+	reg.UpdatePlayerPaidForCount(reg.TotalPlayerPaidFor - 1)
+	return &guest, nil
+}
+
+// ConvertGuest turns a guest into a registration of its own so the player pays their share.
+// The spot moves from the host to the new registration, capacity does not change.
+func (m *Match) ConvertGuest(guestId, playerId uint) (*Registration, error) {
+	host, found := lo.Find(m.Registrations, func(r Registration) bool {
+		return lo.ContainsBy(r.Guests, func(g Guest) bool { return g.ID == guestId })
+	})
+	if !found {
+		return nil, ErrGuestNotFound
+	}
+
+	// The guest itself may already be linked to the player, it is leaving the host
+	if m.isPlayerAttending(playerId, guestId) {
+		return nil, ErrAlreadyRegistered
+	}
+
+	reg := m.findRegistration(host.ID)
+	if _, err := reg.RemoveGuest(guestId); err != nil {
+		return nil, err
+	}
+
+	registration := NewRegistration(playerId, m.ID)
+	registration.Rsvp = reg.Rsvp
+	registration.IsWaitlisted = reg.IsWaitlisted
+	return registration, nil
+}
+
+func (m *Match) findRegistration(registrationId uint) *Registration {
+	for i := range m.Registrations {
+		if m.Registrations[i].ID == registrationId {
+			return &m.Registrations[i]
+		}
+	}
+	return nil
+}
+
+// isPlayerAttending tells whether the player is registered or brought as a guest, the guest given is ignored
+func (m *Match) isPlayerAttending(playerId, exceptGuestId uint) bool {
+	return lo.ContainsBy(m.Registrations, func(r Registration) bool {
+		return r.PlayerId == playerId || lo.ContainsBy(r.Guests, func(g Guest) bool {
+			return g.ID != exceptGuestId && g.PlayerId != nil && *g.PlayerId == playerId
+		})
+	})
+}
@@ -0,0 +1,151 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddGuest(t *testing.T) {
+	m := &Match{
+		CourtBookings: []CourtBooking{{Capacity: 4}},
+		Registrations: []Registration{
+			{BaseModel: BaseModel{ID: 1}, PlayerId: 1, TotalPlayerPaidFor: 2},
+			{BaseModel: BaseModel{ID: 2}, PlayerId: 2, TotalPlayerPaidFor: 1},
+		},
+	}
+
+	// Naming the unnamed +1 does not take another spot
+	guest, _ := NewGuest("Alice", nil)
+	reg, err := m.AddGuest(1, guest)
+	assert.Nil(t, err)
+	assert.Equal(t, uint(2), reg.TotalPlayerPaidFor)
+	assert.Equal(t, 3, m.CalcPlayerCount())
+
+	guest, _ = NewGuest("Bob", nil)
+	reg, err = m.AddGuest(1, guest)
+	assert.Nil(t, err)
+	assert.Equal(t, uint(3), reg.TotalPlayerPaidFor)
+	assert.Equal(t, 4, m.CalcPlayerCount())
+
+	guest, _ = NewGuest("Carol", nil)
+	_, err = m.AddGuest(2, guest)
+	assert.Equal(t, ErrNoFreeSpot, err)
+}
+
+func TestAddGuestRefusesRegisteredPlayer(t *testing.T) {
+	m := &Match{
+		Registrations: []Registration{
+			{BaseModel: BaseModel{ID: 1}, PlayerId: 1, TotalPlayerPaidFor: 1},
+			{BaseModel: BaseModel{ID: 2}, PlayerId: 2, TotalPlayerPaidFor: 1},
+		},
+	}
+
+	playerId := uint(2)
+	guest, _ := NewGuest("Bob", &playerId)
+	_, err := m.AddGuest(1, guest)
+	assert.Equal(t, ErrAlreadyRegistered, err)
+
+	_, err = NewGuest(" ", nil)
+	assert.Equal(t, ErrGuestNameRequired, err)
+}
+
+func TestRemoveGuest(t *testing.T) {
+	reg := &Registration{
+		TotalPlayerPaidFor: 3,
+		Guests:             []Guest{{BaseModel: BaseModel{ID: 1}, Name: "Alice"}},
+	}
+
+	_, err := reg.RemoveGuest(2)
+	assert.Equal(t, ErrGuestNotFound, err)
+
+	_, err = reg.RemoveGuest(1)
+	assert.Nil(t, err)
+	assert.Equal(t, uint(2), reg.TotalPlayerPaidFor)
+	assert.Equal(t, uint(1), reg.UnnamedGuestCount())
+}
+
+func TestConvertGuest(t *testing.T) {
+	m := &Match{
+		BaseModel: BaseModel{ID: 7},
+		Registrations: []Registration{
+			{
+				BaseModel:          BaseModel{ID: 1},
+				PlayerId:           1,
+				TotalPlayerPaidFor: 2,
+				Guests:             []Guest{{BaseModel: BaseModel{ID: 5}, RegistrationId: 1, Name: "Alice"}},
+			},
+		},
+	}
+
+	_, err := m.ConvertGuest(5, 1)
+	assert.Equal(t, ErrAlreadyRegistered, err)
+
+	reg, err := m.ConvertGuest(5, 3)
+	assert.Nil(t, err)
+	assert.Equal(t, uint(3), reg.PlayerId)
+	assert.Equal(t, uint(7), reg.MatchId)
+	assert.Equal(t, uint(1), m.Registrations[0].TotalPlayerPaidFor)
+	assert.Empty(t, m.Registrations[0].Guests)
+}
+
+func TestConvertLinkedGuest(t *testing.T) {
+	bob := uint(4)
+	m := &Match{
+		BaseModel: BaseModel{ID: 7},
+		Registrations: []Registration{
+			{
+				BaseModel:          BaseModel{ID: 1},
+				PlayerId:           1,
+				TotalPlayerPaidFor: 3,
+				Guests: []Guest{
+					{BaseModel: BaseModel{ID: 5}, RegistrationId: 1, Name: "Bob", PlayerId: &bob},
+					{BaseModel: BaseModel{ID: 6}, RegistrationId: 1, Name: "Bob again", PlayerId: &bob},
+				},
+			},
+		},
+	}
+
+	// A second guest linked to the same player still counts as attending
+	_, err := m.ConvertGuest(5, 4)
+	assert.Equal(t, ErrAlreadyRegistered, err)
+
+	m.Registrations[0].Guests = m.Registrations[0].Guests[:1]
+	m.Registrations[0].TotalPlayerPaidFor = 2
+	reg, err := m.ConvertGuest(5, 4)
+	assert.Nil(t, err)
+	assert.Equal(t, uint(4), reg.PlayerId)
+	assert.Empty(t, m.Registrations[0].Guests)
+}
+
+func TestChangePlayerPaidForCount(t *testing.T) {
+	m := &Match{
+		CourtBookings: []CourtBooking{{Capacity: 5}},
+		Registrations: []Registration{
+			{BaseModel: BaseModel{ID: 1}, PlayerId: 1, TotalPlayerPaidFor: 2, Guests: []Guest{{Name: "Alice"}}},
+			{BaseModel: BaseModel{ID: 2}, PlayerId: 2, TotalPlayerPaidFor: 1},
+			{BaseModel: BaseModel{ID: 3}, PlayerId: 3, TotalPlayerPaidFor: 1, IsWaitlisted: true},
+		},
+	}
+
+	reg, err := m.ChangePlayerPaidForCount(2, 3)
+	assert.Nil(t, err)
+	assert.Equal(t, uint(3), reg.TotalPlayerPaidFor)
+	assert.Equal(t, 5, m.CalcPlayerCount())
+
+	_, err = m.ChangePlayerPaidForCount(2, 4)
+	assert.Equal(t, ErrNoFreeSpot, err)
+	assert.Equal(t, 5, m.CalcPlayerCount())
+
+	// Waitlisted registrations take no spot until promoted
+	_, err = m.ChangePlayerPaidForCount(3, 2)
+	assert.Nil(t, err)
+
+	// Named guests keep their spot
+	reg, err = m.ChangePlayerPaidForCount(1, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, uint(2), reg.TotalPlayerPaidFor)
+
+	_, err = m.ChangePlayerPaidForCount(9, 1)
+	assert.Equal(t, ErrRegistrationNotFound, err)
+}
@@ -0,0 +1,380 @@
+package domain
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/samber/lo"
+)
+
+type ChallengeState = string
+
+const (
+	ChallengePending   ChallengeState = "pending"
+	ChallengeAccepted  ChallengeState = "accepted"
+	ChallengePlayed    ChallengeState = "played"
+	ChallengeForfeited ChallengeState = "forfeited"
+	ChallengeCancelled ChallengeState = "cancelled"
+)
+
+var (
+	ErrNotOnLadder          = errors.New("player is not on the ladder")
+	ErrAlreadyOnLadder      = errors.New("player is already on the ladder")
+	ErrChallengeNotFound    = errors.New("challenge not found")
+	ErrChallengeOutOfRange  = errors.New("player can not be challenged from this position")
+	ErrChallengeAlreadyOpen = errors.New("player already has an open challenge")
+	ErrChallengeNotPending  = errors.New("challenge is not waiting for a response")
+	ErrChallengeNotAccepted = errors.New("challenge has not been accepted")
+	ErrNotChallengedPlayer  = errors.New("only the challenged player can respond")
+)
+
+// DefaultPlayDays is how long accepted challenges have to be played when the ladder does not say
+const DefaultPlayDays = 14
+
+// Ladder ranks players by position, a player can challenge players up to ChallengeRange positions above.
+// Challenges not answered within ResponseDays are forfeited by the challenged player, accepted challenges
+// not played within PlayDays are cancelled and players without a played challenge for InactivityDays
+// drop InactivityDrop positions.
+type Ladder struct {
+	BaseModel
+	Name           string            `json:"name"`
+	ChallengeRange int               `json:"challengeRange"`
+	ResponseDays   int               `json:"responseDays"`
+	PlayDays       int               `gorm:"default:14" json:"playDays"`
+	InactivityDays int               `json:"inactivityDays"`
+	InactivityDrop int               `json:"inactivityDrop"`
+	Scoring        ScoringFormat     `gorm:"embedded;embeddedPrefix:scoring_" json:"scoring"`
+	Rungs          []LadderRung      `json:"rungs"`
+	Challenges     []LadderChallenge `json:"challenges"`
+	Penalties      []LadderPenalty   `json:"penalties"`
+}
+
+// LadderRung is the position of a player, position 1 is the top of the ladder
+type LadderRung struct {
+	BaseModel
+	LadderId     uint      `gorm:"index" json:"ladderId"`
+	PlayerId     uint      `gorm:"index" json:"playerId"`
+	Position     int       `json:"position"`
+	LastActiveAt time.Time `json:"lastActiveAt"`
+}
+
+// LadderChallenge keeps the positions at the time of the challenge so the history reads as it happened
+type LadderChallenge struct {
+	BaseModel
+	LadderId           uint           `gorm:"index" json:"ladderId"`
+	ChallengerId       uint           `gorm:"index" json:"challengerId"`
+	DefenderId         uint           `gorm:"index" json:"defenderId"`
+	ChallengerPosition int            `json:"challengerPosition"`
+	DefenderPosition   int            `json:"defenderPosition"`
+	State              ChallengeState `gorm:"index" json:"state"`
+	RespondBy          time.Time      `json:"respondBy"`
+	PlayBy             *time.Time     `json:"playBy"`
+	MatchId            *uint          `gorm:"index" json:"matchId"`
+	Court              string         `json:"court"`
+	Sets               [][2]int       `gorm:"serializer:json" json:"sets"`
+	WinnerId           *uint          `json:"winnerId"`
+	ResolvedAt         *time.Time     `json:"resolvedAt"`
+}
+
+// LadderPenalty records a drop for inactivity
+type LadderPenalty struct {
+	BaseModel
+	LadderId     uint `gorm:"index" json:"ladderId"`
+	PlayerId     uint `gorm:"index" json:"playerId"`
+	FromPosition int  `json:"fromPosition"`
+	ToPosition   int  `json:"toPosition"`
+}
+
+func NewLadder(name string, challengeRange, responseDays, inactivityDays, inactivityDrop int) (*Ladder, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, errors.New("ladder name is required")
+	}
+
+	if challengeRange < 1 {
+		return nil, errors.New("challenge range must be at least 1")
+	}
+
+	if responseDays < 1 {
+		return nil, errors.New("response deadline must be at least 1 day")
+	}
+
+	if inactivityDays < 0 || inactivityDrop < 0 {
+		return nil, errors.New("inactivity rules must not be negative")
+	}
+
+	return &Ladder{
+		Name:           name,
+		ChallengeRange: challengeRange,
+		ResponseDays:   responseDays,
+		PlayDays:       DefaultPlayDays,
+		InactivityDays: inactivityDays,
+		InactivityDrop: inactivityDrop,
+		Scoring:        BadmintonScoring,
+	}, nil
+}
+
+// SetScoring changes how challenges are scored, badminton scoring is used when none is given
+func (l *Ladder) SetScoring(format ScoringFormat) error {
+	if format == (ScoringFormat{}) {
+		format = BadmintonScoring
+	}
+
+	if err := format.validate(); err != nil {
+		return err
+	}
+
+	l.Scoring = format
+	return nil
+}
+
+// SetPlayDays changes how long accepted challenges have to be played, the default is used when zero
+func (l *Ladder) SetPlayDays(days int) error {
+	if days < 0 {
+		return errors.New("play deadline must not be negative")
+	}
+
+	l.PlayDays = lo.Ternary(days == 0, DefaultPlayDays, days)
+	return nil
+}
+
+// Join puts the player at the bottom of the ladder
+func (l *Ladder) Join(playerId uint, now time.Time) (*LadderRung, error) {
+	if l.findRung(playerId) != nil {
+		return nil, ErrAlreadyOnLadder
+	}
+
+	l.Rungs = append(l.Rungs, LadderRung{
+		LadderId:     l.ID,
+		PlayerId:     playerId,
+		Position:     len(l.Rungs) + 1,
+		LastActiveAt: now,
+	})
+	return &l.Rungs[len(l.Rungs)-1], nil
+}
+
+// Leave removes the player, players below move up and open challenges of the player are cancelled
+func (l *Ladder) Leave(playerId uint, now time.Time) error {
+	rung := l.findRung(playerId)
+	if rung == nil {
+		return ErrNotOnLadder
+	}
+
+	position := rung.Position
+	l.Rungs = lo.Filter(l.Rungs, func(r LadderRung, _ int) bool { return r.PlayerId != playerId })
+	for i := range l.Rungs {
+		if l.Rungs[i].Position > position {
+			l.Rungs[i].Position--
+		}
+	}
+
+	for i := range l.Challenges {
+		c := &l.Challenges[i]
+		if c.IsOpen() && (c.ChallengerId == playerId || c.DefenderId == playerId) {
+			c.State = ChallengeCancelled
+			c.ResolvedAt = &now
+		}
+	}
+	return nil
+}
+
+// Challenge is made against a player at most ChallengeRange positions above the challenger,
+// a player can only be part of one open challenge at a time
+func (l *Ladder) Challenge(challengerId, defenderId uint, now time.Time) (*LadderChallenge, error) {
+	challenger, defender := l.findRung(challengerId), l.findRung(defenderId)
+	if challenger == nil || defender == nil {
+		return nil, ErrNotOnLadder
+	}
+
+	if defender.Position >= challenger.Position || challenger.Position-defender.Position > l.ChallengeRange {
+		return nil, ErrChallengeOutOfRange
+	}
+
+	if l.hasOpenChallenge(challengerId) || l.hasOpenChallenge(defenderId) {
+		return nil, ErrChallengeAlreadyOpen
+	}
+
+	l.Challenges = append(l.Challenges, LadderChallenge{
+		LadderId:           l.ID,
+		ChallengerId:       challengerId,
+		DefenderId:         defenderId,
+		ChallengerPosition: challenger.Position,
+		DefenderPosition:   defender.Position,
+		State:              ChallengePending,
+		RespondBy:          now.AddDate(0, 0, l.ResponseDays),
+	})
+	return &l.Challenges[len(l.Challenges)-1], nil
+}
+
+// Respond accepts or declines a pending challenge, declining forfeits it to the challenger.
+// An accepted challenge has to be played within PlayDays.
+func (l *Ladder) Respond(challengeId, playerId uint, accept bool, now time.Time) (*LadderChallenge, error) {
+	c := l.findChallenge(challengeId)
+	if c == nil {
+		return nil, ErrChallengeNotFound
+	}
+
+	if c.DefenderId != playerId {
+		return nil, ErrNotChallengedPlayer
+	}
+
+	if c.State != ChallengePending || !now.Before(c.RespondBy) {
+		return nil, ErrChallengeNotPending
+	}
+
+	if !accept {
+		l.resolve(c, c.ChallengerId, ChallengeForfeited, now)
+		return c, nil
+	}
+
+	playBy := now.AddDate(0, 0, l.getPlayDays())
+	c.State = ChallengeAccepted
+	c.PlayBy = &playBy
+	return c, nil
+}
+
+// Schedule attaches an open challenge to a match session so it is played at a normal court booking
+func (l *Ladder) Schedule(challengeId, matchId uint, court string) (*LadderChallenge, error) {
+	c := l.findChallenge(challengeId)
+	if c == nil {
+		return nil, ErrChallengeNotFound
+	}
+
+	if !c.IsOpen() {
+		return nil, ErrChallengeNotAccepted
+	}
+
+	c.MatchId = &matchId
+	c.Court = strings.TrimSpace(court)
+	return c, nil
+}
+
+// RecordResult scores an accepted challenge, when the challenger wins the two players swap positions
+func (l *Ladder) RecordResult(challengeId uint, scores [][2]int, now time.Time) (*LadderChallenge, error) {
+	c := l.findChallenge(challengeId)
+	if c == nil {
+		return nil, ErrChallengeNotFound
+	}
+
+	if c.State != ChallengeAccepted {
+		return nil, ErrChallengeNotAccepted
+	}
+
+	game := &Game{Format: l.Scoring}
+	if err := game.RecordScores(scores); err != nil {
+		return nil, err
+	}
+
+	c.Sets = scores
+	l.resolve(c, lo.Ternary(game.Winner == SideA, c.ChallengerId, c.DefenderId), ChallengePlayed, now)
+	return c, nil
+}
+
+// Refresh forfeits challenges past their response deadline, cancels accepted challenges past their play deadline
+// and applies the inactivity penalties. It is run before the ladder is read or changed so the ladder is always up to date.
+// Nobody moves when an accepted challenge is not played, both players count as inactive again.
+func (l *Ladder) Refresh(now time.Time) {
+	for i := range l.Challenges {
+		c := &l.Challenges[i]
+		if c.State == ChallengePending && !now.Before(c.RespondBy) {
+			l.resolve(c, c.ChallengerId, ChallengeForfeited, c.RespondBy)
+		}
+
+		if playBy := l.getPlayBy(*c); c.State == ChallengeAccepted && !now.Before(playBy) {
+			c.State = ChallengeCancelled
+			c.ResolvedAt = &playBy
+		}
+	}
+
+	if l.InactivityDays == 0 || l.InactivityDrop == 0 {
+		return
+	}
+
+	// From the bottom up so the players still to be checked keep their positions
+	for _, playerId := range lo.Reverse(lo.Map(l.SortedRungs(), func(r LadderRung, _ int) uint { return r.PlayerId })) {
+		rung := l.findRung(playerId)
+		if l.hasOpenChallenge(playerId) || now.Before(rung.LastActiveAt.AddDate(0, 0, l.InactivityDays)) {
+			continue
+		}
+
+		from := rung.Position
+		to := min(from+l.InactivityDrop, len(l.Rungs))
+		rung.LastActiveAt = now
+		if from == to {
+			continue
+		}
+
+		for i := range l.Rungs {
+			if l.Rungs[i].Position > from && l.Rungs[i].Position <= to {
+				l.Rungs[i].Position--
+			}
+		}
+		rung.Position = to
+		l.Penalties = append(l.Penalties, LadderPenalty{LadderId: l.ID, PlayerId: playerId, FromPosition: from, ToPosition: to})
+	}
+}
+
+// SortedRungs returns the rungs from the top of the ladder
+func (l *Ladder) SortedRungs() []LadderRung {
+	rungs := append([]LadderRung{}, l.Rungs...)
+	sort.SliceStable(rungs, func(i, j int) bool { return rungs[i].Position < rungs[j].Position })
+	return rungs
+}
+
+func (c LadderChallenge) IsOpen() bool {
+	return c.State == ChallengePending || c.State == ChallengeAccepted
+}
+
+func (l *Ladder) resolve(c *LadderChallenge, winnerId uint, state ChallengeState, now time.Time) {
+	c.State = state
+	c.WinnerId = &winnerId
+	c.ResolvedAt = &now
+
+	challenger, defender := l.findRung(c.ChallengerId), l.findRung(c.DefenderId)
+	if state == ChallengePlayed {
+		challenger.LastActiveAt = now
+		defender.LastActiveAt = now
+	}
+
+	if winnerId == c.ChallengerId && challenger.Position > defender.Position {
+		challenger.Position, defender.Position = defender.Position, challenger.Position
+	}
+}
+
+// getPlayBy falls back on the response deadline for challenges accepted before play deadlines existed
+func (l *Ladder) getPlayBy(c LadderChallenge) time.Time {
+	if c.PlayBy != nil {
+		return *c.PlayBy
+	}
+	return c.RespondBy.AddDate(0, 0, l.getPlayDays())
+}
+
+func (l *Ladder) getPlayDays() int {
+	return lo.Ternary(l.PlayDays > 0, l.PlayDays, DefaultPlayDays)
+}
+
+func (l *Ladder) hasOpenChallenge(playerId uint) bool {
+	return lo.ContainsBy(l.Challenges, func(c LadderChallenge) bool {
+		return c.IsOpen() && (c.ChallengerId == playerId || c.DefenderId == playerId)
+	})
+}
+
+func (l *Ladder) findRung(playerId uint) *LadderRung {
+	for i := range l.Rungs {
+		if l.Rungs[i].PlayerId == playerId {
+			return &l.Rungs[i]
+		}
+	}
+	return nil
+}
+
+func (l *Ladder) findChallenge(id uint) *LadderChallenge {
+	for i := range l.Challenges {
+		if l.Challenges[i].ID == id {
+			return &l.Challenges[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,184 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestLadder(t *testing.T, players int, now time.Time) *Ladder {
+	ladder, err := NewLadder("Singles ladder", 2, 3, 30, 2)
+	assert.Nil(t, err)
+
+	for i := 1; i <= players; i++ {
+		_, err := ladder.Join(uint(i), now)
+		assert.Nil(t, err)
+	}
+	return ladder
+}
+
+// challenge gives the challenge an id as the database would
+func challenge(t *testing.T, ladder *Ladder, challengerId, defenderId uint, now time.Time) *LadderChallenge {
+	c, err := ladder.Challenge(challengerId, defenderId, now)
+	assert.Nil(t, err)
+	c.ID = uint(len(ladder.Challenges))
+	return c
+}
+
+func ladderPositions(ladder *Ladder) []uint {
+	var players []uint
+	for _, r := range ladder.SortedRungs() {
+		players = append(players, r.PlayerId)
+	}
+	return players
+}
+
+func TestLadderChallengeRange(t *testing.T) {
+	now := time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC)
+	ladder := newTestLadder(t, 5, now)
+
+	tests := []struct {
+		name         string
+		challengerId uint
+		defenderId   uint
+		err          error
+	}{
+		{"one above", 5, 4, nil},
+		{"two above", 3, 1, nil},
+		{"three above", 4, 1, ErrChallengeOutOfRange},
+		{"below", 2, 3, ErrChallengeOutOfRange},
+		{"already challenged", 2, 1, ErrChallengeAlreadyOpen},
+		{"not on ladder", 6, 5, ErrNotOnLadder},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ladder.Challenge(tt.challengerId, tt.defenderId, now)
+			assert.ErrorIs(t, err, tt.err)
+		})
+	}
+}
+
+func TestLadderResultSwapsPositions(t *testing.T) {
+	now := time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC)
+	ladder := newTestLadder(t, 4, now)
+
+	c := challenge(t, ladder, 4, 2, now)
+	_, err := ladder.RecordResult(c.ID, [][2]int{{21, 10}, {21, 12}}, now)
+	assert.ErrorIs(t, err, ErrChallengeNotAccepted)
+
+	_, err = ladder.Respond(c.ID, 4, true, now)
+	assert.ErrorIs(t, err, ErrNotChallengedPlayer)
+
+	_, err = ladder.Respond(c.ID, 2, true, now)
+	assert.Nil(t, err)
+
+	c, err = ladder.RecordResult(c.ID, [][2]int{{21, 10}, {21, 12}}, now)
+	assert.Nil(t, err)
+	assert.Equal(t, ChallengePlayed, c.State)
+	assert.Equal(t, uint(4), *c.WinnerId)
+	assert.Equal(t, []uint{1, 4, 3, 2}, ladderPositions(ladder))
+
+	// The defender keeps the position after a win
+	c = challenge(t, ladder, 3, 4, now)
+	_, err = ladder.Respond(c.ID, 4, true, now)
+	assert.Nil(t, err)
+	_, err = ladder.RecordResult(c.ID, [][2]int{{10, 21}, {12, 21}}, now)
+	assert.Nil(t, err)
+	assert.Equal(t, []uint{1, 4, 3, 2}, ladderPositions(ladder))
+}
+
+func TestLadderUnansweredChallengeIsForfeited(t *testing.T) {
+	now := time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC)
+	ladder := newTestLadder(t, 3, now)
+
+	c := challenge(t, ladder, 3, 2, now)
+	ladder.Refresh(now.AddDate(0, 0, 2))
+	assert.Equal(t, ChallengePending, c.State)
+
+	ladder.Refresh(now.AddDate(0, 0, 3))
+	assert.Equal(t, ChallengeForfeited, c.State)
+	assert.Equal(t, uint(3), *c.WinnerId)
+	assert.Equal(t, []uint{1, 3, 2}, ladderPositions(ladder))
+
+	_, err := ladder.Respond(c.ID, 2, true, now.AddDate(0, 0, 3))
+	assert.ErrorIs(t, err, ErrChallengeNotPending)
+}
+
+func TestLadderUnplayedChallengeIsCancelled(t *testing.T) {
+	now := time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC)
+	ladder := newTestLadder(t, 3, now)
+	assert.Nil(t, ladder.SetPlayDays(7))
+
+	c := challenge(t, ladder, 2, 1, now)
+	_, err := ladder.Respond(c.ID, 1, true, now)
+	assert.Nil(t, err)
+	assert.Equal(t, now.AddDate(0, 0, 7), *c.PlayBy)
+
+	ladder.Refresh(now.AddDate(0, 0, 6))
+	assert.Equal(t, ChallengeAccepted, c.State)
+	_, err = ladder.Challenge(3, 1, now.AddDate(0, 0, 6))
+	assert.ErrorIs(t, err, ErrChallengeAlreadyOpen)
+
+	// Nobody moves and the top player can be challenged again
+	ladder.Refresh(now.AddDate(0, 0, 7))
+	assert.Equal(t, ChallengeCancelled, c.State)
+	assert.Nil(t, c.WinnerId)
+	assert.Equal(t, []uint{1, 2, 3}, ladderPositions(ladder))
+	_, err = ladder.Challenge(3, 1, now.AddDate(0, 0, 7))
+	assert.Nil(t, err)
+
+	_, err = ladder.RecordResult(c.ID, [][2]int{{21, 10}, {21, 12}}, now.AddDate(0, 0, 7))
+	assert.ErrorIs(t, err, ErrChallengeNotAccepted)
+}
+
+func TestLadderUnplayedChallengeLosesInactivityImmunity(t *testing.T) {
+	now := time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC)
+	ladder := newTestLadder(t, 3, now)
+
+	// Accepted challenges from before play deadlines existed are due PlayDays after the response deadline
+	c := challenge(t, ladder, 2, 1, now)
+	_, err := ladder.Respond(c.ID, 1, true, now)
+	assert.Nil(t, err)
+	c.PlayBy = nil
+
+	ladder.Refresh(now.AddDate(0, 0, 30))
+	assert.Equal(t, ChallengeCancelled, c.State)
+	assert.Equal(t, now.AddDate(0, 0, 3+DefaultPlayDays), *c.ResolvedAt)
+	// Both players of the unplayed challenge are penalized, player 3 is already at the bottom
+	assert.Equal(t, []uint{3, 2, 1}, ladderPositions(ladder))
+	assert.ElementsMatch(t, []uint{1, 2}, lo.Map(ladder.Penalties, func(p LadderPenalty, _ int) uint { return p.PlayerId }))
+}
+
+func TestLadderInactivityPenalty(t *testing.T) {
+	now := time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC)
+	ladder := newTestLadder(t, 5, now)
+
+	// Players 2 and 3 stay active, the others have not played for 30 days
+	c := challenge(t, ladder, 3, 2, now.AddDate(0, 0, 20))
+	_, err := ladder.Respond(c.ID, 2, true, now.AddDate(0, 0, 20))
+	assert.Nil(t, err)
+	_, err = ladder.RecordResult(c.ID, [][2]int{{10, 21}, {12, 21}}, now.AddDate(0, 0, 20))
+	assert.Nil(t, err)
+
+	ladder.Refresh(now.AddDate(0, 0, 30))
+	// Player 5 is already at the bottom, player 4 drops below it and player 1 drops two positions
+	assert.Equal(t, []uint{2, 3, 1, 5, 4}, ladderPositions(ladder))
+	assert.Len(t, ladder.Penalties, 2)
+
+	// Penalties are not applied again until the next inactive period
+	ladder.Refresh(now.AddDate(0, 0, 31))
+	assert.Len(t, ladder.Penalties, 2)
+}
+
+func TestLadderLeave(t *testing.T) {
+	now := time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC)
+	ladder := newTestLadder(t, 3, now)
+	c := challenge(t, ladder, 3, 2, now)
+
+	assert.Nil(t, ladder.Leave(2, now))
+	assert.Equal(t, []uint{1, 3}, ladderPositions(ladder))
+	assert.Equal(t, ChallengeCancelled, c.State)
+}
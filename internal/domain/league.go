@@ -0,0 +1,333 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/samber/lo"
+)
+
+type FixtureState = string
+
+const (
+	FixtureScheduled FixtureState = "scheduled"
+	FixturePostponed FixtureState = "postponed"
+	FixturePlayed    FixtureState = "played"
+)
+
+type Tiebreaker = string
+
+const (
+	TiebreakHeadToHead       Tiebreaker = "head_to_head"
+	TiebreakRubberDifference Tiebreaker = "rubber_difference"
+	TiebreakRubbersWon       Tiebreaker = "rubbers_won"
+	TiebreakWins             Tiebreaker = "wins"
+)
+
+var (
+	ErrFixtureNotFound  = errors.New("fixture not found")
+	ErrFixturePostponed = errors.New("fixture is postponed, reschedule it first")
+	ErrFixturesPlayed   = errors.New("fixtures can not be generated again once results are recorded")
+
+	DefaultTiebreakers = []Tiebreaker{TiebreakHeadToHead, TiebreakRubberDifference, TiebreakRubbersWon}
+)
+
+// Season groups the leagues the club runs over a period
+type Season struct {
+	BaseModel
+	Name     string    `json:"name"`
+	StartsOn time.Time `json:"startsOn"`
+	EndsOn   time.Time `json:"endsOn"`
+	Leagues  []League  `json:"leagues"`
+}
+
+// PointsRules gives league points for the result of a fixture plus points for every rubber won,
+// a fixture is made of a fixed number of rubbers (individual games) when Rubbers is set
+type PointsRules struct {
+	Win       int `json:"win"`
+	Draw      int `json:"draw"`
+	Loss      int `json:"loss"`
+	RubberWin int `json:"rubberWin"`
+	Rubbers   int `json:"rubbers"`
+}
+
+var DefaultPointsRules = PointsRules{Win: 2, Draw: 1, Loss: 0}
+
+// League is played between permanent teams, fixtures are played during match sessions
+type League struct {
+	BaseModel
+	SeasonId    uint         `gorm:"index" json:"seasonId"`
+	Name        string       `json:"name"`
+	Points      PointsRules  `gorm:"embedded;embeddedPrefix:points_" json:"points"`
+	Tiebreakers []Tiebreaker `gorm:"serializer:json" json:"tiebreakers"`
+	HomeAndAway bool         `json:"homeAndAway"`
+	Teams       []Team       `gorm:"many2many:league_teams;" json:"teams"`
+	Fixtures    []Fixture    `json:"fixtures"`
+}
+
+// Fixture is a meeting of two teams, a postponed fixture keeps the session it was planned for
+type Fixture struct {
+	BaseModel
+	LeagueId             uint         `gorm:"index" json:"leagueId"`
+	Round                int          `json:"round"`
+	HomeTeamId           uint         `gorm:"index" json:"homeTeamId"`
+	AwayTeamId           uint         `gorm:"index" json:"awayTeamId"`
+	MatchId              *uint        `gorm:"index" json:"matchId"`
+	State                FixtureState `gorm:"index" json:"state"`
+	HomeRubbers          int          `json:"homeRubbers"`
+	AwayRubbers          int          `json:"awayRubbers"`
+	PostponedReason      string       `json:"postponedReason"`
+	PostponedFromMatchId *uint        `json:"postponedFromMatchId"`
+}
+
+// Standing is the record of a team in the league table
+type Standing struct {
+	TeamId         uint `json:"teamId"`
+	Position       int  `json:"position"`
+	Played         int  `json:"played"`
+	Won            int  `json:"won"`
+	Drawn          int  `json:"drawn"`
+	Lost           int  `json:"lost"`
+	RubbersFor     int  `json:"rubbersFor"`
+	RubbersAgainst int  `json:"rubbersAgainst"`
+	Points         int  `json:"points"`
+}
+
+func NewSeason(name string, startsOn, endsOn time.Time) (*Season, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, errors.New("season name is required")
+	}
+
+	if !endsOn.After(startsOn) {
+		return nil, errors.New("season must end after it starts")
+	}
+
+	return &Season{Name: name, StartsOn: startsOn, EndsOn: endsOn}, nil
+}
+
+func NewLeague(seasonId uint, name string, rules PointsRules, tiebreakers []Tiebreaker, homeAndAway bool) (*League, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, errors.New("league name is required")
+	}
+
+	if rules == (PointsRules{}) {
+		rules = DefaultPointsRules
+	}
+
+	if rules.Win < rules.Draw || rules.Draw < rules.Loss || rules.RubberWin < 0 || rules.Rubbers < 0 {
+		return nil, errors.New("a win must give at least the points of a draw and a draw at least the points of a loss")
+	}
+
+	if len(tiebreakers) == 0 {
+		tiebreakers = DefaultTiebreakers
+	}
+
+	for _, t := range tiebreakers {
+		if !lo.Contains([]Tiebreaker{TiebreakHeadToHead, TiebreakRubberDifference, TiebreakRubbersWon, TiebreakWins}, t) {
+			return nil, fmt.Errorf("unknown tiebreaker %q", t)
+		}
+	}
+
+	return &League{
+		SeasonId:    seasonId,
+		Name:        name,
+		Points:      rules,
+		Tiebreakers: lo.Uniq(tiebreakers),
+		HomeAndAway: homeAndAway,
+	}, nil
+}
+
+// GenerateFixtures plays a round robin between the teams, every team plays at most once per round and hosts
+// about half of its fixtures. With home and away the second half of the season is the first one with the venues swapped.
+func (l *League) GenerateFixtures() ([]Fixture, error) {
+	if lo.SomeBy(l.Fixtures, func(f Fixture) bool { return f.State == FixturePlayed }) {
+		return nil, ErrFixturesPlayed
+	}
+
+	teamIds := lo.Map(l.Teams, func(t Team, _ int) uint { return t.ID })
+	if len(teamIds) < 2 {
+		return nil, errors.New("a league needs at least two teams")
+	}
+
+	// The team with more away than home games hosts, then the team that played away last
+	balance, lastAtHome := map[uint]int{}, map[uint]bool{}
+	firstLeg := []Fixture{}
+	for r, pairs := range roundRobin(teamIds) {
+		for _, pair := range pairs {
+			home, away := pair[0], pair[1]
+			if balance[away] < balance[home] || (balance[away] == balance[home] && lastAtHome[home] && !lastAtHome[away]) {
+				home, away = away, home
+			}
+
+			balance[home]++
+			balance[away]--
+			lastAtHome[home], lastAtHome[away] = true, false
+			firstLeg = append(firstLeg, Fixture{LeagueId: l.ID, Round: r + 1, HomeTeamId: home, AwayTeamId: away, State: FixtureScheduled})
+		}
+	}
+
+	fixtures := firstLeg
+	if l.HomeAndAway {
+		rounds := lo.Max(lo.Map(firstLeg, func(f Fixture, _ int) int { return f.Round }))
+		for _, f := range firstLeg {
+			f.Round += rounds
+			f.HomeTeamId, f.AwayTeamId = f.AwayTeamId, f.HomeTeamId
+			fixtures = append(fixtures, f)
+		}
+	}
+
+	l.Fixtures = fixtures
+	return fixtures, nil
+}
+
+// RecordResult records the rubbers won by each team, a result can be corrected
+func (f *Fixture) RecordResult(homeRubbers, awayRubbers int, rules PointsRules) error {
+	if f.State == FixturePostponed {
+		return ErrFixturePostponed
+	}
+
+	if homeRubbers < 0 || awayRubbers < 0 {
+		return errors.New("rubbers must not be negative")
+	}
+
+	if rules.Rubbers > 0 && homeRubbers+awayRubbers != rules.Rubbers {
+		return fmt.Errorf("a fixture is played over %d rubbers", rules.Rubbers)
+	}
+
+	f.HomeRubbers, f.AwayRubbers = homeRubbers, awayRubbers
+	f.State = FixturePlayed
+	return nil
+}
+
+// Postpone takes the fixture out of its session until a new session is found
+func (f *Fixture) Postpone(reason string) error {
+	if f.State == FixturePlayed {
+		return errors.New("a played fixture can not be postponed")
+	}
+
+	if f.MatchId != nil {
+		f.PostponedFromMatchId = f.MatchId
+	}
+	f.MatchId = nil
+	f.State = FixturePostponed
+	f.PostponedReason = strings.TrimSpace(reason)
+	return nil
+}
+
+// Reschedule plans the fixture in another session, postponed or not
+func (f *Fixture) Reschedule(matchId uint) error {
+	if f.State == FixturePlayed {
+		return errors.New("a played fixture can not be rescheduled")
+	}
+
+	f.MatchId = &matchId
+	f.State = FixtureScheduled
+	return nil
+}
+
+// CalcStandings ranks the teams by points, then by the tiebreakers of the league in order,
+// teams still level keep the order of their ids
+func (l *League) CalcStandings() []Standing {
+	standings := lo.SliceToMap(l.Teams, func(t Team) (uint, *Standing) { return t.ID, &Standing{TeamId: t.ID} })
+	for _, f := range l.Fixtures {
+		home, away := standings[f.HomeTeamId], standings[f.AwayTeamId]
+		if f.State != FixturePlayed || home == nil || away == nil {
+			continue
+		}
+		l.addResult(home, f.HomeRubbers, f.AwayRubbers)
+		l.addResult(away, f.AwayRubbers, f.HomeRubbers)
+	}
+
+	teamIds := lo.Keys(standings)
+	sort.Slice(teamIds, func(i, j int) bool { return teamIds[i] < teamIds[j] })
+	sort.SliceStable(teamIds, func(i, j int) bool { return standings[teamIds[i]].Points > standings[teamIds[j]].Points })
+
+	ranked := []uint{}
+	for _, tied := range groupTied(teamIds, func(id uint) int { return standings[id].Points }) {
+		ranked = append(ranked, l.breakTies(tied, l.Tiebreakers, standings)...)
+	}
+
+	return lo.Map(ranked, func(id uint, i int) Standing {
+		s := *standings[id]
+		s.Position = i + 1
+		return s
+	})
+}
+
+func (l *League) addResult(s *Standing, rubbersFor, rubbersAgainst int) {
+	s.Played++
+	s.RubbersFor += rubbersFor
+	s.RubbersAgainst += rubbersAgainst
+	s.Points += rubbersFor * l.Points.RubberWin
+	switch {
+	case rubbersFor > rubbersAgainst:
+		s.Won++
+		s.Points += l.Points.Win
+	case rubbersFor == rubbersAgainst:
+		s.Drawn++
+		s.Points += l.Points.Draw
+	default:
+		s.Lost++
+		s.Points += l.Points.Loss
+	}
+}
+
+// breakTies orders teams level on points with the first tiebreaker, teams still level go to the next one
+func (l *League) breakTies(teamIds []uint, tiebreakers []Tiebreaker, standings map[uint]*Standing) []uint {
+	if len(teamIds) < 2 || len(tiebreakers) == 0 {
+		return teamIds
+	}
+
+	key := l.tiebreakKey(tiebreakers[0], teamIds, standings)
+	sorted := append([]uint{}, teamIds...)
+	sort.SliceStable(sorted, func(i, j int) bool { return key(sorted[i]) > key(sorted[j]) })
+
+	ranked := []uint{}
+	for _, tied := range groupTied(sorted, key) {
+		ranked = append(ranked, l.breakTies(tied, tiebreakers[1:], standings)...)
+	}
+	return ranked
+}
+
+func (l *League) tiebreakKey(tiebreaker Tiebreaker, teamIds []uint, standings map[uint]*Standing) func(uint) int {
+	switch tiebreaker {
+	case TiebreakHeadToHead:
+		// Points from the fixtures between the tied teams only
+		points := map[uint]int{}
+		for _, f := range l.Fixtures {
+			if f.State != FixturePlayed || !lo.Contains(teamIds, f.HomeTeamId) || !lo.Contains(teamIds, f.AwayTeamId) {
+				continue
+			}
+
+			home, away := &Standing{}, &Standing{}
+			l.addResult(home, f.HomeRubbers, f.AwayRubbers)
+			l.addResult(away, f.AwayRubbers, f.HomeRubbers)
+			points[f.HomeTeamId] += home.Points
+			points[f.AwayTeamId] += away.Points
+		}
+		return func(id uint) int { return points[id] }
+	case TiebreakRubberDifference:
+		return func(id uint) int { return standings[id].RubbersFor - standings[id].RubbersAgainst }
+	case TiebreakRubbersWon:
+		return func(id uint) int { return standings[id].RubbersFor }
+	default:
+		return func(id uint) int { return standings[id].Won }
+	}
+}
+
+// groupTied splits sorted team ids in runs with the same key
+func groupTied(sorted []uint, key func(uint) int) [][]uint {
+	groups := [][]uint{}
+	for i, id := range sorted {
+		if i == 0 || key(id) != key(sorted[i-1]) {
+			groups = append(groups, []uint{})
+		}
+		groups[len(groups)-1] = append(groups[len(groups)-1], id)
+	}
+	return groups
+}
@@ -0,0 +1,126 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestLeague(t *testing.T, teams int, rules PointsRules, tiebreakers ...Tiebreaker) *League {
+	league, err := NewLeague(1, "Division 1", rules, tiebreakers, true)
+	assert.Nil(t, err)
+	league.Teams = lo.Map(lo.RangeFrom(1, teams), func(id int, _ int) Team { return Team{BaseModel: BaseModel{ID: uint(id)}} })
+	return league
+}
+
+func TestNewLeagueValidation(t *testing.T) {
+	_, err := NewLeague(1, "Division 1", PointsRules{Win: 1, Draw: 2}, nil, false)
+	assert.NotNil(t, err)
+
+	_, err = NewLeague(1, "Division 1", PointsRules{}, []Tiebreaker{"coin_toss"}, false)
+	assert.NotNil(t, err)
+
+	league, err := NewLeague(1, "Division 1", PointsRules{}, nil, false)
+	assert.Nil(t, err)
+	assert.Equal(t, DefaultPointsRules, league.Points)
+	assert.Equal(t, DefaultTiebreakers, league.Tiebreakers)
+}
+
+func TestGenerateFixturesHomeAndAway(t *testing.T) {
+	league := newTestLeague(t, 4, PointsRules{})
+	fixtures, err := league.GenerateFixtures()
+	assert.Nil(t, err)
+
+	// 6 meetings played twice over 6 rounds
+	assert.Len(t, fixtures, 12)
+	for round := 1; round <= 6; round++ {
+		inRound := lo.Filter(fixtures, func(f Fixture, _ int) bool { return f.Round == round })
+		teams := lo.FlatMap(inRound, func(f Fixture, _ int) []uint { return []uint{f.HomeTeamId, f.AwayTeamId} })
+		assert.ElementsMatch(t, []uint{1, 2, 3, 4}, teams, "round %d", round)
+	}
+
+	// Every team hosts every other team once
+	for _, home := range []uint{1, 2, 3, 4} {
+		hosted := lo.FilterMap(fixtures, func(f Fixture, _ int) (uint, bool) { return f.AwayTeamId, f.HomeTeamId == home })
+		assert.ElementsMatch(t, lo.Without([]uint{1, 2, 3, 4}, home), hosted)
+	}
+
+	fixtures[0].State = FixturePlayed
+	league.Fixtures = fixtures
+	_, err = league.GenerateFixtures()
+	assert.ErrorIs(t, err, ErrFixturesPlayed)
+}
+
+func TestFixturePostponement(t *testing.T) {
+	session, next := uint(10), uint(11)
+	f := &Fixture{State: FixtureScheduled, MatchId: &session}
+
+	assert.Nil(t, f.Postpone("Hall flooded"))
+	assert.Equal(t, FixturePostponed, f.State)
+	assert.Nil(t, f.MatchId)
+	assert.Equal(t, session, *f.PostponedFromMatchId)
+	assert.ErrorIs(t, f.RecordResult(3, 1, DefaultPointsRules), ErrFixturePostponed)
+
+	assert.Nil(t, f.Reschedule(next))
+	assert.Equal(t, next, *f.MatchId)
+	assert.Nil(t, f.RecordResult(3, 1, DefaultPointsRules))
+	assert.NotNil(t, f.Postpone(""))
+}
+
+func TestRecordFixtureResultRubbers(t *testing.T) {
+	f := &Fixture{State: FixtureScheduled}
+	rules := PointsRules{Win: 2, Rubbers: 4}
+
+	assert.NotNil(t, f.RecordResult(3, 2, rules))
+	assert.Nil(t, f.RecordResult(2, 2, rules))
+	assert.Equal(t, FixturePlayed, f.State)
+}
+
+func TestCalcStandings(t *testing.T) {
+	played := func(home, away uint, homeRubbers, awayRubbers int) Fixture {
+		return Fixture{HomeTeamId: home, AwayTeamId: away, HomeRubbers: homeRubbers, AwayRubbers: awayRubbers, State: FixturePlayed}
+	}
+
+	tests := []struct {
+		name        string
+		rules       PointsRules
+		tiebreakers []Tiebreaker
+		expected    []uint
+	}{
+		// 2 and 3 both have 2 points, 2 beat 3 but 3 has the better rubber difference
+		{"head to head then rubber difference", PointsRules{}, nil, []uint{1, 2, 3, 4}},
+		{"rubber difference first", PointsRules{}, []Tiebreaker{TiebreakRubberDifference}, []uint{1, 3, 2, 4}},
+		// A point per rubber rewards the big wins
+		{"rubber points", PointsRules{Win: 2, RubberWin: 1}, nil, []uint{1, 3, 2, 4}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			league := newTestLeague(t, 4, tt.rules, tt.tiebreakers...)
+			league.Fixtures = []Fixture{
+				played(1, 4, 5, 0),
+				played(1, 2, 3, 2),
+				played(2, 3, 3, 2),
+				played(3, 4, 5, 0),
+				{HomeTeamId: 2, AwayTeamId: 4, State: FixturePostponed},
+			}
+
+			standings := league.CalcStandings()
+			assert.Equal(t, tt.expected, lo.Map(standings, func(s Standing, _ int) uint { return s.TeamId }))
+			assert.Equal(t, 1, standings[0].Position)
+		})
+	}
+}
+
+func TestCalcStandingsRecord(t *testing.T) {
+	league := newTestLeague(t, 2, PointsRules{Win: 3, Draw: 1, Loss: 0})
+	league.Fixtures = []Fixture{
+		{HomeTeamId: 1, AwayTeamId: 2, HomeRubbers: 3, AwayRubbers: 1, State: FixturePlayed},
+		{HomeTeamId: 2, AwayTeamId: 1, HomeRubbers: 2, AwayRubbers: 2, State: FixturePlayed},
+	}
+
+	standings := league.CalcStandings()
+	assert.Equal(t, Standing{TeamId: 1, Position: 1, Played: 2, Won: 1, Drawn: 1, RubbersFor: 5, RubbersAgainst: 3, Points: 4}, standings[0])
+	assert.Equal(t, Standing{TeamId: 2, Position: 2, Played: 2, Drawn: 1, Lost: 1, RubbersFor: 3, RubbersAgainst: 5, Points: 1}, standings[1])
+}
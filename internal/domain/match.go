@@ -3,7 +3,8 @@ package domain
 import (
 	"encoding/json"
 	"errors"
-	"math"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/samber/lo"
@@ -11,24 +12,43 @@ import (
 
 type Match struct {
 	BaseModel
-	Start           time.Time        `json:"start"`
-	End             time.Time        `json:"end"`
-	SportCenterId   uint             `json:"sportCenterId"`
-	Cost            float64          `json:"cost"`
-	AdditionalCosts []AdditionalCost `json:"additionalCosts"`
-	SportCenter     SportCenter      `json:"sportCenter"`
-	Court           string           `json:"court"`
-	CustomSection   *float64         `gorm:"default:null" json:"customSection"`
-	Comment         string           `json:"comment"`
-	Registrations   []Registration   `json:"registrations"`
+	Start         time.Time `json:"start"`
+	End           time.Time `json:"end"`
+	SportCenterId uint      `json:"sportCenterId"`
+	Cost          float64   `json:"cost"`
+	// CostOverridden is set when the cost is entered manually, it is then kept on price changes
+	CostOverridden  bool              `json:"costOverridden"`
+	AdditionalCosts []AdditionalCost  `json:"additionalCosts"`
+	SportCenter     SportCenter       `json:"sportCenter"`
+	Court           string            `json:"court"`
+	CustomSection   *float64          `gorm:"default:null" json:"customSection"`
+	Comment         string            `json:"comment"`
+	Registrations   []Registration    `json:"registrations"`
+	CourtBookings   []CourtBooking    `json:"courtBookings"`
+	State           MatchState        `gorm:"index" json:"state"`
+	CancelledReason string            `json:"cancelledReason"`
+	Visibility      MatchVisibility   `gorm:"default:public" json:"visibility"`
+	Invitations     []MatchInvitation `json:"invitations"`
+	// SeriesId is the first match of a weekly series, it is unset on the first match itself
+	SeriesId *uint `gorm:"index" json:"seriesId"`
+
+	RegistrationOpensAt  *time.Time `json:"registrationOpensAt"`
+	RegistrationClosesAt *time.Time `json:"registrationClosesAt"`
+	CancellationDeadline *time.Time `json:"cancellationDeadline"`
+	LateCancellationFee  *float64   `json:"lateCancellationFee"`
+	BallotDrawAt         *time.Time `json:"ballotDrawAt"`
+	BallotDrawnAt        *time.Time `json:"ballotDrawnAt"`
+	CheckInSecret        string     `json:"-"`
+	CostSplit            CostSplit  `gorm:"default:even" json:"costSplit"`
+	AdditionalCostSplit  CostSplit  `gorm:"default:even" json:"additionalCostSplit"`
 }
 
+// NewMatch books every court of the court description for the whole session
 func NewMatch(
 	start,
 	end time.Time,
 	sportCenterId uint,
-	costPerSection float64,
-	minutePerSection float64,
+	pricing Pricing,
 	court string,
 	customSection *float64,
 ) *Match {
@@ -38,17 +58,30 @@ func NewMatch(
 		End:           end,
 		Court:         court,
 		CustomSection: customSection,
+		State:         MatchOpen,
 	}
-	match.calculateCost(minutePerSection, costPerSection)
+	match.SetCourtBookings(buildCourtBookings(start, end, pricing, court, customSection))
 	return match
 }
 
+// NewMatchWithCourtBookings creates a session with courts booked for different durations or rates
+func NewMatchWithCourtBookings(sportCenterId uint, bookings []CourtBooking) (*Match, error) {
+	if sportCenterId == 0 {
+		return nil, errors.New("sport center is invalid")
+	}
+
+	match := &Match{SportCenterId: sportCenterId, State: MatchOpen}
+	if err := match.SetCourtBookings(bookings); err != nil {
+		return nil, err
+	}
+	return match, nil
+}
+
 func (m *Match) UpdateMatch(
 	sportCenterId uint,
 	start,
 	end time.Time,
-	minutePerSection float64,
-	costPerSection float64,
+	pricing Pricing,
 	court string,
 	customSection *float64,
 ) error {
@@ -56,18 +89,58 @@ func (m *Match) UpdateMatch(
 		return errors.New("sport center is invalid")
 	}
 
-	if end.Before(start) {
-		return errors.New("end date must be equal or after start date")
+	if err := CheckBookingDuration(start, end); err != nil {
+		return err
 	}
 
 	m.SportCenterId = sportCenterId
-	m.Start = start
-	m.End = end
-	m.Court = court
 	m.CustomSection = customSection
-	m.calculateCost(minutePerSection, costPerSection)
 
-	return nil
+	bookings := buildCourtBookings(start, end, pricing, court, customSection)
+	if m.HasUnknownCapacity() {
+		for i := range bookings {
+			bookings[i].Capacity = 0
+		}
+	}
+
+	return m.SetCourtBookings(bookings)
+}
+
+func (m *Match) UpdateCourtBookings(sportCenterId uint, bookings []CourtBooking) error {
+	if sportCenterId == 0 {
+		return errors.New("sport center is invalid")
+	}
+
+	m.SportCenterId = sportCenterId
+	m.CustomSection = nil
+
+	return m.SetCourtBookings(bookings)
+}
+
+// buildCourtBookings books each court of a description like "1 & 2" for the whole session
+func buildCourtBookings(
+	start,
+	end time.Time,
+	pricing Pricing,
+	court string,
+	customSection *float64,
+) []CourtBooking {
+	courts := SplitCourts(court)
+	if len(courts) <= 1 {
+		courts = []string{court}
+	}
+
+	return lo.Map(courts, func(c string, _ int) CourtBooking {
+		booking := CourtBooking{
+			Court:         strings.TrimSpace(c),
+			Start:         start,
+			End:           end,
+			CustomSection: customSection,
+			Capacity:      DefaultCourtCapacity,
+		}
+		booking.ApplyPricing(pricing)
+		return booking
+	})
 }
 
 func (m *Match) UpdateCost(cost float64, comment string) error {
@@ -76,6 +149,7 @@ func (m *Match) UpdateCost(cost float64, comment string) error {
 	}
 
 	m.Cost = cost
+	m.CostOverridden = true
 	m.Comment = comment
 
 	return nil
@@ -91,51 +165,86 @@ func (m *Match) AddCost(description string, amount float64) error {
 	return nil
 }
 
+// IsFinalized tells whether the match cost is settled, once played, finalized or paid by anyone it must not change
+func (m *Match) IsFinalized(now time.Time) bool {
+	if m.GetState() == MatchFinalized || m.IsCancelled() {
+		return true
+	}
+	return !m.Start.After(now) || lo.SomeBy(m.Registrations, func(r Registration) bool { return r.IsPaid })
+}
+
+// CalcPlayerCount counts confirmed players, waitlisted and undecided ones do not play nor share the cost
 func (m *Match) CalcPlayerCount() int {
-	sum := lo.SumBy(m.Registrations, func(r Registration) float64 { return float64(r.TotalPlayerPaidFor) })
+	sum := lo.SumBy(m.Registrations, func(r Registration) float64 {
+		return lo.Ternary(r.IsConfirmed(), float64(r.TotalPlayerPaidFor), 0)
+	})
 	return int(sum)
 }
 
 func (m *Match) Clone() Match {
+	return m.CloneWeeksLater(1)
+}
+
+// CloneWeeksLater copies the match and its court bookings the given number of weeks later.
+// The clone is a one-off match, it must be repriced for its date and a manual cost is not kept.
+func (m *Match) CloneWeeksLater(weeks int) Match {
 	var clone Match
 	data, _ := json.Marshal(m)
 	json.Unmarshal(data, &clone)
 	clone.ID = 0
-	clone.Start = clone.Start.AddDate(0, 0, 7)
-	clone.End = clone.End.AddDate(0, 0, 7)
+	clone.SeriesId = nil
+	clone.CostOverridden = false
+	clone.State = MatchOpen
+	clone.CancelledReason = ""
+	clone.CheckInSecret = ""
+	clone.Start = clone.Start.AddDate(0, 0, 7*weeks)
+	clone.End = clone.End.AddDate(0, 0, 7*weeks)
+	clone.RegistrationOpensAt = shiftWeeks(clone.RegistrationOpensAt, weeks)
+	clone.RegistrationClosesAt = shiftWeeks(clone.RegistrationClosesAt, weeks)
+	clone.CancellationDeadline = shiftWeeks(clone.CancellationDeadline, weeks)
+	clone.BallotDrawAt = shiftWeeks(clone.BallotDrawAt, weeks)
+	clone.BallotDrawnAt = nil
+	clone.Registrations = nil
+	for i := range clone.Invitations {
+		clone.Invitations[i].ID = 0
+		clone.Invitations[i].MatchId = 0
+	}
+	for i := range clone.CourtBookings {
+		clone.CourtBookings[i].ID = 0
+		clone.CourtBookings[i].MatchId = 0
+		clone.CourtBookings[i].Start = clone.CourtBookings[i].Start.AddDate(0, 0, 7*weeks)
+		clone.CourtBookings[i].End = clone.CourtBookings[i].End.AddDate(0, 0, 7*weeks)
+		for j := range clone.CourtBookings[i].CostItems {
+			item := &clone.CourtBookings[i].CostItems[j]
+			item.Start = item.Start.AddDate(0, 0, 7*weeks)
+			item.End = item.End.AddDate(0, 0, 7*weeks)
+		}
+	}
+	clone.syncFromCourtBookings()
 	return clone
 }
 
-func (m *Match) CalcAdditionalCost() float64 {
-	return lo.SumBy(m.AdditionalCosts, func(ac AdditionalCost) float64 { return ac.Amount })
+// RepeatWeeksLater clones the match as the given week of its weekly series
+func (m *Match) RepeatWeeksLater(weeks int) Match {
+	clone := m.CloneWeeksLater(weeks)
+	clone.SeriesId = m.GetSeriesId()
+	return clone
 }
 
-func (m *Match) CalcIndividualCost() float64 {
-
-	additionalCost := lo.SumBy(m.AdditionalCosts, func(ac AdditionalCost) float64 {
-		return ac.Amount
-	})
-
-	allPlayerCount := lo.SumBy(m.Registrations, func(r Registration) float64 { return float64(r.TotalPlayerPaidFor) })
-	playerCount := int(allPlayerCount)
-
-	if playerCount != 0 {
-		return (m.Cost + additionalCost) / float64(playerCount)
+func shiftWeeks(t *time.Time, weeks int) *time.Time {
+	if t == nil {
+		return nil
 	}
-
-	return 0
+	shifted := t.AddDate(0, 0, 7*weeks)
+	return &shifted
 }
 
-func (m *Match) calculateCost(minutePerSection, costPerSection float64) float64 {
-	totalMinutes := math.Abs(m.End.Sub(m.Start).Minutes())
-	var sectionCount float64
-	if m.CustomSection != nil {
-		sectionCount = float64(*m.CustomSection)
-	} else {
-		sectionCount = totalMinutes / minutePerSection
-	}
-
-	m.Cost = sectionCount * costPerSection
+// CalendarUID is the stable identifier of the match in calendar feeds,
+// it must never change so that subscribers receive updates instead of duplicates
+func (m *Match) CalendarUID() string {
+	return fmt.Sprintf("match-%d@racket", m.ID)
+}
 
-	return m.Cost
+func (m *Match) CalcAdditionalCost() float64 {
+	return lo.SumBy(m.AdditionalCosts, func(ac AdditionalCost) float64 { return ac.Amount })
 }
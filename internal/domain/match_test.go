@@ -81,9 +81,9 @@ func TestIndividualCost(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := tt.match.CalcIndividualCost()
+			got := tt.match.CalcFullSessionCost()
 			if got != tt.expectedCost {
-				t.Errorf("CalcIndividualCost() = %v, want %v", got, tt.expectedCost)
+				t.Errorf("CalcFullSessionCost() = %v, want %v", got, tt.expectedCost)
 			}
 		})
 	}
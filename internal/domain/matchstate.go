@@ -0,0 +1,119 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/samber/lo"
+)
+
+type MatchState = string
+
+const (
+	MatchDraft              MatchState = "draft"
+	MatchOpen               MatchState = "open"
+	MatchRegistrationClosed MatchState = "registration_closed"
+	MatchPlayed             MatchState = "played"
+	MatchFinalized          MatchState = "finalized"
+	MatchCancelled          MatchState = "cancelled"
+)
+
+var (
+	MatchStates = []MatchState{
+		MatchDraft,
+		MatchOpen,
+		MatchRegistrationClosed,
+		MatchPlayed,
+		MatchFinalized,
+		MatchCancelled,
+	}
+
+	matchTransitions = map[MatchState][]MatchState{
+		MatchDraft:              {MatchOpen, MatchCancelled},
+		MatchOpen:               {MatchDraft, MatchRegistrationClosed, MatchPlayed, MatchCancelled},
+		MatchRegistrationClosed: {MatchOpen, MatchPlayed, MatchCancelled},
+		MatchPlayed:             {MatchFinalized},
+		MatchFinalized:          {},
+		MatchCancelled:          {},
+	}
+)
+
+var ErrRegistrationClosed = errors.New("match is not open for registration")
+
+// TransitionError is returned when a match can not move to the requested state
+type TransitionError struct {
+	From MatchState
+	To   MatchState
+}
+
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("match can not move from %s to %s", e.From, e.To)
+}
+
+func ParseMatchState(value string) (MatchState, error) {
+	if !lo.Contains(MatchStates, value) {
+		return "", fmt.Errorf("unknown match state %s", value)
+	}
+	return value, nil
+}
+
+// GetState treats matches created before states existed as open
+func (m *Match) GetState() MatchState {
+	if m.State == "" {
+		return MatchOpen
+	}
+	return m.State
+}
+
+func (m *Match) CanTransitionTo(state MatchState) bool {
+	return lo.Contains(matchTransitions[m.GetState()], state)
+}
+
+// TransitionTo moves the match to the given state, side effects such as refunds are up to the caller
+func (m *Match) TransitionTo(state MatchState) error {
+	if !m.CanTransitionTo(state) {
+		return &TransitionError{From: m.GetState(), To: state}
+	}
+
+	m.State = state
+	return nil
+}
+
+func (m *Match) Cancel(reason string) error {
+	if err := m.TransitionTo(MatchCancelled); err != nil {
+		return err
+	}
+
+	m.CancelledReason = reason
+	return nil
+}
+
+// CalcPaidRefunds is what is owed back to each confirmed player marked as paid when the match is cancelled,
+// players who never held a spot are owed nothing. Additional costs must be loaded
+func (m *Match) CalcPaidRefunds() map[uint]float64 {
+	refunds := map[uint]float64{}
+	for _, r := range m.Registrations {
+		if !r.IsPaid || !r.IsConfirmed() {
+			continue
+		}
+
+		if amount := m.CalcIndividualCost(r); amount > 0 {
+			refunds[r.PlayerId] += amount
+		}
+	}
+	return refunds
+}
+
+func (m *Match) IsCancelled() bool {
+	return m.GetState() == MatchCancelled
+}
+
+func (m *Match) AcceptsRegistrations() bool {
+	return m.GetState() == MatchOpen
+}
+
+// AcceptsChanges tells whether registrations and costs can still be changed by an admin
+func (m *Match) AcceptsChanges() bool {
+	state := m.GetState()
+	return state != MatchFinalized && state != MatchCancelled
+}
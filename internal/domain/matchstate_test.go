@@ -0,0 +1,56 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchTransitions(t *testing.T) {
+	tests := []struct {
+		from    MatchState
+		to      MatchState
+		allowed bool
+	}{
+		{MatchDraft, MatchOpen, true},
+		{MatchOpen, MatchRegistrationClosed, true},
+		{MatchRegistrationClosed, MatchOpen, true},
+		{MatchOpen, MatchPlayed, true},
+		{MatchPlayed, MatchFinalized, true},
+		{MatchOpen, MatchCancelled, true},
+		{MatchDraft, MatchPlayed, false},
+		{MatchPlayed, MatchCancelled, false},
+		{MatchFinalized, MatchOpen, false},
+		{MatchCancelled, MatchOpen, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.from+" to "+tt.to, func(t *testing.T) {
+			m := &Match{State: tt.from}
+			err := m.TransitionTo(tt.to)
+			if tt.allowed {
+				assert.Nil(t, err)
+				assert.Equal(t, tt.to, m.State)
+			} else {
+				var transitionErr *TransitionError
+				assert.ErrorAs(t, err, &transitionErr)
+				assert.Equal(t, tt.from, m.State)
+			}
+		})
+	}
+}
+
+func TestLegacyMatchIsOpen(t *testing.T) {
+	m := &Match{}
+	assert.Equal(t, MatchOpen, m.GetState())
+	assert.True(t, m.AcceptsRegistrations())
+}
+
+func TestCancelMatch(t *testing.T) {
+	m := &Match{State: MatchRegistrationClosed}
+	assert.Nil(t, m.Cancel("Venue flooded"))
+	assert.True(t, m.IsCancelled())
+	assert.Equal(t, "Venue flooded", m.CancelledReason)
+	assert.False(t, m.AcceptsRegistrations())
+	assert.False(t, m.AcceptsChanges())
+}
@@ -0,0 +1,39 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// Notification is a message shown to a player in the app, e.g. when a match is cancelled
+type Notification struct {
+	BaseModel
+	PlayerId uint       `gorm:"index" json:"playerId"`
+	MatchId  *uint      `gorm:"index" json:"matchId"`
+	Title    string     `json:"title"`
+	Message  string     `json:"message"`
+	ReadAt   *time.Time `json:"readAt"`
+}
+
+func NewNotification(playerId uint, matchId *uint, title, message string) (*Notification, error) {
+	if playerId == 0 {
+		return nil, errors.New("player is mandatory")
+	}
+
+	if len(title) == 0 {
+		return nil, errors.New("title is mandatory")
+	}
+
+	return &Notification{
+		PlayerId: playerId,
+		MatchId:  matchId,
+		Title:    title,
+		Message:  message,
+	}, nil
+}
+
+func (n *Notification) MarkRead(now time.Time) {
+	if n.ReadAt == nil {
+		n.ReadAt = &now
+	}
+}
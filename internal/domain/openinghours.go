@@ -0,0 +1,121 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/samber/lo"
+)
+
+// OpeningHours is an opening window of a sport center on a weekday in local time,
+// a weekday without any window is a closed day. Centers without any window are always open.
+type OpeningHours struct {
+	BaseModel
+	SportCenterId uint         `gorm:"index" json:"sportCenterId"`
+	Weekday       time.Weekday `json:"weekday"`
+	OpenMinute    int          `json:"openMinute"`
+	CloseMinute   int          `json:"closeMinute"`
+}
+
+// Closure is a period the sport center is shut, e.g. bank holidays or maintenance
+type Closure struct {
+	BaseModel
+	SportCenterId uint      `gorm:"index" json:"sportCenterId"`
+	From          time.Time `json:"from"`
+	To            time.Time `json:"to"`
+	Reason        string    `json:"reason"`
+}
+
+// ClosedError is returned when a slot is outside opening hours or on a closed date,
+// it can be bypassed with an explicit override
+type ClosedError struct {
+	Start  time.Time
+	Reason string
+}
+
+func (e *ClosedError) Error() string {
+	return fmt.Sprintf("sport center is closed on %s: %s", e.Start.Format("02/01/2006 15:04"), e.Reason)
+}
+
+func NewOpeningHours(weekday time.Weekday, openMinute, closeMinute int) (*OpeningHours, error) {
+	if weekday < time.Sunday || weekday > time.Saturday {
+		return nil, errors.New("weekday is invalid")
+	}
+
+	if openMinute < 0 || closeMinute > minutesPerDay || openMinute >= closeMinute {
+		return nil, errors.New("opening hours are invalid, they must be within a day")
+	}
+
+	return &OpeningHours{
+		Weekday:     weekday,
+		OpenMinute:  openMinute,
+		CloseMinute: closeMinute,
+	}, nil
+}
+
+// NewClosure closes the sport center from the start of a day until the end of another day
+func NewClosure(from, to time.Time, reason string) (*Closure, error) {
+	if !to.After(from) {
+		return nil, errors.New("closure must end after it starts")
+	}
+
+	return &Closure{
+		From:   from,
+		To:     to,
+		Reason: reason,
+	}, nil
+}
+
+func (sc *SportCenter) ReplaceOpeningHours(hours []OpeningHours) error {
+	for i := range hours {
+		for j := i + 1; j < len(hours); j++ {
+			a, b := hours[i], hours[j]
+			if a.Weekday == b.Weekday && a.OpenMinute < b.CloseMinute && b.OpenMinute < a.CloseMinute {
+				return fmt.Errorf("opening hours of %s overlap", a.Weekday)
+			}
+		}
+		hours[i].ID = 0
+		hours[i].SportCenterId = sc.ID
+	}
+
+	sc.OpeningHours = hours
+	return nil
+}
+
+// EnsureOpen checks the slot against opening hours and closures, they must be loaded
+func (sc *SportCenter) EnsureOpen(start, end time.Time) error {
+	if closure, found := lo.Find(sc.Closures, func(c Closure) bool {
+		return timeOverlaps(start, end, c.From, c.To)
+	}); found {
+		return &ClosedError{Start: start, Reason: lo.Ternary(closure.Reason != "", closure.Reason, "closure")}
+	}
+
+	if len(sc.OpeningHours) == 0 {
+		return nil
+	}
+
+	loc := sc.GetLocation()
+	localStart, localEnd := start.In(loc), end.In(loc)
+	startMinute := localStart.Hour()*60 + localStart.Minute()
+	endMinute := startMinute + int(localEnd.Sub(localStart).Minutes())
+
+	open := lo.ContainsBy(sc.OpeningHours, func(h OpeningHours) bool {
+		return h.Weekday == localStart.Weekday() && h.OpenMinute <= startMinute && endMinute <= h.CloseMinute
+	})
+	if !open {
+		return &ClosedError{Start: start, Reason: "outside opening hours"}
+	}
+
+	return nil
+}
+
+// EnsureOpenFor checks every court booked by the match
+func (sc *SportCenter) EnsureOpenFor(m *Match) error {
+	for _, slot := range m.courtSlots() {
+		if err := sc.EnsureOpen(slot.Start, slot.End); err != nil {
+			return err
+		}
+	}
+	return nil
+}
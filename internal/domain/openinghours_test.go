@@ -0,0 +1,71 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnsureOpen(t *testing.T) {
+	sc := &SportCenter{}
+	wednesday := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	// No opening hours configured means always open
+	assert.Nil(t, sc.EnsureOpen(wednesday.Add(2*time.Hour), wednesday.Add(3*time.Hour)))
+
+	hours, err := NewOpeningHours(time.Wednesday, 9*60, 22*60)
+	assert.Nil(t, err)
+	assert.Nil(t, sc.ReplaceOpeningHours([]OpeningHours{*hours}))
+
+	tests := []struct {
+		name   string
+		start  time.Time
+		end    time.Time
+		closed bool
+	}{
+		{"within opening hours", wednesday.Add(18 * time.Hour), wednesday.Add(20 * time.Hour), false},
+		{"until closing time", wednesday.Add(20 * time.Hour), wednesday.Add(22 * time.Hour), false},
+		{"before opening", wednesday.Add(8 * time.Hour), wednesday.Add(10 * time.Hour), true},
+		{"after closing", wednesday.Add(21 * time.Hour), wednesday.Add(23 * time.Hour), true},
+		{"closed weekday", wednesday.AddDate(0, 0, 1).Add(18 * time.Hour), wednesday.AddDate(0, 0, 1).Add(20 * time.Hour), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := sc.EnsureOpen(tt.start, tt.end)
+			assert.Equal(t, tt.closed, err != nil)
+		})
+	}
+}
+
+func TestEnsureOpenRefusesClosedDates(t *testing.T) {
+	holiday := time.Date(2024, 5, 6, 0, 0, 0, 0, time.UTC)
+	closure, err := NewClosure(holiday, holiday.AddDate(0, 0, 1), "Bank holiday")
+	assert.Nil(t, err)
+	sc := &SportCenter{Closures: []Closure{*closure}}
+
+	err = sc.EnsureOpen(holiday.Add(18*time.Hour), holiday.Add(20*time.Hour))
+	var closedErr *ClosedError
+	assert.ErrorAs(t, err, &closedErr)
+	assert.Equal(t, "Bank holiday", closedErr.Reason)
+
+	assert.Nil(t, sc.EnsureOpen(holiday.AddDate(0, 0, 1).Add(18*time.Hour), holiday.AddDate(0, 0, 1).Add(20*time.Hour)))
+}
+
+func TestReplaceOpeningHoursRefusesOverlaps(t *testing.T) {
+	morning, _ := NewOpeningHours(time.Monday, 8*60, 12*60)
+	day, _ := NewOpeningHours(time.Monday, 11*60, 18*60)
+
+	sc := &SportCenter{}
+	assert.NotNil(t, sc.ReplaceOpeningHours([]OpeningHours{*morning, *day}))
+}
+
+func TestCloneWeeksLater(t *testing.T) {
+	start := time.Date(2024, 5, 1, 18, 0, 0, 0, time.UTC)
+	m := NewMatch(start, start.Add(2*time.Hour), 1, NewFlatPricing(10, 60), "1", nil)
+
+	clone := m.CloneWeeksLater(3)
+	assert.Equal(t, start.AddDate(0, 0, 21), clone.Start)
+	assert.Equal(t, start.AddDate(0, 0, 21), clone.CourtBookings[0].Start)
+}
@@ -10,11 +10,24 @@ type Player struct {
 	LastName       string   `json:"lastName"`
 	ExternalUserID string   `json:"externalUserId"`
 	Email          string   `json:"email"`
-	Rank           uint     `json:"rank"`
+	Rank           uint     `json:"rank"` // Deprecated: the leaderboard rank is computed from ratings when read
+	Gender         string   `json:"gender"`
 	Teams          []Team   `gorm:"many2many:team_members;" json:"teams"`
 	Wallets        []Wallet `gorm:"foreignKey:OwnerId" json:"wallets"`
+	// Role is the role of the member in the tenant of the player, see Tenant.IsAdmin
+	Role string `gorm:"default:member" json:"role"`
 }
 
+const (
+	GenderMale   = "male"
+	GenderFemale = "female"
+)
+
+const (
+	TenantRoleMember = "member"
+	TenantRoleAdmin  = "admin"
+)
+
 func NewPlayer(externalUserID, email, firstName, lastName string) *Player {
 	return &Player{
 		ExternalUserID: externalUserID,
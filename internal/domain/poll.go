@@ -0,0 +1,277 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/samber/lo"
+)
+
+type PollAnswer = string
+
+const (
+	AnswerYes   PollAnswer = "yes"
+	AnswerMaybe PollAnswer = "maybe"
+	AnswerNo    PollAnswer = "no"
+)
+
+type PollState = string
+
+const (
+	PollOpen   PollState = "open"
+	PollClosed PollState = "closed"
+	PollBooked PollState = "booked"
+)
+
+var (
+	ErrPollClosed       = errors.New("poll is closed")
+	ErrPollBooked       = errors.New("poll is already booked")
+	ErrPollSlotNotFound = errors.New("slot not found")
+	// ErrPollResponseNotFound is returned for an unknown response token, anonymous answers can only be changed with it
+	ErrPollResponseNotFound = errors.New("response not found")
+)
+
+// Poll asks players which candidate slots they can make before courts are booked,
+// players answer through the API or anonymously through the share code
+type Poll struct {
+	BaseModel
+	Title        string         `json:"title"`
+	Description  string         `json:"description"`
+	ShareCode    string         `gorm:"uniqueIndex" json:"-"`
+	ClosesAt     *time.Time     `json:"closesAt"`
+	State        PollState      `gorm:"index" json:"state"`
+	BookedSlotId *uint          `json:"bookedSlotId"`
+	MatchId      *uint          `gorm:"index" json:"matchId"`
+	Slots        []PollSlot     `json:"slots"`
+	Responses    []PollResponse `json:"responses"`
+}
+
+// PollSlot is a candidate time, the sport center is optional until the slot is booked
+type PollSlot struct {
+	BaseModel
+	PollId        uint      `gorm:"index" json:"pollId"`
+	Start         time.Time `json:"start"`
+	End           time.Time `json:"end"`
+	SportCenterId *uint     `json:"sportCenterId"`
+	Court         string    `json:"court"`
+}
+
+// PollResponse holds the answers of one respondent, anonymous respondents have a name but no player.
+// Anonymous respondents get a token when answering, it is required to change their answers.
+type PollResponse struct {
+	BaseModel
+	PollId   uint                `gorm:"index" json:"pollId"`
+	PlayerId *uint               `gorm:"index" json:"playerId"`
+	Name     string              `json:"name"`
+	Answers  map[uint]PollAnswer `gorm:"serializer:json" json:"answers"`
+	Token    string              `gorm:"index" json:"-"`
+}
+
+type PollSlotResult struct {
+	SlotId uint
+	Yes    []PollResponse
+	Maybe  []PollResponse
+	No     []PollResponse
+}
+
+func NewPoll(title, description string, slots []PollSlot, closesAt *time.Time, generator Generator) (*Poll, error) {
+	title = strings.TrimSpace(title)
+	if title == "" {
+		return nil, errors.New("poll title is required")
+	}
+
+	if len(slots) == 0 {
+		return nil, errors.New("at least one slot is required")
+	}
+
+	for _, slot := range slots {
+		if !slot.End.After(slot.Start) {
+			return nil, fmt.Errorf("slot starting %s must end after it starts", slot.Start.Format(time.RFC3339))
+		}
+	}
+
+	shareCode, err := generator.Gen(24)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Poll{
+		Title:       title,
+		Description: strings.TrimSpace(description),
+		ShareCode:   shareCode,
+		ClosesAt:    closesAt,
+		State:       PollOpen,
+		Slots:       slots,
+	}, nil
+}
+
+func (p *Poll) IsOpen(now time.Time) bool {
+	return p.State == PollOpen && (p.ClosesAt == nil || now.Before(*p.ClosesAt))
+}
+
+// Respond records or replaces the answers of a player.
+// Slots without an answer are left out of the results.
+func (p *Poll) Respond(playerId uint, name string, answers map[uint]PollAnswer, now time.Time) (*PollResponse, error) {
+	name, err := p.validateResponse(name, answers, now)
+	if err != nil {
+		return nil, err
+	}
+
+	if r := p.findResponse(func(r PollResponse) bool { return r.PlayerId != nil && *r.PlayerId == playerId }); r != nil {
+		r.Name = name
+		r.Answers = answers
+		return r, nil
+	}
+
+	p.Responses = append(p.Responses, PollResponse{PollId: p.ID, PlayerId: &playerId, Name: name, Answers: answers})
+	return &p.Responses[len(p.Responses)-1], nil
+}
+
+// RespondAnonymously records the answers of a respondent only known by name. Without a token a new response
+// is recorded with a token of its own, the token of an earlier response replaces its answers.
+func (p *Poll) RespondAnonymously(name, token string, answers map[uint]PollAnswer, now time.Time, generator Generator) (*PollResponse, error) {
+	name, err := p.validateResponse(name, answers, now)
+	if err != nil {
+		return nil, err
+	}
+
+	if token != "" {
+		r := p.findResponse(func(r PollResponse) bool { return r.PlayerId == nil && r.Token == token })
+		if r == nil {
+			return nil, ErrPollResponseNotFound
+		}
+
+		r.Name = name
+		r.Answers = answers
+		return r, nil
+	}
+
+	token, err = generator.Gen(24)
+	if err != nil {
+		return nil, err
+	}
+
+	p.Responses = append(p.Responses, PollResponse{PollId: p.ID, Name: name, Answers: answers, Token: token})
+	return &p.Responses[len(p.Responses)-1], nil
+}
+
+func (p *Poll) validateResponse(name string, answers map[uint]PollAnswer, now time.Time) (string, error) {
+	if !p.IsOpen(now) {
+		return "", ErrPollClosed
+	}
+
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", errors.New("name is required")
+	}
+
+	for slotId, answer := range answers {
+		if p.findSlot(slotId) == nil {
+			return "", ErrPollSlotNotFound
+		}
+
+		if !lo.Contains([]PollAnswer{AnswerYes, AnswerMaybe, AnswerNo}, answer) {
+			return "", fmt.Errorf("unknown answer %q", answer)
+		}
+	}
+	return name, nil
+}
+
+func (p *Poll) findResponse(predicate func(r PollResponse) bool) *PollResponse {
+	for i := range p.Responses {
+		if predicate(p.Responses[i]) {
+			return &p.Responses[i]
+		}
+	}
+	return nil
+}
+
+func (p *Poll) Close() error {
+	if p.State == PollBooked {
+		return ErrPollBooked
+	}
+
+	p.State = PollClosed
+	return nil
+}
+
+// Results lists the respondents per slot in answer order
+func (p *Poll) Results() []PollSlotResult {
+	responses := append([]PollResponse{}, p.Responses...)
+	sort.SliceStable(responses, func(i, j int) bool { return responses[i].ID < responses[j].ID })
+
+	return lo.Map(p.Slots, func(slot PollSlot, _ int) PollSlotResult {
+		res := PollSlotResult{SlotId: slot.ID}
+		for _, r := range responses {
+			switch r.Answers[slot.ID] {
+			case AnswerYes:
+				res.Yes = append(res.Yes, r)
+			case AnswerMaybe:
+				res.Maybe = append(res.Maybe, r)
+			case AnswerNo:
+				res.No = append(res.No, r)
+			}
+		}
+		return res
+	})
+}
+
+// BestSlot has the most yes answers, then the most maybe answers, then the earliest start
+func (p *Poll) BestSlot() *PollSlot {
+	results := lo.KeyBy(p.Results(), func(r PollSlotResult) uint { return r.SlotId })
+	slots := append([]PollSlot{}, p.Slots...)
+	sort.SliceStable(slots, func(i, j int) bool {
+		a, b := results[slots[i].ID], results[slots[j].ID]
+		if len(a.Yes) != len(b.Yes) {
+			return len(a.Yes) > len(b.Yes)
+		}
+		if len(a.Maybe) != len(b.Maybe) {
+			return len(a.Maybe) > len(b.Maybe)
+		}
+		return slots[i].Start.Before(slots[j].Start)
+	})
+
+	if len(slots) == 0 {
+		return nil
+	}
+	return p.findSlot(slots[0].ID)
+}
+
+// Book registers every player who answered yes to the match of the slot in answer order,
+// players who do not fit on the booked courts are waitlisted. Anonymous respondents are not registered.
+func (p *Poll) Book(slotId uint, match *Match) error {
+	if p.State == PollBooked {
+		return ErrPollBooked
+	}
+
+	if p.findSlot(slotId) == nil {
+		return ErrPollSlotNotFound
+	}
+
+	result, _ := lo.Find(p.Results(), func(r PollSlotResult) bool { return r.SlotId == slotId })
+	for _, r := range result.Yes {
+		if r.PlayerId == nil {
+			continue
+		}
+
+		registration := NewRegistration(*r.PlayerId, match.ID)
+		registration.IsWaitlisted = match.ShouldWaitlist(registration.TotalPlayerPaidFor)
+		match.Registrations = append(match.Registrations, *registration)
+	}
+
+	p.State = PollBooked
+	p.BookedSlotId = &slotId
+	return nil
+}
+
+func (p *Poll) findSlot(slotId uint) *PollSlot {
+	for i := range p.Slots {
+		if p.Slots[i].ID == slotId {
+			return &p.Slots[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package domain
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestPoll(t *testing.T) *Poll {
+	start := time.Date(2024, 6, 1, 19, 0, 0, 0, time.UTC)
+	poll, err := NewPoll("June session", "", []PollSlot{
+		{BaseModel: BaseModel{ID: 1}, Start: start, End: start.Add(2 * time.Hour)},
+		{BaseModel: BaseModel{ID: 2}, Start: start.AddDate(0, 0, 1), End: start.AddDate(0, 0, 1).Add(2 * time.Hour)},
+	}, nil, &mockGenerator{})
+	assert.Nil(t, err)
+	return poll
+}
+
+func TestNewPoll(t *testing.T) {
+	start := time.Date(2024, 6, 1, 19, 0, 0, 0, time.UTC)
+
+	_, err := NewPoll("June session", "", nil, nil, &mockGenerator{})
+	assert.NotNil(t, err)
+
+	_, err = NewPoll("June session", "", []PollSlot{{Start: start, End: start}}, nil, &mockGenerator{})
+	assert.NotNil(t, err)
+}
+
+type sequenceGenerator struct{ count int }
+
+func (g *sequenceGenerator) Gen(length int) (string, error) {
+	g.count++
+	return fmt.Sprintf("token-%d", g.count), nil
+}
+
+func TestPollRespond(t *testing.T) {
+	now := time.Date(2024, 5, 20, 9, 0, 0, 0, time.UTC)
+	poll := newTestPoll(t)
+
+	_, err := poll.Respond(1, "Alice", map[uint]PollAnswer{1: AnswerYes, 2: AnswerNo}, now)
+	assert.Nil(t, err)
+
+	// Answering again replaces the answers of the player
+	_, err = poll.Respond(1, "Alice", map[uint]PollAnswer{1: AnswerMaybe}, now)
+	assert.Nil(t, err)
+	assert.Len(t, poll.Responses, 1)
+
+	_, err = poll.Respond(1, "Alice", map[uint]PollAnswer{3: AnswerYes}, now)
+	assert.ErrorIs(t, err, ErrPollSlotNotFound)
+
+	_, err = poll.Respond(1, "Alice", map[uint]PollAnswer{1: "perhaps"}, now)
+	assert.NotNil(t, err)
+
+	poll.ClosesAt = &now
+	_, err = poll.Respond(1, "Alice", map[uint]PollAnswer{1: AnswerYes}, now)
+	assert.ErrorIs(t, err, ErrPollClosed)
+}
+
+func TestPollRespondAnonymously(t *testing.T) {
+	now := time.Date(2024, 5, 20, 9, 0, 0, 0, time.UTC)
+	poll := newTestPoll(t)
+	generator := &sequenceGenerator{}
+
+	bob, err := poll.RespondAnonymously("Bob", "", map[uint]PollAnswer{1: AnswerMaybe}, now, generator)
+	assert.Nil(t, err)
+	assert.Equal(t, "token-1", bob.Token)
+
+	// Typing the same name without the token records another response instead of replacing Bob's answers
+	_, err = poll.RespondAnonymously("bob", "", map[uint]PollAnswer{1: AnswerNo}, now, generator)
+	assert.Nil(t, err)
+	assert.Len(t, poll.Responses, 2)
+	assert.Equal(t, AnswerMaybe, poll.Responses[0].Answers[1])
+
+	_, err = poll.RespondAnonymously("Bob", "token-1", map[uint]PollAnswer{1: AnswerYes}, now, generator)
+	assert.Nil(t, err)
+	assert.Len(t, poll.Responses, 2)
+	assert.Equal(t, AnswerYes, poll.Responses[0].Answers[1])
+
+	_, err = poll.RespondAnonymously("Bob", "guessed", map[uint]PollAnswer{1: AnswerNo}, now, generator)
+	assert.ErrorIs(t, err, ErrPollResponseNotFound)
+
+	// Player responses have no token and can not be changed anonymously
+	_, err = poll.Respond(1, "Alice", map[uint]PollAnswer{1: AnswerYes}, now)
+	assert.Nil(t, err)
+	_, err = poll.RespondAnonymously("Alice", "", map[uint]PollAnswer{1: AnswerNo}, now, &mockGenerator{})
+	assert.Nil(t, err)
+	assert.Equal(t, AnswerYes, poll.Responses[2].Answers[1])
+}
+
+func TestPollResults(t *testing.T) {
+	now := time.Date(2024, 5, 20, 9, 0, 0, 0, time.UTC)
+	poll := newTestPoll(t)
+	poll.Respond(1, "Alice", map[uint]PollAnswer{1: AnswerYes, 2: AnswerMaybe}, now)
+	poll.Respond(2, "Bob", map[uint]PollAnswer{1: AnswerNo, 2: AnswerYes}, now)
+	poll.RespondAnonymously("Carol", "", map[uint]PollAnswer{2: AnswerYes}, now, &mockGenerator{})
+
+	results := poll.Results()
+	assert.Len(t, results[0].Yes, 1)
+	assert.Len(t, results[0].No, 1)
+	assert.Equal(t, []string{"Bob", "Carol"}, lo.Map(results[1].Yes, func(r PollResponse, _ int) string { return r.Name }))
+	assert.Equal(t, uint(2), poll.BestSlot().ID)
+}
+
+func TestPollBook(t *testing.T) {
+	now := time.Date(2024, 5, 20, 9, 0, 0, 0, time.UTC)
+	poll := newTestPoll(t)
+	poll.Respond(1, "Alice", map[uint]PollAnswer{1: AnswerYes}, now)
+	poll.Respond(2, "Bob", map[uint]PollAnswer{1: AnswerYes}, now)
+	poll.RespondAnonymously("Carol", "", map[uint]PollAnswer{1: AnswerYes}, now, &mockGenerator{})
+	poll.Respond(3, "Dan", map[uint]PollAnswer{1: AnswerMaybe}, now)
+
+	match := &Match{CourtBookings: []CourtBooking{{Capacity: 1}}}
+	assert.Nil(t, poll.Book(1, match))
+
+	// Anonymous and maybe answers are not registered, players over the capacity are waitlisted
+	assert.Len(t, match.Registrations, 2)
+	assert.False(t, match.Registrations[0].IsWaitlisted)
+	assert.True(t, match.Registrations[1].IsWaitlisted)
+	assert.Equal(t, PollBooked, poll.State)
+
+	assert.ErrorIs(t, poll.Book(1, &Match{}), ErrPollBooked)
+}
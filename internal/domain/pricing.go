@@ -0,0 +1,251 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/samber/lo"
+)
+
+const minutesPerDay = 24 * 60
+
+// MaxBookingDuration bounds court bookings and price quotes, no session lasts longer than a day
+const MaxBookingDuration = 24 * time.Hour
+
+var ErrBookingTooLong = fmt.Errorf("a booking can not last longer than %s", MaxBookingDuration)
+
+// PricingRule is a rate applied by a sport center in a time window on some weekdays,
+// e.g. peak hours, weekends or member rates. When several rules match, the highest priority wins.
+// Windows are in the sport center local time and can not cross midnight.
+type PricingRule struct {
+	BaseModel
+	SportCenterId  uint    `gorm:"index" json:"sportCenterId"`
+	Name           string  `json:"name"`
+	Weekdays       uint8   `json:"weekdays"`
+	StartMinute    int     `json:"startMinute"`
+	EndMinute      int     `json:"endMinute"`
+	CostPerSection float64 `json:"costPerSection"`
+	Priority       int     `json:"priority"`
+	MemberOnly     bool    `json:"memberOnly"`
+}
+
+// CostItem is one line of the itemized cost of a court booking
+type CostItem struct {
+	RuleId         *uint     `json:"ruleId"`
+	Description    string    `json:"description"`
+	Start          time.Time `json:"start"`
+	End            time.Time `json:"end"`
+	Minutes        float64   `json:"minutes"`
+	CostPerSection float64   `json:"costPerSection"`
+	Sections       float64   `json:"sections"`
+	Cost           float64   `json:"cost"`
+}
+
+// Pricing computes the cost of a court booking at a sport center
+type Pricing struct {
+	DefaultCostPerSection float64
+	MinutePerSection      float64
+	Rules                 []PricingRule
+	Location              *time.Location
+	Member                bool
+}
+
+func NewPricingRule(
+	name string,
+	weekdays []time.Weekday,
+	startMinute,
+	endMinute int,
+	costPerSection float64,
+	priority int,
+	memberOnly bool,
+) (*PricingRule, error) {
+	if len(name) == 0 {
+		return nil, errors.New("name is mandatory")
+	}
+
+	if len(weekdays) == 0 {
+		return nil, errors.New("at least one weekday is required")
+	}
+
+	if startMinute < 0 || endMinute > minutesPerDay || startMinute >= endMinute {
+		return nil, errors.New("time window is invalid, it must be within a day")
+	}
+
+	if costPerSection < 0 {
+		return nil, errors.New("cost per section must not be negative")
+	}
+
+	rule := &PricingRule{
+		Name:           name,
+		StartMinute:    startMinute,
+		EndMinute:      endMinute,
+		CostPerSection: costPerSection,
+		Priority:       priority,
+		MemberOnly:     memberOnly,
+	}
+	for _, day := range weekdays {
+		rule.Weekdays |= 1 << uint(day)
+	}
+
+	return rule, nil
+}
+
+func (r *PricingRule) GetWeekdays() []time.Weekday {
+	return lo.Filter([]time.Weekday{
+		time.Sunday, time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday, time.Saturday,
+	}, func(day time.Weekday, _ int) bool { return r.Weekdays&(1<<uint(day)) != 0 })
+}
+
+// AppliesAt tells whether the rule covers the given local time
+func (r *PricingRule) AppliesAt(local time.Time, member bool) bool {
+	if r.MemberOnly && !member {
+		return false
+	}
+
+	if r.Weekdays&(1<<uint(local.Weekday())) == 0 {
+		return false
+	}
+
+	minute := local.Hour()*60 + local.Minute()
+	return minute >= r.StartMinute && minute < r.EndMinute
+}
+
+func NewFlatPricing(costPerSection, minutePerSection float64) Pricing {
+	return Pricing{
+		DefaultCostPerSection: costPerSection,
+		MinutePerSection:      minutePerSection,
+		Location:              time.UTC,
+	}
+}
+
+func (p Pricing) ruleAt(t time.Time) *PricingRule {
+	loc := p.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	local := t.In(loc)
+
+	var best *PricingRule
+	for i := range p.Rules {
+		rule := &p.Rules[i]
+		if rule.AppliesAt(local, p.Member) && (best == nil || rule.Priority > best.Priority) {
+			best = rule
+		}
+	}
+	return best
+}
+
+// CheckBookingDuration rejects bookings ending before they start or lasting longer than MaxBookingDuration
+func CheckBookingDuration(start, end time.Time) error {
+	if end.Before(start) {
+		return errors.New("end date must be equal or after start date")
+	}
+
+	if end.Sub(start) > MaxBookingDuration {
+		return ErrBookingTooLong
+	}
+	return nil
+}
+
+// nextBoundary is the first time after t where the applicable rule may change: the start or end
+// of a rule window or the next local midnight. Windows are computed from the local wall clock
+// so that daylight saving changes are handled.
+func (p Pricing) nextBoundary(t time.Time) time.Time {
+	loc := p.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	local := t.In(loc)
+	year, month, day := local.Date()
+
+	next := time.Date(year, month, day+1, 0, 0, 0, 0, loc)
+	for _, rule := range p.Rules {
+		for _, minute := range []int{rule.StartMinute, rule.EndMinute} {
+			boundary := time.Date(year, month, day, minute/60, minute%60, 0, 0, loc)
+			if boundary.After(t) && boundary.Before(next) {
+				next = boundary
+			}
+		}
+	}
+	return next
+}
+
+// Price splits the booking into segments at every rule boundary and prices each segment.
+// A custom section count overrides the duration, it is spread over the segments pro rata.
+func (p Pricing) Price(start, end time.Time, customSection *float64) ([]CostItem, float64) {
+	items := []CostItem{}
+	if !end.After(start) {
+		return items, 0
+	}
+
+	// Walk from one window boundary to the next, rules only change at those boundaries
+	var lastRule *PricingRule
+	cursor := start
+	for cursor.Before(end) {
+		rule := p.ruleAt(cursor)
+		next := p.nextBoundary(cursor)
+		if next.After(end) {
+			next = end
+		}
+
+		if len(items) > 0 && rule == lastRule {
+			items[len(items)-1].End = next
+		} else {
+			items = append(items, p.newItem(rule, cursor, next))
+		}
+		lastRule = rule
+		cursor = next
+	}
+
+	totalMinutes := end.Sub(start).Minutes()
+	for i := range items {
+		item := &items[i]
+		item.Minutes = item.End.Sub(item.Start).Minutes()
+		if customSection != nil {
+			item.Sections = *customSection * item.Minutes / totalMinutes
+		} else if p.MinutePerSection > 0 {
+			item.Sections = item.Minutes / p.MinutePerSection
+		}
+		item.Cost = item.Sections * item.CostPerSection
+	}
+
+	return items, lo.SumBy(items, func(item CostItem) float64 { return item.Cost })
+}
+
+func (p Pricing) newItem(rule *PricingRule, start, end time.Time) CostItem {
+	if rule == nil {
+		return CostItem{
+			Description:    "Standard rate",
+			Start:          start,
+			End:            end,
+			CostPerSection: p.DefaultCostPerSection,
+		}
+	}
+
+	ruleId := rule.ID
+	return CostItem{
+		RuleId:         &ruleId,
+		Description:    rule.Name,
+		Start:          start,
+		End:            end,
+		CostPerSection: rule.CostPerSection,
+	}
+}
+
+// ParseTimeOfDay converts "18:30" into minutes from midnight, "24:00" is accepted as the end of the day
+func ParseTimeOfDay(value string) (int, error) {
+	if value == "24:00" {
+		return minutesPerDay, nil
+	}
+
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time of day %s", value)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+func FormatTimeOfDay(minute int) string {
+	return fmt.Sprintf("%02d:%02d", minute/60, minute%60)
+}
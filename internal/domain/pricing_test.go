@@ -0,0 +1,169 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestRule(t *testing.T, id uint, name string, weekdays []time.Weekday, start, end string, rate float64, priority int, memberOnly bool) PricingRule {
+	startMinute, err := ParseTimeOfDay(start)
+	assert.Nil(t, err)
+	endMinute, err := ParseTimeOfDay(end)
+	assert.Nil(t, err)
+
+	rule, err := NewPricingRule(name, weekdays, startMinute, endMinute, rate, priority, memberOnly)
+	assert.Nil(t, err)
+	rule.ID = id
+	return *rule
+}
+
+func TestPriceSplitsBookingAtWindowBoundary(t *testing.T) {
+	weekdays := []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}
+	pricing := Pricing{
+		DefaultCostPerSection: 10,
+		MinutePerSection:      60,
+		Rules:                 []PricingRule{newTestRule(t, 1, "Peak", weekdays, "18:00", "22:00", 16, 1, false)},
+		Location:              time.UTC,
+	}
+
+	// Wednesday 17:00 - 19:30, one hour off-peak then 1.5 hours peak
+	start := time.Date(2024, 5, 1, 17, 0, 0, 0, time.UTC)
+	items, total := pricing.Price(start, start.Add(150*time.Minute), nil)
+
+	assert.Len(t, items, 2)
+	assert.Nil(t, items[0].RuleId)
+	assert.Equal(t, float64(60), items[0].Minutes)
+	assert.Equal(t, float64(10), items[0].Cost)
+	assert.Equal(t, uint(1), *items[1].RuleId)
+	assert.Equal(t, float64(90), items[1].Minutes)
+	assert.Equal(t, float64(24), items[1].Cost)
+	assert.Equal(t, float64(34), total)
+}
+
+func TestPriceUsesHighestPriorityAndMemberRules(t *testing.T) {
+	allDays := []time.Weekday{time.Sunday, time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday, time.Saturday}
+	weekend := []time.Weekday{time.Saturday, time.Sunday}
+	pricing := Pricing{
+		DefaultCostPerSection: 10,
+		MinutePerSection:      60,
+		Rules: []PricingRule{
+			newTestRule(t, 1, "All day", allDays, "00:00", "24:00", 12, 0, false),
+			newTestRule(t, 2, "Weekend", weekend, "08:00", "20:00", 15, 1, false),
+			newTestRule(t, 3, "Member weekend", weekend, "08:00", "20:00", 9, 2, true),
+		},
+		Location: time.UTC,
+	}
+
+	// Saturday
+	start := time.Date(2024, 5, 4, 10, 0, 0, 0, time.UTC)
+
+	_, total := pricing.Price(start, start.Add(time.Hour), nil)
+	assert.Equal(t, float64(15), total)
+
+	pricing.Member = true
+	_, total = pricing.Price(start, start.Add(time.Hour), nil)
+	assert.Equal(t, float64(9), total)
+}
+
+func TestPriceUsesSportCenterLocalTime(t *testing.T) {
+	london, err := time.LoadLocation("Europe/London")
+	if err != nil {
+		t.Skip("timezone database not available")
+	}
+
+	allDays := []time.Weekday{time.Sunday, time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday, time.Saturday}
+	pricing := Pricing{
+		DefaultCostPerSection: 10,
+		MinutePerSection:      60,
+		Rules:                 []PricingRule{newTestRule(t, 1, "Peak", allDays, "18:00", "22:00", 16, 1, false)},
+		Location:              london,
+	}
+
+	// 17:00 UTC is 18:00 in London during summer time
+	start := time.Date(2024, 6, 5, 17, 0, 0, 0, time.UTC)
+	items, total := pricing.Price(start, start.Add(time.Hour), nil)
+
+	assert.Len(t, items, 1)
+	assert.Equal(t, float64(16), total)
+}
+
+func TestPriceSpreadsCustomSections(t *testing.T) {
+	weekdays := []time.Weekday{time.Wednesday}
+	pricing := Pricing{
+		DefaultCostPerSection: 10,
+		MinutePerSection:      60,
+		Rules:                 []PricingRule{newTestRule(t, 1, "Peak", weekdays, "18:00", "22:00", 20, 1, false)},
+		Location:              time.UTC,
+	}
+
+	start := time.Date(2024, 5, 1, 17, 0, 0, 0, time.UTC)
+	sections := float64(1)
+	_, total := pricing.Price(start, start.Add(2*time.Hour), &sections)
+
+	assert.Equal(t, float64(15), total)
+}
+
+func TestNewPricingRuleValidation(t *testing.T) {
+	_, err := NewPricingRule("Peak", []time.Weekday{}, 0, 60, 10, 0, false)
+	assert.Error(t, err)
+
+	_, err = NewPricingRule("Peak", []time.Weekday{time.Monday}, 120, 60, 10, 0, false)
+	assert.Error(t, err)
+
+	rule, err := NewPricingRule("Peak", []time.Weekday{time.Monday, time.Friday}, 60, 120, 10, 0, false)
+	assert.Nil(t, err)
+	assert.Equal(t, []time.Weekday{time.Monday, time.Friday}, rule.GetWeekdays())
+}
+
+func TestReplacePricingRulesRejectsAmbiguousOverlap(t *testing.T) {
+	weekdays := []time.Weekday{time.Monday}
+	sc := NewSportCenter("Center", "Somewhere", 10, 60)
+
+	err := sc.ReplacePricingRules([]PricingRule{
+		newTestRule(t, 1, "Peak", weekdays, "18:00", "22:00", 16, 1, false),
+		newTestRule(t, 2, "Evening", weekdays, "20:00", "23:00", 14, 1, false),
+	})
+	assert.Error(t, err)
+
+	err = sc.ReplacePricingRules([]PricingRule{
+		newTestRule(t, 1, "Peak", weekdays, "18:00", "22:00", 16, 1, false),
+		newTestRule(t, 2, "Evening", weekdays, "20:00", "23:00", 14, 2, false),
+	})
+	assert.Nil(t, err)
+}
+
+func TestPriceAcrossMidnight(t *testing.T) {
+	weekdays := []time.Weekday{time.Wednesday}
+	pricing := Pricing{
+		DefaultCostPerSection: 10,
+		MinutePerSection:      60,
+		Rules:                 []PricingRule{newTestRule(t, 1, "Late", weekdays, "22:00", "24:00", 20, 1, false)},
+		Location:              time.UTC,
+	}
+
+	// Wednesday 21:00 - Thursday 01:00, the late rule only applies on Wednesday
+	start := time.Date(2024, 5, 1, 21, 0, 0, 0, time.UTC)
+	items, total := pricing.Price(start, start.Add(4*time.Hour), nil)
+
+	assert.Len(t, items, 3)
+	assert.Nil(t, items[0].RuleId)
+	assert.Equal(t, float64(60), items[0].Minutes)
+	assert.Equal(t, uint(1), *items[1].RuleId)
+	assert.Equal(t, float64(120), items[1].Minutes)
+	assert.Nil(t, items[2].RuleId)
+	assert.Equal(t, float64(60), items[2].Minutes)
+	assert.Equal(t, float64(60), total)
+}
+
+func TestCheckBookingDuration(t *testing.T) {
+	start := time.Date(2024, 5, 1, 17, 0, 0, 0, time.UTC)
+
+	assert.Nil(t, CheckBookingDuration(start, start.Add(MaxBookingDuration)))
+	assert.ErrorIs(t, CheckBookingDuration(start, start.AddDate(3, 0, 0)), ErrBookingTooLong)
+	assert.NotNil(t, CheckBookingDuration(start, start.Add(-time.Hour)))
+
+	_, err := NewCourtBooking("Court 1", start, start.AddDate(0, 0, 2), Pricing{MinutePerSection: 60}, nil)
+	assert.ErrorIs(t, err, ErrBookingTooLong)
+}
@@ -0,0 +1,196 @@
+package domain
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/samber/lo"
+)
+
+const (
+	InitialRating = 1500.0
+	// ProvisionalGames is the number of games a newcomer plays before their rating is established
+	ProvisionalGames = 10
+	// RatingInactivityPeriod is how long a player can stay away before their rating decays
+	RatingInactivityPeriod = 60 * 24 * time.Hour
+	// RatingDecay is the part of the distance to the initial rating lost per inactivity period
+	RatingDecay = 0.1
+
+	ratingK            = 32.0
+	provisionalRatingK = 64.0
+)
+
+// PlayerRating is an Elo rating computed from the game results, a pair is rated as the average of its players
+type PlayerRating struct {
+	BaseModel
+	PlayerId     uint       `gorm:"uniqueIndex" json:"playerId"`
+	Rating       float64    `json:"rating"`
+	Games        int        `json:"games"`
+	LastPlayedAt *time.Time `json:"lastPlayedAt"`
+}
+
+// RatingChange is an entry of the rating history of a player, one per game played
+type RatingChange struct {
+	BaseModel
+	PlayerId uint      `gorm:"index" json:"playerId"`
+	GameId   uint      `gorm:"index" json:"gameId"`
+	MatchId  uint      `json:"matchId"`
+	PlayedAt time.Time `json:"playedAt"`
+	Before   float64   `json:"before"`
+	After    float64   `json:"after"`
+}
+
+// RatedGame is a game result with the time it was played, games are rated in that order
+type RatedGame struct {
+	Game     Game
+	PlayedAt time.Time
+}
+
+func NewPlayerRating(playerId uint) *PlayerRating {
+	return &PlayerRating{PlayerId: playerId, Rating: InitialRating}
+}
+
+func (r *PlayerRating) IsProvisional() bool {
+	return r.Games < ProvisionalGames
+}
+
+// CurrentRating decays the rating towards the initial rating for every full period without games
+func (r *PlayerRating) CurrentRating(now time.Time) float64 {
+	if r.LastPlayedAt == nil {
+		return r.Rating
+	}
+
+	periods := math.Floor(float64(now.Sub(*r.LastPlayedAt)) / float64(RatingInactivityPeriod))
+	if periods < 1 {
+		return r.Rating
+	}
+	return InitialRating + (r.Rating-InitialRating)*math.Pow(1-RatingDecay, periods)
+}
+
+// IsBefore tells whether the game is rated before the given position, games are rated by time then by id
+func (rg RatedGame) IsBefore(playedAt time.Time, gameId uint) bool {
+	if !rg.PlayedAt.Equal(playedAt) {
+		return rg.PlayedAt.Before(playedAt)
+	}
+	return rg.Game.ID < gameId
+}
+
+// CalcRatings replays every game from the start, the result only depends on the games so it can be recomputed at any time.
+// Newcomers move faster while provisional, and established players move slower against them.
+func CalcRatings(games []RatedGame) ([]PlayerRating, []RatingChange) {
+	return CalcRatingsFrom(nil, games)
+}
+
+// CalcRatingsFrom replays the games on top of the ratings the players had before the first of them,
+// the ratings given are returned too even when their player did not play
+func CalcRatingsFrom(start []PlayerRating, games []RatedGame) ([]PlayerRating, []RatingChange) {
+	sorted := append([]RatedGame{}, games...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].IsBefore(sorted[j].PlayedAt, sorted[j].Game.ID) })
+
+	ratings := map[uint]*PlayerRating{}
+	for _, r := range start {
+		r := r
+		ratings[r.PlayerId] = &r
+	}
+
+	get := func(playerId uint, at time.Time) *PlayerRating {
+		r, found := ratings[playerId]
+		if !found {
+			r = NewPlayerRating(playerId)
+			ratings[playerId] = r
+		}
+		r.Rating = r.CurrentRating(at)
+		return r
+	}
+
+	changes := []RatingChange{}
+	for _, rg := range sorted {
+		g := rg.Game
+		if len(g.GetSide(SideA)) == 0 || len(g.GetSide(SideB)) == 0 || g.Winner == "" {
+			continue
+		}
+
+		sideA := lo.Map(g.GetSide(SideA), func(id uint, _ int) *PlayerRating { return get(id, rg.PlayedAt) })
+		sideB := lo.Map(g.GetSide(SideB), func(id uint, _ int) *PlayerRating { return get(id, rg.PlayedAt) })
+
+		expectedA := 1 / (1 + math.Pow(10, (teamRating(sideB)-teamRating(sideA))/400))
+		scoreA := lo.Ternary(g.Winner == SideA, 1.0, 0.0)
+		deltas := map[uint]float64{}
+		for _, r := range sideA {
+			deltas[r.PlayerId] = ratingFactor(r, sideB) * (scoreA - expectedA)
+		}
+		for _, r := range sideB {
+			deltas[r.PlayerId] = ratingFactor(r, sideA) * (expectedA - scoreA)
+		}
+
+		playedAt := rg.PlayedAt
+		for _, r := range append(sideA, sideB...) {
+			before := r.Rating
+			r.Rating += deltas[r.PlayerId]
+			r.Games++
+			r.LastPlayedAt = &playedAt
+			changes = append(changes, RatingChange{
+				PlayerId: r.PlayerId,
+				GameId:   g.ID,
+				MatchId:  g.MatchId,
+				PlayedAt: playedAt,
+				Before:   before,
+				After:    r.Rating,
+			})
+		}
+	}
+
+	result := lo.Map(lo.Values(ratings), func(r *PlayerRating, _ int) PlayerRating { return *r })
+	sort.Slice(result, func(i, j int) bool { return result[i].PlayerId < result[j].PlayerId })
+	return result, changes
+}
+
+// RestoreRating is the rating of the player after the last change of their history, changes must be in rating order
+func RestoreRating(playerId uint, changes []RatingChange) PlayerRating {
+	rating := NewPlayerRating(playerId)
+	for _, c := range changes {
+		if c.PlayerId != playerId {
+			continue
+		}
+
+		playedAt := c.PlayedAt
+		rating.Rating = c.After
+		rating.Games++
+		rating.LastPlayedAt = &playedAt
+	}
+	return *rating
+}
+
+// RankRatings orders established players by their current rating, provisional players are not ranked
+func RankRatings(ratings []PlayerRating, now time.Time) []PlayerRating {
+	return SortRatings(lo.Filter(ratings, func(r PlayerRating, _ int) bool { return !r.IsProvisional() }), now)
+}
+
+// SortRatings orders the ratings best first
+func SortRatings(ratings []PlayerRating, now time.Time) []PlayerRating {
+	sorted := append([]PlayerRating{}, ratings...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := sorted[i].CurrentRating(now), sorted[j].CurrentRating(now)
+		if a != b {
+			return a > b
+		}
+		return sorted[i].PlayerId < sorted[j].PlayerId
+	})
+	return sorted
+}
+
+func teamRating(team []*PlayerRating) float64 {
+	return lo.SumBy(team, func(r *PlayerRating) float64 { return r.Rating }) / float64(len(team))
+}
+
+func ratingFactor(r *PlayerRating, opponents []*PlayerRating) float64 {
+	if r.IsProvisional() {
+		return provisionalRatingK
+	}
+
+	if lo.SomeBy(opponents, func(o *PlayerRating) bool { return o.IsProvisional() }) {
+		return ratingK / 2
+	}
+	return ratingK
+}
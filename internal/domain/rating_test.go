@@ -0,0 +1,106 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func ratedGame(id uint, at time.Time, sideA, sideB []uint, winner GameSide) RatedGame {
+	game, _ := NewGame(1, "1", BadmintonScoring)
+	game.ID = id
+	game.SetPlayers(sideA, sideB)
+	game.Winner = winner
+	return RatedGame{Game: *game, PlayedAt: at}
+}
+
+func TestCalcRatingsForDoubles(t *testing.T) {
+	at := time.Date(2024, 5, 1, 18, 0, 0, 0, time.UTC)
+	ratings, changes := CalcRatings([]RatedGame{
+		ratedGame(1, at, []uint{1, 2}, []uint{3, 4}, SideA),
+	})
+
+	assert.Len(t, ratings, 4)
+	assert.Len(t, changes, 4)
+
+	// Equal teams of provisional players move by half of the provisional factor
+	assert.Equal(t, InitialRating+32, ratings[0].Rating)
+	assert.Equal(t, InitialRating+32, ratings[1].Rating)
+	assert.Equal(t, InitialRating-32, ratings[2].Rating)
+	assert.Equal(t, 1, ratings[3].Games)
+	assert.Equal(t, InitialRating, changes[0].Before)
+}
+
+func TestCalcRatingsIsDeterministic(t *testing.T) {
+	at := time.Date(2024, 5, 1, 18, 0, 0, 0, time.UTC)
+	games := []RatedGame{
+		ratedGame(3, at.AddDate(0, 0, 7), []uint{1, 3}, []uint{2, 4}, SideB),
+		ratedGame(1, at, []uint{1, 2}, []uint{3, 4}, SideA),
+		ratedGame(2, at, []uint{1, 4}, []uint{2, 3}, SideA),
+	}
+	reversed := []RatedGame{games[2], games[1], games[0]}
+
+	a, changesA := CalcRatings(games)
+	b, changesB := CalcRatings(reversed)
+	assert.Equal(t, a, b)
+	assert.Equal(t, changesA, changesB)
+	assert.Equal(t, uint(1), changesA[0].GameId)
+}
+
+func TestCalcRatingsFromMatchesFullReplay(t *testing.T) {
+	at := time.Date(2024, 5, 1, 18, 0, 0, 0, time.UTC)
+	games := []RatedGame{
+		ratedGame(1, at, []uint{1, 2}, []uint{3, 4}, SideA),
+		ratedGame(2, at, []uint{1, 4}, []uint{2, 3}, SideA),
+		ratedGame(3, at.AddDate(0, 0, 90), []uint{1, 3}, []uint{2, 5}, SideB),
+		ratedGame(4, at.AddDate(0, 0, 97), []uint{1, 2}, []uint{4, 5}, SideB),
+	}
+	full, fullChanges := CalcRatings(games)
+
+	// Replay from game 3 on top of the history before it, the inactivity decay is applied the same way
+	_, history := CalcRatings(games[:2])
+	start := []PlayerRating{}
+	for _, id := range []uint{1, 2, 3, 4, 5} {
+		if r := RestoreRating(id, history); r.Games > 0 {
+			start = append(start, r)
+		}
+	}
+	assert.True(t, games[1].IsBefore(games[2].PlayedAt, games[2].Game.ID))
+	assert.False(t, games[2].IsBefore(games[2].PlayedAt, games[2].Game.ID))
+
+	ratings, changes := CalcRatingsFrom(start, games[2:])
+	assert.Equal(t, full, ratings)
+	assert.Equal(t, fullChanges[len(history):], changes)
+}
+
+func TestProvisionalOpponentsMoveEstablishedRatingsLess(t *testing.T) {
+	established := &PlayerRating{PlayerId: 1, Rating: InitialRating, Games: ProvisionalGames}
+	newcomer := &PlayerRating{PlayerId: 2, Rating: InitialRating}
+
+	assert.Equal(t, provisionalRatingK, ratingFactor(newcomer, []*PlayerRating{established}))
+	assert.Equal(t, ratingK/2, ratingFactor(established, []*PlayerRating{newcomer}))
+	assert.Equal(t, ratingK, ratingFactor(established, []*PlayerRating{established}))
+}
+
+func TestRatingDecay(t *testing.T) {
+	lastPlayed := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	r := PlayerRating{Rating: 1700, Games: 20, LastPlayedAt: &lastPlayed}
+
+	assert.Equal(t, 1700.0, r.CurrentRating(lastPlayed.Add(RatingInactivityPeriod-time.Hour)))
+	assert.InDelta(t, 1680, r.CurrentRating(lastPlayed.Add(RatingInactivityPeriod)), 0.0001)
+	assert.InDelta(t, 1662, r.CurrentRating(lastPlayed.Add(2*RatingInactivityPeriod)), 0.0001)
+}
+
+func TestRankRatingsSkipsProvisional(t *testing.T) {
+	now := time.Now()
+	ranked := RankRatings([]PlayerRating{
+		{PlayerId: 1, Rating: 1600, Games: ProvisionalGames},
+		{PlayerId: 2, Rating: 1900, Games: 1},
+		{PlayerId: 3, Rating: 1700, Games: 30},
+	}, now)
+
+	assert.Len(t, ranked, 2)
+	assert.Equal(t, uint(3), ranked[0].PlayerId)
+	assert.Equal(t, uint(1), ranked[1].PlayerId)
+}
@@ -1,18 +1,34 @@
 package domain
 
+import "time"
+
 type Registration struct {
 	BaseModel
-	PlayerId           uint   `gorm:"index" json:"playerId"`
-	MatchId            uint   `gorm:"index" json:"matchId"`
-	TotalPlayerPaidFor uint   `gorm:"default:1" json:"totalPlayerPaidFor"`
-	IsPaid             bool   `gorm:"index" json:"isPaid"`
-	Comment            string `json:"comment"`
+	PlayerId           uint       `gorm:"index" json:"playerId"`
+	MatchId            uint       `gorm:"index" json:"matchId"`
+	TotalPlayerPaidFor uint       `gorm:"default:1" json:"totalPlayerPaidFor"`
+	IsPaid             bool       `gorm:"index" json:"isPaid"`
+	Comment            string     `json:"comment"`
+	Rsvp               RsvpStatus `gorm:"default:going;index" json:"rsvp"`
+	RsvpRemindedAt     *time.Time `json:"rsvpRemindedAt"`
+	IsWaitlisted       bool       `gorm:"default:false" json:"isWaitlisted"`
+	WaitlistPriority   int        `gorm:"default:0" json:"waitlistPriority"`
+	Attendance         string     `json:"attendance"`
+	CheckedInAt        *time.Time `json:"checkedInAt"`
+	Guests             []Guest    `json:"guests"`
+	PlayedFrom         *time.Time `json:"playedFrom"`
+	PlayedTo           *time.Time `json:"playedTo"`
+	// BallotPosition is the place in the ballot draw, drawn registrations left on the waitlist are promoted first in draw order
+	BallotPosition int `gorm:"default:0" json:"ballotPosition"`
+	// StandingRegistrationId is set when the player was registered automatically as a regular
+	StandingRegistrationId *uint `gorm:"index" json:"standingRegistrationId"`
 }
 
 func NewRegistration(playerId, matchId uint) *Registration {
 	return &Registration{
 		PlayerId: playerId,
 		MatchId:  matchId,
+		Rsvp:     RsvpGoing,
 		IsPaid:   false,
 		// By default, main player is registered for a match
 		TotalPlayerPaidFor: 1,
@@ -20,9 +36,10 @@ func NewRegistration(playerId, matchId uint) *Registration {
 	}
 }
 
+// UpdatePlayerPaidForCount can not go below the player and their named guests
 func (reg *Registration) UpdatePlayerPaidForCount(count uint) {
-	if count < 1 {
-		count = 1
+	if named := uint(len(reg.Guests)) + 1; count < named {
+		count = named
 	}
 	reg.TotalPlayerPaidFor = count
 }
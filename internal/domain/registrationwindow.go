@@ -0,0 +1,140 @@
+package domain
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/samber/lo"
+)
+
+var (
+	ErrRegistrationNotOpenYet     = errors.New("registration is not open yet")
+	ErrCancellationDeadlinePassed = errors.New("cancellation deadline has passed")
+)
+
+// LateCancellationFee is charged to a player leaving a match after the cancellation deadline
+// when nobody from the waitlist takes the spot
+type LateCancellationFee struct {
+	BaseModel
+	PlayerId uint    `gorm:"index" json:"playerId"`
+	MatchId  uint    `gorm:"index" json:"matchId"`
+	Amount   float64 `json:"amount"`
+	IsPaid   bool    `gorm:"index" json:"isPaid"`
+}
+
+func NewLateCancellationFee(playerId, matchId uint, amount float64) (*LateCancellationFee, error) {
+	if amount <= 0 {
+		return nil, errors.New("fee must be positive")
+	}
+
+	return &LateCancellationFee{
+		PlayerId: playerId,
+		MatchId:  matchId,
+		Amount:   amount,
+	}, nil
+}
+
+func (f *LateCancellationFee) MarkPaid() {
+	f.IsPaid = true
+}
+
+// SetRegistrationWindow configures when players can register and leave freely.
+// A nil late cancellation fee means players can not leave after the deadline.
+func (m *Match) SetRegistrationWindow(opensAt, closesAt, cancellationDeadline *time.Time, lateCancellationFee *float64) error {
+	if opensAt != nil && closesAt != nil && !closesAt.After(*opensAt) {
+		return errors.New("registration must close after it opens")
+	}
+
+	if cancellationDeadline != nil && opensAt != nil && cancellationDeadline.Before(*opensAt) {
+		return errors.New("cancellation deadline must be after registration opens")
+	}
+
+	if lateCancellationFee != nil && *lateCancellationFee < 0 {
+		return errors.New("late cancellation fee must not be negative")
+	}
+
+	m.RegistrationOpensAt = opensAt
+	m.RegistrationClosesAt = closesAt
+	m.CancellationDeadline = cancellationDeadline
+	m.LateCancellationFee = lateCancellationFee
+	return nil
+}
+
+// EnsureCanRegister checks the match state and the registration window
+func (m *Match) EnsureCanRegister(now time.Time) error {
+	if !m.AcceptsRegistrations() {
+		return ErrRegistrationClosed
+	}
+
+	if m.RegistrationOpensAt != nil && now.Before(*m.RegistrationOpensAt) {
+		return ErrRegistrationNotOpenYet
+	}
+
+	if m.RegistrationClosesAt != nil && !now.Before(*m.RegistrationClosesAt) {
+		return ErrRegistrationClosed
+	}
+
+	return nil
+}
+
+// CheckUnregister tells whether leaving now is late, it fails when leaving late is not allowed
+func (m *Match) CheckUnregister(now time.Time) (bool, error) {
+	if !m.AcceptsChanges() {
+		return false, ErrRegistrationClosed
+	}
+
+	if m.CancellationDeadline == nil || now.Before(*m.CancellationDeadline) {
+		return false, nil
+	}
+
+	if m.LateCancellationFee == nil {
+		return true, ErrCancellationDeadlinePassed
+	}
+
+	return true, nil
+}
+
+// HasFreeSpot tells whether the booked courts can take more players, an unknown capacity has no limit
+func (m *Match) HasFreeSpot(count uint) bool {
+	capacity := m.CalcCapacity()
+	return capacity == 0 || m.CalcPlayerCount()+int(count) <= capacity
+}
+
+// PromoteWaitlist confirms the ballot losers in draw order, then the other waitlisted registrations by priority
+// then registration order while spots are free, nobody is promoted before a pending ballot is drawn
+func (m *Match) PromoteWaitlist() []Registration {
+	if m.IsBallotPending() {
+		return []Registration{}
+	}
+
+	waitlisted := lo.Filter(m.Registrations, func(r Registration, _ int) bool { return r.IsWaitlisted })
+	sort.SliceStable(waitlisted, func(i, j int) bool {
+		if waitlisted[i].IsDrawn() != waitlisted[j].IsDrawn() {
+			return waitlisted[i].IsDrawn()
+		}
+		if waitlisted[i].BallotPosition != waitlisted[j].BallotPosition {
+			return waitlisted[i].BallotPosition < waitlisted[j].BallotPosition
+		}
+		if waitlisted[i].WaitlistPriority != waitlisted[j].WaitlistPriority {
+			return waitlisted[i].WaitlistPriority > waitlisted[j].WaitlistPriority
+		}
+		return waitlisted[i].ID < waitlisted[j].ID
+	})
+
+	promoted := []Registration{}
+	for _, w := range waitlisted {
+		if !m.HasFreeSpot(w.TotalPlayerPaidFor) {
+			break
+		}
+
+		for i := range m.Registrations {
+			if m.Registrations[i].ID == w.ID {
+				m.Registrations[i].IsWaitlisted = false
+				promoted = append(promoted, m.Registrations[i])
+			}
+		}
+	}
+
+	return promoted
+}
@@ -0,0 +1,78 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnsureCanRegister(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	opensAt, closesAt := now.Add(-time.Hour), now.Add(time.Hour)
+
+	m := &Match{State: MatchOpen}
+	assert.Nil(t, m.SetRegistrationWindow(&opensAt, &closesAt, nil, nil))
+
+	assert.Nil(t, m.EnsureCanRegister(now))
+	assert.ErrorIs(t, m.EnsureCanRegister(opensAt.Add(-time.Minute)), ErrRegistrationNotOpenYet)
+	assert.ErrorIs(t, m.EnsureCanRegister(closesAt), ErrRegistrationClosed)
+
+	m.State = MatchRegistrationClosed
+	assert.ErrorIs(t, m.EnsureCanRegister(now), ErrRegistrationClosed)
+}
+
+func TestSetRegistrationWindowValidation(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	before := now.Add(-time.Hour)
+	fee := -1.0
+
+	m := &Match{}
+	assert.NotNil(t, m.SetRegistrationWindow(&now, &before, nil, nil))
+	assert.NotNil(t, m.SetRegistrationWindow(nil, nil, nil, &fee))
+}
+
+func TestCheckUnregister(t *testing.T) {
+	deadline := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	m := &Match{State: MatchOpen}
+	assert.Nil(t, m.SetRegistrationWindow(nil, nil, &deadline, nil))
+
+	isLate, err := m.CheckUnregister(deadline.Add(-time.Minute))
+	assert.False(t, isLate)
+	assert.Nil(t, err)
+
+	// Leaving late is refused without a fee
+	_, err = m.CheckUnregister(deadline)
+	assert.ErrorIs(t, err, ErrCancellationDeadlinePassed)
+
+	fee := 5.0
+	m.LateCancellationFee = &fee
+	isLate, err = m.CheckUnregister(deadline.Add(time.Hour))
+	assert.True(t, isLate)
+	assert.Nil(t, err)
+}
+
+func TestPromoteWaitlist(t *testing.T) {
+	m := &Match{
+		CourtBookings: []CourtBooking{{Capacity: 4}},
+		Registrations: []Registration{
+			{BaseModel: BaseModel{ID: 1}, TotalPlayerPaidFor: 2},
+			{BaseModel: BaseModel{ID: 2}, TotalPlayerPaidFor: 1},
+			{BaseModel: BaseModel{ID: 4}, TotalPlayerPaidFor: 1, IsWaitlisted: true},
+			{BaseModel: BaseModel{ID: 3}, TotalPlayerPaidFor: 2, IsWaitlisted: true},
+		},
+	}
+
+	assert.Equal(t, 3, m.CalcPlayerCount())
+	assert.False(t, m.HasFreeSpot(2))
+
+	// The earliest waitlisted registration needs two spots, nobody jumps the queue
+	assert.Empty(t, m.PromoteWaitlist())
+
+	m.Registrations = m.Registrations[1:]
+	promoted := m.PromoteWaitlist()
+	assert.Len(t, promoted, 2)
+	assert.Equal(t, uint(3), promoted[0].ID)
+	assert.Equal(t, uint(4), promoted[1].ID)
+	assert.Equal(t, 4, m.CalcPlayerCount())
+}
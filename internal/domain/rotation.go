@@ -0,0 +1,288 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+
+	"github.com/samber/lo"
+)
+
+const (
+	// rotationAttempts is the number of candidate line-ups tried for every round, the cheapest wins
+	rotationAttempts = 200
+
+	partnerRepeatCost  = 10.0
+	opponentRepeatCost = 3.0
+	mixedPairCost      = 100.0
+)
+
+var (
+	ErrNotEnoughPlayers = errors.New("at least 4 players are needed for doubles")
+	ErrRotationFinished = errors.New("rotation is already on its last round")
+)
+
+// Rotation is the doubles schedule of a session, rounds are played one after another on every court
+type Rotation struct {
+	BaseModel
+	MatchId      uint            `gorm:"index" json:"matchId"`
+	Seed         int64           `json:"seed"`
+	Courts       int             `json:"courts"`
+	CurrentRound int             `json:"currentRound"`
+	Rounds       []RotationRound `json:"rounds"`
+}
+
+type RotationRound struct {
+	BaseModel
+	RotationId uint             `gorm:"index" json:"rotationId"`
+	Number     int              `json:"number"`
+	Games      []RotationGame   `gorm:"foreignKey:RoundId" json:"games"`
+	SitOuts    []RotationSitOut `gorm:"foreignKey:RoundId" json:"sitOuts"`
+}
+
+// RotationGame is a doubles game of a round, team A plays team B on the court
+type RotationGame struct {
+	BaseModel
+	RoundId uint `gorm:"index" json:"roundId"`
+	Court   int  `json:"court"`
+	TeamA1  uint `json:"teamA1"`
+	TeamA2  uint `json:"teamA2"`
+	TeamB1  uint `json:"teamB1"`
+	TeamB2  uint `json:"teamB2"`
+}
+
+type RotationSitOut struct {
+	BaseModel
+	RoundId  uint `gorm:"index" json:"roundId"`
+	PlayerId uint `json:"playerId"`
+}
+
+type RotationPlayer struct {
+	PlayerId uint
+	Rating   float64
+	Gender   string
+}
+
+type RotationOptions struct {
+	Courts       int
+	Rounds       int
+	Seed         int64
+	BalanceSkill bool
+	Mixed        bool
+	FixedPairs   [][2]uint
+}
+
+// rotationHistory is what happened in the previous rounds, it drives the fairness of the next one
+type rotationHistory struct {
+	partners  map[[2]uint]int
+	opponents map[[2]uint]int
+	sitOuts   map[uint]int
+}
+
+// GenerateRotation plans the rounds of a session. It avoids repeating partners and opponents,
+// spreads sit-outs evenly and keeps fixed pairs together, the same seed always gives the same schedule.
+func GenerateRotation(players []RotationPlayer, opts RotationOptions) (*Rotation, error) {
+	if len(players) < 4 {
+		return nil, ErrNotEnoughPlayers
+	}
+
+	if opts.Courts < 1 || opts.Rounds < 1 {
+		return nil, errors.New("courts and rounds must be at least 1")
+	}
+
+	units, err := buildRotationUnits(players, opts.FixedPairs)
+	if err != nil {
+		return nil, err
+	}
+
+	rng := rand.New(rand.NewSource(opts.Seed))
+	byId := lo.KeyBy(players, func(p RotationPlayer) uint { return p.PlayerId })
+	history := &rotationHistory{
+		partners:  map[[2]uint]int{},
+		opponents: map[[2]uint]int{},
+		sitOuts:   map[uint]int{},
+	}
+
+	rotation := &Rotation{Seed: opts.Seed, Courts: opts.Courts, CurrentRound: 1}
+	for number := 1; number <= opts.Rounds; number++ {
+		playing, sitting := pickSitOuts(units, len(players), opts.Courts, history, rng)
+		games := bestLineUp(playing, byId, opts, history, rng)
+
+		round := RotationRound{Number: number}
+		for i, g := range games {
+			round.Games = append(round.Games, RotationGame{
+				Court:  i + 1,
+				TeamA1: g[0],
+				TeamA2: g[1],
+				TeamB1: g[2],
+				TeamB2: g[3],
+			})
+			history.record(g)
+		}
+
+		for _, id := range sitting {
+			round.SitOuts = append(round.SitOuts, RotationSitOut{PlayerId: id})
+			history.sitOuts[id]++
+		}
+		rotation.Rounds = append(rotation.Rounds, round)
+	}
+
+	return rotation, nil
+}
+
+// Advance moves the session to the next round
+func (r *Rotation) Advance() error {
+	if r.CurrentRound >= len(r.Rounds) {
+		return ErrRotationFinished
+	}
+	r.CurrentRound++
+	return nil
+}
+
+// buildRotationUnits groups fixed pairs so that they play and sit out together
+func buildRotationUnits(players []RotationPlayer, fixedPairs [][2]uint) ([][]uint, error) {
+	present := lo.SliceToMap(players, func(p RotationPlayer) (uint, bool) { return p.PlayerId, true })
+	paired := map[uint]bool{}
+	units := [][]uint{}
+	for _, pair := range fixedPairs {
+		for _, id := range pair {
+			if !present[id] {
+				return nil, fmt.Errorf("player %d of a fixed pair is not present", id)
+			}
+			if paired[id] {
+				return nil, fmt.Errorf("player %d is in more than one fixed pair", id)
+			}
+			paired[id] = true
+		}
+		if pair[0] == pair[1] {
+			return nil, fmt.Errorf("player %d can not be paired with themselves", pair[0])
+		}
+		units = append(units, []uint{pair[0], pair[1]})
+	}
+
+	for _, p := range players {
+		if !paired[p.PlayerId] {
+			units = append(units, []uint{p.PlayerId})
+		}
+	}
+	return units, nil
+}
+
+// pickSitOuts fills the courts with the players who sat out the most, the others sit out this round
+func pickSitOuts(units [][]uint, playerCount, courts int, history *rotationHistory, rng *rand.Rand) ([][]uint, []uint) {
+	playing := lo.Map(rng.Perm(len(units)), func(i int, _ int) []uint { return units[i] })
+	sitOuts := func(u []uint) float64 {
+		return float64(lo.SumBy(u, func(id uint) int { return history.sitOuts[id] })) / float64(len(u))
+	}
+	sort.SliceStable(playing, func(i, j int) bool { return sitOuts(playing[i]) > sitOuts(playing[j]) })
+
+	target := min(4*courts, playerCount/4*4)
+	sitting := []uint{}
+	for n := playerCount; n > target; {
+		// The last units sat out the least, a fixed pair is skipped when only one more player must sit out
+		idx := len(playing) - 1
+		for len(playing[idx]) > n-target {
+			idx--
+		}
+
+		sitting = append(sitting, playing[idx]...)
+		n -= len(playing[idx])
+		playing = append(playing[:idx:idx], playing[idx+1:]...)
+	}
+
+	return playing, sitting
+}
+
+// bestLineUp tries random line-ups and keeps the one with the fewest repeats
+func bestLineUp(units [][]uint, byId map[uint]RotationPlayer, opts RotationOptions, history *rotationHistory, rng *rand.Rand) [][4]uint {
+	var best [][4]uint
+	bestCost := math.Inf(1)
+	for attempt := 0; attempt < rotationAttempts; attempt++ {
+		pairs := makePairs(units, byId, opts.Mixed, rng)
+		rng.Shuffle(len(pairs), func(i, j int) { pairs[i], pairs[j] = pairs[j], pairs[i] })
+
+		games := make([][4]uint, 0, len(pairs)/2)
+		for i := 0; i+1 < len(pairs); i += 2 {
+			games = append(games, [4]uint{pairs[i][0], pairs[i][1], pairs[i+1][0], pairs[i+1][1]})
+		}
+
+		cost := lo.SumBy(games, func(g [4]uint) float64 { return history.cost(g, byId, opts) })
+		if cost < bestCost {
+			best, bestCost = games, cost
+		}
+	}
+	return best
+}
+
+// makePairs keeps fixed pairs and pairs the other players randomly, men with women first for mixed doubles
+func makePairs(units [][]uint, byId map[uint]RotationPlayer, mixed bool, rng *rand.Rand) [][2]uint {
+	pairs := [][2]uint{}
+	singles := []uint{}
+	for _, u := range units {
+		if len(u) == 2 {
+			pairs = append(pairs, [2]uint{u[0], u[1]})
+		} else {
+			singles = append(singles, u[0])
+		}
+	}
+	rng.Shuffle(len(singles), func(i, j int) { singles[i], singles[j] = singles[j], singles[i] })
+
+	if mixed {
+		men := lo.Filter(singles, func(id uint, _ int) bool { return byId[id].Gender == GenderMale })
+		others := lo.Filter(singles, func(id uint, _ int) bool { return byId[id].Gender != GenderMale })
+		for len(men) > 0 && len(others) > 0 {
+			pairs = append(pairs, [2]uint{men[0], others[0]})
+			men, others = men[1:], others[1:]
+		}
+		singles = append(men, others...)
+	}
+
+	for i := 0; i+1 < len(singles); i += 2 {
+		pairs = append(pairs, [2]uint{singles[i], singles[i+1]})
+	}
+	return pairs
+}
+
+func (h *rotationHistory) cost(g [4]uint, byId map[uint]RotationPlayer, opts RotationOptions) float64 {
+	cost := partnerRepeatCost * float64(h.partners[pairKey(g[0], g[1])]+h.partners[pairKey(g[2], g[3])])
+	for _, a := range g[:2] {
+		for _, b := range g[2:] {
+			cost += opponentRepeatCost * float64(h.opponents[pairKey(a, b)])
+		}
+	}
+
+	if opts.BalanceSkill {
+		// 200 rating points between the teams weigh as much as a repeat partner
+		teamA := byId[g[0]].Rating + byId[g[1]].Rating
+		teamB := byId[g[2]].Rating + byId[g[3]].Rating
+		cost += math.Abs(teamA-teamB) / 20
+	}
+
+	if opts.Mixed {
+		for _, pair := range [][2]uint{{g[0], g[1]}, {g[2], g[3]}} {
+			if (byId[pair[0]].Gender == GenderMale) == (byId[pair[1]].Gender == GenderMale) {
+				cost += mixedPairCost
+			}
+		}
+	}
+	return cost
+}
+
+func (h *rotationHistory) record(g [4]uint) {
+	h.partners[pairKey(g[0], g[1])]++
+	h.partners[pairKey(g[2], g[3])]++
+	for _, a := range g[:2] {
+		for _, b := range g[2:] {
+			h.opponents[pairKey(a, b)]++
+		}
+	}
+}
+
+func pairKey(a, b uint) [2]uint {
+	if a > b {
+		a, b = b, a
+	}
+	return [2]uint{a, b}
+}
@@ -0,0 +1,118 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+)
+
+func rotationPlayers(n int) []RotationPlayer {
+	return lo.Times(n, func(i int) RotationPlayer {
+		return RotationPlayer{
+			PlayerId: uint(i + 1),
+			Rating:   InitialRating + float64(i*50),
+			Gender:   lo.Ternary(i%2 == 0, GenderMale, GenderFemale),
+		}
+	})
+}
+
+func TestGenerateRotationIsDeterministic(t *testing.T) {
+	opts := RotationOptions{Courts: 2, Rounds: 6, Seed: 42}
+	a, err := GenerateRotation(rotationPlayers(10), opts)
+	assert.Nil(t, err)
+	b, _ := GenerateRotation(rotationPlayers(10), opts)
+	assert.Equal(t, a, b)
+
+	opts.Seed = 7
+	c, _ := GenerateRotation(rotationPlayers(10), opts)
+	assert.NotEqual(t, a, c)
+}
+
+func TestGenerateRotationBalancesSitOuts(t *testing.T) {
+	rotation, err := GenerateRotation(rotationPlayers(10), RotationOptions{Courts: 2, Rounds: 5, Seed: 1})
+	assert.Nil(t, err)
+	assert.Len(t, rotation.Rounds, 5)
+
+	sitOuts := map[uint]int{}
+	for _, round := range rotation.Rounds {
+		assert.Len(t, round.Games, 2)
+		assert.Len(t, round.SitOuts, 2)
+
+		seen := map[uint]bool{}
+		for _, g := range round.Games {
+			for _, id := range []uint{g.TeamA1, g.TeamA2, g.TeamB1, g.TeamB2} {
+				assert.False(t, seen[id], "player %d plays twice in round %d", id, round.Number)
+				seen[id] = true
+			}
+		}
+		for _, s := range round.SitOuts {
+			assert.False(t, seen[s.PlayerId])
+			sitOuts[s.PlayerId]++
+		}
+	}
+
+	// 10 sit-outs over 10 players, everyone sits out exactly once
+	for id := uint(1); id <= 10; id++ {
+		assert.Equal(t, 1, sitOuts[id], "player %d", id)
+	}
+}
+
+func TestGenerateRotationAvoidsRepeatPartners(t *testing.T) {
+	rotation, err := GenerateRotation(rotationPlayers(8), RotationOptions{Courts: 2, Rounds: 7, Seed: 3})
+	assert.Nil(t, err)
+
+	partners := map[[2]uint]int{}
+	for _, round := range rotation.Rounds {
+		for _, g := range round.Games {
+			partners[pairKey(g.TeamA1, g.TeamA2)]++
+			partners[pairKey(g.TeamB1, g.TeamB2)]++
+		}
+	}
+
+	// 7 rounds of 8 players can be played without any repeat partner, allow a few
+	repeats := lo.SumBy(lo.Values(partners), func(n int) int { return n - 1 })
+	assert.LessOrEqual(t, repeats, 3)
+}
+
+func TestGenerateRotationKeepsFixedPairsAndMixes(t *testing.T) {
+	players := rotationPlayers(9)
+	rotation, err := GenerateRotation(players, RotationOptions{
+		Courts:     2,
+		Rounds:     4,
+		Seed:       5,
+		Mixed:      true,
+		FixedPairs: [][2]uint{{1, 2}},
+	})
+	assert.Nil(t, err)
+
+	byId := lo.KeyBy(players, func(p RotationPlayer) uint { return p.PlayerId })
+	for _, round := range rotation.Rounds {
+		assert.Len(t, round.SitOuts, 1)
+		for _, g := range round.Games {
+			for _, pair := range [][2]uint{{g.TeamA1, g.TeamA2}, {g.TeamB1, g.TeamB2}} {
+				if pair[0] == 1 || pair[1] == 1 {
+					assert.Equal(t, pairKey(1, 2), pairKey(pair[0], pair[1]))
+				}
+			}
+		}
+
+		// 5 men and 4 women on the bench of 1, at most one pair is not mixed
+		notMixed := lo.CountBy(round.Games, func(g RotationGame) bool {
+			return byId[g.TeamA1].Gender == byId[g.TeamA2].Gender || byId[g.TeamB1].Gender == byId[g.TeamB2].Gender
+		})
+		assert.LessOrEqual(t, notMixed, 1)
+	}
+}
+
+func TestGenerateRotationValidates(t *testing.T) {
+	_, err := GenerateRotation(rotationPlayers(3), RotationOptions{Courts: 1, Rounds: 1})
+	assert.Equal(t, ErrNotEnoughPlayers, err)
+
+	_, err = GenerateRotation(rotationPlayers(4), RotationOptions{Courts: 1, Rounds: 1, FixedPairs: [][2]uint{{1, 9}}})
+	assert.NotNil(t, err)
+
+	rotation, _ := GenerateRotation(rotationPlayers(4), RotationOptions{Courts: 1, Rounds: 2})
+	assert.Nil(t, rotation.Advance())
+	assert.Equal(t, ErrRotationFinished, rotation.Advance())
+}
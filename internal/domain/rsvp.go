@@ -0,0 +1,97 @@
+package domain
+
+import (
+	"errors"
+	"time"
+
+	"github.com/samber/lo"
+)
+
+type RsvpStatus = string
+
+const (
+	RsvpGoing    RsvpStatus = "going"
+	RsvpMaybe    RsvpStatus = "maybe"
+	RsvpNotGoing RsvpStatus = "not_going"
+	RsvpInvited  RsvpStatus = "invited"
+)
+
+// RsvpReminderWindow is how long before registration closes undecided players are reminded
+const RsvpReminderWindow = 24 * time.Hour
+
+var ErrUnknownRsvp = errors.New("rsvp must be going, maybe or not_going")
+
+// ParseRsvp reads the answer of a player, invited is only set by the organizer
+func ParseRsvp(value string) (RsvpStatus, error) {
+	if !lo.Contains([]RsvpStatus{RsvpGoing, RsvpMaybe, RsvpNotGoing}, value) {
+		return "", ErrUnknownRsvp
+	}
+	return value, nil
+}
+
+// NewInvitation lists the player on the match without taking a spot until the player answers
+func NewInvitation(playerId, matchId uint) *Registration {
+	registration := NewRegistration(playerId, matchId)
+	registration.Rsvp = RsvpInvited
+	return registration
+}
+
+// NewInvitations lists the invited players who are not on the match yet, players who already answered are kept as they are.
+// Team matches are only shown to the invited teams, so invited players are not listed there.
+func (m *Match) NewInvitations(playerIds []uint) []Registration {
+	if m.GetVisibility() == VisibilityTeam || !m.AcceptsRegistrations() {
+		return nil
+	}
+
+	return lo.FilterMap(lo.Uniq(playerIds), func(playerId uint, _ int) (Registration, bool) {
+		if lo.ContainsBy(m.Registrations, func(r Registration) bool { return r.PlayerId == playerId }) {
+			return Registration{}, false
+		}
+		return *NewInvitation(playerId, m.ID), true
+	})
+}
+
+// IsGoing is true for registrations made before rsvp states existed too
+func (reg *Registration) IsGoing() bool {
+	return reg.Rsvp == "" || reg.Rsvp == RsvpGoing
+}
+
+// IsConfirmed registrations take a spot and share the cost
+func (reg *Registration) IsConfirmed() bool {
+	return reg.IsGoing() && !reg.IsWaitlisted
+}
+
+// ChangeRsvp records the answer, only going players can be waitlisted and stepping back gives up the place in the draw
+func (reg *Registration) ChangeRsvp(status RsvpStatus) {
+	reg.Rsvp = status
+	if !reg.IsGoing() {
+		reg.IsWaitlisted = false
+		reg.WaitlistPriority = 0
+		reg.BallotPosition = 0
+	}
+}
+
+// GetConfirmedRegistrations returns the registrations sharing the cost of the match
+func (m *Match) GetConfirmedRegistrations() []Registration {
+	return lo.Filter(m.Registrations, func(r Registration, _ int) bool { return r.IsConfirmed() })
+}
+
+func (m *Match) CalcMaybeCount() int {
+	return lo.CountBy(m.Registrations, func(r Registration) bool { return r.Rsvp == RsvpMaybe })
+}
+
+// GetRsvpReminders returns the undecided registrations to remind once registration closes within the reminder window,
+// every registration is reminded once
+func (m *Match) GetRsvpReminders(now time.Time) []Registration {
+	if m.RegistrationClosesAt == nil || !m.AcceptsRegistrations() {
+		return nil
+	}
+
+	if !now.Before(*m.RegistrationClosesAt) || now.Add(RsvpReminderWindow).Before(*m.RegistrationClosesAt) {
+		return nil
+	}
+
+	return lo.Filter(m.Registrations, func(r Registration, _ int) bool {
+		return (r.Rsvp == RsvpMaybe || r.Rsvp == RsvpInvited) && r.RsvpRemindedAt == nil
+	})
+}
@@ -0,0 +1,106 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRsvp(t *testing.T) {
+	for _, value := range []string{RsvpGoing, RsvpMaybe, RsvpNotGoing} {
+		rsvp, err := ParseRsvp(value)
+		assert.Nil(t, err)
+		assert.Equal(t, value, rsvp)
+	}
+
+	// Invitations are only sent by organizers
+	_, err := ParseRsvp(RsvpInvited)
+	assert.ErrorIs(t, err, ErrUnknownRsvp)
+}
+
+func TestRsvpPlayerCount(t *testing.T) {
+	m := &Match{
+		CourtBookings: []CourtBooking{{Capacity: 3}},
+		Registrations: []Registration{
+			{TotalPlayerPaidFor: 1, Rsvp: RsvpGoing},
+			{TotalPlayerPaidFor: 1},
+			{TotalPlayerPaidFor: 1, Rsvp: RsvpMaybe},
+			{TotalPlayerPaidFor: 1, Rsvp: RsvpNotGoing},
+			{TotalPlayerPaidFor: 1, Rsvp: RsvpInvited},
+			{TotalPlayerPaidFor: 1, Rsvp: RsvpGoing, IsWaitlisted: true},
+		},
+	}
+
+	// Registrations made before rsvp existed count as going
+	assert.Equal(t, 2, m.CalcPlayerCount())
+	assert.Equal(t, 1, m.CalcMaybeCount())
+	assert.Len(t, m.GetConfirmedRegistrations(), 2)
+	assert.True(t, m.HasFreeSpot(1))
+	assert.False(t, m.HasFreeSpot(2))
+}
+
+func TestChangeRsvp(t *testing.T) {
+	reg := NewRegistration(1, 1)
+	assert.Equal(t, RsvpGoing, reg.Rsvp)
+
+	reg.IsWaitlisted = true
+	reg.WaitlistPriority = 2
+	reg.ChangeRsvp(RsvpMaybe)
+	assert.False(t, reg.IsWaitlisted)
+	assert.Equal(t, 0, int(reg.WaitlistPriority))
+	assert.False(t, reg.IsConfirmed())
+
+	reg.ChangeRsvp(RsvpGoing)
+	assert.True(t, reg.IsConfirmed())
+}
+
+func TestGetRsvpReminders(t *testing.T) {
+	closesAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	reminded := closesAt.Add(-time.Hour)
+	m := &Match{
+		RegistrationClosesAt: &closesAt,
+		Registrations: []Registration{
+			{BaseModel: BaseModel{ID: 1}, Rsvp: RsvpGoing},
+			{BaseModel: BaseModel{ID: 2}, Rsvp: RsvpMaybe},
+			{BaseModel: BaseModel{ID: 3}, Rsvp: RsvpInvited},
+			{BaseModel: BaseModel{ID: 4}, Rsvp: RsvpMaybe, RsvpRemindedAt: &reminded},
+			{BaseModel: BaseModel{ID: 5}, Rsvp: RsvpNotGoing},
+		},
+	}
+
+	ids := func(regs []Registration) []uint {
+		return lo.Map(regs, func(r Registration, _ int) uint { return r.ID })
+	}
+
+	assert.Empty(t, m.GetRsvpReminders(closesAt.Add(-RsvpReminderWindow-time.Minute)))
+	assert.Equal(t, []uint{2, 3}, ids(m.GetRsvpReminders(closesAt.Add(-time.Hour))))
+	assert.Empty(t, m.GetRsvpReminders(closesAt))
+
+	m.State = MatchCancelled
+	assert.Empty(t, m.GetRsvpReminders(closesAt.Add(-time.Hour)))
+}
+
+func TestNewInvitations(t *testing.T) {
+	m := &Match{
+		BaseModel:     BaseModel{ID: 9},
+		Visibility:    VisibilityInviteOnly,
+		Registrations: []Registration{{PlayerId: 1, Rsvp: RsvpMaybe}},
+	}
+
+	// Players who already answered keep their answer
+	invitations := m.NewInvitations([]uint{1, 2, 2})
+	assert.Len(t, invitations, 1)
+	assert.Equal(t, uint(2), invitations[0].PlayerId)
+	assert.Equal(t, uint(9), invitations[0].MatchId)
+	assert.Equal(t, RsvpInvited, invitations[0].Rsvp)
+	assert.False(t, invitations[0].IsConfirmed())
+
+	m.Visibility = VisibilityTeam
+	assert.Empty(t, m.NewInvitations([]uint{2}))
+
+	m.Visibility = VisibilityPublic
+	m.State = MatchRegistrationClosed
+	assert.Empty(t, m.NewInvitations([]uint{2}))
+}
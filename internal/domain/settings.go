@@ -2,5 +2,7 @@ package domain
 
 type Settings struct {
 	BaseModel
-	MessageTemplate string `json:"messageTemplate"`
+	MessageTemplate    string `json:"messageTemplate"`
+	NoShowThreshold    uint   `json:"noShowThreshold"`
+	NoShowLookbackDays uint   `json:"noShowLookbackDays"`
 }
@@ -1,13 +1,22 @@
 package domain
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"time"
+)
 
 type SportCenter struct {
 	BaseModel
-	Name             string  `json:"name"`
-	Location         string  `json:"location"`
-	CostPerSection   float64 `json:"costPerSection"`
-	MinutePerSection uint    `json:"minutePerSection"`
+	Name             string             `json:"name"`
+	Location         string             `json:"location"`
+	CostPerSection   float64            `json:"costPerSection"`
+	MinutePerSection uint               `json:"minutePerSection"`
+	Timezone         string             `json:"timezone"`
+	PricingRules     []PricingRule      `json:"pricingRules"`
+	PriceHistory     []SportCenterPrice `json:"priceHistory"`
+	OpeningHours     []OpeningHours     `json:"openingHours"`
+	Closures         []Closure          `json:"closures"`
 }
 
 func NewSportCenter(name, location string, costPerSection float64, minutePerSection uint) *SportCenter {
@@ -16,6 +25,9 @@ func NewSportCenter(name, location string, costPerSection float64, minutePerSect
 		Location:         location,
 		CostPerSection:   costPerSection,
 		MinutePerSection: minutePerSection,
+		PriceHistory: []SportCenterPrice{
+			{CostPerSection: costPerSection, MinutePerSection: minutePerSection},
+		},
 	}
 }
 
@@ -33,3 +45,59 @@ func (sc *SportCenter) Update(name, location string) error {
 
 	return nil
 }
+
+// GetLocation is the time zone of the sport center, pricing windows are expressed in local time
+func (sc *SportCenter) GetLocation() *time.Location {
+	if sc.Timezone == "" {
+		return time.UTC
+	}
+
+	loc, err := time.LoadLocation(sc.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+func (sc *SportCenter) SetTimezone(timezone string) error {
+	if timezone != "" {
+		if _, err := time.LoadLocation(timezone); err != nil {
+			return errors.New("timezone is invalid")
+		}
+	}
+	sc.Timezone = timezone
+	return nil
+}
+
+// GetPricing is the pricing of a booking starting at the given time,
+// it requires the pricing rules and the price history to be loaded
+func (sc *SportCenter) GetPricing(at time.Time, member bool) Pricing {
+	costPerSection, minutePerSection := sc.PriceAt(at)
+	return Pricing{
+		DefaultCostPerSection: costPerSection,
+		MinutePerSection:      float64(minutePerSection),
+		Rules:                 sc.PricingRules,
+		Location:              sc.GetLocation(),
+		Member:                member,
+	}
+}
+
+// ReplacePricingRules validates that no two rules with the same priority overlap,
+// otherwise the rate applied would be ambiguous
+func (sc *SportCenter) ReplacePricingRules(rules []PricingRule) error {
+	for i := range rules {
+		for j := i + 1; j < len(rules); j++ {
+			a, b := rules[i], rules[j]
+			if a.Priority == b.Priority &&
+				a.Weekdays&b.Weekdays != 0 &&
+				a.StartMinute < b.EndMinute && b.StartMinute < a.EndMinute {
+				return fmt.Errorf("rules %s and %s overlap with the same priority", a.Name, b.Name)
+			}
+		}
+		rules[i].ID = 0
+		rules[i].SportCenterId = sc.ID
+	}
+
+	sc.PricingRules = rules
+	return nil
+}
@@ -0,0 +1,75 @@
+package domain
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/samber/lo"
+)
+
+// SportCenterPrice is a version of the base rate of a sport center, it applies to matches
+// starting from EffectiveFrom until the next version. Past costs can then always be explained.
+type SportCenterPrice struct {
+	BaseModel
+	SportCenterId    uint      `gorm:"index" json:"sportCenterId"`
+	CostPerSection   float64   `json:"costPerSection"`
+	MinutePerSection uint      `json:"minutePerSection"`
+	EffectiveFrom    time.Time `json:"effectiveFrom"`
+}
+
+// ChangePrice records a new price version, the price history must be loaded.
+// CostPerSection and MinutePerSection hold the price in effect now, a scheduled price does not change them.
+func (sc *SportCenter) ChangePrice(costPerSection float64, minutePerSection uint, effectiveFrom time.Time) (*SportCenterPrice, error) {
+	if costPerSection < 0 {
+		return nil, errors.New("cost per section must not be negative")
+	}
+
+	if minutePerSection == 0 {
+		return nil, errors.New("minute per section must be positive")
+	}
+
+	if len(sc.PriceHistory) == 0 {
+		// Centers created before the history existed, their price has always applied
+		sc.PriceHistory = append(sc.PriceHistory, SportCenterPrice{
+			SportCenterId:    sc.ID,
+			CostPerSection:   sc.CostPerSection,
+			MinutePerSection: sc.MinutePerSection,
+		})
+	}
+
+	if lo.ContainsBy(sc.PriceHistory, func(p SportCenterPrice) bool { return p.EffectiveFrom.Equal(effectiveFrom) }) {
+		return nil, errors.New("a price is already effective from this date")
+	}
+
+	price := SportCenterPrice{
+		SportCenterId:    sc.ID,
+		CostPerSection:   costPerSection,
+		MinutePerSection: minutePerSection,
+		EffectiveFrom:    effectiveFrom,
+	}
+	sc.PriceHistory = append(sc.PriceHistory, price)
+	sort.SliceStable(sc.PriceHistory, func(i, j int) bool {
+		return sc.PriceHistory[i].EffectiveFrom.Before(sc.PriceHistory[j].EffectiveFrom)
+	})
+
+	sc.CostPerSection, sc.MinutePerSection = sc.PriceAt(time.Now())
+
+	return &price, nil
+}
+
+// PriceAt returns the base rate effective at the given time
+func (sc *SportCenter) PriceAt(t time.Time) (float64, uint) {
+	var effective *SportCenterPrice
+	for i := range sc.PriceHistory {
+		p := &sc.PriceHistory[i]
+		if !p.EffectiveFrom.After(t) && (effective == nil || p.EffectiveFrom.After(effective.EffectiveFrom)) {
+			effective = p
+		}
+	}
+
+	if effective == nil {
+		return sc.CostPerSection, sc.MinutePerSection
+	}
+	return effective.CostPerSection, effective.MinutePerSection
+}
@@ -0,0 +1,119 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChangePriceKeepsHistory(t *testing.T) {
+	sc := &SportCenter{CostPerSection: 10, MinutePerSection: 60}
+	effectiveFrom := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	_, err := sc.ChangePrice(12, 60, effectiveFrom)
+	assert.Nil(t, err)
+
+	// The price before the history existed is kept as the initial version
+	assert.Len(t, sc.PriceHistory, 2)
+	assert.Equal(t, float64(12), sc.CostPerSection)
+
+	cost, minutes := sc.PriceAt(effectiveFrom.Add(-time.Hour))
+	assert.Equal(t, float64(10), cost)
+	assert.Equal(t, uint(60), minutes)
+
+	cost, _ = sc.PriceAt(effectiveFrom)
+	assert.Equal(t, float64(12), cost)
+
+	_, err = sc.ChangePrice(15, 60, effectiveFrom)
+	assert.NotNil(t, err)
+}
+
+func TestChangePriceScheduledBeforeLatest(t *testing.T) {
+	sc := NewSportCenter("Center", "Somewhere", 10, 60)
+	june := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	may := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	_, err := sc.ChangePrice(14, 60, june)
+	assert.Nil(t, err)
+	_, err = sc.ChangePrice(12, 45, may)
+	assert.Nil(t, err)
+
+	// The latest version stays the current one
+	assert.Equal(t, float64(14), sc.CostPerSection)
+
+	cost, minutes := sc.PriceAt(may.AddDate(0, 0, 10))
+	assert.Equal(t, float64(12), cost)
+	assert.Equal(t, uint(45), minutes)
+}
+
+func TestChangePriceScheduledInTheFuture(t *testing.T) {
+	sc := NewSportCenter("Center", "Somewhere", 10, 60)
+	nextMonth := time.Now().AddDate(0, 1, 0)
+
+	_, err := sc.ChangePrice(14, 45, nextMonth)
+	assert.Nil(t, err)
+
+	// The current price stays in effect until the scheduled one starts
+	assert.Equal(t, float64(10), sc.CostPerSection)
+	assert.Equal(t, uint(60), sc.MinutePerSection)
+
+	cost, minutes := sc.PriceAt(nextMonth)
+	assert.Equal(t, float64(14), cost)
+	assert.Equal(t, uint(45), minutes)
+}
+
+func TestRepriceUsesPriceEffectiveAtBooking(t *testing.T) {
+	sc := NewSportCenter("Center", "Somewhere", 10, 60)
+	start := time.Date(2024, 6, 5, 18, 0, 0, 0, time.UTC)
+	m := NewMatch(start, start.Add(2*time.Hour), 1, sc.GetPricing(start, false), "1 & 2", nil)
+	assert.Equal(t, float64(40), m.Cost)
+
+	_, err := sc.ChangePrice(12, 60, time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC))
+	assert.Nil(t, err)
+
+	m.Reprice(sc)
+	assert.Equal(t, float64(48), m.Cost)
+	assert.Equal(t, float64(24), m.CourtBookings[0].Cost)
+}
+
+func TestRepriceKeepsManualAndCustomCosts(t *testing.T) {
+	sc := NewSportCenter("Center", "Somewhere", 10, 60)
+	start := time.Date(2024, 6, 5, 18, 0, 0, 0, time.UTC)
+	_, err := sc.ChangePrice(12, 60, time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC))
+	assert.Nil(t, err)
+
+	// A court with its own rate keeps it, the other court follows the new price
+	custom := NewMatch(start, start.Add(2*time.Hour), 1, NewFlatPricing(10, 60), "1 & 2", nil)
+	custom.CourtBookings[0].ApplyCustomRate(NewFlatPricing(10, 60), 8)
+	assert.True(t, custom.Reprice(sc))
+	assert.Equal(t, float64(16), custom.CourtBookings[0].Cost)
+	assert.Equal(t, float64(24), custom.CourtBookings[1].Cost)
+	assert.Equal(t, float64(40), custom.Cost)
+
+	manual := NewMatch(start, start.Add(2*time.Hour), 1, NewFlatPricing(10, 60), "1", nil)
+	assert.Nil(t, manual.UpdateCost(15, "Discount"))
+	assert.False(t, manual.Reprice(sc))
+	assert.Equal(t, float64(15), manual.Cost)
+
+	legacy := &Match{Start: start, End: start.Add(2 * time.Hour), Court: "1 & 2", Cost: 30}
+	legacy.CourtBookings = legacy.LegacyCourtBookings()
+	assert.False(t, legacy.Reprice(sc))
+	assert.Equal(t, float64(30), legacy.Cost)
+
+	// Booking another court prices the match from its bookings again
+	assert.Nil(t, manual.SetCourtBookings(NewMatch(start, start.Add(2*time.Hour), 1, NewFlatPricing(10, 60), "2", nil).CourtBookings))
+	assert.True(t, manual.Reprice(sc))
+	assert.Equal(t, float64(24), manual.Cost)
+}
+
+func TestIsFinalized(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	m := &Match{Start: now.Add(time.Hour), Registrations: []Registration{{IsPaid: false}}}
+	assert.False(t, m.IsFinalized(now))
+
+	m.Registrations = append(m.Registrations, Registration{IsPaid: true})
+	assert.True(t, m.IsFinalized(now))
+
+	assert.True(t, (&Match{Start: now.Add(-time.Hour)}).IsFinalized(now))
+}
@@ -0,0 +1,108 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrNotStandingRegistration = errors.New("registration was not made by a standing registration")
+	ErrOptOutWindowClosed      = errors.New("opt-out window has closed")
+)
+
+// StandingRegistration registers a regular player to every new match of a series, or to every new match
+// at the sport center on the weekday and start minute in the sport center local time
+type StandingRegistration struct {
+	BaseModel
+	PlayerId      uint          `gorm:"index" json:"playerId"`
+	SeriesId      *uint         `gorm:"index" json:"seriesId"`
+	SportCenterId *uint         `gorm:"index" json:"sportCenterId"`
+	Weekday       *time.Weekday `json:"weekday"`
+	StartMinute   *int          `json:"startMinute"`
+	PausedUntil   *time.Time    `json:"pausedUntil"`
+}
+
+func NewSeriesStandingRegistration(playerId, seriesId uint) *StandingRegistration {
+	return &StandingRegistration{PlayerId: playerId, SeriesId: &seriesId}
+}
+
+func NewWeeklyStandingRegistration(playerId, sportCenterId uint, weekday time.Weekday, startMinute int) (*StandingRegistration, error) {
+	if sportCenterId == 0 {
+		return nil, errors.New("sport center is invalid")
+	}
+
+	if weekday < time.Sunday || weekday > time.Saturday {
+		return nil, errors.New("weekday is invalid")
+	}
+
+	if startMinute < 0 || startMinute >= minutesPerDay {
+		return nil, errors.New("start minute must be within a day")
+	}
+
+	return &StandingRegistration{
+		PlayerId:      playerId,
+		SportCenterId: &sportCenterId,
+		Weekday:       &weekday,
+		StartMinute:   &startMinute,
+	}, nil
+}
+
+// Pause skips the matches starting before the date, a nil date resumes the standing registration
+func (s *StandingRegistration) Pause(until *time.Time) {
+	s.PausedUntil = until
+}
+
+// Matches tells whether the player is registered to the match, loc is the sport center location
+func (s *StandingRegistration) Matches(m *Match, loc *time.Location) bool {
+	if s.PausedUntil != nil && m.Start.Before(*s.PausedUntil) {
+		return false
+	}
+
+	if s.SeriesId != nil {
+		seriesId := m.GetSeriesId()
+		return seriesId != nil && *seriesId == *s.SeriesId
+	}
+
+	if s.SportCenterId == nil || s.Weekday == nil || s.StartMinute == nil || *s.SportCenterId != m.SportCenterId {
+		return false
+	}
+
+	localStart := m.Start.In(loc)
+	return localStart.Weekday() == *s.Weekday && localStart.Hour()*60+localStart.Minute() == *s.StartMinute
+}
+
+// GetSeriesId is the first match of the series the match was generated from, a saved match starts its own series
+func (m *Match) GetSeriesId() *uint {
+	if m.SeriesId != nil {
+		return m.SeriesId
+	}
+
+	if m.ID == 0 {
+		return nil
+	}
+
+	id := m.ID
+	return &id
+}
+
+// GetOptOutDeadline is when players registered by a standing registration can no longer leave freely
+func (m *Match) GetOptOutDeadline() time.Time {
+	if m.RegistrationClosesAt != nil {
+		return *m.RegistrationClosesAt
+	}
+	return m.Start
+}
+
+// CheckOptOut lets a player leave a match the player was registered to automatically,
+// without any late cancellation fee, until registration closes
+func (m *Match) CheckOptOut(registration Registration, now time.Time) error {
+	if registration.StandingRegistrationId == nil {
+		return ErrNotStandingRegistration
+	}
+
+	if !m.AcceptsChanges() || !now.Before(m.GetOptOutDeadline()) {
+		return ErrOptOutWindowClosed
+	}
+
+	return nil
+}
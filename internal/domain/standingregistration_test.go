@@ -0,0 +1,95 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewWeeklyStandingRegistration(t *testing.T) {
+	_, err := NewWeeklyStandingRegistration(1, 0, time.Tuesday, 19*60)
+	assert.NotNil(t, err)
+
+	_, err = NewWeeklyStandingRegistration(1, 2, time.Weekday(7), 19*60)
+	assert.NotNil(t, err)
+
+	_, err = NewWeeklyStandingRegistration(1, 2, time.Tuesday, minutesPerDay)
+	assert.NotNil(t, err)
+
+	standing, err := NewWeeklyStandingRegistration(1, 2, time.Tuesday, 19*60)
+	assert.Nil(t, err)
+	assert.Equal(t, time.Tuesday, *standing.Weekday)
+}
+
+func TestStandingRegistrationMatches(t *testing.T) {
+	loc, _ := time.LoadLocation("Europe/London")
+	// Tuesday 19:00 in London is 18:00 UTC in summer
+	tuesday := time.Date(2024, 6, 4, 18, 0, 0, 0, time.UTC)
+	weekly, _ := NewWeeklyStandingRegistration(1, 2, time.Tuesday, 19*60)
+
+	tests := []struct {
+		name     string
+		standing *StandingRegistration
+		match    *Match
+		expected bool
+	}{
+		{"weekday and time in local time", weekly, &Match{SportCenterId: 2, Start: tuesday}, true},
+		{"other sport center", weekly, &Match{SportCenterId: 3, Start: tuesday}, false},
+		{"other start time", weekly, &Match{SportCenterId: 2, Start: tuesday.Add(time.Hour)}, false},
+		{"other weekday", weekly, &Match{SportCenterId: 2, Start: tuesday.AddDate(0, 0, 1)}, false},
+		{"first match of the series", NewSeriesStandingRegistration(1, 5), &Match{BaseModel: BaseModel{ID: 5}}, true},
+		{"generated match of the series", NewSeriesStandingRegistration(1, 5), &Match{BaseModel: BaseModel{ID: 9}, SeriesId: lo.ToPtr(uint(5))}, true},
+		{"other series", NewSeriesStandingRegistration(1, 5), &Match{BaseModel: BaseModel{ID: 6}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.standing.Matches(tt.match, loc))
+		})
+	}
+}
+
+func TestStandingRegistrationPause(t *testing.T) {
+	start := time.Date(2024, 6, 4, 19, 0, 0, 0, time.UTC)
+	standing := NewSeriesStandingRegistration(1, 5)
+	match := &Match{BaseModel: BaseModel{ID: 5}, Start: start}
+
+	standing.Pause(lo.ToPtr(start.AddDate(0, 0, 7)))
+	assert.False(t, standing.Matches(match, time.UTC))
+
+	match.Start = start.AddDate(0, 0, 7)
+	assert.True(t, standing.Matches(match, time.UTC))
+
+	standing.Pause(nil)
+	assert.True(t, standing.Matches(&Match{BaseModel: BaseModel{ID: 5}, Start: start}, time.UTC))
+}
+
+func TestRepeatKeepsSeries(t *testing.T) {
+	m := &Match{BaseModel: BaseModel{ID: 5}}
+	repeated := m.RepeatWeeksLater(1)
+	assert.Equal(t, uint(5), *repeated.SeriesId)
+
+	repeated.ID = 6
+	assert.Equal(t, uint(5), *repeated.RepeatWeeksLater(1).SeriesId)
+
+	// A one-off clone does not join the series
+	assert.Nil(t, repeated.CloneWeeksLater(1).SeriesId)
+}
+
+func TestCheckOptOut(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	closesAt := now.Add(time.Hour)
+	m := &Match{State: MatchOpen, Start: now.Add(24 * time.Hour), RegistrationClosesAt: &closesAt}
+	standing := Registration{StandingRegistrationId: lo.ToPtr(uint(1))}
+
+	assert.Nil(t, m.CheckOptOut(standing, now))
+	assert.ErrorIs(t, m.CheckOptOut(Registration{}, now), ErrNotStandingRegistration)
+	assert.ErrorIs(t, m.CheckOptOut(standing, closesAt), ErrOptOutWindowClosed)
+
+	// Without a registration deadline players can opt out until the match starts
+	m.RegistrationClosesAt = nil
+	assert.Nil(t, m.CheckOptOut(standing, closesAt))
+	assert.ErrorIs(t, m.CheckOptOut(standing, m.Start), ErrOptOutWindowClosed)
+}
@@ -6,6 +6,8 @@ type Team struct {
 	Description string   `json:"description"`
 	Members     []Player `json:"members" gorm:"many2many:team_members;"`
 	OwnerID     string   `json:"ownerId" gorm:"index"`
+	// MatchId is set on event teams which only exist for one match, e.g. a team battle night
+	MatchId *uint `json:"matchId" gorm:"index"`
 }
 
 type TeamMember struct {
@@ -23,6 +25,13 @@ func NewTeam(name, description, ownerID string) *Team {
 	return team
 }
 
+// NewEventTeam creates a team for one match only, it is not listed with the permanent teams
+func NewEventTeam(name, ownerID string, matchId uint) *Team {
+	team := NewTeam(name, "", ownerID)
+	team.MatchId = &matchId
+	return team
+}
+
 func (t *Team) AddMember(player Player, role string) {
 	t.Members = append(t.Members, player)
 }
@@ -0,0 +1,263 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/samber/lo"
+)
+
+type RatingLevel = string
+
+const (
+	LevelBeginner     RatingLevel = "beginner"
+	LevelIntermediate RatingLevel = "intermediate"
+	LevelAdvanced     RatingLevel = "advanced"
+
+	// teamSplitAttempts is the number of random starts, each one is improved by swapping players
+	teamSplitAttempts = 100
+
+	quotaPenalty = 100.0
+	sizePenalty  = 1000.0
+	apartPenalty = 10000.0
+)
+
+// GetRatingLevel places a rating in a level, used for level quotas of event teams
+func GetRatingLevel(rating float64) RatingLevel {
+	switch {
+	case rating >= InitialRating+100:
+		return LevelAdvanced
+	case rating >= InitialRating-100:
+		return LevelIntermediate
+	default:
+		return LevelBeginner
+	}
+}
+
+type TeamSplitPlayer struct {
+	PlayerId uint
+	Rating   float64
+	Gender   string
+}
+
+// TeamSplitOptions splits players in teams, quotas spread each gender or level evenly across the teams
+type TeamSplitOptions struct {
+	Teams        int
+	Candidates   int
+	Seed         int64
+	GenderQuota  bool
+	LevelQuota   bool
+	KeepTogether [][]uint
+	KeepApart    [][2]uint
+}
+
+// TeamSplit is a candidate split, the lower the score the more balanced the teams
+type TeamSplit struct {
+	Teams        [][]uint
+	TeamRatings  []float64
+	RatingSpread float64
+	Score        float64
+}
+
+// SplitTeams suggests the most balanced splits of the players in teams of equal size and total rating.
+// Players kept together are moved as one, the same seed always gives the same suggestions.
+func SplitTeams(players []TeamSplitPlayer, opts TeamSplitOptions) ([]TeamSplit, error) {
+	byId := lo.KeyBy(players, func(p TeamSplitPlayer) uint { return p.PlayerId })
+	units, err := buildTeamUnits(players, opts.KeepTogether)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.Teams < 2 || opts.Teams > len(units) {
+		return nil, errors.New("there must be at least 2 teams and enough players for every team")
+	}
+
+	for _, pair := range opts.KeepApart {
+		if _, found := byId[pair[0]]; !found {
+			return nil, fmt.Errorf("player %d is not in the roster", pair[0])
+		}
+		if _, found := byId[pair[1]]; !found {
+			return nil, fmt.Errorf("player %d is not in the roster", pair[1])
+		}
+	}
+
+	scorer := &teamSplitScorer{byId: byId, opts: opts, playerCount: len(players)}
+	rng := rand.New(rand.NewSource(opts.Seed))
+	splits := map[string]TeamSplit{}
+	for attempt := 0; attempt < teamSplitAttempts; attempt++ {
+		teams := scorer.improve(scorer.draft(units, rng))
+		split := scorer.toSplit(teams)
+		splits[split.key()] = split
+	}
+
+	result := lo.Values(splits)
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Score != result[j].Score {
+			return result[i].Score < result[j].Score
+		}
+		return result[i].key() < result[j].key()
+	})
+
+	candidates := lo.Ternary(opts.Candidates > 0, opts.Candidates, 3)
+	return result[:min(candidates, len(result))], nil
+}
+
+// buildTeamUnits merges overlapping keep together groups, every other player is a unit of their own
+func buildTeamUnits(players []TeamSplitPlayer, together [][]uint) ([][]uint, error) {
+	unitOf := map[uint]int{}
+	units := [][]uint{}
+	for _, p := range players {
+		unitOf[p.PlayerId] = len(units)
+		units = append(units, []uint{p.PlayerId})
+	}
+
+	for _, group := range together {
+		for _, id := range group {
+			if _, found := unitOf[id]; !found {
+				return nil, fmt.Errorf("player %d is not in the roster", id)
+			}
+		}
+
+		for _, id := range group[1:] {
+			from, to := unitOf[id], unitOf[group[0]]
+			if from == to {
+				continue
+			}
+			for _, member := range units[from] {
+				unitOf[member] = to
+			}
+			units[to] = append(units[to], units[from]...)
+			units[from] = nil
+		}
+	}
+
+	return lo.Filter(units, func(u []uint, _ int) bool { return len(u) > 0 }), nil
+}
+
+type teamSplitScorer struct {
+	byId        map[uint]TeamSplitPlayer
+	opts        TeamSplitOptions
+	playerCount int
+}
+
+// draft gives the biggest and strongest units first to the weakest team that still has room
+func (s *teamSplitScorer) draft(units [][]uint, rng *rand.Rand) [][][]uint {
+	shuffled := lo.Map(rng.Perm(len(units)), func(i int, _ int) []uint { return units[i] })
+	sort.SliceStable(shuffled, func(i, j int) bool {
+		if len(shuffled[i]) != len(shuffled[j]) {
+			return len(shuffled[i]) > len(shuffled[j])
+		}
+		// Only roughly by rating so that the random order gives different candidates
+		return math.Round(s.unitRating(shuffled[i])/100) > math.Round(s.unitRating(shuffled[j])/100)
+	})
+
+	teams := make([][][]uint, s.opts.Teams)
+	for _, u := range shuffled {
+		best, bestCost := 0, math.Inf(1)
+		for t := range teams {
+			teams[t] = append(teams[t], u)
+			cost := s.score(teams)
+			teams[t] = teams[t][:len(teams[t])-1]
+			if cost < bestCost {
+				best, bestCost = t, cost
+			}
+		}
+		teams[best] = append(teams[best], u)
+	}
+	return teams
+}
+
+// improve swaps or moves units between teams while it lowers the score
+func (s *teamSplitScorer) improve(teams [][][]uint) [][][]uint {
+	current := s.score(teams)
+	for improved := true; improved; {
+		improved = false
+		for a := range teams {
+			for b := a + 1; b < len(teams); b++ {
+				for i := range teams[a] {
+					for j := range teams[b] {
+						teams[a][i], teams[b][j] = teams[b][j], teams[a][i]
+						if cost := s.score(teams); cost < current-1e-9 {
+							current, improved = cost, true
+							continue
+						}
+						teams[a][i], teams[b][j] = teams[b][j], teams[a][i]
+					}
+				}
+			}
+		}
+	}
+	return teams
+}
+
+func (s *teamSplitScorer) score(teams [][][]uint) float64 {
+	members := lo.Map(teams, func(t [][]uint, _ int) []uint { return lo.Flatten(t) })
+	ratings := lo.Map(members, func(m []uint, _ int) float64 { return s.totalRating(m) })
+	cost := lo.Max(ratings) - lo.Min(ratings)
+
+	// Team sizes may only differ by one player
+	sizes := lo.Map(members, func(m []uint, _ int) int { return len(m) })
+	cost += sizePenalty * float64(max(lo.Max(sizes)-lo.Min(sizes)-1, 0))
+
+	if s.opts.GenderQuota {
+		cost += quotaPenalty * s.quotaExcess(members, func(p TeamSplitPlayer) string { return p.Gender })
+	}
+
+	if s.opts.LevelQuota {
+		cost += quotaPenalty * s.quotaExcess(members, func(p TeamSplitPlayer) string { return GetRatingLevel(p.Rating) })
+	}
+
+	for _, pair := range s.opts.KeepApart {
+		if lo.SomeBy(members, func(m []uint) bool { return lo.Contains(m, pair[0]) && lo.Contains(m, pair[1]) }) {
+			cost += apartPenalty
+		}
+	}
+	return cost
+}
+
+// quotaExcess counts how far every group, e.g. a gender, is from being spread evenly over the teams
+func (s *teamSplitScorer) quotaExcess(members [][]uint, group func(TeamSplitPlayer) string) float64 {
+	groups := lo.Uniq(lo.Map(lo.Values(s.byId), func(p TeamSplitPlayer, _ int) string { return group(p) }))
+	excess := 0
+	for _, g := range groups {
+		counts := lo.Map(members, func(m []uint, _ int) int {
+			return lo.CountBy(m, func(id uint) bool { return group(s.byId[id]) == g })
+		})
+		excess += max(lo.Max(counts)-lo.Min(counts)-1, 0)
+	}
+	return float64(excess)
+}
+
+func (s *teamSplitScorer) toSplit(teams [][][]uint) TeamSplit {
+	members := lo.Map(teams, func(t [][]uint, _ int) []uint {
+		m := lo.Flatten(t)
+		slices.Sort(m)
+		return m
+	})
+	sort.Slice(members, func(i, j int) bool { return members[i][0] < members[j][0] })
+
+	ratings := lo.Map(members, func(m []uint, _ int) float64 { return s.totalRating(m) })
+	return TeamSplit{
+		Teams:        members,
+		TeamRatings:  ratings,
+		RatingSpread: lo.Max(ratings) - lo.Min(ratings),
+		Score:        s.score(lo.Map(members, func(m []uint, _ int) [][]uint { return [][]uint{m} })),
+	}
+}
+
+func (s *teamSplitScorer) totalRating(ids []uint) float64 {
+	return lo.SumBy(ids, func(id uint) float64 { return s.byId[id].Rating })
+}
+
+func (s *teamSplitScorer) unitRating(u []uint) float64 {
+	return s.totalRating(u) / float64(len(u))
+}
+
+func (split TeamSplit) key() string {
+	return strings.Join(lo.Map(split.Teams, func(t []uint, _ int) string { return fmt.Sprint(t) }), "|")
+}
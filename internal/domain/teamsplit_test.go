@@ -0,0 +1,76 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+)
+
+func teamSplitPlayers(n int) []TeamSplitPlayer {
+	return lo.Times(n, func(i int) TeamSplitPlayer {
+		return TeamSplitPlayer{
+			PlayerId: uint(i + 1),
+			Rating:   1300 + float64(i*40),
+			Gender:   lo.Ternary(i%3 == 0, GenderFemale, GenderMale),
+		}
+	})
+}
+
+func TestSplitTeamsBalancesRatings(t *testing.T) {
+	splits, err := SplitTeams(teamSplitPlayers(12), TeamSplitOptions{Teams: 3, Seed: 1})
+	assert.Nil(t, err)
+	assert.Len(t, splits, 3)
+
+	best := splits[0]
+	assert.Len(t, best.Teams, 3)
+	for _, team := range best.Teams {
+		assert.Len(t, team, 4)
+	}
+	assert.Less(t, best.RatingSpread, 40.0)
+	assert.LessOrEqual(t, splits[0].Score, splits[1].Score)
+	assert.Len(t, lo.Uniq(lo.Flatten(best.Teams)), 12)
+}
+
+func TestSplitTeamsIsDeterministic(t *testing.T) {
+	opts := TeamSplitOptions{Teams: 2, Seed: 9, GenderQuota: true}
+	a, _ := SplitTeams(teamSplitPlayers(10), opts)
+	b, _ := SplitTeams(teamSplitPlayers(10), opts)
+	assert.Equal(t, a, b)
+}
+
+func TestSplitTeamsRespectsConstraints(t *testing.T) {
+	players := teamSplitPlayers(12)
+	splits, err := SplitTeams(players, TeamSplitOptions{
+		Teams:        3,
+		Seed:         2,
+		GenderQuota:  true,
+		LevelQuota:   true,
+		KeepTogether: [][]uint{{1, 12}},
+		KeepApart:    [][2]uint{{2, 3}},
+	})
+	assert.Nil(t, err)
+
+	byId := lo.KeyBy(players, func(p TeamSplitPlayer) uint { return p.PlayerId })
+	for _, split := range splits {
+		assert.True(t, lo.SomeBy(split.Teams, func(team []uint) bool { return lo.Contains(team, 1) && lo.Contains(team, 12) }))
+		assert.False(t, lo.SomeBy(split.Teams, func(team []uint) bool { return lo.Contains(team, 2) && lo.Contains(team, 3) }))
+
+		// 4 women over 3 teams
+		women := lo.Map(split.Teams, func(team []uint, _ int) int {
+			return lo.CountBy(team, func(id uint) bool { return byId[id].Gender == GenderFemale })
+		})
+		assert.LessOrEqual(t, lo.Max(women)-lo.Min(women), 1)
+	}
+}
+
+func TestSplitTeamsValidates(t *testing.T) {
+	_, err := SplitTeams(teamSplitPlayers(4), TeamSplitOptions{Teams: 1})
+	assert.NotNil(t, err)
+
+	_, err = SplitTeams(teamSplitPlayers(4), TeamSplitOptions{Teams: 2, KeepTogether: [][]uint{{1, 9}}})
+	assert.NotNil(t, err)
+
+	_, err = SplitTeams(teamSplitPlayers(3), TeamSplitOptions{Teams: 3, KeepTogether: [][]uint{{1, 2}}})
+	assert.NotNil(t, err)
+}
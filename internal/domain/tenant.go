@@ -0,0 +1,117 @@
+package domain
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/samber/lo"
+)
+
+// TenantContextKey holds the tenant of the request in the gin and gorm statement context
+const TenantContextKey = "tenant_id"
+
+// TenantAdminContextKey tells in the gin context whether the user administers the tenant of the request
+const TenantAdminContextKey = "tenant_admin"
+
+// DefaultTenantSlug owns the data created before tenants existed and the players signing up
+const DefaultTenantSlug = "default"
+
+var (
+	ErrTenantRequired     = errors.New("tenant is required when the user belongs to several")
+	ErrNotTenantMember    = errors.New("user is not a member of the tenant")
+	ErrAlreadyMember      = errors.New("user is already a member of the tenant")
+	ErrNotTenantManager   = errors.New("only the owner of the tenant or an admin can add members")
+	ErrInvalidTenantSlug  = errors.New("slug must be lowercase letters, digits and dashes")
+	ErrTenantSlugTaken    = errors.New("slug is already taken by another tenant")
+	ErrTenantNameRequired = errors.New("tenant name is mandatory")
+)
+
+var tenantSlugRegex = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// Tenant is a club or group sharing the deployment, every other entity belongs to one.
+// A user is a member of a tenant through a player of that tenant linked to their IdP account,
+// so they have a player, wallets and a balance per tenant.
+type Tenant struct {
+	ID          uint      `gorm:"primarykey" json:"id"`
+	Name        string    `json:"name"`
+	Slug        string    `gorm:"uniqueIndex" json:"slug"`
+	CreatedByID string    `json:"createdById" gorm:"index"`
+	CreatedAt   time.Time `json:"createdAt" gorm:"autoCreateTime"`
+	UpdatedAt   time.Time `json:"updatedAt" gorm:"autoUpdateTime"`
+}
+
+func NewTenant(name, slug, createdByID string) (*Tenant, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, ErrTenantNameRequired
+	}
+
+	if !tenantSlugRegex.MatchString(slug) {
+		return nil, ErrInvalidTenantSlug
+	}
+
+	return &Tenant{
+		Name:        name,
+		Slug:        slug,
+		CreatedByID: createdByID,
+	}, nil
+}
+
+// ResolveTenant picks the tenant of a request among the tenants the user is a member of,
+// the requested tenant can be left out (0) by members of a single tenant
+func ResolveTenant(requested uint, memberships []uint) (uint, error) {
+	if requested == 0 {
+		switch len(memberships) {
+		case 0:
+			return 0, ErrNotTenantMember
+		case 1:
+			return memberships[0], nil
+		default:
+			return 0, ErrTenantRequired
+		}
+	}
+
+	if !lo.Contains(memberships, requested) {
+		return 0, ErrNotTenantMember
+	}
+
+	return requested, nil
+}
+
+// IsAdmin tells whether the member administers the tenant: its owner and members with the admin role.
+// The IdP admin role predates tenants, it only makes admins of the default tenant.
+func (t *Tenant) IsAdmin(member Player, hasIdpAdminRole bool) bool {
+	if member.TenantId != t.ID || member.ExternalUserID == "" {
+		return false
+	}
+
+	return member.Role == TenantRoleAdmin ||
+		t.CreatedByID == member.ExternalUserID ||
+		(hasIdpAdminRole && t.Slug == DefaultTenantSlug)
+}
+
+// EnsureCanManageMembers lets the admins of the tenant add members
+func (t *Tenant) EnsureCanManageMembers(member Player, hasIdpAdminRole bool) error {
+	if !t.IsAdmin(member, hasIdpAdminRole) {
+		return ErrNotTenantManager
+	}
+	return nil
+}
+
+// NewMember creates the player of an existing account in another tenant
+func (t *Tenant) NewMember(account Player) (*Player, error) {
+	if account.ExternalUserID == "" {
+		return nil, errors.New("player has no account")
+	}
+
+	if account.TenantId == t.ID {
+		return nil, ErrAlreadyMember
+	}
+
+	player := NewPlayer(account.ExternalUserID, account.Email, account.FirstName, account.LastName)
+	player.Gender = account.Gender
+	player.TenantId = t.ID
+	return player, nil
+}
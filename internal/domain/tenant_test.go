@@ -0,0 +1,94 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewTenant(t *testing.T) {
+	tenant, err := NewTenant(" Sister Club ", "sister-club", "auth0|1")
+	assert.Nil(t, err)
+	assert.Equal(t, "Sister Club", tenant.Name)
+
+	_, err = NewTenant(" ", "sister-club", "auth0|1")
+	assert.ErrorIs(t, err, ErrTenantNameRequired)
+
+	for _, slug := range []string{"", "Sister", "sister club", "-sister", "sister-"} {
+		_, err = NewTenant("Sister Club", slug, "auth0|1")
+		assert.ErrorIs(t, err, ErrInvalidTenantSlug, slug)
+	}
+}
+
+func TestResolveTenant(t *testing.T) {
+	tests := []struct {
+		name        string
+		requested   uint
+		memberships []uint
+		expected    uint
+		err         error
+	}{
+		{"single membership without a request", 0, []uint{3}, 3, nil},
+		{"several memberships without a request", 0, []uint{3, 4}, 0, ErrTenantRequired},
+		{"no membership", 0, nil, 0, ErrNotTenantMember},
+		{"requested membership", 4, []uint{3, 4}, 4, nil},
+		{"requested tenant of someone else", 5, []uint{3, 4}, 0, ErrNotTenantMember},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tenantId, err := ResolveTenant(tt.requested, tt.memberships)
+			assert.ErrorIs(t, err, tt.err)
+			assert.Equal(t, tt.expected, tenantId)
+		})
+	}
+}
+
+func TestTenantNewMember(t *testing.T) {
+	tenant := &Tenant{ID: 2}
+	account := NewPlayer("auth0|1", "jane@example.com", "Jane", "Doe")
+	account.ID, account.TenantId = 7, 1
+
+	// The member is a new player of the tenant, with its own wallets and balance
+	member, err := tenant.NewMember(*account)
+	assert.Nil(t, err)
+	assert.Equal(t, uint(0), member.ID)
+	assert.Equal(t, uint(2), member.TenantId)
+	assert.Equal(t, "auth0|1", member.ExternalUserID)
+	assert.Equal(t, "Jane", member.FirstName)
+
+	account.TenantId = 2
+	_, err = tenant.NewMember(*account)
+	assert.ErrorIs(t, err, ErrAlreadyMember)
+
+	_, err = tenant.NewMember(Player{FirstName: "Guest"})
+	assert.NotNil(t, err)
+}
+
+func TestTenantIsAdmin(t *testing.T) {
+	tenant := &Tenant{ID: 2, Slug: "club", CreatedByID: "auth0|owner"}
+	member := func(idpUserId, role string) Player {
+		return Player{BaseModel: BaseModel{TenantId: 2}, ExternalUserID: idpUserId, Role: role}
+	}
+
+	assert.True(t, tenant.IsAdmin(member("auth0|owner", TenantRoleMember), false))
+	assert.True(t, tenant.IsAdmin(member("auth0|admin", TenantRoleAdmin), false))
+	assert.False(t, tenant.IsAdmin(member("auth0|member", TenantRoleMember), false))
+
+	// The IdP admin role does not carry over to other tenants
+	assert.False(t, tenant.IsAdmin(member("auth0|member", TenantRoleMember), true))
+	other := member("auth0|admin", TenantRoleAdmin)
+	other.TenantId = 3
+	assert.False(t, tenant.IsAdmin(other, false))
+
+	defaultTenant := &Tenant{ID: 2, Slug: DefaultTenantSlug}
+	assert.True(t, defaultTenant.IsAdmin(member("auth0|member", TenantRoleMember), true))
+	assert.False(t, defaultTenant.IsAdmin(member("auth0|member", TenantRoleMember), false))
+
+	// Players without an account and users who are not members never administer a tenant
+	assert.False(t, defaultTenant.IsAdmin(member("", TenantRoleAdmin), true))
+	assert.False(t, defaultTenant.IsAdmin(Player{}, true))
+
+	assert.Nil(t, tenant.EnsureCanManageMembers(member("auth0|owner", TenantRoleMember), false))
+	assert.ErrorIs(t, tenant.EnsureCanManageMembers(member("auth0|member", TenantRoleMember), true), ErrNotTenantManager)
+}
@@ -0,0 +1,213 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/samber/lo"
+)
+
+type TournamentFormat = string
+
+const (
+	FormatSingleElimination TournamentFormat = "single_elimination"
+	FormatDoubleElimination TournamentFormat = "double_elimination"
+	FormatGroupsKnockout    TournamentFormat = "groups_knockout"
+)
+
+type EntryType = string
+
+const (
+	EntrySingles EntryType = "singles"
+	EntryDoubles EntryType = "doubles"
+)
+
+type TournamentState = string
+
+const (
+	TournamentDraft      TournamentState = "draft"
+	TournamentInProgress TournamentState = "in_progress"
+	TournamentFinished   TournamentState = "finished"
+)
+
+var (
+	ErrTournamentStarted    = errors.New("tournament has already started")
+	ErrTournamentNotStarted = errors.New("tournament has not started")
+	ErrEntryNotFound        = errors.New("entry not found")
+	ErrPlayerAlreadyEntered = errors.New("player already entered the tournament")
+)
+
+// Tournament is played over one or more match sessions, entries pay the entry fee per player
+type Tournament struct {
+	BaseModel
+	Name               string            `json:"name"`
+	Format             TournamentFormat  `json:"format"`
+	EntryType          EntryType         `json:"entryType"`
+	EntryFee           float64           `json:"entryFee"`
+	Scoring            ScoringFormat     `gorm:"embedded;embeddedPrefix:scoring_" json:"scoring"`
+	GroupCount         int               `json:"groupCount"`
+	QualifiersPerGroup int               `json:"qualifiersPerGroup"`
+	SlotMinutes        int               `json:"slotMinutes"`
+	State              TournamentState   `gorm:"index" json:"state"`
+	ShareCode          string            `gorm:"uniqueIndex" json:"-"`
+	WinnerEntryId      *uint             `json:"winnerEntryId"`
+	Sessions           []Match           `gorm:"many2many:tournament_sessions;" json:"sessions"`
+	Entries            []TournamentEntry `json:"entries"`
+	BracketMatches     []BracketMatch    `json:"bracketMatches"`
+}
+
+// TournamentEntry is a player or a pair, entries are seeded by their average rating
+type TournamentEntry struct {
+	BaseModel
+	TournamentId uint                    `gorm:"index" json:"tournamentId"`
+	Name         string                  `json:"name"`
+	Seed         int                     `json:"seed"`
+	Rating       float64                 `json:"rating"`
+	Group        int                     `json:"group"`
+	Players      []TournamentEntryPlayer `gorm:"foreignKey:EntryId" json:"players"`
+}
+
+// TournamentEntryPlayer owes the entry fee until it is paid
+type TournamentEntryPlayer struct {
+	BaseModel
+	EntryId  uint `gorm:"index" json:"entryId"`
+	PlayerId uint `gorm:"index" json:"playerId"`
+	IsPaid   bool `gorm:"index" json:"isPaid"`
+}
+
+func NewTournament(name string, format TournamentFormat, entryType EntryType, entryFee float64, generator Generator) (*Tournament, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, errors.New("tournament name is required")
+	}
+
+	if !lo.Contains([]TournamentFormat{FormatSingleElimination, FormatDoubleElimination, FormatGroupsKnockout}, format) {
+		return nil, fmt.Errorf("unknown tournament format %q", format)
+	}
+
+	if entryType != EntrySingles && entryType != EntryDoubles {
+		return nil, fmt.Errorf("unknown entry type %q", entryType)
+	}
+
+	if entryFee < 0 {
+		return nil, errors.New("entry fee must not be negative")
+	}
+
+	shareCode, err := generator.Gen(24)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Tournament{
+		Name:               name,
+		Format:             format,
+		EntryType:          entryType,
+		EntryFee:           entryFee,
+		Scoring:            BadmintonScoring,
+		GroupCount:         1,
+		QualifiersPerGroup: 2,
+		SlotMinutes:        30,
+		State:              TournamentDraft,
+		ShareCode:          shareCode,
+	}, nil
+}
+
+// SetGroups configures the group stage of a groups followed by knockout tournament
+func (t *Tournament) SetGroups(groupCount, qualifiersPerGroup int) error {
+	if t.State != TournamentDraft {
+		return ErrTournamentStarted
+	}
+
+	if groupCount < 1 || qualifiersPerGroup < 1 || groupCount*qualifiersPerGroup < 2 {
+		return errors.New("at least two entries must qualify for the knockout")
+	}
+
+	t.GroupCount = groupCount
+	t.QualifiersPerGroup = qualifiersPerGroup
+	return nil
+}
+
+// SetScoring changes how bracket matches are scored, badminton scoring is used when none is given
+func (t *Tournament) SetScoring(format ScoringFormat) error {
+	if t.State != TournamentDraft {
+		return ErrTournamentStarted
+	}
+
+	if format == (ScoringFormat{}) {
+		format = BadmintonScoring
+	}
+
+	if err := format.validate(); err != nil {
+		return err
+	}
+
+	t.Scoring = format
+	return nil
+}
+
+// AddEntry enters a player for singles or a pair for doubles, a player can only be in one entry
+func (t *Tournament) AddEntry(name string, playerIds []uint) (*TournamentEntry, error) {
+	if t.State != TournamentDraft {
+		return nil, ErrTournamentStarted
+	}
+
+	size := lo.Ternary(t.EntryType == EntryDoubles, 2, 1)
+	if len(playerIds) != size || len(lo.Uniq(playerIds)) != size {
+		return nil, fmt.Errorf("a %s entry needs %d different players", t.EntryType, size)
+	}
+
+	entered := lo.FlatMap(t.Entries, func(e TournamentEntry, _ int) []uint { return e.PlayerIds() })
+	if len(lo.Intersect(entered, playerIds)) > 0 {
+		return nil, ErrPlayerAlreadyEntered
+	}
+
+	entry := TournamentEntry{
+		TournamentId: t.ID,
+		Name:         strings.TrimSpace(name),
+		Players: lo.Map(playerIds, func(id uint, _ int) TournamentEntryPlayer {
+			return TournamentEntryPlayer{PlayerId: id, IsPaid: t.EntryFee == 0}
+		}),
+	}
+	t.Entries = append(t.Entries, entry)
+	return &t.Entries[len(t.Entries)-1], nil
+}
+
+// RemoveEntry withdraws an entry before the bracket is drawn
+func (t *Tournament) RemoveEntry(entryId uint) (*TournamentEntry, error) {
+	if t.State != TournamentDraft {
+		return nil, ErrTournamentStarted
+	}
+
+	entry, found := lo.Find(t.Entries, func(e TournamentEntry) bool { return e.ID == entryId })
+	if !found {
+		return nil, ErrEntryNotFound
+	}
+
+	t.Entries = lo.Filter(t.Entries, func(e TournamentEntry, _ int) bool { return e.ID != entryId })
+	return &entry, nil
+}
+
+// SeedEntries ranks the entries by the average rating of their players, the highest rating is seed 1
+func (t *Tournament) SeedEntries(ratings map[uint]float64) {
+	for i := range t.Entries {
+		e := &t.Entries[i]
+		e.Rating = lo.SumBy(e.Players, func(p TournamentEntryPlayer) float64 { return ratings[p.PlayerId] }) / float64(len(e.Players))
+	}
+
+	sort.SliceStable(t.Entries, func(i, j int) bool {
+		if t.Entries[i].Rating != t.Entries[j].Rating {
+			return t.Entries[i].Rating > t.Entries[j].Rating
+		}
+		return t.Entries[i].ID < t.Entries[j].ID
+	})
+
+	for i := range t.Entries {
+		t.Entries[i].Seed = i + 1
+	}
+}
+
+func (e TournamentEntry) PlayerIds() []uint {
+	return lo.Map(e.Players, func(p TournamentEntryPlayer, _ int) uint { return p.PlayerId })
+}
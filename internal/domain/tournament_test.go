@@ -0,0 +1,185 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestTournament(t *testing.T, format TournamentFormat, entries int) *Tournament {
+	tournament, err := NewTournament("Club champs", format, EntrySingles, 10, &mockGenerator{})
+	assert.Nil(t, err)
+
+	for i := 1; i <= entries; i++ {
+		entry, err := tournament.AddEntry("", []uint{uint(i)})
+		assert.Nil(t, err)
+		entry.ID = uint(i)
+	}
+
+	// Player 1 is the best rated, player n the worst
+	tournament.SeedEntries(lo.SliceToMap(lo.Range(entries), func(i int) (uint, float64) {
+		return uint(i + 1), float64(2000 - i*10)
+	}))
+	return tournament
+}
+
+// playBracketMatch gives the bracket matches an id as the database would and records a straight sets win
+func playBracketMatch(t *testing.T, tournament *Tournament, code string, sideAWins bool) *BracketMatch {
+	for i := range tournament.BracketMatches {
+		if tournament.BracketMatches[i].ID == 0 {
+			tournament.BracketMatches[i].ID = uint(1000 + i)
+		}
+	}
+
+	scores := lo.Ternary(sideAWins, [][2]int{{21, 10}, {21, 12}}, [][2]int{{10, 21}, {12, 21}})
+	m, err := tournament.RecordResult(tournament.findBracketMatch(code).ID, scores)
+	assert.Nil(t, err)
+	return m
+}
+
+func TestAddEntry(t *testing.T) {
+	tournament, err := NewTournament("Doubles cup", FormatSingleElimination, EntryDoubles, 0, &mockGenerator{})
+	assert.Nil(t, err)
+
+	_, err = tournament.AddEntry("", []uint{1})
+	assert.NotNil(t, err)
+
+	entry, err := tournament.AddEntry("Smash bros", []uint{1, 2})
+	assert.Nil(t, err)
+	assert.True(t, entry.Players[0].IsPaid, "nothing to pay without an entry fee")
+
+	_, err = tournament.AddEntry("", []uint{2, 3})
+	assert.ErrorIs(t, err, ErrPlayerAlreadyEntered)
+}
+
+func TestSeedEntries(t *testing.T) {
+	tournament := &Tournament{Entries: []TournamentEntry{
+		{BaseModel: BaseModel{ID: 1}, Players: []TournamentEntryPlayer{{PlayerId: 1}, {PlayerId: 2}}},
+		{BaseModel: BaseModel{ID: 2}, Players: []TournamentEntryPlayer{{PlayerId: 3}, {PlayerId: 4}}},
+	}}
+
+	tournament.SeedEntries(map[uint]float64{1: 1400, 2: 1500, 3: 1700, 4: 1300})
+
+	assert.Equal(t, uint(2), tournament.Entries[0].ID)
+	assert.Equal(t, 1, tournament.Entries[0].Seed)
+	assert.Equal(t, 1500.0, tournament.Entries[0].Rating)
+	assert.Equal(t, 2, tournament.Entries[1].Seed)
+}
+
+func TestSeedOrder(t *testing.T) {
+	assert.Equal(t, []int{1, 2}, seedOrder(2))
+	assert.Equal(t, []int{1, 4, 2, 3}, seedOrder(4))
+	assert.Equal(t, []int{1, 8, 4, 5, 2, 7, 3, 6}, seedOrder(8))
+}
+
+func TestSingleEliminationWithByes(t *testing.T) {
+	tournament := newTestTournament(t, FormatSingleElimination, 5)
+	assert.Nil(t, tournament.GenerateBracket())
+	assert.Len(t, tournament.BracketMatches, 7)
+
+	// The three best seeds go through without playing
+	byes := lo.Filter(tournament.BracketMatches, func(m BracketMatch, _ int) bool { return m.IsBye })
+	assert.Len(t, byes, 3)
+	assert.Equal(t, uint(1), *tournament.findBracketMatch("W2-0").EntryAId)
+
+	_, err := tournament.RecordResult(tournament.findBracketMatch("W2-0").ID, [][2]int{{21, 10}, {21, 10}})
+	assert.NotNil(t, err, "the other side is not known yet")
+
+	playBracketMatch(t, tournament, "W1-1", false)
+	assert.Equal(t, uint(5), *tournament.findBracketMatch("W2-0").EntryBId)
+
+	playBracketMatch(t, tournament, "W2-0", true)
+	playBracketMatch(t, tournament, "W2-1", true)
+	final := playBracketMatch(t, tournament, "W3-0", false)
+
+	assert.Equal(t, uint(2), *final.WinnerId)
+	assert.Equal(t, TournamentFinished, tournament.State)
+	assert.Equal(t, uint(2), *tournament.WinnerEntryId)
+}
+
+func TestDoubleElimination(t *testing.T) {
+	tournament := newTestTournament(t, FormatDoubleElimination, 4)
+	assert.Nil(t, tournament.GenerateBracket())
+
+	// 3 winners, 2 losers and the grand final
+	assert.Len(t, tournament.BracketMatches, 6)
+
+	playBracketMatch(t, tournament, "W1-0", true)  // 1 beats 4
+	playBracketMatch(t, tournament, "W1-1", false) // 3 beats 2
+	l1 := tournament.findBracketMatch("L1-0")
+	assert.Equal(t, []uint{4, 2}, []uint{*l1.EntryAId, *l1.EntryBId})
+
+	playBracketMatch(t, tournament, "W2-0", true) // 1 beats 3
+	playBracketMatch(t, tournament, "L1-0", false)
+	l2 := tournament.findBracketMatch("L2-0")
+	assert.Equal(t, []uint{2, 3}, []uint{*l2.EntryAId, *l2.EntryBId})
+
+	playBracketMatch(t, tournament, "L2-0", true)
+	gf := tournament.findBracketMatch(grandFinalCode)
+	assert.Equal(t, []uint{1, 2}, []uint{*gf.EntryAId, *gf.EntryBId})
+	assert.Equal(t, TournamentInProgress, tournament.State)
+
+	playBracketMatch(t, tournament, grandFinalCode, false)
+	assert.Equal(t, uint(2), *tournament.WinnerEntryId)
+}
+
+func TestDoubleEliminationByeInLosersBracket(t *testing.T) {
+	tournament := newTestTournament(t, FormatDoubleElimination, 3)
+	assert.Nil(t, tournament.GenerateBracket())
+
+	// Seed 1 has a bye so nobody drops from W1-0, L1-0 waits for the loser of W1-1 only
+	l1 := tournament.findBracketMatch("L1-0")
+	assert.False(t, l1.IsFinished)
+	assert.True(t, tournament.isPendingBye(l1))
+
+	playBracketMatch(t, tournament, "W1-1", true)
+	l1 = tournament.findBracketMatch("L1-0")
+	assert.True(t, l1.IsBye)
+	assert.Equal(t, uint(3), *tournament.findBracketMatch("L2-0").EntryAId)
+}
+
+func TestGroupsFollowedByKnockout(t *testing.T) {
+	tournament := newTestTournament(t, FormatGroupsKnockout, 6)
+	assert.Nil(t, tournament.SetGroups(2, 2))
+	assert.Nil(t, tournament.GenerateBracket())
+
+	// Seeds snake over the groups: 1 4 5 and 2 3 6
+	assert.Equal(t, 1, tournament.findEntry(1).Group)
+	assert.Equal(t, 2, tournament.findEntry(3).Group)
+	assert.Equal(t, 1, tournament.findEntry(5).Group)
+	assert.Len(t, tournament.BracketMatches, 6)
+
+	// The better seed always wins
+	for _, m := range lo.Filter(tournament.BracketMatches, func(m BracketMatch, _ int) bool { return m.Stage == StageGroup }) {
+		playBracketMatch(t, tournament, m.Code, *m.EntryAId < *m.EntryBId)
+	}
+
+	standings := tournament.GroupStandings()
+	assert.Equal(t, GroupStanding{EntryId: 1, Group: 1, Rank: 1, Played: 2, Won: 2, SetsFor: 4, PointsFor: 84, PointsAgainst: 44}, standings[0])
+
+	// Group winners 1 and 2 are seeded first, runners-up 3 and 4 after them
+	knockout := lo.Filter(tournament.BracketMatches, func(m BracketMatch, _ int) bool { return m.Stage == StageWinners })
+	assert.Len(t, knockout, 3)
+	semi := tournament.findBracketMatch("W1-0")
+	assert.Equal(t, []uint{1, 4}, []uint{*semi.EntryAId, *semi.EntryBId})
+}
+
+func TestScheduleBracket(t *testing.T) {
+	start := time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC)
+	tournament := newTestTournament(t, FormatSingleElimination, 4)
+	tournament.Sessions = []Match{{BaseModel: BaseModel{ID: 7}, Start: start, End: start.Add(time.Hour), Court: "1, 2"}}
+	assert.Nil(t, tournament.GenerateBracket())
+	assert.Nil(t, tournament.Schedule())
+
+	semi1, semi2, final := tournament.findBracketMatch("W1-0"), tournament.findBracketMatch("W1-1"), tournament.findBracketMatch("W2-0")
+	assert.Equal(t, start, *semi1.StartAt)
+	assert.Equal(t, start, *semi2.StartAt)
+	assert.NotEqual(t, semi1.Court, semi2.Court)
+	assert.Equal(t, start.Add(30*time.Minute), *final.StartAt)
+	assert.Equal(t, uint(7), *final.SessionId)
+
+	tournament.Sessions[0].End = start.Add(30 * time.Minute)
+	assert.ErrorIs(t, tournament.Schedule(), ErrNotEnoughSlots)
+}
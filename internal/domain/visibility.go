@@ -0,0 +1,114 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/samber/lo"
+)
+
+type MatchVisibility = string
+
+const (
+	// VisibilityPublic shows the match to everyone in the group, it is the default
+	VisibilityPublic MatchVisibility = "public"
+	// VisibilityTeam shows the match to the members of the invited teams only
+	VisibilityTeam MatchVisibility = "team"
+	// VisibilityInviteOnly shows the match to the invited players and the members of the invited teams
+	VisibilityInviteOnly MatchVisibility = "invite_only"
+)
+
+var (
+	ErrMatchNotVisible      = errors.New("match is only open to invited players")
+	ErrInvitationNotFound   = errors.New("invitation not found")
+	ErrInvitationIncomplete = errors.New("an invitation is for either a player or a team")
+)
+
+// MatchInvitation lets a player, or every member of a team, see and register for a private match
+type MatchInvitation struct {
+	BaseModel
+	MatchId  uint  `gorm:"index" json:"matchId"`
+	PlayerId *uint `gorm:"index" json:"playerId"`
+	TeamId   *uint `gorm:"index" json:"teamId"`
+}
+
+func ParseMatchVisibility(value string) (MatchVisibility, error) {
+	switch value {
+	case "", VisibilityPublic:
+		return VisibilityPublic, nil
+	case VisibilityTeam, VisibilityInviteOnly:
+		return value, nil
+	default:
+		return "", fmt.Errorf("unknown match visibility %s", value)
+	}
+}
+
+func NewPlayerInvitation(matchId, playerId uint) *MatchInvitation {
+	return &MatchInvitation{MatchId: matchId, PlayerId: &playerId}
+}
+
+func NewTeamInvitation(matchId, teamId uint) *MatchInvitation {
+	return &MatchInvitation{MatchId: matchId, TeamId: &teamId}
+}
+
+func (m *Match) GetVisibility() MatchVisibility {
+	if m.Visibility == "" {
+		return VisibilityPublic
+	}
+	return m.Visibility
+}
+
+func (m *Match) IsPrivate() bool {
+	return m.GetVisibility() != VisibilityPublic
+}
+
+// Invite adds the invitation unless the player or the team is already invited, it returns whether it was added
+func (m *Match) Invite(invitation MatchInvitation) (bool, error) {
+	if (invitation.PlayerId == nil) == (invitation.TeamId == nil) {
+		return false, ErrInvitationIncomplete
+	}
+
+	exists := lo.ContainsBy(m.Invitations, func(i MatchInvitation) bool {
+		return lo.FromPtr(i.PlayerId) == lo.FromPtr(invitation.PlayerId) && lo.FromPtr(i.TeamId) == lo.FromPtr(invitation.TeamId)
+	})
+	if exists {
+		return false, nil
+	}
+
+	invitation.MatchId = m.ID
+	m.Invitations = append(m.Invitations, invitation)
+	return true, nil
+}
+
+// IsVisibleTo tells whether the player can see and register for the match.
+// Players who are already on the roster keep seeing it when the match is made private,
+// an invited registration only lasts as long as the invitation it was made for.
+func (m *Match) IsVisibleTo(playerId uint, teamIds []uint) bool {
+	if !m.IsPrivate() {
+		return true
+	}
+
+	if lo.ContainsBy(m.Registrations, func(r Registration) bool { return r.PlayerId == playerId && r.Rsvp != RsvpInvited }) {
+		return true
+	}
+
+	return lo.ContainsBy(m.Invitations, func(i MatchInvitation) bool {
+		if i.TeamId != nil {
+			return lo.Contains(teamIds, *i.TeamId)
+		}
+		return m.GetVisibility() == VisibilityInviteOnly && lo.FromPtr(i.PlayerId) == playerId
+	})
+}
+
+// IsVisibleToTeam tells whether the match belongs on the calendar of the team
+func (m *Match) IsVisibleToTeam(teamId uint) bool {
+	return !m.IsPrivate() || lo.ContainsBy(m.Invitations, func(i MatchInvitation) bool { return lo.FromPtr(i.TeamId) == teamId })
+}
+
+// EnsureVisibleTo refuses registrations from players the match is hidden from
+func (m *Match) EnsureVisibleTo(playerId uint, teamIds []uint) error {
+	if !m.IsVisibleTo(playerId, teamIds) {
+		return ErrMatchNotVisible
+	}
+	return nil
+}
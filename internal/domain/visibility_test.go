@@ -0,0 +1,95 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMatchVisibility(t *testing.T) {
+	visibility, err := ParseMatchVisibility("")
+	assert.Nil(t, err)
+	assert.Equal(t, VisibilityPublic, visibility)
+
+	visibility, err = ParseMatchVisibility(VisibilityInviteOnly)
+	assert.Nil(t, err)
+	assert.Equal(t, VisibilityInviteOnly, visibility)
+
+	_, err = ParseMatchVisibility("secret")
+	assert.NotNil(t, err)
+}
+
+func TestMatchInvite(t *testing.T) {
+	m := &Match{BaseModel: BaseModel{ID: 1}}
+
+	added, err := m.Invite(*NewPlayerInvitation(1, 10))
+	assert.Nil(t, err)
+	assert.True(t, added)
+
+	added, err = m.Invite(*NewTeamInvitation(1, 10))
+	assert.Nil(t, err)
+	assert.True(t, added)
+
+	// Inviting twice keeps one invitation
+	added, err = m.Invite(*NewPlayerInvitation(1, 10))
+	assert.Nil(t, err)
+	assert.False(t, added)
+	assert.Len(t, m.Invitations, 2)
+
+	_, err = m.Invite(MatchInvitation{})
+	assert.ErrorIs(t, err, ErrInvitationIncomplete)
+}
+
+func TestIsVisibleTo(t *testing.T) {
+	invitations := []MatchInvitation{*NewPlayerInvitation(1, 1), *NewTeamInvitation(1, 7)}
+	registrations := []Registration{{PlayerId: 3}, {PlayerId: 4, Rsvp: RsvpInvited}}
+
+	tests := []struct {
+		name       string
+		visibility MatchVisibility
+		playerId   uint
+		teamIds    []uint
+		expected   bool
+	}{
+		{"public match is visible to everyone", VisibilityPublic, 5, nil, true},
+		{"unset visibility is public", "", 5, nil, true},
+		{"team match is visible to members of invited teams", VisibilityTeam, 5, []uint{7}, true},
+		{"team match ignores invited players", VisibilityTeam, 1, nil, false},
+		{"team match is hidden from other teams", VisibilityTeam, 5, []uint{8}, false},
+		{"invite-only match is visible to invited players", VisibilityInviteOnly, 1, nil, true},
+		{"invite-only match is visible to members of invited teams", VisibilityInviteOnly, 5, []uint{7}, true},
+		{"invite-only match is hidden from others", VisibilityInviteOnly, 5, nil, false},
+		{"registered players keep seeing a private match", VisibilityInviteOnly, 3, nil, true},
+		{"invited registrations need an invitation", VisibilityInviteOnly, 4, nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &Match{Visibility: tt.visibility, Invitations: invitations, Registrations: registrations}
+			assert.Equal(t, tt.expected, m.IsVisibleTo(tt.playerId, tt.teamIds))
+		})
+	}
+}
+
+func TestIsVisibleToTeam(t *testing.T) {
+	m := &Match{Visibility: VisibilityTeam, Invitations: []MatchInvitation{*NewTeamInvitation(1, 7)}}
+	assert.True(t, m.IsVisibleToTeam(7))
+	assert.False(t, m.IsVisibleToTeam(8))
+	assert.ErrorIs(t, m.EnsureVisibleTo(5, nil), ErrMatchNotVisible)
+
+	m.Visibility = VisibilityPublic
+	assert.True(t, m.IsVisibleToTeam(8))
+}
+
+func TestCloneKeepsInvitations(t *testing.T) {
+	m := &Match{
+		Visibility:  VisibilityInviteOnly,
+		Invitations: []MatchInvitation{{BaseModel: BaseModel{ID: 4}, MatchId: 1, PlayerId: new(uint)}},
+	}
+
+	clone := m.Clone()
+	assert.Equal(t, VisibilityInviteOnly, clone.Visibility)
+	assert.Len(t, clone.Invitations, 1)
+	assert.Equal(t, uint(0), clone.Invitations[0].ID)
+	assert.Equal(t, uint(0), clone.Invitations[0].MatchId)
+}
@@ -25,6 +25,7 @@ type (
 		WalletId        uint            `json:"walletId" gorm:"index"`
 		TransactionType TransactionType `json:"transactionType"`
 		Description     string          `json:"description"`
+		MatchId         *uint           `json:"matchId" gorm:"index"`
 	}
 )
 
@@ -43,7 +44,7 @@ func newWalletWithAmount(ownerId uint, initAmount float64) *Wallet {
 	}
 }
 
-func (a *Wallet) addTransaction(tranType TransactionType, amount float64, description string) {
+func (a *Wallet) addTransaction(tranType TransactionType, amount float64, description string) *WalletTransaction {
 	tran := &WalletTransaction{
 		WalletId:        a.ID,
 		TransactionType: tranType,
@@ -51,6 +52,7 @@ func (a *Wallet) addTransaction(tranType TransactionType, amount float64, descri
 		Amount:          amount,
 	}
 	a.Transactions = append(a.Transactions, tran)
+	return tran
 }
 
 func (a *Wallet) Credit(amount float64, description string) error {
@@ -71,3 +73,13 @@ func (a *Wallet) Debit(amount float64, description string) error {
 
 	return nil
 }
+
+// CreditForMatch refunds a match payment, the transaction is linked to the match
+func (a *Wallet) CreditForMatch(matchId uint, amount float64, description string) error {
+	if err := a.Credit(amount, description); err != nil {
+		return err
+	}
+
+	a.Transactions[len(a.Transactions)-1].MatchId = &matchId
+	return nil
+}
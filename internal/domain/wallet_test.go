@@ -2,6 +2,7 @@ package domain
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -54,3 +55,38 @@ func TestDebitInsufficientAmount(t *testing.T) {
 	err := acc.Debit(100, "test debig insufficient value")
 	assert.Error(t, err)
 }
+
+func TestCancelPaidMatchRefundsPaidPlayers(t *testing.T) {
+	start := time.Date(2024, 6, 5, 18, 0, 0, 0, time.UTC)
+	m := NewMatch(start, start.Add(2*time.Hour), 1, NewFlatPricing(10, 60), "1", nil)
+	m.ID = 7
+	m.Registrations = []Registration{
+		{PlayerId: 1, MatchId: 7, IsPaid: true, TotalPlayerPaidFor: 1},
+		{PlayerId: 2, MatchId: 7, IsPaid: true, TotalPlayerPaidFor: 1},
+		{PlayerId: 3, MatchId: 7, IsPaid: false, TotalPlayerPaidFor: 2},
+	}
+	assert.Nil(t, m.Cancel("Rain"))
+
+	// Player 3 has not paid and is owed nothing
+	refunds := m.CalcPaidRefunds()
+	assert.Equal(t, map[uint]float64{1: 5, 2: 5}, refunds)
+
+	wallet := NewWallet(1, "Refunds")
+	assert.Nil(t, wallet.CreditForMatch(m.ID, refunds[1], "Refund"))
+	assert.Equal(t, float64(5), wallet.Balance)
+	assert.Equal(t, uint(7), *wallet.Transactions[0].MatchId)
+}
+
+func TestCancelMatchDoesNotRefundPlayersWithoutSpot(t *testing.T) {
+	start := time.Date(2024, 6, 5, 18, 0, 0, 0, time.UTC)
+	m := NewMatch(start, start.Add(2*time.Hour), 1, NewFlatPricing(10, 60), "1", nil)
+	m.Registrations = []Registration{
+		{PlayerId: 1, IsPaid: true, Rsvp: RsvpGoing, TotalPlayerPaidFor: 1},
+		{PlayerId: 2, IsPaid: true, Rsvp: RsvpGoing, TotalPlayerPaidFor: 1},
+		{PlayerId: 3, IsPaid: true, Rsvp: RsvpGoing, IsWaitlisted: true, TotalPlayerPaidFor: 1},
+		{PlayerId: 4, IsPaid: true, Rsvp: RsvpMaybe, TotalPlayerPaidFor: 1},
+	}
+	assert.Nil(t, m.Cancel("Rain"))
+
+	assert.Equal(t, map[uint]float64{1: 10, 2: 10}, m.CalcPaidRefunds())
+}
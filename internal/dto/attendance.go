@@ -0,0 +1,31 @@
+package dto
+
+import "time"
+
+type (
+	CheckInCodeDto struct {
+		MatchId   uint      `json:"matchId"`
+		Code      string    `json:"code"`
+		ExpiresAt time.Time `json:"expiresAt"`
+	}
+
+	CheckInDto struct {
+		Code string `json:"code"`
+	}
+
+	AttendanceDto struct {
+		RegistrationId uint       `json:"registrationId"`
+		Attendance     string     `json:"attendance"`
+		CheckedInAt    *time.Time `json:"checkedInAt"`
+	}
+
+	PlayerReliabilityDto struct {
+		PlayerId    uint    `json:"playerId"`
+		PlayerName  string  `json:"playerName"`
+		Matches     int     `json:"matches"`
+		Attended    int     `json:"attended"`
+		Late        int     `json:"late"`
+		NoShows     int     `json:"noShows"`
+		Reliability float64 `json:"reliability"`
+	}
+)
@@ -0,0 +1,39 @@
+package dto
+
+import "time"
+
+type (
+	// BallotRequestDto turns the ballot on with a draw time, a null draw time turns it off
+	BallotRequestDto struct {
+		DrawAt *time.Time `json:"drawAt"`
+	}
+
+	BallotDto struct {
+		MatchId      uint             `json:"matchId"`
+		DrawAt       *time.Time       `json:"drawAt"`
+		DrawnAt      *time.Time       `json:"drawnAt"`
+		EntrantCount int              `json:"entrantCount"`
+		Seed         *int64           `json:"seed"`
+		Seats        int              `json:"seats"`
+		Entries      []BallotEntryDto `json:"entries"`
+		IsVerified   bool             `json:"isVerified"`
+	}
+
+	// BallotEntryDto shows the inputs of the draw next to its outcome so it can be checked
+	BallotEntryDto struct {
+		RegistrationId uint    `json:"registrationId"`
+		PlayerId       uint    `json:"playerId"`
+		PlayerName     string  `json:"playerName"`
+		Seats          uint    `json:"seats"`
+		IsMember       bool    `json:"isMember"`
+		RecentSessions int     `json:"recentSessions"`
+		Reliability    float64 `json:"reliability"`
+		Weight         float64 `json:"weight"`
+		Position       int     `json:"position"`
+		Won            bool    `json:"won"`
+	}
+
+	BallotDrawResultDto struct {
+		DrawnMatchIds []uint `json:"drawnMatchIds"`
+	}
+)
@@ -0,0 +1,39 @@
+package dto
+
+import "time"
+
+type (
+	BookingImportOptions struct {
+		Timezone                string  `form:"timezone" json:"timezone"`
+		DefaultCostPerSection   float64 `form:"defaultCostPerSection" json:"defaultCostPerSection"`
+		DefaultMinutePerSection uint    `form:"defaultMinutePerSection" json:"defaultMinutePerSection"`
+		AllowClosed             bool    `form:"allowClosed" json:"allowClosed"`
+		AllowConflicts          bool    `form:"allowConflicts" json:"allowConflicts"`
+	}
+
+	BookingImportItemDto struct {
+		Reference          string    `json:"reference"`
+		Venue              string    `json:"venue"`
+		SportCenterId      uint      `json:"sportCenterId"`
+		SportCenterName    string    `json:"sportCenterName"`
+		IsNewSportCenter   bool      `json:"isNewSportCenter"`
+		Start              time.Time `json:"start"`
+		End                time.Time `json:"end"`
+		Court              string    `json:"court"`
+		Cost               float64   `json:"cost"`
+		IsDuplicate        bool      `json:"isDuplicate"`
+		DuplicateMatchId   *uint     `json:"duplicateMatchId"`
+		CreatedMatchId     *uint     `json:"createdMatchId"`
+		ValidationErrorMsg string    `json:"validationError,omitempty"`
+	}
+
+	BookingImportResultDto struct {
+		Committed       bool                   `json:"committed"`
+		Items           []BookingImportItemDto `json:"items"`
+		NewCount        int                    `json:"newCount"`
+		DuplicateCount  int                    `json:"duplicateCount"`
+		InvalidCount    int                    `json:"invalidCount"`
+		TotalCost       float64                `json:"totalCost"`
+		NewSportCenters []string               `json:"newSportCenters"`
+	}
+)
@@ -0,0 +1,7 @@
+package dto
+
+type CalendarFeedDto struct {
+	Token     string `json:"token"`
+	Url       string `json:"url"`
+	WebcalUrl string `json:"webcalUrl"`
+}
@@ -0,0 +1,37 @@
+package dto
+
+import "time"
+
+type (
+	MatchConflictDto struct {
+		MatchId         uint      `json:"matchId"`
+		Start           time.Time `json:"start"`
+		End             time.Time `json:"end"`
+		SportCenterId   uint      `json:"sportCenterId"`
+		SportCenterName string    `json:"sportCenterName"`
+		Court           string    `json:"court"`
+	}
+
+	ConflictErrorDto struct {
+		Error     string             `json:"error"`
+		Kind      string             `json:"kind"`
+		Conflicts []MatchConflictDto `json:"conflicts"`
+	}
+
+	CourtConflictReportDto struct {
+		SportCenterId   uint               `json:"sportCenterId"`
+		SportCenterName string             `json:"sportCenterName"`
+		Matches         []MatchConflictDto `json:"matches"`
+	}
+
+	PlayerConflictReportDto struct {
+		PlayerId   uint               `json:"playerId"`
+		PlayerName string             `json:"playerName"`
+		Matches    []MatchConflictDto `json:"matches"`
+	}
+
+	ConflictReportDto struct {
+		CourtConflicts  []CourtConflictReportDto  `json:"courtConflicts"`
+		PlayerConflicts []PlayerConflictReportDto `json:"playerConflicts"`
+	}
+)
@@ -0,0 +1,58 @@
+package dto
+
+import "time"
+
+type (
+	ScoringFormatDto struct {
+		Points int `json:"points"`
+		WinBy  int `json:"winBy"`
+		Cap    int `json:"cap"`
+		BestOf int `json:"bestOf"`
+	}
+
+	SetScoreDto struct {
+		A int `json:"a"`
+		B int `json:"b"`
+	}
+
+	// GameRequestDto records a result, badminton scoring is used when no format is given.
+	// On update the court and format are kept when left out.
+	GameRequestDto struct {
+		Court  *string           `json:"court"`
+		SideA  []uint            `json:"sideA"`
+		SideB  []uint            `json:"sideB"`
+		Sets   []SetScoreDto     `json:"sets"`
+		Format *ScoringFormatDto `json:"format"`
+	}
+
+	GameDto struct {
+		Id        uint               `json:"id"`
+		MatchId   uint               `json:"matchId"`
+		Court     string             `json:"court"`
+		Format    ScoringFormatDto   `json:"format"`
+		SideA     []PlayerSummaryDto `json:"sideA"`
+		SideB     []PlayerSummaryDto `json:"sideB"`
+		Sets      []SetScoreDto      `json:"sets"`
+		Winner    string             `json:"winner"`
+		UpdatedAt time.Time          `json:"updatedAt"`
+	}
+
+	GameRevisionDto struct {
+		EditedBy string    `json:"editedBy"`
+		EditedAt time.Time `json:"editedAt"`
+		Players  string    `json:"players"`
+		Scores   string    `json:"scores"`
+		Winner   string    `json:"winner"`
+	}
+
+	PlayerStatsDto struct {
+		PlayerId      uint    `json:"playerId"`
+		PlayerName    string  `json:"playerName"`
+		Rank          uint    `json:"rank"`
+		Played        int     `json:"played"`
+		Won           int     `json:"won"`
+		WinRate       float64 `json:"winRate"`
+		PointsFor     int     `json:"pointsFor"`
+		PointsAgainst int     `json:"pointsAgainst"`
+	}
+)
@@ -0,0 +1,92 @@
+package dto
+
+import "time"
+
+type (
+	// LadderRequestDto uses badminton scoring and the default play deadline when not given,
+	// inactivity penalties are off when zero
+	LadderRequestDto struct {
+		Name           string            `json:"name" binding:"required"`
+		ChallengeRange int               `json:"challengeRange" binding:"required"`
+		ResponseDays   int               `json:"responseDays" binding:"required"`
+		PlayDays       int               `json:"playDays"`
+		InactivityDays int               `json:"inactivityDays"`
+		InactivityDrop int               `json:"inactivityDrop"`
+		Scoring        *ScoringFormatDto `json:"scoring"`
+	}
+
+	// ChallengeRequestDto can attach the challenge to a match session straight away
+	ChallengeRequestDto struct {
+		DefenderId uint   `json:"defenderId" binding:"required"`
+		MatchId    *uint  `json:"matchId"`
+		Court      string `json:"court"`
+	}
+
+	ChallengeResponseDto struct {
+		Accept bool `json:"accept"`
+	}
+
+	ScheduleChallengeDto struct {
+		MatchId uint   `json:"matchId" binding:"required"`
+		Court   string `json:"court"`
+	}
+
+	ChallengeResultDto struct {
+		Sets []SetScoreDto `json:"sets" binding:"required"`
+	}
+
+	LadderSummaryDto struct {
+		Id          uint   `json:"id"`
+		Name        string `json:"name"`
+		PlayerCount int    `json:"playerCount"`
+	}
+
+	LadderDto struct {
+		Id             uint                 `json:"id"`
+		Name           string               `json:"name"`
+		ChallengeRange int                  `json:"challengeRange"`
+		ResponseDays   int                  `json:"responseDays"`
+		PlayDays       int                  `json:"playDays"`
+		InactivityDays int                  `json:"inactivityDays"`
+		InactivityDrop int                  `json:"inactivityDrop"`
+		Scoring        ScoringFormatDto     `json:"scoring"`
+		Rungs          []LadderRungDto      `json:"rungs"`
+		OpenChallenges []LadderChallengeDto `json:"openChallenges"`
+	}
+
+	LadderRungDto struct {
+		Position     int              `json:"position"`
+		Player       PlayerSummaryDto `json:"player"`
+		LastActiveAt time.Time        `json:"lastActiveAt"`
+	}
+
+	LadderChallengeDto struct {
+		Id                 uint             `json:"id"`
+		Challenger         PlayerSummaryDto `json:"challenger"`
+		Defender           PlayerSummaryDto `json:"defender"`
+		ChallengerPosition int              `json:"challengerPosition"`
+		DefenderPosition   int              `json:"defenderPosition"`
+		State              string           `json:"state"`
+		RespondBy          time.Time        `json:"respondBy"`
+		PlayBy             *time.Time       `json:"playBy"`
+		MatchId            *uint            `json:"matchId"`
+		Court              string           `json:"court"`
+		Sets               []SetScoreDto    `json:"sets"`
+		WinnerId           *uint            `json:"winnerId"`
+		CreatedAt          time.Time        `json:"createdAt"`
+		ResolvedAt         *time.Time       `json:"resolvedAt"`
+	}
+
+	LadderPenaltyDto struct {
+		Player       PlayerSummaryDto `json:"player"`
+		FromPosition int              `json:"fromPosition"`
+		ToPosition   int              `json:"toPosition"`
+		CreatedAt    time.Time        `json:"createdAt"`
+	}
+
+	// LadderHistoryDto lists the challenges and penalties, the latest first
+	LadderHistoryDto struct {
+		Challenges []LadderChallengeDto `json:"challenges"`
+		Penalties  []LadderPenaltyDto   `json:"penalties"`
+	}
+)
@@ -0,0 +1,105 @@
+package dto
+
+import "time"
+
+type (
+	SeasonRequestDto struct {
+		Name     string    `json:"name" binding:"required"`
+		StartsOn time.Time `json:"startsOn" binding:"required"`
+		EndsOn   time.Time `json:"endsOn" binding:"required"`
+	}
+
+	SeasonDto struct {
+		Id       uint               `json:"id"`
+		Name     string             `json:"name"`
+		StartsOn time.Time          `json:"startsOn"`
+		EndsOn   time.Time          `json:"endsOn"`
+		Leagues  []LeagueSummaryDto `json:"leagues"`
+	}
+
+	LeagueSummaryDto struct {
+		Id   uint   `json:"id"`
+		Name string `json:"name"`
+	}
+
+	LeagueTeamDto struct {
+		Id   uint   `json:"id"`
+		Name string `json:"name"`
+	}
+
+	PointsRulesDto struct {
+		Win       int `json:"win"`
+		Draw      int `json:"draw"`
+		Loss      int `json:"loss"`
+		RubberWin int `json:"rubberWin"`
+		Rubbers   int `json:"rubbers"`
+	}
+
+	// LeagueRequestDto uses 2 points for a win, 1 for a draw and head to head first when not given
+	LeagueRequestDto struct {
+		Name        string          `json:"name" binding:"required"`
+		TeamIds     []uint          `json:"teamIds" binding:"required"`
+		Points      *PointsRulesDto `json:"points"`
+		Tiebreakers []string        `json:"tiebreakers"`
+		HomeAndAway bool            `json:"homeAndAway"`
+	}
+
+	LeagueDto struct {
+		Id          uint            `json:"id"`
+		SeasonId    uint            `json:"seasonId"`
+		Name        string          `json:"name"`
+		Points      PointsRulesDto  `json:"points"`
+		Tiebreakers []string        `json:"tiebreakers"`
+		HomeAndAway bool            `json:"homeAndAway"`
+		Teams       []LeagueTeamDto `json:"teams"`
+		Fixtures    []FixtureDto    `json:"fixtures"`
+	}
+
+	FixtureDto struct {
+		Id                   uint       `json:"id"`
+		Round                int        `json:"round"`
+		HomeTeamId           uint       `json:"homeTeamId"`
+		HomeTeamName         string     `json:"homeTeamName"`
+		AwayTeamId           uint       `json:"awayTeamId"`
+		AwayTeamName         string     `json:"awayTeamName"`
+		MatchId              *uint      `json:"matchId"`
+		MatchStart           *time.Time `json:"matchStart"`
+		State                string     `json:"state"`
+		HomeRubbers          int        `json:"homeRubbers"`
+		AwayRubbers          int        `json:"awayRubbers"`
+		PostponedReason      string     `json:"postponedReason"`
+		PostponedFromMatchId *uint      `json:"postponedFromMatchId"`
+	}
+
+	// GenerateFixturesDto plays round n during the nth session, rounds without a session are planned later
+	GenerateFixturesDto struct {
+		MatchIds []uint `json:"matchIds"`
+	}
+
+	FixtureResultDto struct {
+		HomeRubbers int `json:"homeRubbers"`
+		AwayRubbers int `json:"awayRubbers"`
+	}
+
+	PostponeFixtureDto struct {
+		Reason string `json:"reason"`
+	}
+
+	RescheduleFixtureDto struct {
+		MatchId uint `json:"matchId" binding:"required"`
+	}
+
+	StandingDto struct {
+		Position         int    `json:"position"`
+		TeamId           uint   `json:"teamId"`
+		TeamName         string `json:"teamName"`
+		Played           int    `json:"played"`
+		Won              int    `json:"won"`
+		Drawn            int    `json:"drawn"`
+		Lost             int    `json:"lost"`
+		RubbersFor       int    `json:"rubbersFor"`
+		RubbersAgainst   int    `json:"rubbersAgainst"`
+		RubberDifference int    `json:"rubberDifference"`
+		Points           int    `json:"points"`
+	}
+)
@@ -4,21 +4,113 @@ import "time"
 
 type (
 	MatchDto struct {
-		MatchId          uint      `json:"matchId"`
-		Start            time.Time `json:"start"`
-		End              time.Time `json:"end"`
-		SportCenterName  string    `json:"sportCenterName"`
-		SportCenterId    uint      `json:"sportCenterId"`
-		CostPerSection   float64   `json:"costPerSection"`
-		MinutePerSection uint      `json:"minutePerSection"`
-		IndividualCost   float64   `json:"individualCost"`
-		Cost             float64   `json:"cost"`
-		AdditionalCost   float64   `json:"additionalCost"`
-		Court            string    `json:"court"`
-		CustomSection    *float64  `json:"customSection"`
-		PlayerCount      int       `json:"playerCount"`
-		RegistrationIds  []uint    `json:"registrationIds"`
-		IsRegistered     bool      `json:"isRegistered"`
+		MatchId          uint              `json:"matchId"`
+		Start            time.Time         `json:"start"`
+		End              time.Time         `json:"end"`
+		SportCenterName  string            `json:"sportCenterName"`
+		SportCenterId    uint              `json:"sportCenterId"`
+		CostPerSection   float64           `json:"costPerSection"`
+		MinutePerSection uint              `json:"minutePerSection"`
+		IndividualCost   float64           `json:"individualCost"`
+		Cost             float64           `json:"cost"`
+		AdditionalCost   float64           `json:"additionalCost"`
+		Court            string            `json:"court"`
+		CustomSection    *float64          `json:"customSection"`
+		PlayerCount      int               `json:"playerCount"`
+		MaybeCount       int               `json:"maybeCount"`
+		RegistrationIds  []uint            `json:"registrationIds"`
+		IsRegistered     bool              `json:"isRegistered"`
+		Rsvp             string            `json:"rsvp,omitempty"`
+		OptOutDeadline   *time.Time        `json:"optOutDeadline,omitempty"`
+		AllowConflicts   bool              `json:"allowConflicts,omitempty"`
+		AllowClosed      bool              `json:"allowClosed,omitempty"`
+		Capacity         int               `json:"capacity"`
+		CourtBookings    []CourtBookingDto `json:"courtBookings"`
+		State            string            `json:"state"`
+		Visibility       string            `json:"visibility"`
+
+		RegistrationOpensAt  *time.Time `json:"registrationOpensAt"`
+		RegistrationClosesAt *time.Time `json:"registrationClosesAt"`
+		CancellationDeadline *time.Time `json:"cancellationDeadline"`
+		LateCancellationFee  *float64   `json:"lateCancellationFee"`
+		// BallotDrawAt collects registrations until the draw instead of first-come-first-served
+		BallotDrawAt *time.Time `json:"ballotDrawAt"`
+	}
+
+	MatchStateDto struct {
+		State  string `json:"state"`
+		Reason string `json:"reason"`
+	}
+
+	// CourtBookingDto is one court of a session, the sport center pricing is used when no rate is given
+	CourtBookingDto struct {
+		Id               uint          `json:"id"`
+		Court            string        `json:"court"`
+		Start            time.Time     `json:"start"`
+		End              time.Time     `json:"end"`
+		CostPerSection   float64       `json:"costPerSection"`
+		MinutePerSection float64       `json:"minutePerSection"`
+		CustomSection    *float64      `json:"customSection"`
+		Cost             float64       `json:"cost"`
+		Capacity         uint          `json:"capacity"`
+		MemberRate       bool          `json:"memberRate"`
+		CostItems        []CostItemDto `json:"costItems"`
+		PriceSource      string        `json:"priceSource"`
+	}
+
+	MatchRosterDto struct {
+		MatchId       uint                      `json:"matchId"`
+		Start         time.Time                 `json:"start"`
+		End           time.Time                 `json:"end"`
+		Capacity      int                       `json:"capacity"`
+		PlayerCount   int                       `json:"playerCount"`
+		MaybeCount    int                       `json:"maybeCount"`
+		Courts        []CourtBookingDto         `json:"courts"`
+		Registrations []RegistrationOverviewDto `json:"registrations"`
+	}
+
+	MatchCostBreakdownDto struct {
+		MatchId             uint                  `json:"matchId"`
+		Courts              []CourtBookingDto     `json:"courts"`
+		CourtCost           float64               `json:"courtCost"`
+		AdditionalCost      float64               `json:"additionalCost"`
+		TotalCost           float64               `json:"totalCost"`
+		PlayerCount         int                   `json:"playerCount"`
+		IndividualCost      float64               `json:"individualCost"`
+		CostSplit           string                `json:"costSplit"`
+		AdditionalCostSplit string                `json:"additionalCostSplit"`
+		Registrations       []RegistrationCostDto `json:"registrations"`
+	}
+
+	RegistrationCostDto struct {
+		RegistrationId     uint    `json:"registrationId"`
+		PlayerId           uint    `json:"playerId"`
+		TotalPlayerPaidFor uint    `json:"totalPlayerPaidFor"`
+		PlayedMinutes      float64 `json:"playedMinutes"`
+		Amount             float64 `json:"amount"`
+	}
+
+	MatchVisibilityDto struct {
+		Visibility string `json:"visibility" binding:"required"`
+	}
+
+	// MatchInvitationRequestDto invites players and whole teams to a private match
+	MatchInvitationRequestDto struct {
+		PlayerIds []uint `json:"playerIds"`
+		TeamIds   []uint `json:"teamIds"`
+	}
+
+	MatchInvitationDto struct {
+		Id         uint   `json:"id"`
+		PlayerId   *uint  `json:"playerId"`
+		PlayerName string `json:"playerName,omitempty"`
+		TeamId     *uint  `json:"teamId"`
+		TeamName   string `json:"teamName,omitempty"`
+	}
+
+	CostSplitDto struct {
+		CourtCost      string `json:"courtCost"`
+		AdditionalCost string `json:"additionalCost"`
 	}
 
 	MatchSummaryDto struct {
@@ -41,11 +133,43 @@ type (
 	}
 
 	UpdateMatchDto struct {
-		SportCenterId string    `json:"sportCenterId"`
-		Start         time.Time `json:"start"`
-		End           time.Time `json:"end"`
-		Court         string    `json:"court"`
-		CustomSection *float64  `json:"customSection"`
+		SportCenterId  string            `json:"sportCenterId"`
+		Start          time.Time         `json:"start"`
+		End            time.Time         `json:"end"`
+		Court          string            `json:"court"`
+		CustomSection  *float64          `json:"customSection"`
+		AllowConflicts bool              `json:"allowConflicts"`
+		AllowClosed    bool              `json:"allowClosed"`
+		CourtBookings  []CourtBookingDto `json:"courtBookings"`
+
+		RegistrationOpensAt  *time.Time `json:"registrationOpensAt"`
+		RegistrationClosesAt *time.Time `json:"registrationClosesAt"`
+		CancellationDeadline *time.Time `json:"cancellationDeadline"`
+		LateCancellationFee  *float64   `json:"lateCancellationFee"`
+		BallotDrawAt         *time.Time `json:"ballotDrawAt"`
+	}
+
+	// MatchSeriesDto repeats a match weekly, closed dates and court conflicts are skipped unless allowed
+	MatchSeriesDto struct {
+		Weeks          int  `json:"weeks"`
+		AllowClosed    bool `json:"allowClosed"`
+		AllowConflicts bool `json:"allowConflicts"`
+	}
+
+	MatchSeriesResultDto struct {
+		CreatedMatchIds []uint             `json:"createdMatchIds"`
+		SkippedDates    []SkippedMatchDate `json:"skippedDates"`
+	}
+
+	SkippedMatchDate struct {
+		Start  time.Time `json:"start"`
+		Reason string    `json:"reason"`
+	}
+
+	ClosedErrorDto struct {
+		Error  string    `json:"error"`
+		Start  time.Time `json:"start"`
+		Reason string    `json:"reason"`
 	}
 
 	MatchCostDto struct {
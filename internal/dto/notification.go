@@ -0,0 +1,12 @@
+package dto
+
+import "time"
+
+type NotificationDto struct {
+	Id        uint       `json:"id"`
+	MatchId   *uint      `json:"matchId"`
+	Title     string     `json:"title"`
+	Message   string     `json:"message"`
+	ReadAt    *time.Time `json:"readAt"`
+	CreatedAt time.Time  `json:"createdAt"`
+}
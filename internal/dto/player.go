@@ -4,4 +4,5 @@ type PlayerSummaryDto struct {
 	PlayerId  uint   `json:"playerId"`
 	FirstName string `json:"firstName"`
 	LastName  string `json:"lastName"`
+	Gender    string `json:"gender,omitempty"`
 }
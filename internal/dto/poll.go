@@ -0,0 +1,80 @@
+package dto
+
+import "time"
+
+type (
+	PollSlotRequestDto struct {
+		Start         time.Time `json:"start" binding:"required"`
+		End           time.Time `json:"end" binding:"required"`
+		SportCenterId *uint     `json:"sportCenterId"`
+		Court         string    `json:"court"`
+	}
+
+	CreatePollDto struct {
+		Title       string               `json:"title" binding:"required"`
+		Description string               `json:"description"`
+		ClosesAt    *time.Time           `json:"closesAt"`
+		Slots       []PollSlotRequestDto `json:"slots" binding:"required"`
+	}
+
+	// PollResponseRequestDto maps slot ids to yes, maybe or no, the name is only used for anonymous answers.
+	// Anonymous respondents send the response token they were given to change their answers.
+	PollResponseRequestDto struct {
+		Name          string          `json:"name"`
+		Answers       map[uint]string `json:"answers" binding:"required"`
+		ResponseToken string          `json:"responseToken"`
+	}
+
+	// BookPollDto books the slot with the most yes answers when no slot is given,
+	// the sport center and court of the slot are used unless overridden
+	BookPollDto struct {
+		SlotId         *uint  `json:"slotId"`
+		SportCenterId  *uint  `json:"sportCenterId"`
+		Court          string `json:"court"`
+		AllowConflicts bool   `json:"allowConflicts"`
+		AllowClosed    bool   `json:"allowClosed"`
+	}
+
+	PollSummaryDto struct {
+		Id            uint       `json:"id"`
+		Title         string     `json:"title"`
+		State         string     `json:"state"`
+		ClosesAt      *time.Time `json:"closesAt"`
+		ResponseCount int        `json:"responseCount"`
+		MatchId       *uint      `json:"matchId"`
+	}
+
+	PollDto struct {
+		Id           uint          `json:"id"`
+		Title        string        `json:"title"`
+		Description  string        `json:"description"`
+		State        string        `json:"state"`
+		ClosesAt     *time.Time    `json:"closesAt"`
+		ShareCode    string        `json:"shareCode,omitempty"`
+		BookedSlotId *uint         `json:"bookedSlotId"`
+		MatchId      *uint         `json:"matchId"`
+		Slots        []PollSlotDto `json:"slots"`
+		// ResponseToken is returned to anonymous respondents, it is required to change their answers
+		ResponseToken string `json:"responseToken,omitempty"`
+	}
+
+	PollSlotDto struct {
+		Id              uint                `json:"id"`
+		Start           time.Time           `json:"start"`
+		End             time.Time           `json:"end"`
+		SportCenterId   *uint               `json:"sportCenterId"`
+		SportCenterName string              `json:"sportCenterName"`
+		Court           string              `json:"court"`
+		YesCount        int                 `json:"yesCount"`
+		MaybeCount      int                 `json:"maybeCount"`
+		NoCount         int                 `json:"noCount"`
+		Yes             []PollRespondentDto `json:"yes"`
+		Maybe           []PollRespondentDto `json:"maybe"`
+		No              []PollRespondentDto `json:"no"`
+	}
+
+	PollRespondentDto struct {
+		PlayerId *uint  `json:"playerId"`
+		Name     string `json:"name"`
+	}
+)
@@ -0,0 +1,23 @@
+package dto
+
+import "time"
+
+type (
+	LeaderboardEntryDto struct {
+		Rank         uint       `json:"rank"`
+		PlayerId     uint       `json:"playerId"`
+		PlayerName   string     `json:"playerName"`
+		Rating       float64    `json:"rating"`
+		Games        int        `json:"games"`
+		Provisional  bool       `json:"provisional"`
+		LastPlayedAt *time.Time `json:"lastPlayedAt"`
+	}
+
+	RatingChangeDto struct {
+		GameId   uint      `json:"gameId"`
+		MatchId  uint      `json:"matchId"`
+		PlayedAt time.Time `json:"playedAt"`
+		Before   float64   `json:"before"`
+		After    float64   `json:"after"`
+	}
+)
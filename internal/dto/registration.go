@@ -1,23 +1,34 @@
 package dto
 
+import "time"
+
 type (
 	RegistrationOverviewDto struct {
-		RegistrationId     uint   `json:"registrationId"`
-		MatchId            uint   `json:"matchId"`
-		PlayerId           uint   `json:"playerId"`
-		PlayerName         string `json:"playerName"`
-		TotalPlayerPaidFor uint   `json:"totalPlayerPaidFor"`
-		Email              string `json:"email"`
-		IsPaid             bool   `json:"isPaid"`
+		RegistrationId     uint       `json:"registrationId"`
+		MatchId            uint       `json:"matchId"`
+		PlayerId           uint       `json:"playerId"`
+		PlayerName         string     `json:"playerName"`
+		TotalPlayerPaidFor uint       `json:"totalPlayerPaidFor"`
+		Email              string     `json:"email"`
+		IsPaid             bool       `json:"isPaid"`
+		Rsvp               string     `json:"rsvp"`
+		IsWaitlisted       bool       `json:"isWaitlisted"`
+		Attendance         string     `json:"attendance"`
+		CheckedInAt        *time.Time `json:"checkedInAt"`
+		Guests             []GuestDto `json:"guests" gorm:"-"`
 	}
 
 	RegistrationDto struct {
-		PlayerId uint `json:"playerId"`
-		MatchId  uint `json:"matchId"`
+		PlayerId       uint `json:"playerId"`
+		MatchId        uint `json:"matchId"`
+		AllowConflicts bool `json:"allowConflicts"`
+		AllowClosed    bool `json:"allowClosed"`
+		AllowPrivate   bool `json:"allowPrivate"`
 	}
 
 	MatchRegistrationDto struct {
-		MatchId uint `json:"matchId"`
+		MatchId        uint `json:"matchId"`
+		AllowConflicts bool `json:"allowConflicts"`
 	}
 
 	AttendantRequestDto struct {
@@ -36,4 +47,55 @@ type (
 	UpdateTotalPlayerPaidForDto struct {
 		Count uint `json:"count"`
 	}
+
+	MatchRegistrationResultDto struct {
+		RegistrationId uint `json:"registrationId"`
+		IsWaitlisted   bool `json:"isWaitlisted"`
+	}
+
+	// RsvpDto is going, maybe or not_going
+	RsvpDto struct {
+		MatchId        uint   `json:"matchId" binding:"required"`
+		Rsvp           string `json:"rsvp" binding:"required"`
+		AllowConflicts bool   `json:"allowConflicts"`
+	}
+
+	RsvpResultDto struct {
+		RegistrationId       uint    `json:"registrationId"`
+		Rsvp                 string  `json:"rsvp"`
+		IsWaitlisted         bool    `json:"isWaitlisted"`
+		ReplacedFromWaitlist bool    `json:"replacedFromWaitlist"`
+		LateCancellationFee  float64 `json:"lateCancellationFee"`
+	}
+
+	RsvpReminderResultDto struct {
+		Reminded int `json:"reminded"`
+	}
+
+	UnregisterResultDto struct {
+		ReplacedFromWaitlist bool    `json:"replacedFromWaitlist"`
+		LateCancellationFee  float64 `json:"lateCancellationFee"`
+	}
+
+	PlayedTimeDto struct {
+		From *time.Time `json:"from"`
+		To   *time.Time `json:"to"`
+	}
+
+	GuestDto struct {
+		Id             uint   `json:"id"`
+		RegistrationId uint   `json:"registrationId"`
+		Name           string `json:"name"`
+		PlayerId       *uint  `json:"playerId"`
+	}
+
+	LateCancellationFeeDto struct {
+		Id         uint      `json:"id"`
+		PlayerId   uint      `json:"playerId"`
+		PlayerName string    `json:"playerName"`
+		MatchId    uint      `json:"matchId"`
+		MatchStart time.Time `json:"matchStart"`
+		Amount     float64   `json:"amount"`
+		IsPaid     bool      `json:"isPaid"`
+	}
 )
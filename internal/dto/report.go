@@ -3,21 +3,29 @@ package dto
 import "time"
 
 type AdminOutstandingPaymentReportDto struct {
-	PlayerId            uint    `json:"playerId"`
-	PlayerName          string  `json:"playerName"`
-	Email               string  `json:"email"`
-	MatchCount          uint    `json:"matchCount"`
-	UnpaidAmount        float64 `json:"unpaidAmount"`
-	RegistrationSummary string  `json:"registrationSummary"`
+	PlayerId             uint    `json:"playerId"`
+	PlayerName           string  `json:"playerName"`
+	Email                string  `json:"email"`
+	MatchCount           uint    `json:"matchCount"`
+	UnpaidAmount         float64 `json:"unpaidAmount"`
+	RegistrationSummary  string  `json:"registrationSummary"`
+	LateCancellationFees float64 `json:"lateCancellationFees"`
+	EntryFeeCount        uint    `json:"entryFeeCount"`
+	EntryFees            float64 `json:"entryFees"`
 }
 
 type AnonymousOutstandingPaymentReportDto struct {
-	PlayerId            uint      `json:"playerId"`
-	TotalPlayerPaidFor  uint      `json:"totalPlayerPaidFor"`
-	PlayerName          string    `json:"playerName"`
-	PlayerEmail         string    `json:"playerEmail"`
-	MatchDate           time.Time `json:"matchDate"`
-	MatchCost           float64   `json:"matchCost"`
-	MatchAdditionalCost float64   `json:"matchAdditionalCost"`
-	MatchPlayerCount    uint      `json:"matchPlayerCount"`
+	PlayerId              uint      `json:"playerId"`
+	TotalPlayerPaidFor    uint      `json:"totalPlayerPaidFor"`
+	PlayerName            string    `json:"playerName"`
+	PlayerEmail           string    `json:"playerEmail"`
+	MatchId               uint      `json:"matchId"`
+	TournamentId          uint      `json:"tournamentId,omitempty"`
+	LateCancellationFeeId uint      `json:"lateCancellationFeeId,omitempty"`
+	MatchDate             time.Time `json:"matchDate"`
+	MatchCost             float64   `json:"matchCost"`
+	MatchAdditionalCost   float64   `json:"matchAdditionalCost"`
+	MatchPlayerCount      uint      `json:"matchPlayerCount"`
+	PlayedMinutes         float64   `json:"playedMinutes"`
+	Amount                float64   `json:"amount"`
 }
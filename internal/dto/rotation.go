@@ -0,0 +1,34 @@
+package dto
+
+type (
+	// GenerateRotationDto uses the booked courts, one round per 15 minutes and a random seed when not given
+	GenerateRotationDto struct {
+		Courts       int       `json:"courts"`
+		Rounds       int       `json:"rounds"`
+		Seed         *int64    `json:"seed"`
+		BalanceSkill bool      `json:"balanceSkill"`
+		Mixed        bool      `json:"mixed"`
+		FixedPairs   [][2]uint `json:"fixedPairs"`
+	}
+
+	RotationDto struct {
+		Id           uint               `json:"id"`
+		MatchId      uint               `json:"matchId"`
+		Seed         int64              `json:"seed"`
+		Courts       int                `json:"courts"`
+		CurrentRound int                `json:"currentRound"`
+		Rounds       []RotationRoundDto `json:"rounds"`
+	}
+
+	RotationRoundDto struct {
+		Number  int                `json:"number"`
+		Games   []RotationGameDto  `json:"games"`
+		SitOuts []PlayerSummaryDto `json:"sitOuts"`
+	}
+
+	RotationGameDto struct {
+		Court int                `json:"court"`
+		TeamA []PlayerSummaryDto `json:"teamA"`
+		TeamB []PlayerSummaryDto `json:"teamB"`
+	}
+)
@@ -3,3 +3,9 @@ package dto
 type MessageTemplateDto struct {
 	Template string `json:"template"`
 }
+
+// NoShowPolicyDto lowers the waitlist priority of players with this many no-shows, 0 disables it
+type NoShowPolicyDto struct {
+	Threshold    uint `json:"threshold"`
+	LookbackDays uint `json:"lookbackDays"`
+}
@@ -1,5 +1,7 @@
 package dto
 
+import "time"
+
 type (
 	SportCenterDto struct {
 		ID               uint    `json:"id"`
@@ -7,5 +9,103 @@ type (
 		Location         string  `json:"location"`
 		CostPerSection   float64 `json:"costPerSection"`
 		MinutePerSection uint    `json:"minutePerSection"`
+		Timezone         string  `json:"timezone"`
+	}
+
+	// PricingRuleDto uses Go weekday numbers (0 is Sunday) and "15:04" local times
+	PricingRuleDto struct {
+		Id             uint    `json:"id"`
+		Name           string  `json:"name"`
+		Weekdays       []int   `json:"weekdays"`
+		StartTime      string  `json:"startTime"`
+		EndTime        string  `json:"endTime"`
+		CostPerSection float64 `json:"costPerSection"`
+		Priority       int     `json:"priority"`
+		MemberOnly     bool    `json:"memberOnly"`
+	}
+
+	PricingQuoteRequestDto struct {
+		Start         time.Time `json:"start"`
+		End           time.Time `json:"end"`
+		MemberRate    bool      `json:"memberRate"`
+		CustomSection *float64  `json:"customSection"`
+	}
+
+	PricingQuoteDto struct {
+		Items []CostItemDto `json:"items"`
+		Cost  float64       `json:"cost"`
+	}
+
+	CostItemDto struct {
+		RuleId         *uint     `json:"ruleId"`
+		Description    string    `json:"description"`
+		Start          time.Time `json:"start"`
+		End            time.Time `json:"end"`
+		Minutes        float64   `json:"minutes"`
+		CostPerSection float64   `json:"costPerSection"`
+		Sections       float64   `json:"sections"`
+		Cost           float64   `json:"cost"`
+	}
+)
+
+type (
+	SportCenterPriceDto struct {
+		Id               uint      `json:"id"`
+		CostPerSection   float64   `json:"costPerSection"`
+		MinutePerSection uint      `json:"minutePerSection"`
+		EffectiveFrom    time.Time `json:"effectiveFrom"`
+	}
+
+	// ChangeSportCenterPriceDto takes effect immediately when no date is given
+	ChangeSportCenterPriceDto struct {
+		CostPerSection   float64    `json:"costPerSection"`
+		MinutePerSection uint       `json:"minutePerSection"`
+		EffectiveFrom    *time.Time `json:"effectiveFrom"`
+	}
+
+	PriceRecalculationDto struct {
+		SportCenterId    uint                    `json:"sportCenterId"`
+		Applied          bool                    `json:"applied"`
+		Matches          []MatchRecalculationDto `json:"matches"`
+		FinalizedMatches []uint                  `json:"finalizedMatches"`
+		// ManualMatches keep their cost, it was entered manually or every court has a custom rate
+		ManualMatches []uint  `json:"manualMatches"`
+		OldTotal      float64 `json:"oldTotal"`
+		NewTotal      float64 `json:"newTotal"`
+	}
+
+	MatchRecalculationDto struct {
+		MatchId           uint                `json:"matchId"`
+		Start             time.Time           `json:"start"`
+		OldCost           float64             `json:"oldCost"`
+		NewCost           float64             `json:"newCost"`
+		OldIndividualCost float64             `json:"oldIndividualCost"`
+		NewIndividualCost float64             `json:"newIndividualCost"`
+		Players           []PlayerCostDiffDto `json:"players"`
+	}
+
+	PlayerCostDiffDto struct {
+		PlayerId   uint    `json:"playerId"`
+		PlayerName string  `json:"playerName"`
+		OldCost    float64 `json:"oldCost"`
+		NewCost    float64 `json:"newCost"`
+		Diff       float64 `json:"diff"`
+	}
+)
+
+type (
+	// OpeningHoursDto uses Go weekday numbers (0 is Sunday) and "15:04" local times
+	OpeningHoursDto struct {
+		Weekday   int    `json:"weekday"`
+		OpenTime  string `json:"openTime"`
+		CloseTime string `json:"closeTime"`
+	}
+
+	// ClosureDto dates are "yyyy-mm-dd" local dates, both days included
+	ClosureDto struct {
+		Id       uint   `json:"id"`
+		FromDate string `json:"fromDate"`
+		ToDate   string `json:"toDate"`
+		Reason   string `json:"reason"`
 	}
 )
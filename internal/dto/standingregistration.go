@@ -0,0 +1,28 @@
+package dto
+
+import "time"
+
+type (
+	// StandingRegistrationRequestDto follows either a series or a weekday and start minute at a sport center
+	StandingRegistrationRequestDto struct {
+		SeriesId      *uint         `json:"seriesId"`
+		SportCenterId uint          `json:"sportCenterId"`
+		Weekday       *time.Weekday `json:"weekday"`
+		StartMinute   int           `json:"startMinute"`
+		PausedUntil   *time.Time    `json:"pausedUntil"`
+	}
+
+	StandingRegistrationDto struct {
+		Id              uint          `json:"id"`
+		SeriesId        *uint         `json:"seriesId"`
+		SportCenterId   *uint         `json:"sportCenterId"`
+		SportCenterName string        `json:"sportCenterName,omitempty"`
+		Weekday         *time.Weekday `json:"weekday"`
+		StartMinute     *int          `json:"startMinute"`
+		PausedUntil     *time.Time    `json:"pausedUntil"`
+	}
+
+	PauseStandingRegistrationDto struct {
+		PausedUntil *time.Time `json:"pausedUntil"`
+	}
+)
@@ -0,0 +1,38 @@
+package dto
+
+type (
+	// TeamSplitRequestDto splits the confirmed players of a match, three candidates and a random seed when not given
+	TeamSplitRequestDto struct {
+		Teams        int       `json:"teams" binding:"required,min=2"`
+		Candidates   int       `json:"candidates"`
+		Seed         *int64    `json:"seed"`
+		GenderQuota  bool      `json:"genderQuota"`
+		LevelQuota   bool      `json:"levelQuota"`
+		KeepTogether [][]uint  `json:"keepTogether"`
+		KeepApart    [][2]uint `json:"keepApart"`
+	}
+
+	TeamSplitDto struct {
+		Seed         int64                 `json:"seed"`
+		RatingSpread float64               `json:"ratingSpread"`
+		Score        float64               `json:"score"`
+		Teams        []TeamSplitMembersDto `json:"teams"`
+	}
+
+	TeamSplitMembersDto struct {
+		Rating  float64            `json:"rating"`
+		Players []PlayerSummaryDto `json:"players"`
+	}
+
+	// ApplyTeamSplitDto saves the chosen split as the event teams of the match, names default to Team 1, Team 2...
+	ApplyTeamSplitDto struct {
+		Teams [][]uint `json:"teams" binding:"required,min=2"`
+		Names []string `json:"names"`
+	}
+
+	EventTeamDto struct {
+		Id      uint               `json:"id"`
+		Name    string             `json:"name"`
+		Players []PlayerSummaryDto `json:"players"`
+	}
+)
@@ -0,0 +1,21 @@
+package dto
+
+type (
+	// TenantDto is a club or group of the current user with the user's player in it
+	TenantDto struct {
+		Id       uint   `json:"id"`
+		Name     string `json:"name"`
+		Slug     string `json:"slug"`
+		PlayerId uint   `json:"playerId"`
+	}
+
+	CreateTenantDto struct {
+		Name string `json:"name"`
+		Slug string `json:"slug"`
+	}
+
+	// AddTenantMemberDto adds the account registered with the email to the tenant
+	AddTenantMemberDto struct {
+		Email string `json:"email"`
+	}
+)
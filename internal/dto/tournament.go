@@ -0,0 +1,115 @@
+package dto
+
+import "time"
+
+type (
+	// CreateTournamentDto uses badminton scoring and 30 minute slots when not given
+	CreateTournamentDto struct {
+		Name               string            `json:"name" binding:"required"`
+		Format             string            `json:"format" binding:"required"`
+		EntryType          string            `json:"entryType" binding:"required"`
+		EntryFee           float64           `json:"entryFee"`
+		GroupCount         int               `json:"groupCount"`
+		QualifiersPerGroup int               `json:"qualifiersPerGroup"`
+		SlotMinutes        int               `json:"slotMinutes"`
+		Scoring            *ScoringFormatDto `json:"scoring"`
+	}
+
+	// TournamentEntryRequestDto is named after its players when no name is given
+	TournamentEntryRequestDto struct {
+		Name      string `json:"name"`
+		PlayerIds []uint `json:"playerIds" binding:"required"`
+	}
+
+	TournamentSessionsDto struct {
+		MatchIds []uint `json:"matchIds" binding:"required"`
+	}
+
+	BracketResultDto struct {
+		Sets []SetScoreDto `json:"sets" binding:"required"`
+	}
+
+	TournamentDto struct {
+		Id                 uint                 `json:"id"`
+		Name               string               `json:"name"`
+		Format             string               `json:"format"`
+		EntryType          string               `json:"entryType"`
+		EntryFee           float64              `json:"entryFee"`
+		Scoring            ScoringFormatDto     `json:"scoring"`
+		GroupCount         int                  `json:"groupCount"`
+		QualifiersPerGroup int                  `json:"qualifiersPerGroup"`
+		SlotMinutes        int                  `json:"slotMinutes"`
+		State              string               `json:"state"`
+		ShareCode          string               `json:"shareCode"`
+		WinnerEntryId      *uint                `json:"winnerEntryId"`
+		SessionIds         []uint               `json:"sessionIds"`
+		Entries            []TournamentEntryDto `json:"entries"`
+	}
+
+	TournamentEntryDto struct {
+		Id      uint                       `json:"id"`
+		Name    string                     `json:"name"`
+		Seed    int                        `json:"seed"`
+		Rating  float64                    `json:"rating"`
+		Group   int                        `json:"group"`
+		Players []TournamentEntryPlayerDto `json:"players"`
+	}
+
+	TournamentEntryPlayerDto struct {
+		PlayerId  uint   `json:"playerId"`
+		FirstName string `json:"firstName"`
+		LastName  string `json:"lastName"`
+		IsPaid    bool   `json:"isPaid"`
+	}
+
+	// BracketDto is the public view of a tournament, it is also shown through its share code
+	BracketDto struct {
+		TournamentId  uint               `json:"tournamentId"`
+		Name          string             `json:"name"`
+		Format        string             `json:"format"`
+		State         string             `json:"state"`
+		WinnerEntryId *uint              `json:"winnerEntryId"`
+		Entries       []BracketEntryDto  `json:"entries"`
+		Matches       []BracketMatchDto  `json:"matches"`
+		Standings     []GroupStandingDto `json:"standings"`
+	}
+
+	BracketEntryDto struct {
+		Id    uint   `json:"id"`
+		Name  string `json:"name"`
+		Seed  int    `json:"seed"`
+		Group int    `json:"group"`
+	}
+
+	BracketMatchDto struct {
+		Id            uint          `json:"id"`
+		Code          string        `json:"code"`
+		Stage         string        `json:"stage"`
+		Group         int           `json:"group"`
+		Round         int           `json:"round"`
+		Position      int           `json:"position"`
+		EntryAId      *uint         `json:"entryAId"`
+		EntryBId      *uint         `json:"entryBId"`
+		WinnerId      *uint         `json:"winnerId"`
+		IsFinished    bool          `json:"isFinished"`
+		IsBye         bool          `json:"isBye"`
+		Sets          []SetScoreDto `json:"sets"`
+		NextCode      string        `json:"nextCode"`
+		LoserNextCode string        `json:"loserNextCode"`
+		SessionId     *uint         `json:"sessionId"`
+		Court         string        `json:"court"`
+		StartAt       *time.Time    `json:"startAt"`
+	}
+
+	GroupStandingDto struct {
+		EntryId       uint `json:"entryId"`
+		Group         int  `json:"group"`
+		Rank          int  `json:"rank"`
+		Played        int  `json:"played"`
+		Won           int  `json:"won"`
+		SetsFor       int  `json:"setsFor"`
+		SetsAgainst   int  `json:"setsAgainst"`
+		PointsFor     int  `json:"pointsFor"`
+		PointsAgainst int  `json:"pointsAgainst"`
+	}
+)
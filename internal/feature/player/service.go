@@ -1,6 +1,8 @@
 package player
 
 import (
+	"context"
+
 	"github.com/tructn/racket/internal/domain"
 	"github.com/tructn/racket/internal/dto"
 	"github.com/tructn/racket/internal/service"
@@ -17,6 +19,12 @@ func NewPlayerService(db *gorm.DB) service.PlayerService {
 	}
 }
 
+func (s *playerService) WithContext(ctx context.Context) service.PlayerService {
+	clone := *s
+	clone.db = s.db.WithContext(ctx)
+	return &clone
+}
+
 func (s *playerService) GetPlayerSummary(playerId uint) (*dto.PlayerSummaryDto, error) {
 	model := &dto.PlayerSummaryDto{}
 	err := s.db.Model(&domain.Player{}).
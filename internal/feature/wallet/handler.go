@@ -25,7 +25,7 @@ func NewWalletHandler(db *gorm.DB, logger *zap.SugaredLogger) *WalletHandler {
 
 func (h *WalletHandler) GetAll(c *gin.Context) {
 	var wallets []domain.Wallet
-	if err := h.db.Preload("Owner").Find(&wallets).Error; err != nil {
+	if err := h.db.WithContext(c).Preload("Owner").Find(&wallets).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			c.JSON(400, gin.H{"error": "No wallets found"})
 			return
@@ -55,7 +55,7 @@ func (h *WalletHandler) Create(c *gin.Context) {
 	}
 
 	var exists bool
-	if err := h.db.Find(&domain.Wallet{}, "owner_id = ?", dto.OwnerId).Select("count(1) > 0").Scan(&exists).Error; err != nil {
+	if err := h.db.WithContext(c).Find(&domain.Wallet{}, "owner_id = ?", dto.OwnerId).Select("count(1) > 0").Scan(&exists).Error; err != nil {
 		h.logger.Errorw("Failed to check if wallet exists", "error", err)
 		c.AbortWithError(500, err)
 		return
@@ -67,7 +67,7 @@ func (h *WalletHandler) Create(c *gin.Context) {
 	}
 
 	wallet := domain.NewWallet(dto.OwnerId, dto.Name)
-	if err := h.db.Create(&wallet).Error; err != nil {
+	if err := h.db.WithContext(c).Create(&wallet).Error; err != nil {
 		h.logger.Errorw("Failed to create wallet", "error", err)
 		c.AbortWithError(500, err)
 		return
@@ -18,6 +18,6 @@ func NewActivityHandler(activitysvc *service.ActivityService) *ActivityHandler {
 }
 
 func (h *ActivityHandler) GetAll(c *gin.Context) {
-	res := h.activitysvc.Get()
+	res := h.activitysvc.WithContext(c).Get()
 	c.JSON(http.StatusOK, res)
 }
@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 	"sort"
 	"strconv"
@@ -12,6 +13,8 @@ import (
 	"github.com/tructn/racket/internal/domain"
 	"github.com/tructn/racket/internal/dto"
 	"github.com/tructn/racket/internal/service"
+	"github.com/tructn/racket/pkg/result"
+	"github.com/tructn/racket/pkg/scopes"
 	"gorm.io/gorm"
 )
 
@@ -35,12 +38,27 @@ type (
 )
 
 type AnonymousHandler struct {
-	db             *gorm.DB
-	paymentservice *service.PaymentService
+	db                *gorm.DB
+	paymentservice    *service.PaymentService
+	calendarService   *service.CalendarService
+	tournamentService *service.TournamentService
+	pollService       *service.PollService
 }
 
-func NewAnonymousHandler(db *gorm.DB, paymentservice *service.PaymentService) *AnonymousHandler {
-	return &AnonymousHandler{db: db, paymentservice: paymentservice}
+func NewAnonymousHandler(
+	db *gorm.DB,
+	paymentservice *service.PaymentService,
+	calendarService *service.CalendarService,
+	tournamentService *service.TournamentService,
+	pollService *service.PollService,
+) *AnonymousHandler {
+	return &AnonymousHandler{
+		db:                db,
+		paymentservice:    paymentservice,
+		calendarService:   calendarService,
+		tournamentService: tournamentService,
+		pollService:       pollService,
+	}
 }
 
 func (h *AnonymousHandler) UseRouter(router *gin.RouterGroup) {
@@ -48,7 +66,71 @@ func (h *AnonymousHandler) UseRouter(router *gin.RouterGroup) {
 	{
 		group.POST("/webhooks/auth0", h.syncUserWebHook)
 		group.GET("/reports/outstanding-payments", h.getOutstandingPaymentReport)
+		group.GET("/calendars/:token/calendar.ics", h.getCalendarFeed)
+		group.GET("/tournaments/:shareCode/bracket", h.getTournamentBracket)
+		group.GET("/polls/:shareCode", h.getPoll)
+		group.PUT("/polls/:shareCode/responses", h.respondToPoll)
+	}
+}
+
+// Brackets are shared with players and spectators who do not have an account
+func (h *AnonymousHandler) getTournamentBracket(c *gin.Context) {
+	bracket, err := h.tournamentService.WithContext(scopes.AllTenants(c)).GetBracketByShareCode(c.Param("shareCode"))
+	if errors.Is(err, result.ErrorNotFound) {
+		c.AbortWithStatus(http.StatusNotFound)
+		return
+	}
+
+	if err != nil {
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusOK, bracket)
+}
+
+// Polls are answered by players who may not have an account yet
+func (h *AnonymousHandler) getPoll(c *gin.Context) {
+	poll, err := h.pollService.WithContext(scopes.AllTenants(c)).GetByShareCode(c.Param("shareCode"))
+	if err != nil {
+		abortWithPollError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, poll)
+}
+
+func (h *AnonymousHandler) respondToPoll(c *gin.Context) {
+	var req dto.PollResponseRequestDto
+	if err := c.BindJSON(&req); err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	poll, err := h.pollService.WithContext(scopes.AllTenants(c)).RespondAnonymously(c.Param("shareCode"), req)
+	if err != nil {
+		abortWithPollError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, poll)
+}
+
+// The feed token is the credential here, calendar clients can not do the Auth0 login flow
+func (h *AnonymousHandler) getCalendarFeed(c *gin.Context) {
+	token := c.Param("token")
+	cal, err := h.calendarService.WithContext(scopes.AllTenants(c)).RenderFeed(token)
+	if errors.Is(err, result.ErrorNotFound) {
+		c.AbortWithStatus(http.StatusNotFound)
+		return
 	}
+
+	if err != nil {
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	c.Data(http.StatusOK, calendarContentType, []byte(cal))
 }
 
 func (h *AnonymousHandler) getOutstandingPaymentReport(c *gin.Context) {
@@ -59,12 +141,13 @@ func (h *AnonymousHandler) getOutstandingPaymentReport(c *gin.Context) {
 	}
 
 	var shareCodeUrl domain.ShareCode
-	if err := h.db.Where("code = ?", shareCode).First(&shareCodeUrl).Error; err != nil {
+	if err := h.db.WithContext(scopes.AllTenants(c)).Where("code = ?", shareCode).First(&shareCodeUrl).Error; err != nil {
 		c.AbortWithStatus(http.StatusUnauthorized)
 		return
 	}
 
-	data, err := h.paymentservice.GetOutstandingPaymentReportForAnonymous()
+	// The report only covers the tenant the share code was created in
+	data, err := h.paymentservice.WithContext(scopes.WithTenant(c, shareCodeUrl.TenantId)).GetOutstandingPaymentReportForAnonymous()
 	if err != nil {
 		c.AbortWithStatus(http.StatusInternalServerError)
 		return
@@ -77,16 +160,13 @@ func (h *AnonymousHandler) getOutstandingPaymentReport(c *gin.Context) {
 	aggregation := lo.MapValues(groupedByPlayer, func(value []dto.AnonymousOutstandingPaymentReportDto, key string) player {
 		items := groupedByPlayer[key]
 		matches := lo.Map(items, func(item dto.AnonymousOutstandingPaymentReportDto, index int) match {
-			matchCost := item.MatchCost + item.MatchAdditionalCost
-			individualCost := float64(item.TotalPlayerPaidFor) * (matchCost / float64(item.MatchPlayerCount))
-
 			return match{
 				Date:               item.MatchDate,
-				MatchCost:          matchCost,
+				MatchCost:          item.MatchCost + item.MatchAdditionalCost,
 				TotalPlayerPaidFor: item.TotalPlayerPaidFor,
 				AdditionalCost:     item.MatchAdditionalCost,
 				MatchPlayerCount:   item.MatchPlayerCount,
-				IndividualCost:     individualCost,
+				IndividualCost:     item.Amount,
 			}
 		})
 
@@ -130,8 +210,15 @@ func (h *AnonymousHandler) syncUserWebHook(c *gin.Context) {
 		return
 	}
 
+	// New users join the default tenant, other tenants add them as members
 	err := h.db.Transaction(func(tx *gorm.DB) error {
+		tenant := domain.Tenant{}
+		if err := tx.Where("slug = ?", domain.DefaultTenantSlug).First(&tenant).Error; err != nil {
+			return err
+		}
+
 		player := domain.NewPlayer(req.UserID, req.Email, req.FirstName, req.LastName)
+		player.TenantId = tenant.ID
 		if err := tx.Create(player).Error; err != nil {
 			return err
 		}
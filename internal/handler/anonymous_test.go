@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tructn/racket/internal/db/dbtest"
+	"github.com/tructn/racket/internal/domain"
+	"github.com/tructn/racket/internal/service"
+	"github.com/tructn/racket/pkg/scopes"
+	"go.uber.org/zap"
+)
+
+func TestCalendarFeedIsRenderedInTheTenantOfTheToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	conn := dbtest.Open(t)
+	tenantDb := conn.WithContext(scopes.WithTenant(context.Background(), 2))
+
+	player := &domain.Player{FirstName: "Anna"}
+	require.NoError(t, tenantDb.Create(player).Error)
+	start := time.Now().Add(48 * time.Hour)
+	match := &domain.Match{Start: start, End: start.Add(time.Hour), State: domain.MatchOpen}
+	require.NoError(t, tenantDb.Create(match).Error)
+	require.NoError(t, tenantDb.Create(domain.NewRegistration(player.ID, match.ID)).Error)
+
+	calendarService := service.NewCalendarService(conn, zap.NewNop().Sugar())
+	feed, err := calendarService.WithContext(scopes.WithTenant(context.Background(), 2)).GetOrCreatePlayerFeed(player.ID)
+	require.NoError(t, err)
+
+	handler := NewAnonymousHandler(conn, nil, calendarService, nil, nil)
+	router := gin.New()
+	handler.UseRouter(router.Group(""))
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, fmt.Sprintf("/anonymous/calendars/%s/calendar.ics", feed.Token), nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "BEGIN:VEVENT")
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/anonymous/calendars/unknown/calendar.ics", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,136 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tructn/racket/internal/domain"
+	"github.com/tructn/racket/internal/dto"
+	"github.com/tructn/racket/internal/service"
+	"github.com/tructn/racket/pkg/currentuser"
+	"github.com/tructn/racket/pkg/middleware"
+	"github.com/tructn/racket/pkg/result"
+	"github.com/tructn/racket/pkg/util"
+	"gorm.io/gorm"
+)
+
+type AttendanceHandler struct {
+	db                *gorm.DB
+	attendanceService *service.AttendanceService
+}
+
+func NewAttendanceHandler(db *gorm.DB, attendanceService *service.AttendanceService) *AttendanceHandler {
+	return &AttendanceHandler{
+		db:                db,
+		attendanceService: attendanceService,
+	}
+}
+
+func (h *AttendanceHandler) UseRouter(router *gin.RouterGroup) {
+	// The code is shown at the venue, players who could read it remotely would check in without coming
+	router.GET("/matches/:matchId/check-in-code", middleware.AdminRequired(), h.getCheckInCode)
+	router.POST("/matches/:matchId/check-in", h.checkIn)
+	router.PUT("/matches/:matchId/attendance", middleware.AdminRequired(), h.markAttendance)
+	router.GET("/players/:playerId/reliability", h.getPlayerReliability)
+	router.GET("/reports/reliability", h.getReliability)
+}
+
+func (h *AttendanceHandler) getCheckInCode(c *gin.Context) {
+	matchId := util.GetIntRouteParam(c, "matchId")
+	code, err := h.attendanceService.WithContext(c).GetCheckInCode(matchId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "match not found"})
+		return
+	}
+
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, code)
+}
+
+func (h *AttendanceHandler) checkIn(c *gin.Context) {
+	var req dto.CheckInDto
+	if err := c.BindJSON(&req); err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	playerId, err := currentuser.GetPlayerId(c, h.db)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	matchId := util.GetIntRouteParam(c, "matchId")
+	attendance, err := h.attendanceService.WithContext(c).CheckIn(playerId, matchId, req.Code)
+
+	if errors.Is(err, gorm.ErrRecordNotFound) || errors.Is(err, result.ErrorNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "registration not found"})
+		return
+	}
+
+	if errors.Is(err, domain.ErrInvalidCheckInCode) || errors.Is(err, domain.ErrCheckInClosed) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, attendance)
+}
+
+func (h *AttendanceHandler) markAttendance(c *gin.Context) {
+	var items []dto.AttendanceDto
+	if err := c.BindJSON(&items); err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	matchId := util.GetIntRouteParam(c, "matchId")
+	res, err := h.attendanceService.WithContext(c).MarkAttendance(matchId, items)
+
+	if errors.Is(err, result.ErrorNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "registration not found"})
+		return
+	}
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (h *AttendanceHandler) getPlayerReliability(c *gin.Context) {
+	playerId := util.GetIntRouteParam(c, "playerId")
+	res, err := h.attendanceService.WithContext(c).GetReliability(playerId)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	if len(res) == 0 {
+		c.JSON(http.StatusOK, dto.PlayerReliabilityDto{PlayerId: playerId})
+		return
+	}
+
+	c.JSON(http.StatusOK, res[0])
+}
+
+func (h *AttendanceHandler) getReliability(c *gin.Context) {
+	res, err := h.attendanceService.WithContext(c).GetReliability(0)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
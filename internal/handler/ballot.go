@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tructn/racket/internal/domain"
+	"github.com/tructn/racket/internal/dto"
+	"github.com/tructn/racket/internal/service"
+	"github.com/tructn/racket/pkg/util"
+	"gorm.io/gorm"
+)
+
+type BallotHandler struct {
+	ballotService *service.BallotService
+}
+
+func NewBallotHandler(ballotService *service.BallotService) *BallotHandler {
+	return &BallotHandler{ballotService: ballotService}
+}
+
+func (h *BallotHandler) UseRouter(router *gin.RouterGroup) {
+	router.GET("/matches/:matchId/ballot", h.get)
+	router.PUT("/matches/:matchId/ballot", h.setBallot)
+	router.POST("/matches/:matchId/ballot/draw", h.draw)
+	router.POST("/ballots/draw-due", h.drawDue)
+}
+
+func (h *BallotHandler) get(c *gin.Context) {
+	matchId := util.GetIntRouteParam(c, "matchId")
+	ballot, err := h.ballotService.WithContext(c).Get(matchId)
+	if err != nil {
+		abortWithBallotError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ballot)
+}
+
+func (h *BallotHandler) setBallot(c *gin.Context) {
+	matchId := util.GetIntRouteParam(c, "matchId")
+	var req dto.BallotRequestDto
+	if err := c.BindJSON(&req); err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	if err := h.ballotService.WithContext(c).SetBallot(matchId, req); err != nil {
+		abortWithBallotError(c, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+func (h *BallotHandler) draw(c *gin.Context) {
+	matchId := util.GetIntRouteParam(c, "matchId")
+	ballot, err := h.ballotService.WithContext(c).Draw(matchId)
+	if err != nil {
+		abortWithBallotError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ballot)
+}
+
+// drawDue runs the due draws straight away, the scheduler also runs them every few minutes
+func (h *BallotHandler) drawDue(c *gin.Context) {
+	res, err := h.ballotService.WithContext(c).DrawDue()
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func abortWithBallotError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "match not found"})
+	case errors.Is(err, domain.ErrNoBallot):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrBallotDrawn), errors.Is(err, domain.ErrBallotNotDue):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrBallotTooLate):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.AbortWithError(http.StatusInternalServerError, err)
+	}
+}
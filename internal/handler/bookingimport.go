@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tructn/racket/internal/dto"
+	"github.com/tructn/racket/internal/service"
+	"go.uber.org/zap"
+)
+
+type BookingImportHandler struct {
+	logger               *zap.SugaredLogger
+	bookingImportService *service.BookingImportService
+}
+
+func NewBookingImportHandler(logger *zap.SugaredLogger, bookingImportService *service.BookingImportService) *BookingImportHandler {
+	return &BookingImportHandler{
+		logger:               logger,
+		bookingImportService: bookingImportService,
+	}
+}
+
+func (h *BookingImportHandler) UseRouter(router *gin.RouterGroup) {
+	group := router.Group("/imports/bookings")
+	{
+		group.POST("/preview", h.preview)
+		group.POST("", h.importBookings)
+	}
+}
+
+// preview expects a multipart form with the booking file in "file"
+func (h *BookingImportHandler) preview(c *gin.Context) {
+	h.handle(c, h.bookingImportService.WithContext(c).Preview)
+}
+
+func (h *BookingImportHandler) importBookings(c *gin.Context) {
+	h.handle(c, h.bookingImportService.WithContext(c).Import)
+}
+
+func (h *BookingImportHandler) handle(
+	c *gin.Context,
+	run func(filename string, r io.Reader, opts dto.BookingImportOptions) (*dto.BookingImportResultDto, error),
+) {
+	var opts dto.BookingImportOptions
+	if err := c.ShouldBind(&opts); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "file is mandatory"})
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	defer file.Close()
+
+	res, err := run(fileHeader.Filename, file, opts)
+	if errors.Is(err, service.ErrUnsupportedImportFormat) || errors.Is(err, service.ErrInvalidImportFile) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err != nil {
+		h.logger.Errorw("Failed to import bookings", "file", fileHeader.Filename, "error", err)
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
@@ -0,0 +1,147 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tructn/racket/internal/domain"
+	"github.com/tructn/racket/internal/dto"
+	"github.com/tructn/racket/internal/service"
+	"github.com/tructn/racket/pkg/currentuser"
+	"github.com/tructn/racket/pkg/result"
+	"github.com/tructn/racket/pkg/util"
+	"gorm.io/gorm"
+)
+
+const calendarContentType = "text/calendar; charset=utf-8"
+
+type CalendarHandler struct {
+	db              *gorm.DB
+	calendarService *service.CalendarService
+}
+
+func NewCalendarHandler(db *gorm.DB, calendarService *service.CalendarService) *CalendarHandler {
+	return &CalendarHandler{
+		db:              db,
+		calendarService: calendarService,
+	}
+}
+
+func (h *CalendarHandler) UseRouter(router *gin.RouterGroup) {
+	group := router.Group("/calendar-feeds")
+	{
+		group.GET("/me", h.getMyFeed)
+		group.DELETE("/me", h.revokeMyFeed)
+		group.GET("/teams/:teamId", h.getTeamFeed)
+		group.DELETE("/teams/:teamId", h.revokeTeamFeed)
+	}
+}
+
+func (h *CalendarHandler) getMyFeed(c *gin.Context) {
+	playerId, err := currentuser.GetPlayerId(c, h.db)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	feed, err := h.calendarService.WithContext(c).GetOrCreatePlayerFeed(playerId)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, toCalendarFeedDto(c, feed))
+}
+
+func (h *CalendarHandler) revokeMyFeed(c *gin.Context) {
+	playerId, err := currentuser.GetPlayerId(c, h.db)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	if err := h.calendarService.WithContext(c).RevokePlayerFeed(playerId); err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+func (h *CalendarHandler) getTeamFeed(c *gin.Context) {
+	teamId := util.GetIntRouteParam(c, "teamId")
+	playerId, idpUserId, err := h.getCurrentUser(c)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	feed, err := h.calendarService.WithContext(c).GetOrCreateTeamFeed(teamId, playerId, idpUserId)
+	if errors.Is(err, result.ErrorNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "team not found"})
+		return
+	}
+
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, toCalendarFeedDto(c, feed))
+}
+
+func (h *CalendarHandler) revokeTeamFeed(c *gin.Context) {
+	teamId := util.GetIntRouteParam(c, "teamId")
+	playerId, idpUserId, err := h.getCurrentUser(c)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	err = h.calendarService.WithContext(c).RevokeTeamFeed(teamId, playerId, idpUserId)
+	if errors.Is(err, result.ErrorNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "team not found"})
+		return
+	}
+
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+func (h *CalendarHandler) getCurrentUser(c *gin.Context) (uint, string, error) {
+	idpUserId, err := currentuser.GetIdpUserId(c)
+	if err != nil {
+		return 0, "", err
+	}
+
+	playerId, err := currentuser.GetPlayerId(c, h.db)
+	if err != nil {
+		return 0, "", err
+	}
+
+	return playerId, idpUserId, nil
+}
+
+func toCalendarFeedDto(c *gin.Context, feed *domain.CalendarFeed) dto.CalendarFeedDto {
+	scheme := "http"
+	if c.Request.TLS != nil {
+		scheme = "https"
+	}
+	if proto := c.GetHeader("X-Forwarded-Proto"); proto != "" {
+		scheme = proto
+	}
+
+	path := fmt.Sprintf("%s/api/v1/anonymous/calendars/%s/calendar.ics", c.Request.Host, feed.Token)
+
+	return dto.CalendarFeedDto{
+		Token:     feed.Token,
+		Url:       fmt.Sprintf("%s://%s", scheme, path),
+		WebcalUrl: fmt.Sprintf("webcal://%s", path),
+	}
+}
@@ -0,0 +1,138 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tructn/racket/internal/domain"
+	"github.com/tructn/racket/internal/dto"
+	"github.com/tructn/racket/internal/service"
+	"github.com/tructn/racket/pkg/currentuser"
+	"github.com/tructn/racket/pkg/result"
+	"github.com/tructn/racket/pkg/util"
+	"gorm.io/gorm"
+)
+
+type GameHandler struct {
+	gameService *service.GameService
+}
+
+func NewGameHandler(gameService *service.GameService) *GameHandler {
+	return &GameHandler{
+		gameService: gameService,
+	}
+}
+
+func (h *GameHandler) UseRouter(router *gin.RouterGroup) {
+	router.GET("/matches/:matchId/games", h.getAll)
+	router.POST("/matches/:matchId/games", h.create)
+	router.PUT("/matches/:matchId/games/:gameId", h.update)
+	router.DELETE("/matches/:matchId/games/:gameId", h.delete)
+	router.GET("/matches/:matchId/games/:gameId/history", h.getHistory)
+	router.GET("/reports/player-stats", h.getPlayerStats)
+}
+
+func (h *GameHandler) getAll(c *gin.Context) {
+	matchId := util.GetIntRouteParam(c, "matchId")
+	games, err := h.gameService.WithContext(c).GetGames(matchId)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, games)
+}
+
+func (h *GameHandler) create(c *gin.Context) {
+	var req dto.GameRequestDto
+	if err := c.BindJSON(&req); err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	editedBy, err := currentuser.GetIdpUserId(c)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	matchId := util.GetIntRouteParam(c, "matchId")
+	game, err := h.gameService.WithContext(c).CreateGame(matchId, editedBy, req)
+	if err != nil {
+		abortWithGameError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, game)
+}
+
+func (h *GameHandler) update(c *gin.Context) {
+	var req dto.GameRequestDto
+	if err := c.BindJSON(&req); err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	editedBy, err := currentuser.GetIdpUserId(c)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	matchId := util.GetIntRouteParam(c, "matchId")
+	gameId := util.GetIntRouteParam(c, "gameId")
+	game, err := h.gameService.WithContext(c).UpdateGame(matchId, gameId, editedBy, req)
+	if err != nil {
+		abortWithGameError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, game)
+}
+
+func (h *GameHandler) delete(c *gin.Context) {
+	matchId := util.GetIntRouteParam(c, "matchId")
+	gameId := util.GetIntRouteParam(c, "gameId")
+	if err := h.gameService.WithContext(c).DeleteGame(matchId, gameId); err != nil {
+		abortWithGameError(c, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+func (h *GameHandler) getHistory(c *gin.Context) {
+	matchId := util.GetIntRouteParam(c, "matchId")
+	gameId := util.GetIntRouteParam(c, "gameId")
+	history, err := h.gameService.WithContext(c).GetHistory(matchId, gameId)
+	if err != nil {
+		abortWithGameError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, history)
+}
+
+func (h *GameHandler) getPlayerStats(c *gin.Context) {
+	stats, err := h.gameService.WithContext(c).GetPlayerStats()
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
+
+func abortWithGameError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "match not found"})
+	case errors.Is(err, result.ErrorNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "game not found"})
+	case errors.Is(err, domain.ErrPlayerNotInMatch):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	}
+}
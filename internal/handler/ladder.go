@@ -0,0 +1,224 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tructn/racket/internal/domain"
+	"github.com/tructn/racket/internal/dto"
+	"github.com/tructn/racket/internal/service"
+	"github.com/tructn/racket/pkg/currentuser"
+	"github.com/tructn/racket/pkg/result"
+	"github.com/tructn/racket/pkg/util"
+	"gorm.io/gorm"
+)
+
+type LadderHandler struct {
+	db            *gorm.DB
+	ladderService *service.LadderService
+}
+
+func NewLadderHandler(db *gorm.DB, ladderService *service.LadderService) *LadderHandler {
+	return &LadderHandler{
+		db:            db,
+		ladderService: ladderService,
+	}
+}
+
+func (h *LadderHandler) UseRouter(router *gin.RouterGroup) {
+	group := router.Group("/ladders")
+	{
+		group.GET("", h.getAll)
+		group.POST("", h.create)
+		group.GET("/:ladderId", h.get)
+		group.GET("/:ladderId/history", h.getHistory)
+		group.POST("/:ladderId/players/:playerId", h.join)
+		group.DELETE("/:ladderId/players/:playerId", h.leave)
+		group.POST("/:ladderId/challenges", h.challenge)
+		group.PUT("/:ladderId/challenges/:challengeId/response", h.respond)
+		group.PUT("/:ladderId/challenges/:challengeId/schedule", h.schedule)
+		group.PUT("/:ladderId/challenges/:challengeId/result", h.recordResult)
+	}
+}
+
+func (h *LadderHandler) getAll(c *gin.Context) {
+	ladders, err := h.ladderService.WithContext(c).GetAll()
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ladders)
+}
+
+func (h *LadderHandler) create(c *gin.Context) {
+	var req dto.LadderRequestDto
+	if err := c.BindJSON(&req); err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	ladder, err := h.ladderService.WithContext(c).Create(req)
+	if err != nil {
+		abortWithLadderError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, ladder)
+}
+
+func (h *LadderHandler) get(c *gin.Context) {
+	ladderId := util.GetIntRouteParam(c, "ladderId")
+	ladder, err := h.ladderService.WithContext(c).Get(ladderId)
+	if err != nil {
+		abortWithLadderError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ladder)
+}
+
+func (h *LadderHandler) getHistory(c *gin.Context) {
+	ladderId := util.GetIntRouteParam(c, "ladderId")
+	history, err := h.ladderService.WithContext(c).GetHistory(ladderId)
+	if err != nil {
+		abortWithLadderError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, history)
+}
+
+func (h *LadderHandler) join(c *gin.Context) {
+	ladderId := util.GetIntRouteParam(c, "ladderId")
+	playerId := util.GetIntRouteParam(c, "playerId")
+	ladder, err := h.ladderService.WithContext(c).Join(ladderId, playerId)
+	if err != nil {
+		abortWithLadderError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ladder)
+}
+
+func (h *LadderHandler) leave(c *gin.Context) {
+	ladderId := util.GetIntRouteParam(c, "ladderId")
+	playerId := util.GetIntRouteParam(c, "playerId")
+	ladder, err := h.ladderService.WithContext(c).Leave(ladderId, playerId)
+	if err != nil {
+		abortWithLadderError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ladder)
+}
+
+func (h *LadderHandler) challenge(c *gin.Context) {
+	var req dto.ChallengeRequestDto
+	if err := c.BindJSON(&req); err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	playerId, err := currentuser.GetPlayerId(c, h.db)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	ladderId := util.GetIntRouteParam(c, "ladderId")
+	ladder, err := h.ladderService.WithContext(c).Challenge(ladderId, playerId, req)
+	if err != nil {
+		abortWithLadderError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, ladder)
+}
+
+func (h *LadderHandler) respond(c *gin.Context) {
+	var req dto.ChallengeResponseDto
+	if err := c.BindJSON(&req); err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	playerId, err := currentuser.GetPlayerId(c, h.db)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	ladderId := util.GetIntRouteParam(c, "ladderId")
+	challengeId := util.GetIntRouteParam(c, "challengeId")
+	ladder, err := h.ladderService.WithContext(c).Respond(ladderId, challengeId, playerId, req)
+	if err != nil {
+		abortWithLadderError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ladder)
+}
+
+func (h *LadderHandler) schedule(c *gin.Context) {
+	var req dto.ScheduleChallengeDto
+	if err := c.BindJSON(&req); err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	ladderId := util.GetIntRouteParam(c, "ladderId")
+	challengeId := util.GetIntRouteParam(c, "challengeId")
+	ladder, err := h.ladderService.WithContext(c).Schedule(ladderId, challengeId, req)
+	if err != nil {
+		abortWithLadderError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ladder)
+}
+
+func (h *LadderHandler) recordResult(c *gin.Context) {
+	var req dto.ChallengeResultDto
+	if err := c.BindJSON(&req); err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	editedBy, err := currentuser.GetIdpUserId(c)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	ladderId := util.GetIntRouteParam(c, "ladderId")
+	challengeId := util.GetIntRouteParam(c, "challengeId")
+	ladder, err := h.ladderService.WithContext(c).RecordResult(ladderId, challengeId, editedBy, req)
+	if err != nil {
+		abortWithLadderError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ladder)
+}
+
+func abortWithLadderError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, result.ErrorNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "ladder not found"})
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "match not found"})
+	case errors.Is(err, domain.ErrNotOnLadder), errors.Is(err, domain.ErrChallengeNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrNotChallengedPlayer):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrAlreadyOnLadder),
+		errors.Is(err, domain.ErrChallengeAlreadyOpen),
+		errors.Is(err, domain.ErrChallengeNotPending),
+		errors.Is(err, domain.ErrChallengeNotAccepted):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,212 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tructn/racket/internal/domain"
+	"github.com/tructn/racket/internal/dto"
+	"github.com/tructn/racket/internal/service"
+	"github.com/tructn/racket/pkg/result"
+	"github.com/tructn/racket/pkg/util"
+	"gorm.io/gorm"
+)
+
+const csvContentType = "text/csv; charset=utf-8"
+
+type LeagueHandler struct {
+	leagueService *service.LeagueService
+}
+
+func NewLeagueHandler(leagueService *service.LeagueService) *LeagueHandler {
+	return &LeagueHandler{
+		leagueService: leagueService,
+	}
+}
+
+func (h *LeagueHandler) UseRouter(router *gin.RouterGroup) {
+	router.GET("/seasons", h.getSeasons)
+	router.POST("/seasons", h.createSeason)
+	router.POST("/seasons/:seasonId/leagues", h.createLeague)
+
+	group := router.Group("/leagues/:leagueId")
+	{
+		group.GET("", h.getLeague)
+		group.POST("/fixtures", h.generateFixtures)
+		group.PUT("/fixtures/:fixtureId/result", h.recordResult)
+		group.PUT("/fixtures/:fixtureId/postpone", h.postpone)
+		group.PUT("/fixtures/:fixtureId/reschedule", h.reschedule)
+		group.GET("/standings", h.getStandings)
+		group.GET("/standings/export", h.exportStandings)
+	}
+}
+
+func (h *LeagueHandler) getSeasons(c *gin.Context) {
+	seasons, err := h.leagueService.WithContext(c).GetSeasons()
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, seasons)
+}
+
+func (h *LeagueHandler) createSeason(c *gin.Context) {
+	var req dto.SeasonRequestDto
+	if err := c.BindJSON(&req); err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	season, err := h.leagueService.WithContext(c).CreateSeason(req)
+	if err != nil {
+		abortWithLeagueError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, season)
+}
+
+func (h *LeagueHandler) createLeague(c *gin.Context) {
+	var req dto.LeagueRequestDto
+	if err := c.BindJSON(&req); err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	seasonId := util.GetIntRouteParam(c, "seasonId")
+	league, err := h.leagueService.WithContext(c).CreateLeague(seasonId, req)
+	if errors.Is(err, result.ErrorNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "season not found"})
+		return
+	}
+
+	if err != nil {
+		abortWithLeagueError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, league)
+}
+
+func (h *LeagueHandler) getLeague(c *gin.Context) {
+	leagueId := util.GetIntRouteParam(c, "leagueId")
+	league, err := h.leagueService.WithContext(c).GetLeague(leagueId)
+	if err != nil {
+		abortWithLeagueError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, league)
+}
+
+func (h *LeagueHandler) generateFixtures(c *gin.Context) {
+	var req dto.GenerateFixturesDto
+	if err := c.BindJSON(&req); err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	leagueId := util.GetIntRouteParam(c, "leagueId")
+	league, err := h.leagueService.WithContext(c).GenerateFixtures(leagueId, req)
+	if err != nil {
+		abortWithLeagueError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, league)
+}
+
+func (h *LeagueHandler) recordResult(c *gin.Context) {
+	var req dto.FixtureResultDto
+	if err := c.BindJSON(&req); err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	leagueId := util.GetIntRouteParam(c, "leagueId")
+	fixtureId := util.GetIntRouteParam(c, "fixtureId")
+	league, err := h.leagueService.WithContext(c).RecordResult(leagueId, fixtureId, req)
+	if err != nil {
+		abortWithLeagueError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, league)
+}
+
+func (h *LeagueHandler) postpone(c *gin.Context) {
+	var req dto.PostponeFixtureDto
+	if err := c.BindJSON(&req); err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	leagueId := util.GetIntRouteParam(c, "leagueId")
+	fixtureId := util.GetIntRouteParam(c, "fixtureId")
+	league, err := h.leagueService.WithContext(c).Postpone(leagueId, fixtureId, req)
+	if err != nil {
+		abortWithLeagueError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, league)
+}
+
+func (h *LeagueHandler) reschedule(c *gin.Context) {
+	var req dto.RescheduleFixtureDto
+	if err := c.BindJSON(&req); err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	leagueId := util.GetIntRouteParam(c, "leagueId")
+	fixtureId := util.GetIntRouteParam(c, "fixtureId")
+	league, err := h.leagueService.WithContext(c).Reschedule(leagueId, fixtureId, req)
+	if err != nil {
+		abortWithLeagueError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, league)
+}
+
+func (h *LeagueHandler) getStandings(c *gin.Context) {
+	leagueId := util.GetIntRouteParam(c, "leagueId")
+	standings, err := h.leagueService.WithContext(c).GetStandings(leagueId)
+	if err != nil {
+		abortWithLeagueError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, standings)
+}
+
+func (h *LeagueHandler) exportStandings(c *gin.Context) {
+	leagueId := util.GetIntRouteParam(c, "leagueId")
+	content, err := h.leagueService.WithContext(c).ExportStandings(leagueId)
+	if err != nil {
+		abortWithLeagueError(c, err)
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=league-%d-standings.csv", leagueId))
+	c.Data(http.StatusOK, csvContentType, content)
+}
+
+func abortWithLeagueError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, result.ErrorNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "league not found"})
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "match not found"})
+	case errors.Is(err, domain.ErrFixtureNotFound), errors.Is(err, service.ErrTeamNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrFixturePostponed), errors.Is(err, domain.ErrFixturesPlayed):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	}
+}
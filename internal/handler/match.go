@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -9,116 +10,250 @@ import (
 	"github.com/tructn/racket/internal/domain"
 	"github.com/tructn/racket/internal/dto"
 	"github.com/tructn/racket/internal/service"
+	"github.com/tructn/racket/pkg/currentuser"
+	"github.com/tructn/racket/pkg/result"
+	"github.com/tructn/racket/pkg/scopes"
 	"github.com/tructn/racket/pkg/util"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 )
 
 type MatchHandler struct {
-	db       *gorm.DB
-	logger   *zap.SugaredLogger
-	matchSvc *service.MatchService
+	db                      *gorm.DB
+	logger                  *zap.SugaredLogger
+	matchSvc                *service.MatchService
+	standingRegistrationSvc *service.StandingRegistrationService
 }
 
-func NewMatchHandler(db *gorm.DB, logger *zap.SugaredLogger, matchSvc *service.MatchService) *MatchHandler {
+func NewMatchHandler(
+	db *gorm.DB,
+	logger *zap.SugaredLogger,
+	matchSvc *service.MatchService,
+	standingRegistrationSvc *service.StandingRegistrationService,
+) *MatchHandler {
 	return &MatchHandler{
-		db:       db,
-		logger:   logger,
-		matchSvc: matchSvc,
+		db:                      db,
+		logger:                  logger,
+		matchSvc:                matchSvc,
+		standingRegistrationSvc: standingRegistrationSvc,
 	}
 }
 
 func (h *MatchHandler) GetAll(c *gin.Context) {
+	states, ok := h.getStateFilter(c)
+	if !ok {
+		return
+	}
+
 	var matches []domain.Match
-	h.db.
+	h.db.WithContext(c).
+		Scopes(service.MatchStateScope(states)).
 		Preload("SportCenter").
 		Preload("AdditionalCosts").
+		Preload("Registrations", "rsvp = ?", domain.RsvpMaybe).
+		Preload("CourtBookings").
 		Order("start DESC").
 		Find(&matches)
 
-	result := lo.Map(matches, func(m domain.Match, _ int) dto.MatchDto {
-		return dto.MatchDto{
-			MatchId:          m.ID,
-			Start:            m.Start,
-			End:              m.End,
-			SportCenterId:    m.SportCenterId,
-			SportCenterName:  m.SportCenter.Name,
-			CostPerSection:   m.SportCenter.CostPerSection,
-			MinutePerSection: m.SportCenter.MinutePerSection,
-			Cost:             m.Cost,
-			AdditionalCost: lo.SumBy(m.AdditionalCosts, func(ac domain.AdditionalCost) float64 {
-				return ac.Amount
-			}),
-			Court:         m.Court,
-			CustomSection: m.CustomSection,
-		}
-	})
+	result := lo.Map(matches, func(m domain.Match, _ int) dto.MatchDto { return service.ToMatchDto(m) })
 
 	c.JSON(http.StatusOK, result)
 }
 
 func (h *MatchHandler) GetTodayMatches(c *gin.Context) {
-	result := h.matchSvc.GetTodayMatches()
+	states, ok := h.getStateFilter(c)
+	if !ok {
+		return
+	}
+
+	result := h.matchSvc.WithContext(c).GetTodayMatches(states)
 	c.JSON(http.StatusOK, result)
 }
 
 func (h *MatchHandler) GetFutureMatches(c *gin.Context) {
-	result := h.matchSvc.GetFutureMatches()
+	states, ok := h.getStateFilter(c)
+	if !ok {
+		return
+	}
+
+	result := h.matchSvc.WithContext(c).GetFutureMatches(states)
 	c.JSON(http.StatusOK, result)
 }
 
 func (h *MatchHandler) GetArchivedMatches(c *gin.Context) {
-	result := h.matchSvc.GetArchivedMatches()
+	states, ok := h.getStateFilter(c)
+	if !ok {
+		return
+	}
+
+	result := h.matchSvc.WithContext(c).GetArchivedMatches(states)
 	c.JSON(http.StatusOK, result)
 }
 
 // This is for player to register match
 // This might show some extra infomation about the cost of individual
 // This is not just the same with get future match
+// Private matches are only listed for the players invited to them
 func (h *MatchHandler) GetUpcomingMatches(c *gin.Context) {
+	playerId, err := currentuser.GetPlayerId(c, h.db)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
 	var matches []domain.Match
-	h.db.
+	h.db.WithContext(c).
 		Preload("SportCenter").
 		Preload("AdditionalCosts").
 		Preload("Registrations").
+		Preload("CourtBookings").
+		Preload("Invitations").
+		Scopes(service.MatchStateScope(service.PlayerVisibleMatchStates)).
 		Where("start::date >= CURRENT_DATE::date").Order("start ASC").
 		Find(&matches)
 
+	matches, err = h.matchSvc.WithContext(c).FilterVisibleMatches(matches, playerId)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
 	h.logger.Debugf("Query matches: %v", matches)
 
 	result := lo.Map(matches, func(m domain.Match, _ int) dto.MatchDto {
-		return dto.MatchDto{
-			MatchId:          m.ID,
-			Start:            m.Start,
-			End:              m.End,
-			SportCenterId:    m.SportCenterId,
-			SportCenterName:  m.SportCenter.Name,
-			CostPerSection:   m.SportCenter.CostPerSection,
-			MinutePerSection: m.SportCenter.MinutePerSection,
-			Cost:             m.Cost,
-			AdditionalCost:   m.CalcAdditionalCost(),
-			Court:            m.Court,
-			PlayerCount:      m.CalcPlayerCount(),
-			RegistrationIds:  lo.Map(m.Registrations, func(reg domain.Registration, _ int) uint { return reg.ID }),
-			IndividualCost:   m.CalcIndividualCost(),
-		}
+		res := service.ToMatchDto(m)
+		res.PlayerCount = m.CalcPlayerCount()
+		res.RegistrationIds = lo.FilterMap(m.Registrations, func(reg domain.Registration, _ int) (uint, bool) { return reg.ID, reg.IsGoing() })
+		res.IndividualCost = m.CalcFullSessionCost()
+		return res
 	})
 
 	c.JSON(http.StatusOK, result)
 }
 
+// ChangeVisibility makes the match public to the group, team-only or invite-only
+func (h *MatchHandler) ChangeVisibility(c *gin.Context) {
+	matchId := util.GetIntRouteParam(c, "matchId")
+	req := dto.MatchVisibilityDto{}
+	if err := c.BindJSON(&req); err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	visibility, err := domain.ParseMatchVisibility(req.Visibility)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.matchSvc.WithContext(c).ChangeVisibility(matchId, visibility); err != nil {
+		h.abortWithMatchError(c, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+func (h *MatchHandler) GetInvitations(c *gin.Context) {
+	matchId := util.GetIntRouteParam(c, "matchId")
+	invitations, err := h.matchSvc.WithContext(c).GetInvitations(matchId)
+	if err != nil {
+		h.abortWithMatchError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, invitations)
+}
+
+func (h *MatchHandler) Invite(c *gin.Context) {
+	matchId := util.GetIntRouteParam(c, "matchId")
+	req := dto.MatchInvitationRequestDto{}
+	if err := c.BindJSON(&req); err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	invitations, err := h.matchSvc.WithContext(c).Invite(matchId, req)
+	if errors.Is(err, result.ErrorNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "player or team not found"})
+		return
+	}
+
+	if err != nil {
+		h.abortWithMatchError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, invitations)
+}
+
+func (h *MatchHandler) RemoveInvitation(c *gin.Context) {
+	matchId := util.GetIntRouteParam(c, "matchId")
+	invitationId := util.GetIntRouteParam(c, "invitationId")
+	if err := h.matchSvc.WithContext(c).RemoveInvitation(matchId, invitationId); err != nil {
+		h.abortWithMatchError(c, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// Delete cancels the match, it is kept with its registrations for history
 func (h *MatchHandler) Delete(c *gin.Context) {
-	matchId := util.GetRouteString(c, "matchId")
-	if err := h.db.Transaction(func(tx *gorm.DB) error {
-		if err := tx.Where("match_id = ?", matchId).Delete(&domain.Registration{}).Error; err != nil {
-			return err
-		}
-		if err := tx.Delete(&domain.Match{}, matchId).Error; err != nil {
-			return err
-		}
-		return nil
-	}); err != nil {
-		c.AbortWithError(http.StatusInternalServerError, err)
+	matchId := util.GetIntRouteParam(c, "matchId")
+	if err := h.matchSvc.WithContext(c).CancelMatch(matchId, c.Query("reason")); err != nil {
+		h.abortWithMatchError(c, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+func (h *MatchHandler) ChangeState(c *gin.Context) {
+	matchId := util.GetIntRouteParam(c, "matchId")
+	req := dto.MatchStateDto{}
+	if err := c.BindJSON(&req); err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	state, err := domain.ParseMatchState(req.State)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.matchSvc.WithContext(c).ChangeState(matchId, state, req.Reason); err != nil {
+		h.abortWithMatchError(c, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// ChangeCostSplit switches between an even split and a split by player-minutes
+func (h *MatchHandler) ChangeCostSplit(c *gin.Context) {
+	matchId := util.GetIntRouteParam(c, "matchId")
+	req := dto.CostSplitDto{}
+	if err := c.BindJSON(&req); err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	courtCost, err := domain.ParseCostSplit(req.CourtCost)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	additionalCost, err := domain.ParseCostSplit(req.AdditionalCost)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.matchSvc.WithContext(c).ChangeCostSplit(matchId, courtCost, additionalCost); err != nil {
+		h.abortWithMatchError(c, err)
 		return
 	}
 
@@ -136,50 +271,125 @@ func (h *MatchHandler) Create(c *gin.Context) {
 	h.logger.Debug(dto)
 
 	sc := domain.SportCenter{}
-	h.db.Find(&sc, dto.SportCenterId)
-
-	m := domain.NewMatch(
-		dto.Start,
-		dto.End,
-		dto.SportCenterId,
-		sc.CostPerSection,
-		float64(sc.MinutePerSection),
-		dto.Court,
-		dto.CustomSection,
-	)
+	h.db.WithContext(c).Preload("PricingRules").Preload("PriceHistory").Find(&sc, dto.SportCenterId)
+
+	var m *domain.Match
+	if len(dto.CourtBookings) > 0 {
+		bookings, err := h.matchSvc.WithContext(c).BuildCourtBookings(dto.CourtBookings, &sc)
+		if err != nil {
+			c.AbortWithError(http.StatusBadRequest, err)
+			return
+		}
+
+		if m, err = domain.NewMatchWithCourtBookings(dto.SportCenterId, bookings); err != nil {
+			c.AbortWithError(http.StatusBadRequest, err)
+			return
+		}
+	} else {
+		if err = domain.CheckBookingDuration(dto.Start, dto.End); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		m = domain.NewMatch(
+			dto.Start,
+			dto.End,
+			dto.SportCenterId,
+			sc.GetPricing(dto.Start, false),
+			dto.Court,
+			dto.CustomSection,
+		)
+	}
 
 	h.logger.Debug(m)
 
-	if err = h.db.Create(m).Error; err != nil {
+	if m.Visibility, err = domain.ParseMatchVisibility(dto.Visibility); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err = m.SetRegistrationWindow(
+		dto.RegistrationOpensAt,
+		dto.RegistrationClosesAt,
+		dto.CancellationDeadline,
+		dto.LateCancellationFee,
+	); err != nil {
 		c.AbortWithError(http.StatusBadRequest, err)
 		return
 	}
 
+	if err = m.SetBallot(dto.BallotDrawAt); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err = h.matchSvc.WithContext(c).EnsureSportCenterOpen(m, dto.AllowClosed); err != nil {
+		h.abortWithMatchError(c, err)
+		return
+	}
+
+	if err = h.matchSvc.WithContext(c).EnsureNoCourtConflict(m, dto.AllowConflicts); err != nil {
+		h.abortWithMatchError(c, err)
+		return
+	}
+
+	if err = h.db.WithContext(c).Create(m).Error; err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	h.applyStandingRegistrations(c, m.ID)
 	c.JSON(http.StatusCreated, m)
 }
 
 func (h *MatchHandler) Clone(c *gin.Context) {
-	matchId, _ := c.Params.Get("matchId")
-	match := domain.Match{}
-	if err := h.db.Find(&match, matchId).Error; err != nil {
-		c.AbortWithError(http.StatusInternalServerError, err)
+	matchId := util.GetIntRouteParam(c, "matchId")
+	clone, err := h.matchSvc.WithContext(c).CloneMatch(matchId, c.Query("allowClosed") == "true", c.Query("allowConflicts") == "true")
+	if err != nil {
+		h.abortWithMatchError(c, err)
 		return
 	}
 
-	clone := match.Clone()
-	if err := h.db.Create(&clone).Error; err != nil {
-		c.AbortWithError(http.StatusInternalServerError, err)
+	h.applyStandingRegistrations(c, clone.ID)
+	c.JSON(http.StatusCreated, clone)
+}
+
+func (h *MatchHandler) CreateSeries(c *gin.Context) {
+	matchId := util.GetIntRouteParam(c, "matchId")
+	req := dto.MatchSeriesDto{}
+	if err := c.BindJSON(&req); err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
 		return
 	}
 
-	c.JSON(http.StatusCreated, clone)
+	res, err := h.matchSvc.WithContext(c).GenerateSeries(matchId, req)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "match not found"})
+		return
+	}
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.applyStandingRegistrations(c, res.CreatedMatchIds...)
+	c.JSON(http.StatusCreated, res)
+}
+
+// applyStandingRegistrations registers the regulars to new matches, the matches are kept when it fails
+func (h *MatchHandler) applyStandingRegistrations(c *gin.Context, matchIds ...uint) {
+	if err := h.standingRegistrationSvc.WithContext(c).Apply(matchIds); err != nil {
+		h.logger.Errorw("Failed to apply standing registrations", "matchIds", matchIds, "error", err)
+	}
 }
 
 func (h *MatchHandler) GetRegistrationsByMatch(c *gin.Context) {
 	var result []dto.RegistrationOverviewDto
 	matchId, _ := c.Params.Get("matchId")
 	h.logger.Infof("getting match id %s", matchId)
-	h.db.Raw(`
+	tenantId, _ := scopes.GetTenantId(c)
+	h.db.WithContext(c).Raw(`
 	SELECT
 		pl.id AS player_id,
 		TRIM(CONCAT(pl.first_name, ' ', pl.last_name)) AS player_name,
@@ -187,18 +397,52 @@ func (h *MatchHandler) GetRegistrationsByMatch(c *gin.Context) {
 		re.id AS registration_id,
 		re.match_id,
 		re.is_paid,
-		re.total_player_paid_for
+		re.total_player_paid_for,
+		COALESCE(re.rsvp, '') AS rsvp,
+		COALESCE(re.is_waitlisted, false) AS is_waitlisted
 	FROM "players" pl
 	LEFT JOIN "registrations" re ON pl.id = re.player_id AND re.deleted_at IS NULL AND re.match_id = ?
-	WHERE pl.deleted_at IS NULL
+	WHERE pl.deleted_at IS NULL AND pl.tenant_id = ?
 	ORDER BY pl.first_name ASC
-	`, matchId).Scan(&result)
+	`, matchId, tenantId).Scan(&result)
 
 	h.logger.Info(result)
 
 	c.JSON(http.StatusOK, result)
 }
 
+func (h *MatchHandler) GetRoster(c *gin.Context) {
+	matchId := util.GetIntRouteParam(c, "matchId")
+	roster, err := h.matchSvc.WithContext(c).GetRoster(matchId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "match not found"})
+		return
+	}
+
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, roster)
+}
+
+func (h *MatchHandler) GetCostBreakdown(c *gin.Context) {
+	matchId := util.GetIntRouteParam(c, "matchId")
+	breakdown, err := h.matchSvc.WithContext(c).GetCostBreakdown(matchId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "match not found"})
+		return
+	}
+
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, breakdown)
+}
+
 func (h *MatchHandler) UpdateCost(c *gin.Context) {
 	matchId := util.GetRouteString(c, "matchId")
 	dto := dto.MatchCostDto{}
@@ -208,60 +452,125 @@ func (h *MatchHandler) UpdateCost(c *gin.Context) {
 	}
 
 	match := domain.Match{}
-	if err := h.db.Find(&match, matchId).Error; err != nil {
+	if err := h.db.WithContext(c).Find(&match, matchId).Error; err != nil {
 		c.AbortWithStatus(http.StatusBadRequest)
 		return
 	}
 
+	if !match.AcceptsChanges() {
+		c.JSON(http.StatusConflict, gin.H{"error": "match is " + match.GetState()})
+		return
+	}
+
 	match.UpdateCost(dto.Cost, "Invalidate auto-calc cost, update manual")
-	h.db.Save(&match)
+	h.db.WithContext(c).Save(&match)
 	c.JSON(http.StatusOK, match)
 }
 
+// UpdateMatch keeps the registration window and the ballot of the match when the request leaves them out,
+// they are cleared with an explicit null
 func (h *MatchHandler) UpdateMatch(c *gin.Context) {
 	matchId := util.GetRouteString(c, "matchId")
-	dto := dto.UpdateMatchDto{}
-	if err := c.BindJSON(&dto); err != nil {
+	match := domain.Match{}
+	if err := h.db.WithContext(c).Preload("CourtBookings").Find(&match, matchId).Error; err != nil {
 		c.AbortWithError(http.StatusBadRequest, err)
 		return
 	}
 
-	match := domain.Match{}
-	if err := h.db.Find(&match, matchId).Error; err != nil {
+	dto := dto.UpdateMatchDto{
+		RegistrationOpensAt:  match.RegistrationOpensAt,
+		RegistrationClosesAt: match.RegistrationClosesAt,
+		CancellationDeadline: match.CancellationDeadline,
+		LateCancellationFee:  match.LateCancellationFee,
+		BallotDrawAt:         match.BallotDrawAt,
+	}
+	if err := c.BindJSON(&dto); err != nil {
 		c.AbortWithError(http.StatusBadRequest, err)
 		return
 	}
 
+	if !match.AcceptsChanges() {
+		c.JSON(http.StatusConflict, gin.H{"error": "match is " + match.GetState()})
+		return
+	}
+
 	sportCenterId, _ := strconv.Atoi(dto.SportCenterId)
 
 	spc := &domain.SportCenter{}
-	h.db.Find(&spc, sportCenterId)
+	h.db.WithContext(c).Preload("PricingRules").Preload("PriceHistory").Find(&spc, sportCenterId)
 
 	h.logger.Debugf("get sport center %v", spc)
 
-	err := match.UpdateMatch(
-		uint(sportCenterId),
-		dto.Start,
-		dto.End,
-		float64(spc.MinutePerSection),
-		spc.CostPerSection,
-		dto.Court,
-		dto.CustomSection,
-	)
+	var err error
+	if len(dto.CourtBookings) > 0 {
+		bookings, buildErr := h.matchSvc.WithContext(c).BuildCourtBookings(dto.CourtBookings, spc)
+		if buildErr != nil {
+			c.AbortWithError(http.StatusBadRequest, buildErr)
+			return
+		}
+
+		// Courts left without a capacity stay unlimited on matches booked before capacities existed
+		if match.HasUnknownCapacity() {
+			for i, item := range dto.CourtBookings {
+				if item.Capacity == 0 {
+					bookings[i].Capacity = 0
+				}
+			}
+		}
+		err = match.UpdateCourtBookings(uint(sportCenterId), bookings)
+	} else {
+		err = match.UpdateMatch(
+			uint(sportCenterId),
+			dto.Start,
+			dto.End,
+			spc.GetPricing(dto.Start, false),
+			dto.Court,
+			dto.CustomSection,
+		)
+	}
 
 	if err != nil {
 		c.AbortWithError(http.StatusBadRequest, err)
 		return
 	}
 
-	h.db.Save(&match)
+	if err = match.SetRegistrationWindow(
+		dto.RegistrationOpensAt,
+		dto.RegistrationClosesAt,
+		dto.CancellationDeadline,
+		dto.LateCancellationFee,
+	); err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	if err = match.UpdateBallot(dto.BallotDrawAt); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err = h.matchSvc.WithContext(c).EnsureSportCenterOpen(&match, dto.AllowClosed); err != nil {
+		h.abortWithMatchError(c, err)
+		return
+	}
+
+	if err = h.matchSvc.WithContext(c).EnsureNoCourtConflict(&match, dto.AllowConflicts); err != nil {
+		h.abortWithMatchError(c, err)
+		return
+	}
+
+	if err = h.matchSvc.WithContext(c).SaveMatch(&match); err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
 	c.JSON(http.StatusOK, match)
 }
 
 func (h *MatchHandler) GetCost(c *gin.Context) {
 	matchId := util.GetRouteString(c, "matchId")
 	var cost float64
-	if err := h.db.Select("cost").Find(&domain.Match{}, matchId).Scan(&cost).Error; err != nil {
+	if err := h.db.WithContext(c).Select("cost").Find(&domain.Match{}, matchId).Scan(&cost).Error; err != nil {
 		c.AbortWithStatus(http.StatusInternalServerError)
 		return
 	}
@@ -271,7 +580,7 @@ func (h *MatchHandler) GetCost(c *gin.Context) {
 func (h *MatchHandler) GetAdditionalCost(c *gin.Context) {
 	matchId := util.GetRouteString(c, "matchId")
 	var costs []float64
-	if err := h.db.Model(&domain.AdditionalCost{}).Where("match_id = ?", matchId).Select("amount").Scan(&costs).Error; err != nil {
+	if err := h.db.WithContext(c).Model(&domain.AdditionalCost{}).Where("match_id = ?", matchId).Select("amount").Scan(&costs).Error; err != nil {
 		c.AbortWithStatus(http.StatusInternalServerError)
 		return
 	}
@@ -291,17 +600,73 @@ func (h *MatchHandler) CreateAdditionalCost(c *gin.Context) {
 	}
 
 	match := domain.Match{}
-	if err := h.db.Preload("AdditionalCosts").Find(&match, matchId).Error; err != nil {
+	if err := h.db.WithContext(c).Preload("AdditionalCosts").Find(&match, matchId).Error; err != nil {
 		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{
 			"error": "match not found",
 		})
 		return
 	}
 
+	if !match.AcceptsChanges() {
+		c.JSON(http.StatusConflict, gin.H{"error": "match is " + match.GetState()})
+		return
+	}
+
 	for _, c := range costs {
 		match.AddCost(c.Description, c.Amount)
 	}
 
-	h.db.Save(&match)
+	h.db.WithContext(c).Save(&match)
 	c.JSON(http.StatusOK, match)
 }
+
+// getStateFilter reads the "state" query, e.g. ?state=open,registration_closed
+func (h *MatchHandler) getStateFilter(c *gin.Context) ([]domain.MatchState, bool) {
+	states, err := service.ParseMatchStates(c.Query("state"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	return states, true
+}
+
+func (h *MatchHandler) abortWithMatchError(c *gin.Context, err error) {
+	var conflictErr *domain.ConflictError
+	if errors.As(err, &conflictErr) {
+		c.AbortWithStatusJSON(http.StatusConflict, service.ToConflictErrorDto(conflictErr))
+		return
+	}
+
+	var transitionErr *domain.TransitionError
+	if errors.As(err, &transitionErr) {
+		c.JSON(http.StatusConflict, gin.H{"error": transitionErr.Error()})
+		return
+	}
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "match not found"})
+		return
+	}
+
+	if errors.Is(err, domain.ErrInvitationNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	if errors.Is(err, domain.ErrCostSettled) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+
+	var closedErr *domain.ClosedError
+	if errors.As(err, &closedErr) {
+		c.AbortWithStatusJSON(http.StatusConflict, dto.ClosedErrorDto{
+			Error:  closedErr.Error(),
+			Start:  closedErr.Start,
+			Reason: closedErr.Reason,
+		})
+		return
+	}
+
+	c.AbortWithError(http.StatusInternalServerError, err)
+}
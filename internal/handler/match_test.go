@@ -0,0 +1,97 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tructn/racket/internal/db/dbtest"
+	"github.com/tructn/racket/internal/domain"
+	"github.com/tructn/racket/internal/service"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+func updateMatch(t *testing.T, db *gorm.DB, matchId uint, body map[string]any) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	logger := zap.NewNop().Sugar()
+	handler := NewMatchHandler(db, logger, service.NewMatchService(db, logger), nil)
+	router := newTenantRouter()
+	router.PUT("/matches/:matchId", handler.UpdateMatch)
+
+	payload, err := json.Marshal(body)
+	require.NoError(t, err)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPut, fmt.Sprintf("/matches/%d", matchId), bytes.NewReader(payload)))
+	return w
+}
+
+func createWindowedMatch(t *testing.T, db *gorm.DB) (*domain.Match, *domain.SportCenter) {
+	sc := domain.NewSportCenter("Center", "Somewhere", 10, 60)
+	require.NoError(t, db.Create(sc).Error)
+
+	start := time.Date(2030, 6, 5, 18, 0, 0, 0, time.UTC)
+	match := domain.NewMatch(start, start.Add(2*time.Hour), sc.ID, sc.GetPricing(start, false), "1", nil)
+	opensAt, closesAt, deadline, fee := start.AddDate(0, 0, -7), start.Add(-2*time.Hour), start.Add(-24*time.Hour), 5.0
+	require.NoError(t, match.SetRegistrationWindow(&opensAt, &closesAt, &deadline, &fee))
+	drawAt := start.AddDate(0, 0, -2)
+	require.NoError(t, match.SetBallot(&drawAt))
+	require.NoError(t, db.Create(match).Error)
+	return match, sc
+}
+
+func TestUpdateMatchKeepsRegistrationWindowLeftOut(t *testing.T) {
+	db := dbtest.OpenTenant(t)
+	match, sc := createWindowedMatch(t, db)
+
+	start := match.Start.Add(time.Hour)
+	w := updateMatch(t, db, match.ID, map[string]any{
+		"sportCenterId": fmt.Sprint(sc.ID),
+		"start":         start,
+		"end":           start.Add(2 * time.Hour),
+		"court":         "1",
+	})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	updated := domain.Match{}
+	require.NoError(t, db.First(&updated, match.ID).Error)
+	assert.True(t, start.Equal(updated.Start))
+	require.NotNil(t, updated.RegistrationOpensAt)
+	assert.True(t, match.RegistrationOpensAt.Equal(*updated.RegistrationOpensAt))
+	require.NotNil(t, updated.RegistrationClosesAt)
+	require.NotNil(t, updated.CancellationDeadline)
+	require.NotNil(t, updated.LateCancellationFee)
+	assert.Equal(t, 5.0, *updated.LateCancellationFee)
+	require.NotNil(t, updated.BallotDrawAt)
+	assert.True(t, match.BallotDrawAt.Equal(*updated.BallotDrawAt))
+}
+
+func TestUpdateMatchChangesRegistrationWindowAndBallot(t *testing.T) {
+	db := dbtest.OpenTenant(t)
+	match, sc := createWindowedMatch(t, db)
+
+	drawAt := match.Start.AddDate(0, 0, -1)
+	w := updateMatch(t, db, match.ID, map[string]any{
+		"sportCenterId":       fmt.Sprint(sc.ID),
+		"start":               match.Start,
+		"end":                 match.End,
+		"court":               "1",
+		"lateCancellationFee": nil,
+		"ballotDrawAt":        drawAt,
+	})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	updated := domain.Match{}
+	require.NoError(t, db.First(&updated, match.ID).Error)
+	assert.Nil(t, updated.LateCancellationFee)
+	require.NotNil(t, updated.CancellationDeadline)
+	require.NotNil(t, updated.BallotDrawAt)
+	assert.True(t, drawAt.Equal(*updated.BallotDrawAt))
+}
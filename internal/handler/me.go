@@ -9,16 +9,18 @@ import (
 	"github.com/tructn/racket/internal/service"
 	"github.com/tructn/racket/pkg/currentuser"
 	"github.com/tructn/racket/pkg/result"
+	"github.com/tructn/racket/pkg/util"
 	"gorm.io/gorm"
 )
 
 type MeHandler struct {
-	db        *gorm.DB
-	meService *service.MeService
+	db              *gorm.DB
+	meService       *service.MeService
+	calendarService *service.CalendarService
 }
 
-func NewMeHandler(db *gorm.DB, meService *service.MeService) *MeHandler {
-	return &MeHandler{db: db, meService: meService}
+func NewMeHandler(db *gorm.DB, meService *service.MeService, calendarService *service.CalendarService) *MeHandler {
+	return &MeHandler{db: db, meService: meService, calendarService: calendarService}
 }
 
 func (h *MeHandler) UseRouter(router *gin.RouterGroup) {
@@ -27,6 +29,9 @@ func (h *MeHandler) UseRouter(router *gin.RouterGroup) {
 		group.GET("/profile", h.getProfile)
 		group.GET("/upcoming-matches", h.getMyUpcomingMatches)
 		group.GET("/wallet", h.getMyWallet)
+		group.GET("/calendar.ics", h.getMyCalendar)
+		group.GET("/notifications", h.getMyNotifications)
+		group.PUT("/notifications/:notificationId/read", h.markNotificationRead)
 	}
 }
 
@@ -48,7 +53,7 @@ func (h *MeHandler) getMyUpcomingMatches(c *gin.Context) {
 		return
 	}
 
-	matches, err := h.meService.GetMyUpcommingMatches(playerId)
+	matches, err := h.meService.WithContext(c).GetMyUpcommingMatches(playerId)
 	if err != nil {
 		c.AbortWithError(http.StatusInternalServerError, err)
 		return
@@ -63,7 +68,7 @@ func (h *MeHandler) getMyWallet(c *gin.Context) {
 		return
 	}
 
-	wallet, err := h.meService.GetMyWallet(playerId)
+	wallet, err := h.meService.WithContext(c).GetMyWallet(playerId)
 
 	if errors.Is(err, result.ErrorNotFound) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "wallet not found"})
@@ -79,3 +84,58 @@ func (h *MeHandler) getMyWallet(c *gin.Context) {
 
 	c.JSON(http.StatusOK, wallet)
 }
+
+func (h *MeHandler) getMyCalendar(c *gin.Context) {
+	playerId, err := currentuser.GetPlayerId(c, h.db)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	cal, err := h.calendarService.WithContext(c).RenderPlayerCalendar(playerId)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	c.Data(http.StatusOK, calendarContentType, []byte(cal))
+}
+
+func (h *MeHandler) getMyNotifications(c *gin.Context) {
+	playerId, err := currentuser.GetPlayerId(c, h.db)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	notifications, err := h.meService.WithContext(c).GetMyNotifications(playerId)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, notifications)
+}
+
+func (h *MeHandler) markNotificationRead(c *gin.Context) {
+	playerId, err := currentuser.GetPlayerId(c, h.db)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	notificationId := util.GetIntRouteParam(c, "notificationId")
+	err = h.meService.WithContext(c).MarkNotificationRead(playerId, notificationId)
+
+	if errors.Is(err, result.ErrorNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "notification not found"})
+		return
+	}
+
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/tructn/racket/internal/domain"
 	"github.com/tructn/racket/internal/dto"
+	"github.com/tructn/racket/pkg/scopes"
 	"github.com/tructn/racket/pkg/util"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
@@ -34,14 +35,15 @@ func (h *PlayerHandler) Create(c *gin.Context) {
 	p := &domain.Player{
 		FirstName: dto.FirstName,
 		LastName:  dto.LastName,
+		Gender:    dto.Gender,
 	}
-	h.db.Create(p)
+	h.db.WithContext(c).Create(p)
 	c.JSON(http.StatusCreated, p)
 }
 
 func (h *PlayerHandler) GetAll(c *gin.Context) {
 	var result []domain.Player
-	if err := h.db.Model(&domain.Player{}).
+	if err := h.db.WithContext(c).Model(&domain.Player{}).
 		Preload("Wallets").
 		Order("first_name ASC").
 		Find(&result).Error; err != nil {
@@ -55,13 +57,14 @@ func (h *PlayerHandler) GetAll(c *gin.Context) {
 func (h *PlayerHandler) GetExternalUserAttendantRequests(c *gin.Context) {
 	var result []dto.PlayerAttendantRequestDto
 	externalUserId := util.GetRouteString(c, "externalUserId")
-	h.db.Raw(`
+	tenantId, _ := scopes.GetTenantId(c)
+	h.db.WithContext(c).Raw(`
 	SELECT m.id as match_id, pl.id as player_id
 	FROM matches m
 	JOIN registrations re ON m.id = re.match_id
 	JOIN players pl ON pl.id = re.player_id
-	WHERE pl.external_user_id = ?
-	`, externalUserId).Scan(&result)
+	WHERE pl.external_user_id = ? AND pl.tenant_id = ?
+	`, externalUserId, tenantId).Scan(&result)
 
 	h.logger.Info(result)
 
@@ -76,38 +79,63 @@ func (h *PlayerHandler) Update(c *gin.Context) {
 		return
 	}
 	p := domain.Player{}
-	h.db.Find(&p, id)
+	h.db.WithContext(c).Find(&p, id)
 
 	p.FirstName = model.FirstName
 	p.LastName = model.LastName
-	h.db.Save(&p)
+	p.Gender = model.Gender
+	h.db.WithContext(c).Save(&p)
 	c.JSON(http.StatusOK, p)
 }
 
 func (h *PlayerHandler) Delete(c *gin.Context) {
 	id := util.GetRouteString(c, "playerId")
 
-	if err := h.db.Unscoped().Where("player_id = ?", id).Delete(&domain.Registration{}).Error; err != nil {
+	if err := h.db.WithContext(c).Unscoped().Where("player_id = ?", id).Delete(&domain.Registration{}).Error; err != nil {
 		c.AbortWithStatusJSON(http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	if err := h.db.Unscoped().Delete(&domain.Player{}, id).Error; err != nil {
+	if err := h.db.WithContext(c).Unscoped().Delete(&domain.Player{}, id).Error; err != nil {
 		c.AbortWithStatusJSON(http.StatusInternalServerError, err.Error())
 		return
 	}
 	c.Status(http.StatusOK)
 }
 
+// MarkOutstandingPaymentsAsPaid settles the unpaid matches the player held a spot in and their late cancellation fees,
+// tournament entry fees are only settled when asked for with includeEntryFees=true
 func (h *PlayerHandler) MarkOutstandingPaymentsAsPaid(c *gin.Context) {
 	playerId := util.GetRouteString(c, "playerId")
-	if err := h.db.
-		Model(&domain.Registration{}).
-		Where("player_id = ? AND is_paid = false", playerId).
-		Updates(map[string]interface{}{
-			"is_paid": true,
-		}).Error; err != nil {
-
+	includeEntryFees := c.Query("includeEntryFees") == "true"
+	err := h.db.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.
+			Model(&domain.Registration{}).
+			Where("player_id = ? AND is_paid = false AND rsvp = ? AND is_waitlisted = false", playerId, domain.RsvpGoing).
+			Updates(map[string]interface{}{
+				"is_paid": true,
+			}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.
+			Model(&domain.LateCancellationFee{}).
+			Where("player_id = ? AND is_paid = false", playerId).
+			Update("is_paid", true).Error; err != nil {
+			return err
+		}
+
+		if !includeEntryFees {
+			return nil
+		}
+
+		return tx.
+			Model(&domain.TournamentEntryPlayer{}).
+			Where("player_id = ? AND is_paid = false", playerId).
+			Update("is_paid", true).Error
+	})
+
+	if err != nil {
 		c.AbortWithError(http.StatusInternalServerError, err)
 		return
 	}
@@ -0,0 +1,55 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tructn/racket/internal/db/dbtest"
+	"github.com/tructn/racket/internal/domain"
+	"go.uber.org/zap"
+)
+
+func TestMarkOutstandingPaymentsAsPaidSettlesConfirmedRegistrationsAndFees(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := dbtest.OpenTenant(t)
+	handler := NewPlayerHandler(db, zap.NewNop().Sugar())
+	router := newTenantRouter()
+	router.PUT("/players/:playerId/outstanding-payments/paid", handler.MarkOutstandingPaymentsAsPaid)
+
+	player := &domain.Player{FirstName: "Anna"}
+	require.NoError(t, db.Create(player).Error)
+
+	start := time.Now().Add(-48 * time.Hour)
+	match := &domain.Match{Start: start, End: start.Add(2 * time.Hour), State: domain.MatchPlayed}
+	require.NoError(t, db.Create(match).Error)
+
+	confirmed := domain.NewRegistration(player.ID, match.ID)
+	waitlisted := &domain.Registration{PlayerId: player.ID, MatchId: match.ID, Rsvp: domain.RsvpGoing, IsWaitlisted: true}
+	declined := &domain.Registration{PlayerId: player.ID, MatchId: match.ID, Rsvp: domain.RsvpNotGoing}
+	require.NoError(t, db.Create([]*domain.Registration{confirmed, waitlisted, declined}).Error)
+
+	fee, err := domain.NewLateCancellationFee(player.ID, match.ID, 5)
+	require.NoError(t, err)
+	require.NoError(t, db.Create(fee).Error)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPut, fmt.Sprintf("/players/%d/outstanding-payments/paid", player.ID), nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	paid := map[uint]bool{}
+	var regs []domain.Registration
+	require.NoError(t, db.Find(&regs).Error)
+	for _, r := range regs {
+		paid[r.ID] = r.IsPaid
+	}
+	assert.Equal(t, map[uint]bool{confirmed.ID: true, waitlisted.ID: false, declined.ID: false}, paid)
+
+	require.NoError(t, db.First(fee, fee.ID).Error)
+	assert.True(t, fee.IsPaid)
+}
@@ -0,0 +1,158 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tructn/racket/internal/domain"
+	"github.com/tructn/racket/internal/dto"
+	"github.com/tructn/racket/internal/service"
+	"github.com/tructn/racket/pkg/currentuser"
+	"github.com/tructn/racket/pkg/result"
+	"github.com/tructn/racket/pkg/util"
+	"gorm.io/gorm"
+)
+
+type PollHandler struct {
+	db          *gorm.DB
+	pollService *service.PollService
+}
+
+func NewPollHandler(db *gorm.DB, pollService *service.PollService) *PollHandler {
+	return &PollHandler{
+		db:          db,
+		pollService: pollService,
+	}
+}
+
+func (h *PollHandler) UseRouter(router *gin.RouterGroup) {
+	group := router.Group("/polls")
+	{
+		group.GET("", h.getAll)
+		group.POST("", h.create)
+		group.GET("/:pollId", h.get)
+		group.PUT("/:pollId/responses", h.respond)
+		group.PUT("/:pollId/close", h.close)
+		group.POST("/:pollId/book", h.book)
+	}
+}
+
+func (h *PollHandler) getAll(c *gin.Context) {
+	polls, err := h.pollService.WithContext(c).GetAll()
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, polls)
+}
+
+func (h *PollHandler) create(c *gin.Context) {
+	var req dto.CreatePollDto
+	if err := c.BindJSON(&req); err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	poll, err := h.pollService.WithContext(c).Create(req)
+	if err != nil {
+		abortWithPollError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, poll)
+}
+
+func (h *PollHandler) get(c *gin.Context) {
+	pollId := util.GetIntRouteParam(c, "pollId")
+	poll, err := h.pollService.WithContext(c).Get(pollId)
+	if err != nil {
+		abortWithPollError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, poll)
+}
+
+func (h *PollHandler) respond(c *gin.Context) {
+	var req dto.PollResponseRequestDto
+	if err := c.BindJSON(&req); err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	playerId, err := currentuser.GetPlayerId(c, h.db)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	pollId := util.GetIntRouteParam(c, "pollId")
+	poll, err := h.pollService.WithContext(c).Respond(pollId, playerId, req)
+	if err != nil {
+		abortWithPollError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, poll)
+}
+
+func (h *PollHandler) close(c *gin.Context) {
+	pollId := util.GetIntRouteParam(c, "pollId")
+	poll, err := h.pollService.WithContext(c).Close(pollId)
+	if err != nil {
+		abortWithPollError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, poll)
+}
+
+func (h *PollHandler) book(c *gin.Context) {
+	var req dto.BookPollDto
+	if err := c.BindJSON(&req); err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	pollId := util.GetIntRouteParam(c, "pollId")
+	poll, err := h.pollService.WithContext(c).Book(pollId, req)
+	if err != nil {
+		abortWithPollError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, poll)
+}
+
+func abortWithPollError(c *gin.Context, err error) {
+	var conflictErr *domain.ConflictError
+	if errors.As(err, &conflictErr) {
+		c.AbortWithStatusJSON(http.StatusConflict, service.ToConflictErrorDto(conflictErr))
+		return
+	}
+
+	var closedErr *domain.ClosedError
+	if errors.As(err, &closedErr) {
+		c.AbortWithStatusJSON(http.StatusConflict, dto.ClosedErrorDto{
+			Error:  closedErr.Error(),
+			Start:  closedErr.Start,
+			Reason: closedErr.Reason,
+		})
+		return
+	}
+
+	switch {
+	case errors.Is(err, result.ErrorNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "poll not found"})
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "sport center not found"})
+	case errors.Is(err, domain.ErrPollSlotNotFound), errors.Is(err, domain.ErrPollResponseNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrPollClosed), errors.Is(err, domain.ErrPollBooked):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	}
+}
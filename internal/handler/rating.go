@@ -0,0 +1,55 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tructn/racket/internal/service"
+	"github.com/tructn/racket/pkg/util"
+)
+
+type RatingHandler struct {
+	ratingService *service.RatingService
+}
+
+func NewRatingHandler(ratingService *service.RatingService) *RatingHandler {
+	return &RatingHandler{
+		ratingService: ratingService,
+	}
+}
+
+func (h *RatingHandler) UseRouter(router *gin.RouterGroup) {
+	router.GET("/ratings/leaderboard", h.getLeaderboard)
+	router.POST("/ratings/recompute", h.recompute)
+	router.GET("/players/:playerId/rating-history", h.getHistory)
+}
+
+func (h *RatingHandler) getLeaderboard(c *gin.Context) {
+	leaderboard, err := h.ratingService.WithContext(c).GetLeaderboard()
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, leaderboard)
+}
+
+func (h *RatingHandler) recompute(c *gin.Context) {
+	if err := h.ratingService.WithContext(c).RecomputeAll(); err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	h.getLeaderboard(c)
+}
+
+func (h *RatingHandler) getHistory(c *gin.Context) {
+	playerId := util.GetIntRouteParam(c, "playerId")
+	history, err := h.ratingService.WithContext(c).GetHistory(playerId)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, history)
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/tructn/racket/internal/dto"
 	"github.com/tructn/racket/internal/service"
 	"github.com/tructn/racket/pkg/currentuser"
+	"github.com/tructn/racket/pkg/scopes"
 	"github.com/tructn/racket/pkg/util"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
@@ -46,89 +47,139 @@ func (h *RegistrationHandler) UseRouter(router *gin.RouterGroup) {
 		group.DELETE("/:registrationId", h.Unregister)
 		group.POST("/matches/register", h.RegisterMatch)
 		group.POST("/matches/unregister", h.UnregisterMatch)
+		group.PUT("/matches/rsvp", h.SetRsvp)
+		group.POST("/rsvp-reminders", h.SendRsvpReminders)
 		group.PUT("/:registrationId/total-paid-for", h.UpdateTotalPlayerPaidFor)
+		group.PUT("/:registrationId/played-time", h.SetPlayedTime)
+		group.POST("/:registrationId/guests", h.AddGuest)
+		group.DELETE("/:registrationId/guests/:guestId", h.RemoveGuest)
+		group.POST("/:registrationId/guests/:guestId/convert", h.ConvertGuest)
+		group.GET("/late-cancellation-fees", h.GetLateCancellationFees)
+		group.PUT("/late-cancellation-fees/:feeId/paid", h.MarkLateCancellationFeePaid)
 	}
 }
 
+// AttendantRequest toggles the registration of the signed in player, it follows the same rules as RegisterMatch and UnregisterMatch
 func (h *RegistrationHandler) AttendantRequest(c *gin.Context) {
-	dto := dto.AttendantRequestDto{}
-	var err error
-	if err = c.BindJSON(&dto); err != nil {
+	req := dto.AttendantRequestDto{}
+	if err := c.BindJSON(&req); err != nil {
 		c.AbortWithError(http.StatusBadRequest, err)
 		return
 	}
 
-	debugf := h.logger.Debugf
-
-	err = h.db.Transaction(func(tx *gorm.DB) error {
-		var player *domain.Player
-		err := h.db.Where("external_user_id = ?", dto.ExternalUserId).First(&player).Error
-
-		debugf("getting player done %v with error %v", player, err)
-
-		// create player if it not exist
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			player = &domain.Player{
-				FirstName:      dto.FirstName,
-				LastName:       dto.LastName,
-				Email:          dto.Email,
-				ExternalUserID: dto.ExternalUserId,
-			}
+	player, err := h.getOrCreatePlayer(c, req)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
 
-			if err := tx.Create(player).Error; err != nil {
-				return err
+	reg := domain.Registration{}
+	err = h.db.WithContext(c).
+		Where("player_id = ? AND match_id = ?", player.ID, req.MatchId).
+		Limit(1).
+		Find(&reg).Error
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
 
-			}
+	h.logger.Debugf("toggling registration of player %d for match %d, registration %d", player.ID, req.MatchId, reg.ID)
 
-			debugf("create player done %v with error %v", player, err)
+	if reg.ID != 0 && reg.IsGoing() {
+		res, err := h.registrationService.WithContext(c).UnregisterMatch(player.ID, req.MatchId)
+		if err != nil {
+			abortWithRegistrationError(c, err)
+			return
 		}
 
-		// find existing registration for this player & this match
-		var reg *domain.Registration
-		err = h.db.Model(&domain.Registration{}).
-			Where("player_id = ? AND match_id = ?", player.ID, dto.MatchId).
-			Scan(&reg).
-			Error
+		c.JSON(http.StatusOK, res)
+		return
+	}
 
-		debugf("getting registration done %v with error %v", reg, err)
+	registration, err := h.registrationService.WithContext(c).RegisterMatch(player.ID, req.MatchId, service.RegisterOptions{})
+	if err != nil {
+		abortWithRegistrationError(c, err)
+		return
+	}
 
-		if err != nil {
-			return err
-		}
+	c.JSON(http.StatusOK, dto.MatchRegistrationResultDto{
+		RegistrationId: registration.ID,
+		IsWaitlisted:   registration.IsWaitlisted,
+	})
+}
 
-		// create registration if not found
-		if reg == nil {
-			reg = &domain.Registration{
-				MatchId:  dto.MatchId,
-				PlayerId: player.ID,
-			}
+// getOrCreatePlayer finds the player of the external user, players signing in for the first time are created
+func (h *RegistrationHandler) getOrCreatePlayer(c *gin.Context, req dto.AttendantRequestDto) (*domain.Player, error) {
+	player := &domain.Player{}
+	err := h.db.WithContext(c).Where("external_user_id = ?", req.ExternalUserId).First(player).Error
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return player, err
+	}
 
-			err = tx.Create(reg).Error
+	player = &domain.Player{
+		FirstName:      req.FirstName,
+		LastName:       req.LastName,
+		Email:          req.Email,
+		ExternalUserID: req.ExternalUserId,
+	}
 
-			debugf("create registration done %v with error %v", reg, err)
+	if err := h.db.WithContext(c).Create(player).Error; err != nil {
+		return nil, err
+	}
 
-			return err
-		}
+	return player, nil
+}
 
-		// delete if already registed
-		err = tx.Unscoped().Delete(reg).Error
+func (h *RegistrationHandler) RegisterMatch(c *gin.Context) {
+	var req dto.MatchRegistrationDto
+	if err := c.BindJSON(&req); err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
 
-		debugf("delete registration done %v with error %v", reg, err)
+	playerId, err := currentuser.GetPlayerId(c, h.db)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	reg, err := h.registrationService.WithContext(c).RegisterMatch(playerId, req.MatchId, service.RegisterOptions{AllowConflicts: req.AllowConflicts})
+	if err != nil {
+		abortWithRegistrationError(c, err)
+		return
+	}
 
-		return err
+	c.JSON(http.StatusOK, dto.MatchRegistrationResultDto{
+		RegistrationId: reg.ID,
+		IsWaitlisted:   reg.IsWaitlisted,
 	})
+}
 
+func (h *RegistrationHandler) UnregisterMatch(c *gin.Context) {
+	var req dto.MatchRegistrationDto
+	if err := c.BindJSON(&req); err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	playerId, err := currentuser.GetPlayerId(c, h.db)
 	if err != nil {
 		c.AbortWithError(http.StatusInternalServerError, err)
 		return
 	}
 
-	c.Status(http.StatusOK)
+	res, err := h.registrationService.WithContext(c).UnregisterMatch(playerId, req.MatchId)
+	if err != nil {
+		abortWithRegistrationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
 }
 
-func (h *RegistrationHandler) RegisterMatch(c *gin.Context) {
-	var dto dto.MatchRegistrationDto
-	if err := c.BindJSON(&dto); err != nil {
+func (h *RegistrationHandler) SetRsvp(c *gin.Context) {
+	var req dto.RsvpDto
+	if err := c.BindJSON(&req); err != nil {
 		c.AbortWithError(http.StatusBadRequest, err)
 		return
 	}
@@ -139,29 +190,69 @@ func (h *RegistrationHandler) RegisterMatch(c *gin.Context) {
 		return
 	}
 
-	err = h.registrationService.RegisterMatch(playerId, dto.MatchId)
+	res, err := h.registrationService.WithContext(c).SetRsvp(playerId, req)
+	var conflictErr *domain.ConflictError
+	if errors.As(err, &conflictErr) {
+		c.JSON(http.StatusConflict, service.ToConflictErrorDto(conflictErr))
+		return
+	}
+
+	if errors.Is(err, domain.ErrMatchNotVisible) {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "match not found"})
+		return
+	}
+
+	if errors.Is(err, domain.ErrUnknownRsvp) ||
+		errors.Is(err, domain.ErrRegistrationClosed) ||
+		errors.Is(err, domain.ErrRegistrationNotOpenYet) ||
+		errors.Is(err, domain.ErrCancellationDeadlinePassed) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	if err != nil {
 		c.AbortWithError(http.StatusInternalServerError, err)
 		return
 	}
 
-	c.Status(http.StatusOK)
+	c.JSON(http.StatusOK, res)
 }
 
-func (h *RegistrationHandler) UnregisterMatch(c *gin.Context) {
-	var dto dto.MatchRegistrationDto
-	if err := c.BindJSON(&dto); err != nil {
-		c.AbortWithError(http.StatusBadRequest, err)
+// SendRsvpReminders sends the due reminders straight away, the scheduler also sends them every few minutes
+func (h *RegistrationHandler) SendRsvpReminders(c *gin.Context) {
+	res, err := h.registrationService.WithContext(c).SendRsvpReminders()
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
 		return
 	}
 
-	playerId, err := currentuser.GetPlayerId(c, h.db)
+	c.JSON(http.StatusOK, res)
+}
+
+func (h *RegistrationHandler) GetLateCancellationFees(c *gin.Context) {
+	fees, err := h.registrationService.WithContext(c).GetUnpaidLateCancellationFees()
 	if err != nil {
 		c.AbortWithError(http.StatusInternalServerError, err)
 		return
 	}
 
-	err = h.registrationService.UnregisterMatch(playerId, dto.MatchId)
+	c.JSON(http.StatusOK, fees)
+}
+
+func (h *RegistrationHandler) MarkLateCancellationFeePaid(c *gin.Context) {
+	feeId := util.GetIntRouteParam(c, "feeId")
+	err := h.registrationService.WithContext(c).MarkLateCancellationFeePaid(feeId)
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "fee not found"})
+		return
+	}
+
 	if err != nil {
 		c.AbortWithError(http.StatusInternalServerError, err)
 		return
@@ -170,102 +261,130 @@ func (h *RegistrationHandler) UnregisterMatch(c *gin.Context) {
 	c.Status(http.StatusOK)
 }
 
+// Register registers a player on their behalf with the rules of RegisterMatch,
+// the admin may override the registration window, visibility and player conflicts
 func (h *RegistrationHandler) Register(c *gin.Context) {
-	dto := dto.RegistrationDto{}
-	var err error
-	if err = c.BindJSON(&dto); err != nil {
+	req := dto.RegistrationDto{}
+	if err := c.BindJSON(&req); err != nil {
 		c.AbortWithError(http.StatusBadRequest, err)
 		return
 	}
 
-	var count int64
-	h.db.Model(&domain.Registration{}).
-		Where("player_id = ? AND match_id = ?", dto.PlayerId, dto.MatchId).
-		Count(&count)
-
-	if count > 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Player already registered this match"})
+	reg, err := h.registrationService.WithContext(c).RegisterMatch(req.PlayerId, req.MatchId, service.RegisterOptions{
+		AllowConflicts: req.AllowConflicts,
+		AllowClosed:    req.AllowClosed,
+		AllowPrivate:   req.AllowPrivate,
+	})
+	if err != nil {
+		abortWithRegistrationError(c, err)
 		return
 	}
 
-	var reg *domain.Registration
-	h.db.Transaction(func(tx *gorm.DB) error {
-		reg = &domain.Registration{
-			PlayerId: dto.PlayerId,
-			MatchId:  dto.MatchId,
-			IsPaid:   false,
-		}
-		if err := tx.Create(reg).Error; err != nil {
-			return err
-		}
-
-		ac, err := h.activityService.BuildRegisterActivity(dto.PlayerId, dto.MatchId)
-		if err != nil {
-			return err
-		}
-
-		if err := tx.Create(ac).Error; err != nil {
-			return err
-		}
+	ac, err := h.activityService.WithContext(c).BuildRegisterActivity(req.PlayerId, req.MatchId)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
 
-		return nil
-	})
+	if err := h.db.WithContext(c).Create(ac).Error; err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
 
 	c.JSON(http.StatusCreated, reg)
 }
 
+// Unregister removes a registration on behalf of its player with the rules of UnregisterMatch,
+// it is soft deleted so that calendar feeds publish the withdrawal
 func (h *RegistrationHandler) Unregister(c *gin.Context) {
-	id, _ := c.Params.Get("registrationId")
+	registrationId := util.GetIntRouteParam(c, "registrationId")
 
-	h.db.Transaction(func(tx *gorm.DB) error {
-		reg := &domain.Registration{}
-		if err := tx.Find(&reg, id).Error; err != nil {
-			return err
-		}
-		h.logger.Info(reg)
-		ac, err := h.activityService.BuildUnregisterActivity(reg.PlayerId, reg.MatchId)
-		if err != nil {
-			return err
-		}
+	reg := domain.Registration{}
+	err := h.db.WithContext(c).First(&reg, registrationId).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "registration not found"})
+		return
+	}
 
-		if err := tx.Create(ac).Error; err != nil {
-			return err
-		}
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
 
-		if err := tx.Unscoped().Delete(&domain.Registration{}, id).Error; err != nil {
-			return err
-		}
+	res, err := h.registrationService.WithContext(c).UnregisterMatch(reg.PlayerId, reg.MatchId)
+	if err != nil {
+		abortWithRegistrationError(c, err)
+		return
+	}
 
-		return nil
-	})
+	ac, err := h.activityService.WithContext(c).BuildUnregisterActivity(reg.PlayerId, reg.MatchId)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
 
-	c.JSON(http.StatusOK, id)
+	if err := h.db.WithContext(c).Create(ac).Error; err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
 }
 
 func (h *RegistrationHandler) MarkPaid(c *gin.Context) {
-	registrationId, _ := c.Params.Get("registrationId")
-	entity := domain.Registration{}
-	h.db.Find(&entity, registrationId)
-
-	entity.MarkPaid()
-	h.db.Save(&entity)
-	c.JSON(http.StatusOK, entity)
+	h.setPaid(c, (*domain.Registration).MarkPaid)
 }
 
 func (h *RegistrationHandler) MarkUnPaid(c *gin.Context) {
-	registrationId, _ := c.Params.Get("registrationId")
+	h.setPaid(c, (*domain.Registration).MarkUnpaid)
+}
+
+// setPaid only settles confirmed registrations of a match still accepting changes
+func (h *RegistrationHandler) setPaid(c *gin.Context, mark func(*domain.Registration)) {
+	registrationId := util.GetIntRouteParam(c, "registrationId")
+
 	entity := domain.Registration{}
-	h.db.Find(&entity, registrationId)
+	err := h.db.WithContext(c).First(&entity, registrationId).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "registration not found"})
+		return
+	}
+
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	match := domain.Match{}
+	if err := h.db.WithContext(c).First(&match, entity.MatchId).Error; err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	if !match.AcceptsChanges() {
+		c.JSON(http.StatusConflict, gin.H{"error": "match is " + match.GetState()})
+		return
+	}
+
+	if !entity.IsConfirmed() {
+		c.JSON(http.StatusConflict, gin.H{"error": "registration is not confirmed"})
+		return
+	}
+
+	mark(&entity)
+	if err := h.db.WithContext(c).Model(&entity).Update("is_paid", entity.IsPaid).Error; err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
 
-	entity.MarkUnpaid()
-	h.db.Save(&entity)
 	c.JSON(http.StatusOK, entity)
 }
 
 func (h *RegistrationHandler) GetAll(c *gin.Context) {
 	var result []dto.RegistrationOverviewDto
 	h.logger.Info("querying registration report")
-	h.db.Raw(`
+	tenantId, _ := scopes.GetTenantId(c)
+	h.db.WithContext(c).Raw(`
 		SELECT	
 			r.id AS registration_id,
 			m.id AS match_id, 
@@ -281,9 +400,9 @@ func (h *RegistrationHandler) GetAll(c *gin.Context) {
 		LEFT JOIN "registrations" r ON m.id = r.match_id
 		LEFT JOIN "players" p ON p.id = r.player_id AND p.deleted_at IS NULL
 		LEFT JOIN "sport_centers" sc ON sc.id = m.sport_center_id
-		WHERE r.deleted_at IS NULL
+		WHERE r.deleted_at IS NULL AND m.tenant_id = ?
 		ORDER BY p.first_name ASC
-	`).Scan(&result)
+	`, tenantId).Scan(&result)
 
 	c.JSON(http.StatusOK, result)
 }
@@ -300,7 +419,40 @@ func (h *RegistrationHandler) UpdateTotalPlayerPaidFor(c *gin.Context) {
 		dto.Count = 1
 	}
 
-	err := h.registrationService.UpdateTotalPlayerPaidFor(registrationId, dto.Count)
+	err := h.registrationService.WithContext(c).UpdateTotalPlayerPaidFor(registrationId, dto.Count)
+	if err != nil {
+		abortWithGuestError(c, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+func (h *RegistrationHandler) SetPlayedTime(c *gin.Context) {
+	var req dto.PlayedTimeDto
+	if err := c.BindJSON(&req); err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	registrationId := util.GetIntRouteParam(c, "registrationId")
+	err := h.registrationService.WithContext(c).SetPlayedTime(registrationId, req.From, req.To)
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "registration not found"})
+		return
+	}
+
+	if errors.Is(err, domain.ErrInvalidPlayedTime) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if errors.Is(err, domain.ErrCostSettled) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+
 	if err != nil {
 		c.AbortWithError(http.StatusInternalServerError, err)
 		return
@@ -308,3 +460,76 @@ func (h *RegistrationHandler) UpdateTotalPlayerPaidFor(c *gin.Context) {
 
 	c.Status(http.StatusOK)
 }
+
+func (h *RegistrationHandler) AddGuest(c *gin.Context) {
+	var req dto.GuestDto
+	if err := c.BindJSON(&req); err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	registrationId := util.GetIntRouteParam(c, "registrationId")
+	guest, err := h.registrationService.WithContext(c).AddGuest(registrationId, req)
+	if err != nil {
+		abortWithGuestError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, guest)
+}
+
+func (h *RegistrationHandler) RemoveGuest(c *gin.Context) {
+	registrationId := util.GetIntRouteParam(c, "registrationId")
+	guestId := util.GetIntRouteParam(c, "guestId")
+	if err := h.registrationService.WithContext(c).RemoveGuest(registrationId, guestId); err != nil {
+		abortWithGuestError(c, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+func (h *RegistrationHandler) ConvertGuest(c *gin.Context) {
+	registrationId := util.GetIntRouteParam(c, "registrationId")
+	guestId := util.GetIntRouteParam(c, "guestId")
+	reg, err := h.registrationService.WithContext(c).ConvertGuest(registrationId, guestId)
+	if err != nil {
+		abortWithGuestError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, reg)
+}
+
+func abortWithRegistrationError(c *gin.Context, err error) {
+	var conflictErr *domain.ConflictError
+	switch {
+	case errors.As(err, &conflictErr):
+		c.JSON(http.StatusConflict, service.ToConflictErrorDto(conflictErr))
+	case errors.Is(err, domain.ErrMatchNotVisible):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrAlreadyRegistered):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "match not found"})
+	case errors.Is(err, domain.ErrRegistrationClosed),
+		errors.Is(err, domain.ErrRegistrationNotOpenYet),
+		errors.Is(err, domain.ErrCancellationDeadlinePassed):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.AbortWithError(http.StatusInternalServerError, err)
+	}
+}
+
+func abortWithGuestError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound), errors.Is(err, domain.ErrGuestNotFound), errors.Is(err, domain.ErrRegistrationNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrNoFreeSpot), errors.Is(err, domain.ErrAlreadyRegistered):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrGuestNameRequired):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.AbortWithError(http.StatusInternalServerError, err)
+	}
+}
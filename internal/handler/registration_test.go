@@ -0,0 +1,249 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tructn/racket/internal/db/dbtest"
+	"github.com/tructn/racket/internal/domain"
+	"github.com/tructn/racket/internal/dto"
+	playerfeature "github.com/tructn/racket/internal/feature/player"
+	"github.com/tructn/racket/internal/service"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// newTenantRouter serves the requests in the tenant of dbtest.OpenTenant as TenantRequired would
+func newTenantRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set(domain.TenantContextKey, dbtest.TenantId)
+	})
+	return router
+}
+
+func newAttendantRouter(t *testing.T, db *gorm.DB) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	logger := zap.NewNop().Sugar()
+	activityService := service.NewActivityService(db, logger, service.NewMatchService(db, logger), playerfeature.NewPlayerService(db))
+	handler := NewRegistrationHandler(db, logger, activityService, service.NewRegistrationService(db))
+
+	router := newTenantRouter()
+	handler.UseRouter(router.Group(""))
+	return router
+}
+
+func toggleAttendance(router *gin.Engine, externalUserId string, matchId uint) *httptest.ResponseRecorder {
+	body, _ := json.Marshal(dto.AttendantRequestDto{ExternalUserId: externalUserId, FirstName: externalUserId, MatchId: matchId})
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/registrations/attendant-requests", bytes.NewReader(body)))
+	return w
+}
+
+func createToggleMatch(t *testing.T, db *gorm.DB, capacity uint) (*domain.Match, *domain.Player) {
+	start := time.Now().Add(48 * time.Hour)
+	regular := &domain.Player{FirstName: "Regular", ExternalUserID: "regular"}
+	require.NoError(t, db.Create(regular).Error)
+
+	match := &domain.Match{
+		Start: start,
+		End:   start.Add(2 * time.Hour),
+		State: domain.MatchOpen,
+		CourtBookings: []domain.CourtBooking{
+			{Court: "1", Start: start, End: start.Add(2 * time.Hour), Capacity: capacity},
+		},
+		Registrations: []domain.Registration{*domain.NewRegistration(regular.ID, 0)},
+	}
+	require.NoError(t, db.Create(match).Error)
+	return match, regular
+}
+
+func TestAttendantRequestWaitlistsWhenFull(t *testing.T) {
+	db := dbtest.OpenTenant(t)
+	router := newAttendantRouter(t, db)
+	match, _ := createToggleMatch(t, db, 1)
+
+	w := toggleAttendance(router, "newcomer", match.ID)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	res := dto.MatchRegistrationResultDto{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+	assert.True(t, res.IsWaitlisted)
+}
+
+func TestAttendantRequestPromotesWaitlistOnLeave(t *testing.T) {
+	db := dbtest.OpenTenant(t)
+	router := newAttendantRouter(t, db)
+	match, regular := createToggleMatch(t, db, 1)
+
+	require.Equal(t, http.StatusOK, toggleAttendance(router, "newcomer", match.ID).Code)
+	require.Equal(t, http.StatusOK, toggleAttendance(router, regular.ExternalUserID, match.ID).Code)
+
+	var regs []domain.Registration
+	require.NoError(t, db.Where("match_id = ?", match.ID).Find(&regs).Error)
+	require.Len(t, regs, 1)
+	assert.NotEqual(t, regular.ID, regs[0].PlayerId)
+	assert.False(t, regs[0].IsWaitlisted)
+}
+
+func TestAttendantRequestKeepsRegistrationAfterDeadline(t *testing.T) {
+	db := dbtest.OpenTenant(t)
+	router := newAttendantRouter(t, db)
+	match, regular := createToggleMatch(t, db, 0)
+
+	deadline := time.Now().Add(-time.Hour)
+	require.NoError(t, db.Model(match).Update("cancellation_deadline", deadline).Error)
+
+	w := toggleAttendance(router, regular.ExternalUserID, match.ID)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	var count int64
+	require.NoError(t, db.Model(&domain.Registration{}).Where("match_id = ?", match.ID).Count(&count).Error)
+	assert.Equal(t, int64(1), count)
+}
+
+func TestAttendantRequestRejectsClosedRegistration(t *testing.T) {
+	db := dbtest.OpenTenant(t)
+	router := newAttendantRouter(t, db)
+	match, _ := createToggleMatch(t, db, 0)
+
+	closedAt := time.Now().Add(-time.Hour)
+	require.NoError(t, db.Model(match).Update("registration_closes_at", closedAt).Error)
+
+	w := toggleAttendance(router, "newcomer", match.ID)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func unregister(router *gin.Engine, registrationId uint) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, fmt.Sprintf("/registrations/%d", registrationId), nil))
+	return w
+}
+
+func TestUnregisterSoftDeletesAndPromotesWaitlist(t *testing.T) {
+	db := dbtest.OpenTenant(t)
+	router := newAttendantRouter(t, db)
+	match, regular := createToggleMatch(t, db, 1)
+	require.Equal(t, http.StatusOK, toggleAttendance(router, "newcomer", match.ID).Code)
+
+	reg := domain.Registration{}
+	require.NoError(t, db.Where("player_id = ?", regular.ID).First(&reg).Error)
+	guest, err := domain.NewGuest("Friend", nil)
+	require.NoError(t, err)
+	guest.RegistrationId = reg.ID
+	require.NoError(t, db.Create(guest).Error)
+
+	w := unregister(router, reg.ID)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	// The calendar feed publishes soft deleted registrations as cancelled
+	removed := domain.Registration{}
+	require.NoError(t, db.Unscoped().First(&removed, reg.ID).Error)
+	assert.True(t, removed.DeletedAt.Valid)
+	require.NoError(t, db.Unscoped().First(guest, guest.ID).Error)
+	assert.True(t, guest.DeletedAt.Valid)
+
+	var waitlisted int64
+	require.NoError(t, db.Model(&domain.Registration{}).Where("match_id = ? AND is_waitlisted = ?", match.ID, true).Count(&waitlisted).Error)
+	assert.Zero(t, waitlisted)
+}
+
+func TestUnregisterFollowsCancellationDeadline(t *testing.T) {
+	db := dbtest.OpenTenant(t)
+	router := newAttendantRouter(t, db)
+	match, regular := createToggleMatch(t, db, 0)
+	require.NoError(t, db.Model(match).Update("cancellation_deadline", time.Now().Add(-time.Hour)).Error)
+
+	reg := domain.Registration{}
+	require.NoError(t, db.Where("player_id = ?", regular.ID).First(&reg).Error)
+
+	assert.Equal(t, http.StatusBadRequest, unregister(router, reg.ID).Code)
+	assert.Equal(t, http.StatusNotFound, unregister(router, reg.ID+100).Code)
+	require.NoError(t, db.First(&reg, reg.ID).Error)
+}
+
+func register(router *gin.Engine, req dto.RegistrationDto) *httptest.ResponseRecorder {
+	body, _ := json.Marshal(req)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/registrations", bytes.NewReader(body)))
+	return w
+}
+
+func TestRegisterWaitlistsWhenFull(t *testing.T) {
+	db := dbtest.OpenTenant(t)
+	router := newAttendantRouter(t, db)
+	match, regular := createToggleMatch(t, db, 1)
+
+	newcomer := &domain.Player{FirstName: "Newcomer"}
+	require.NoError(t, db.Create(newcomer).Error)
+
+	w := register(router, dto.RegistrationDto{PlayerId: newcomer.ID, MatchId: match.ID})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	reg := domain.Registration{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &reg))
+	assert.True(t, reg.IsWaitlisted)
+
+	assert.Equal(t, http.StatusConflict, register(router, dto.RegistrationDto{PlayerId: regular.ID, MatchId: match.ID}).Code)
+}
+
+func TestRegisterOverridesClosedRegistration(t *testing.T) {
+	db := dbtest.OpenTenant(t)
+	router := newAttendantRouter(t, db)
+	match, _ := createToggleMatch(t, db, 0)
+	require.NoError(t, db.Model(match).Update("registration_closes_at", time.Now().Add(-time.Hour)).Error)
+
+	newcomer := &domain.Player{FirstName: "Newcomer"}
+	require.NoError(t, db.Create(newcomer).Error)
+
+	req := dto.RegistrationDto{PlayerId: newcomer.ID, MatchId: match.ID}
+	assert.Equal(t, http.StatusBadRequest, register(router, req).Code)
+
+	req.AllowClosed = true
+	assert.Equal(t, http.StatusCreated, register(router, req).Code)
+
+	require.NoError(t, db.Model(match).Update("state", domain.MatchCancelled).Error)
+	other := &domain.Player{FirstName: "Other"}
+	require.NoError(t, db.Create(other).Error)
+	req.PlayerId = other.ID
+	assert.Equal(t, http.StatusBadRequest, register(router, req).Code)
+}
+
+func markPaid(router *gin.Engine, registrationId uint) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPut, fmt.Sprintf("/registrations/%d/paid", registrationId), nil))
+	return w
+}
+
+func TestMarkPaidOnlySettlesConfirmedRegistrations(t *testing.T) {
+	db := dbtest.OpenTenant(t)
+	router := newAttendantRouter(t, db)
+	match, regular := createToggleMatch(t, db, 1)
+	require.Equal(t, http.StatusOK, toggleAttendance(router, "newcomer", match.ID).Code)
+
+	confirmed, waitlisted := domain.Registration{}, domain.Registration{}
+	require.NoError(t, db.Where("player_id = ?", regular.ID).First(&confirmed).Error)
+	require.NoError(t, db.Where("match_id = ? AND is_waitlisted = ?", match.ID, true).First(&waitlisted).Error)
+
+	assert.Equal(t, http.StatusConflict, markPaid(router, waitlisted.ID).Code)
+	assert.Equal(t, http.StatusNotFound, markPaid(router, waitlisted.ID+100).Code)
+	require.Equal(t, http.StatusOK, markPaid(router, confirmed.ID).Code)
+	require.NoError(t, db.First(&confirmed, confirmed.ID).Error)
+	assert.True(t, confirmed.IsPaid)
+
+	require.NoError(t, db.Model(match).Update("state", domain.MatchCancelled).Error)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPut, fmt.Sprintf("/registrations/%d/unpaid", confirmed.ID), nil))
+	assert.Equal(t, http.StatusConflict, w.Code)
+	require.NoError(t, db.First(&confirmed, confirmed.ID).Error)
+	assert.True(t, confirmed.IsPaid)
+}
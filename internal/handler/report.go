@@ -2,6 +2,7 @@ package handler
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/tructn/racket/internal/service"
@@ -12,14 +13,21 @@ import (
 type (
 	ReportHandler struct {
 		paymentservice *service.PaymentService
+		matchService   *service.MatchService
 		db             *gorm.DB
 		logger         *zap.SugaredLogger
 	}
 )
 
-func NewReportHandler(paymentservice *service.PaymentService, db *gorm.DB, logger *zap.SugaredLogger) *ReportHandler {
+func NewReportHandler(
+	paymentservice *service.PaymentService,
+	matchService *service.MatchService,
+	db *gorm.DB,
+	logger *zap.SugaredLogger,
+) *ReportHandler {
 	return &ReportHandler{
 		paymentservice: paymentservice,
+		matchService:   matchService,
 		db:             db,
 		logger:         logger,
 	}
@@ -29,14 +37,37 @@ func (h *ReportHandler) UseRouter(router *gin.RouterGroup) {
 	group := router.Group("/reports")
 	{
 		group.GET("/outstanding-payments", h.getOutstandingPayments)
+		group.GET("/conflicts", h.getConflicts)
 	}
 }
 
 func (h *ReportHandler) getOutstandingPayments(c *gin.Context) {
-	res, err := h.paymentservice.GetOutstandingPaymentReportForAdmin()
+	res, err := h.paymentservice.WithContext(c).GetOutstandingPaymentReportForAdmin()
 	if err != nil {
 		c.AbortWithStatus(http.StatusInternalServerError)
 		return
 	}
 	c.JSON(http.StatusOK, res)
 }
+
+// getConflicts lists overlapping court bookings and player registrations,
+// from today by default or from the "from" query date (yyyy-mm-dd)
+func (h *ReportHandler) getConflicts(c *gin.Context) {
+	from := time.Now().UTC().Truncate(24 * time.Hour)
+	if value := c.Query("from"); value != "" {
+		parsed, err := time.Parse(time.DateOnly, value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid from date"})
+			return
+		}
+		from = parsed
+	}
+
+	res, err := h.matchService.WithContext(c).GetConflictReport(from)
+	if err != nil {
+		h.logger.Errorw("Failed to build conflict report", "error", err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tructn/racket/internal/domain"
+	"github.com/tructn/racket/internal/dto"
+	"github.com/tructn/racket/internal/service"
+	"github.com/tructn/racket/pkg/result"
+	"github.com/tructn/racket/pkg/util"
+	"gorm.io/gorm"
+)
+
+type RotationHandler struct {
+	rotationService *service.RotationService
+}
+
+func NewRotationHandler(rotationService *service.RotationService) *RotationHandler {
+	return &RotationHandler{
+		rotationService: rotationService,
+	}
+}
+
+func (h *RotationHandler) UseRouter(router *gin.RouterGroup) {
+	router.GET("/matches/:matchId/rotation", h.get)
+	router.POST("/matches/:matchId/rotation", h.generate)
+	router.POST("/matches/:matchId/rotation/advance", h.advance)
+}
+
+func (h *RotationHandler) get(c *gin.Context) {
+	matchId := util.GetIntRouteParam(c, "matchId")
+	rotation, err := h.rotationService.WithContext(c).Get(matchId)
+	if err != nil {
+		abortWithRotationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, rotation)
+}
+
+func (h *RotationHandler) generate(c *gin.Context) {
+	var req dto.GenerateRotationDto
+	if err := c.BindJSON(&req); err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	matchId := util.GetIntRouteParam(c, "matchId")
+	rotation, err := h.rotationService.WithContext(c).Generate(matchId, req)
+	if err != nil {
+		abortWithRotationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, rotation)
+}
+
+func (h *RotationHandler) advance(c *gin.Context) {
+	matchId := util.GetIntRouteParam(c, "matchId")
+	rotation, err := h.rotationService.WithContext(c).Advance(matchId)
+	if err != nil {
+		abortWithRotationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, rotation)
+}
+
+func abortWithRotationError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "match not found"})
+	case errors.Is(err, result.ErrorNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "rotation not found"})
+	case errors.Is(err, domain.ErrRotationFinished):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	}
+}
@@ -24,7 +24,7 @@ func NewSettingsHandler(db *gorm.DB, logger *zap.SugaredLogger) *SettingsHandler
 
 func (h *SettingsHandler) GetMessageTemplate(c *gin.Context) {
 	var result string
-	h.db.Model(&domain.Settings{}).Select("message_template").Scan(&result)
+	h.db.WithContext(c).Model(&domain.Settings{}).Select("message_template").Scan(&result)
 	c.JSON(http.StatusOK, result)
 }
 
@@ -38,12 +38,43 @@ func (h *SettingsHandler) CreateMessageTemplate(c *gin.Context) {
 	}
 
 	settings := domain.Settings{}
-	h.db.Model(&settings).FirstOrCreate(&domain.Settings{
+	h.db.WithContext(c).Model(&settings).FirstOrCreate(&domain.Settings{
 		MessageTemplate: dto.Template,
 	})
 
 	settings.MessageTemplate = dto.Template
-	h.db.Save(&settings)
+	h.db.WithContext(c).Save(&settings)
 
 	c.JSON(http.StatusOK, settings)
 }
+
+func (h *SettingsHandler) GetNoShowPolicy(c *gin.Context) {
+	settings := domain.Settings{}
+	h.db.WithContext(c).Model(&settings).First(&settings)
+
+	policy := settings.GetNoShowPolicy()
+	c.JSON(http.StatusOK, dto.NoShowPolicyDto{
+		Threshold:    policy.Threshold,
+		LookbackDays: policy.LookbackDays,
+	})
+}
+
+func (h *SettingsHandler) UpdateNoShowPolicy(c *gin.Context) {
+	dto := dto.NoShowPolicyDto{}
+	if err := c.BindJSON(&dto); err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	settings := domain.Settings{}
+	h.db.WithContext(c).FirstOrCreate(&settings)
+
+	settings.NoShowThreshold = dto.Threshold
+	settings.NoShowLookbackDays = dto.LookbackDays
+	if err := h.db.WithContext(c).Save(&settings).Error; err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto)
+}
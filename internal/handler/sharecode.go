@@ -33,7 +33,7 @@ func (h *ShareCodeHandler) CreateShareUrl(c *gin.Context) {
 
 	sc := domain.NewShareCodeWithUrl(dto.Url, &domain.DefaultGenerator{})
 
-	if err := h.db.Create(sc).Error; err != nil {
+	if err := h.db.WithContext(c).Create(sc).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, err)
 		return
 	}
@@ -43,7 +43,7 @@ func (h *ShareCodeHandler) CreateShareUrl(c *gin.Context) {
 
 func (h *ShareCodeHandler) GetShareUrls(c *gin.Context) {
 	shareUrls := []dto.ShareCodeDto{}
-	if err := h.db.Model(&domain.ShareCode{}).Select("Id", "FullUrl").Scan(&shareUrls).Error; err != nil {
+	if err := h.db.WithContext(c).Model(&domain.ShareCode{}).Select("Id", "FullUrl").Scan(&shareUrls).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, err)
 		return
 	}
@@ -52,7 +52,7 @@ func (h *ShareCodeHandler) GetShareUrls(c *gin.Context) {
 
 func (h *ShareCodeHandler) DeleteShareCodeUrl(c *gin.Context) {
 	id := util.GetRouteString(c, "shareCodeId")
-	if err := h.db.Unscoped().Delete(&domain.ShareCode{}, id).Error; err != nil {
+	if err := h.db.WithContext(c).Unscoped().Delete(&domain.ShareCode{}, id).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, err)
 		return
 	}
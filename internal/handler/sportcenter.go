@@ -20,7 +20,7 @@ func NewSportCenterHandler(service *service.SportCenterService) *SportCenterHand
 }
 
 func (h *SportCenterHandler) GetAll(c *gin.Context) {
-	result, err := h.service.GetAll()
+	result, err := h.service.WithContext(c).GetAll()
 	if err != nil {
 		c.AbortWithStatusJSON(http.StatusInternalServerError, err)
 		return
@@ -29,7 +29,7 @@ func (h *SportCenterHandler) GetAll(c *gin.Context) {
 }
 
 func (h *SportCenterHandler) GetOptions(c *gin.Context) {
-	result, err := h.service.GetOptions()
+	result, err := h.service.WithContext(c).GetOptions()
 	if err != nil {
 		c.AbortWithStatusJSON(http.StatusInternalServerError, err)
 		return
@@ -44,11 +44,12 @@ func (h *SportCenterHandler) Create(c *gin.Context) {
 		return
 	}
 
-	err := h.service.Create(
+	err := h.service.WithContext(c).Create(
 		dto.Name,
 		dto.Location,
 		dto.CostPerSection,
 		dto.MinutePerSection,
+		dto.Timezone,
 	)
 
 	if err != nil {
@@ -68,12 +69,13 @@ func (h *SportCenterHandler) Update(c *gin.Context) {
 		return
 	}
 
-	err := h.service.Update(
+	err := h.service.WithContext(c).Update(
 		id,
 		dto.Name,
 		dto.Location,
 		dto.CostPerSection,
 		dto.MinutePerSection,
+		dto.Timezone,
 	)
 
 	if err != nil {
@@ -83,3 +85,154 @@ func (h *SportCenterHandler) Update(c *gin.Context) {
 
 	c.Status(http.StatusOK)
 }
+
+func (h *SportCenterHandler) GetPricingRules(c *gin.Context) {
+	id := util.GetIntRouteParam(c, "sportCenterId")
+	result, err := h.service.WithContext(c).GetPricingRules(id)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *SportCenterHandler) UpdatePricingRules(c *gin.Context) {
+	id := util.GetIntRouteParam(c, "sportCenterId")
+	rules := []dto.PricingRuleDto{}
+	if err := c.BindJSON(&rules); err != nil {
+		c.AbortWithStatus(http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.WithContext(c).ReplacePricingRules(id, rules); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+func (h *SportCenterHandler) QuotePrice(c *gin.Context) {
+	id := util.GetIntRouteParam(c, "sportCenterId")
+	req := dto.PricingQuoteRequestDto{}
+	if err := c.BindJSON(&req); err != nil {
+		c.AbortWithStatus(http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.service.WithContext(c).Quote(id, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *SportCenterHandler) GetPriceHistory(c *gin.Context) {
+	id := util.GetIntRouteParam(c, "sportCenterId")
+	result, err := h.service.WithContext(c).GetPriceHistory(id)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *SportCenterHandler) ChangePrice(c *gin.Context) {
+	id := util.GetIntRouteParam(c, "sportCenterId")
+	req := dto.ChangeSportCenterPriceDto{}
+	if err := c.BindJSON(&req); err != nil {
+		c.AbortWithStatus(http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.WithContext(c).ChangePrice(id, req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+func (h *SportCenterHandler) PreviewRecalculation(c *gin.Context) {
+	id := util.GetIntRouteParam(c, "sportCenterId")
+	result, err := h.service.WithContext(c).PreviewRecalculation(id)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *SportCenterHandler) ApplyRecalculation(c *gin.Context) {
+	id := util.GetIntRouteParam(c, "sportCenterId")
+	result, err := h.service.WithContext(c).ApplyRecalculation(id)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *SportCenterHandler) GetOpeningHours(c *gin.Context) {
+	id := util.GetIntRouteParam(c, "sportCenterId")
+	result, err := h.service.WithContext(c).GetOpeningHours(id)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *SportCenterHandler) UpdateOpeningHours(c *gin.Context) {
+	id := util.GetIntRouteParam(c, "sportCenterId")
+	hours := []dto.OpeningHoursDto{}
+	if err := c.BindJSON(&hours); err != nil {
+		c.AbortWithStatus(http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.WithContext(c).ReplaceOpeningHours(id, hours); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+func (h *SportCenterHandler) GetClosures(c *gin.Context) {
+	id := util.GetIntRouteParam(c, "sportCenterId")
+	result, err := h.service.WithContext(c).GetClosures(id)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *SportCenterHandler) AddClosure(c *gin.Context) {
+	id := util.GetIntRouteParam(c, "sportCenterId")
+	closure := dto.ClosureDto{}
+	if err := c.BindJSON(&closure); err != nil {
+		c.AbortWithStatus(http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.WithContext(c).AddClosure(id, closure); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusCreated)
+}
+
+func (h *SportCenterHandler) DeleteClosure(c *gin.Context) {
+	id := util.GetIntRouteParam(c, "sportCenterId")
+	closureId := util.GetIntRouteParam(c, "closureId")
+	if err := h.service.WithContext(c).DeleteClosure(id, closureId); err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
@@ -0,0 +1,160 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tructn/racket/internal/domain"
+	"github.com/tructn/racket/internal/dto"
+	"github.com/tructn/racket/internal/service"
+	"github.com/tructn/racket/pkg/currentuser"
+	"github.com/tructn/racket/pkg/result"
+	"github.com/tructn/racket/pkg/util"
+	"gorm.io/gorm"
+)
+
+type StandingRegistrationHandler struct {
+	db                          *gorm.DB
+	standingRegistrationService *service.StandingRegistrationService
+}
+
+func NewStandingRegistrationHandler(
+	db *gorm.DB,
+	standingRegistrationService *service.StandingRegistrationService,
+) *StandingRegistrationHandler {
+	return &StandingRegistrationHandler{
+		db:                          db,
+		standingRegistrationService: standingRegistrationService,
+	}
+}
+
+// UseRouter serves the standing registrations of the current player
+func (h *StandingRegistrationHandler) UseRouter(router *gin.RouterGroup) {
+	group := router.Group("/standing-registrations")
+	{
+		group.GET("", h.getMine)
+		group.POST("", h.create)
+		group.PUT("/:standingRegistrationId/pause", h.pause)
+		group.DELETE("/:standingRegistrationId", h.delete)
+		group.POST("/matches/:matchId/opt-out", h.optOut)
+	}
+}
+
+func (h *StandingRegistrationHandler) getMine(c *gin.Context) {
+	playerId, err := currentuser.GetPlayerId(c, h.db)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	standings, err := h.standingRegistrationService.WithContext(c).GetMine(playerId)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, standings)
+}
+
+func (h *StandingRegistrationHandler) create(c *gin.Context) {
+	var req dto.StandingRegistrationRequestDto
+	if err := c.BindJSON(&req); err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	playerId, err := currentuser.GetPlayerId(c, h.db)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	standing, err := h.standingRegistrationService.WithContext(c).Create(playerId, req)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "match or sport center not found"})
+		return
+	}
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, standing)
+}
+
+func (h *StandingRegistrationHandler) pause(c *gin.Context) {
+	id := util.GetIntRouteParam(c, "standingRegistrationId")
+	var req dto.PauseStandingRegistrationDto
+	if err := c.BindJSON(&req); err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	playerId, err := currentuser.GetPlayerId(c, h.db)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	if err := h.standingRegistrationService.WithContext(c).Pause(playerId, id, req); err != nil {
+		abortWithStandingRegistrationError(c, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+func (h *StandingRegistrationHandler) delete(c *gin.Context) {
+	id := util.GetIntRouteParam(c, "standingRegistrationId")
+	playerId, err := currentuser.GetPlayerId(c, h.db)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	if err := h.standingRegistrationService.WithContext(c).Delete(playerId, id); err != nil {
+		abortWithStandingRegistrationError(c, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// optOut leaves a match the player was registered to automatically, it is free until registration closes
+func (h *StandingRegistrationHandler) optOut(c *gin.Context) {
+	matchId := util.GetIntRouteParam(c, "matchId")
+	playerId, err := currentuser.GetPlayerId(c, h.db)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	res, err := h.standingRegistrationService.WithContext(c).OptOut(playerId, matchId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "match not found"})
+		return
+	}
+
+	if errors.Is(err, domain.ErrNotStandingRegistration) || errors.Is(err, domain.ErrOptOutWindowClosed) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func abortWithStandingRegistrationError(c *gin.Context, err error) {
+	if errors.Is(err, result.ErrorNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "standing registration not found"})
+		return
+	}
+
+	c.AbortWithError(http.StatusInternalServerError, err)
+}
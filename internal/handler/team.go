@@ -32,7 +32,7 @@ func (h *TeamHandler) UseRouter(router *gin.RouterGroup) {
 }
 
 func (h *TeamHandler) getTeams(c *gin.Context) {
-	teams, err := h.teamService.GetTeams(c.Request.Context())
+	teams, err := h.teamService.WithContext(c).GetTeams(c.Request.Context())
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -50,7 +50,7 @@ func (h *TeamHandler) createTeam(c *gin.Context) {
 
 	userId, _ := currentuser.GetIdpUserId(c)
 
-	team, err := h.teamService.CreateTeam(req, userId)
+	team, err := h.teamService.WithContext(c).CreateTeam(req, userId)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -67,7 +67,7 @@ func (h *TeamHandler) getTeam(c *gin.Context) {
 	}
 
 	userId, _ := currentuser.GetIdpUserId(c)
-	team, err := h.teamService.GetTeam(uint(id), userId)
+	team, err := h.teamService.WithContext(c).GetTeam(uint(id), userId)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Team not found"})
 		return
@@ -90,7 +90,7 @@ func (h *TeamHandler) updateTeam(c *gin.Context) {
 	}
 
 	userId, _ := currentuser.GetIdpUserId(c)
-	if err := h.teamService.UpdateTeam(uint(id), req, userId); err != nil {
+	if err := h.teamService.WithContext(c).UpdateTeam(uint(id), req, userId); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -106,7 +106,7 @@ func (h *TeamHandler) deleteTeam(c *gin.Context) {
 	}
 
 	userId, _ := currentuser.GetIdpUserId(c)
-	if err := h.teamService.DeleteTeam(uint(id), userId); err != nil {
+	if err := h.teamService.WithContext(c).DeleteTeam(uint(id), userId); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -128,7 +128,7 @@ func (h *TeamHandler) addPlayer(c *gin.Context) {
 	}
 
 	userId, _ := currentuser.GetIdpUserId(c)
-	if err := h.teamService.AddPlayer(uint(teamID), req.PlayerID, req.Role, userId); err != nil {
+	if err := h.teamService.WithContext(c).AddPlayer(uint(teamID), req.PlayerID, req.Role, userId); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -150,7 +150,7 @@ func (h *TeamHandler) removePlayer(c *gin.Context) {
 	}
 
 	userId, _ := currentuser.GetIdpUserId(c)
-	if err := h.teamService.RemovePlayer(uint(teamID), uint(playerID), userId); err != nil {
+	if err := h.teamService.WithContext(c).RemovePlayer(uint(teamID), uint(playerID), userId); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tructn/racket/internal/dto"
+	"github.com/tructn/racket/internal/service"
+	"github.com/tructn/racket/pkg/currentuser"
+	"github.com/tructn/racket/pkg/util"
+	"gorm.io/gorm"
+)
+
+type TeamSplitHandler struct {
+	teamSplitService *service.TeamSplitService
+}
+
+func NewTeamSplitHandler(teamSplitService *service.TeamSplitService) *TeamSplitHandler {
+	return &TeamSplitHandler{
+		teamSplitService: teamSplitService,
+	}
+}
+
+func (h *TeamSplitHandler) UseRouter(router *gin.RouterGroup) {
+	router.POST("/matches/:matchId/team-splits", h.suggest)
+	router.GET("/matches/:matchId/teams", h.getTeams)
+	router.PUT("/matches/:matchId/teams", h.apply)
+}
+
+func (h *TeamSplitHandler) suggest(c *gin.Context) {
+	var req dto.TeamSplitRequestDto
+	if err := c.BindJSON(&req); err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	matchId := util.GetIntRouteParam(c, "matchId")
+	splits, err := h.teamSplitService.WithContext(c).Suggest(matchId, req)
+	if err != nil {
+		abortWithTeamSplitError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, splits)
+}
+
+func (h *TeamSplitHandler) getTeams(c *gin.Context) {
+	matchId := util.GetIntRouteParam(c, "matchId")
+	teams, err := h.teamSplitService.WithContext(c).GetEventTeams(matchId)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, teams)
+}
+
+func (h *TeamSplitHandler) apply(c *gin.Context) {
+	var req dto.ApplyTeamSplitDto
+	if err := c.BindJSON(&req); err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	ownerId, _ := currentuser.GetIdpUserId(c)
+	matchId := util.GetIntRouteParam(c, "matchId")
+	teams, err := h.teamSplitService.WithContext(c).Apply(matchId, ownerId, req)
+	if err != nil {
+		abortWithTeamSplitError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, teams)
+}
+
+func abortWithTeamSplitError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "match not found"})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,115 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tructn/racket/internal/domain"
+	"github.com/tructn/racket/internal/dto"
+	"github.com/tructn/racket/internal/service"
+	"github.com/tructn/racket/pkg/currentuser"
+	"github.com/tructn/racket/pkg/middleware"
+	"github.com/tructn/racket/pkg/result"
+	"github.com/tructn/racket/pkg/util"
+	"gorm.io/gorm"
+)
+
+type TenantHandler struct {
+	tenantService *service.TenantService
+}
+
+func NewTenantHandler(tenantService *service.TenantService) *TenantHandler {
+	return &TenantHandler{tenantService: tenantService}
+}
+
+// UseRouter serves the memberships of the current user, the routes do not need a tenant to be selected
+func (h *TenantHandler) UseRouter(router *gin.RouterGroup) {
+	group := router.Group("/tenants")
+	{
+		group.GET("", h.getMine)
+		group.POST("", h.create)
+		group.POST("/:tenantId/members", h.addMember)
+	}
+}
+
+func (h *TenantHandler) getMine(c *gin.Context) {
+	idpUserId, err := currentuser.GetIdpUserId(c)
+	if err != nil {
+		c.AbortWithStatus(http.StatusUnauthorized)
+		return
+	}
+
+	tenants, err := h.tenantService.GetMine(idpUserId)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, tenants)
+}
+
+func (h *TenantHandler) create(c *gin.Context) {
+	var req dto.CreateTenantDto
+	if err := c.BindJSON(&req); err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	idpUserId, err := currentuser.GetIdpUserId(c)
+	if err != nil {
+		c.AbortWithStatus(http.StatusUnauthorized)
+		return
+	}
+
+	tenant, err := h.tenantService.Create(idpUserId, req)
+	if err != nil {
+		abortWithTenantError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, tenant)
+}
+
+func (h *TenantHandler) addMember(c *gin.Context) {
+	tenantId := util.GetIntRouteParam(c, "tenantId")
+	var req dto.AddTenantMemberDto
+	if err := c.BindJSON(&req); err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	idpUserId, err := currentuser.GetIdpUserId(c)
+	if err != nil {
+		c.AbortWithStatus(http.StatusUnauthorized)
+		return
+	}
+
+	roles, _ := currentuser.GetIdpUserRoles(c)
+	hasIdpAdminRole := middleware.CustomClaims{Roles: roles}.IsAdmin()
+
+	player, err := h.tenantService.AddMember(idpUserId, hasIdpAdminRole, tenantId, req)
+	if err != nil {
+		abortWithTenantError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, player)
+}
+
+func abortWithTenantError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, result.ErrorNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "account not found"})
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "tenant not found"})
+	case errors.Is(err, domain.ErrNotTenantMember), errors.Is(err, domain.ErrNotTenantManager):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrAlreadyMember), errors.Is(err, domain.ErrTenantSlugTaken):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrInvalidTenantSlug), errors.Is(err, domain.ErrTenantNameRequired):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.AbortWithError(http.StatusInternalServerError, err)
+	}
+}
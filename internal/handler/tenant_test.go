@@ -0,0 +1,59 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tructn/racket/internal/db/dbtest"
+	"github.com/tructn/racket/internal/domain"
+	"github.com/tructn/racket/internal/dto"
+	"github.com/tructn/racket/internal/service"
+	"go.uber.org/zap"
+)
+
+func TestAddTenantMemberRequiresOwnerOrAdmin(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := dbtest.Open(t)
+
+	other := &domain.Tenant{Name: "Other", Slug: "other"}
+	tenant := &domain.Tenant{Name: "Club", Slug: "club", CreatedByID: "auth0|owner"}
+	require.NoError(t, db.Create([]*domain.Tenant{other, tenant}).Error)
+
+	for _, p := range []*domain.Player{
+		{BaseModel: domain.BaseModel{TenantId: tenant.ID}, ExternalUserID: "auth0|owner", Email: "owner@example.com"},
+		{BaseModel: domain.BaseModel{TenantId: tenant.ID}, ExternalUserID: "auth0|member", Email: "member@example.com"},
+		{BaseModel: domain.BaseModel{TenantId: tenant.ID}, ExternalUserID: "auth0|admin", Email: "admin@example.com", Role: domain.TenantRoleAdmin},
+		{BaseModel: domain.BaseModel{TenantId: other.ID}, ExternalUserID: "auth0|jane", Email: "jane@example.com"},
+		{BaseModel: domain.BaseModel{TenantId: other.ID}, ExternalUserID: "auth0|john", Email: "john@example.com"},
+	} {
+		require.NoError(t, db.Create(p).Error)
+	}
+
+	handler := NewTenantHandler(service.NewTenantService(db, zap.NewNop().Sugar()))
+	addMember := func(idpUserId string, roles []string, email string) int {
+		router := gin.New()
+		router.Use(func(c *gin.Context) {
+			c.Set("idp_user_id", idpUserId)
+			c.Set("idp_user_roles", roles)
+		})
+		handler.UseRouter(router.Group(""))
+
+		body, _ := json.Marshal(dto.AddTenantMemberDto{Email: email})
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, fmt.Sprintf("/tenants/%d/members", tenant.ID), bytes.NewReader(body)))
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusForbidden, addMember("auth0|member", []string{"player"}, "jane@example.com"))
+	// The IdP admin role only applies to the default tenant, the membership decides in the others
+	assert.Equal(t, http.StatusForbidden, addMember("auth0|member", []string{"admin"}, "jane@example.com"))
+	assert.Equal(t, http.StatusCreated, addMember("auth0|owner", []string{"player"}, "jane@example.com"))
+	assert.Equal(t, http.StatusCreated, addMember("auth0|admin", []string{"player"}, "john@example.com"))
+}
@@ -0,0 +1,213 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tructn/racket/internal/domain"
+	"github.com/tructn/racket/internal/dto"
+	"github.com/tructn/racket/internal/service"
+	"github.com/tructn/racket/pkg/currentuser"
+	"github.com/tructn/racket/pkg/result"
+	"github.com/tructn/racket/pkg/util"
+	"gorm.io/gorm"
+)
+
+type TournamentHandler struct {
+	tournamentService *service.TournamentService
+}
+
+func NewTournamentHandler(tournamentService *service.TournamentService) *TournamentHandler {
+	return &TournamentHandler{
+		tournamentService: tournamentService,
+	}
+}
+
+func (h *TournamentHandler) UseRouter(router *gin.RouterGroup) {
+	group := router.Group("/tournaments")
+	{
+		group.GET("", h.getAll)
+		group.POST("", h.create)
+		group.GET("/:tournamentId", h.get)
+		group.POST("/:tournamentId/entries", h.addEntry)
+		group.DELETE("/:tournamentId/entries/:entryId", h.removeEntry)
+		group.PUT("/:tournamentId/entries/:entryId/players/:playerId/paid", h.markEntryFeePaid)
+		group.PUT("/:tournamentId/sessions", h.setSessions)
+		group.POST("/:tournamentId/draw", h.draw)
+		group.POST("/:tournamentId/schedule", h.schedule)
+		group.GET("/:tournamentId/bracket", h.getBracket)
+		group.PUT("/:tournamentId/bracket/:bracketMatchId/result", h.recordResult)
+	}
+}
+
+func (h *TournamentHandler) getAll(c *gin.Context) {
+	tournaments, err := h.tournamentService.WithContext(c).GetAll()
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, tournaments)
+}
+
+func (h *TournamentHandler) create(c *gin.Context) {
+	var req dto.CreateTournamentDto
+	if err := c.BindJSON(&req); err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	tournament, err := h.tournamentService.WithContext(c).Create(req)
+	if err != nil {
+		abortWithTournamentError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, tournament)
+}
+
+func (h *TournamentHandler) get(c *gin.Context) {
+	tournamentId := util.GetIntRouteParam(c, "tournamentId")
+	tournament, err := h.tournamentService.WithContext(c).Get(tournamentId)
+	if err != nil {
+		abortWithTournamentError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, tournament)
+}
+
+func (h *TournamentHandler) addEntry(c *gin.Context) {
+	var req dto.TournamentEntryRequestDto
+	if err := c.BindJSON(&req); err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	tournamentId := util.GetIntRouteParam(c, "tournamentId")
+	tournament, err := h.tournamentService.WithContext(c).AddEntry(tournamentId, req)
+	if err != nil {
+		abortWithTournamentError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, tournament)
+}
+
+func (h *TournamentHandler) removeEntry(c *gin.Context) {
+	tournamentId := util.GetIntRouteParam(c, "tournamentId")
+	entryId := util.GetIntRouteParam(c, "entryId")
+	if err := h.tournamentService.WithContext(c).RemoveEntry(tournamentId, entryId); err != nil {
+		abortWithTournamentError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *TournamentHandler) markEntryFeePaid(c *gin.Context) {
+	tournamentId := util.GetIntRouteParam(c, "tournamentId")
+	entryId := util.GetIntRouteParam(c, "entryId")
+	playerId := util.GetIntRouteParam(c, "playerId")
+	if err := h.tournamentService.WithContext(c).MarkEntryFeePaid(tournamentId, entryId, playerId); err != nil {
+		abortWithTournamentError(c, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+func (h *TournamentHandler) setSessions(c *gin.Context) {
+	var req dto.TournamentSessionsDto
+	if err := c.BindJSON(&req); err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	tournamentId := util.GetIntRouteParam(c, "tournamentId")
+	tournament, err := h.tournamentService.WithContext(c).SetSessions(tournamentId, req)
+	if err != nil {
+		abortWithTournamentError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, tournament)
+}
+
+func (h *TournamentHandler) draw(c *gin.Context) {
+	tournamentId := util.GetIntRouteParam(c, "tournamentId")
+	bracket, err := h.tournamentService.WithContext(c).Draw(tournamentId)
+	if err != nil {
+		abortWithTournamentError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, bracket)
+}
+
+func (h *TournamentHandler) schedule(c *gin.Context) {
+	tournamentId := util.GetIntRouteParam(c, "tournamentId")
+	bracket, err := h.tournamentService.WithContext(c).Schedule(tournamentId)
+	if err != nil {
+		abortWithTournamentError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, bracket)
+}
+
+func (h *TournamentHandler) getBracket(c *gin.Context) {
+	tournamentId := util.GetIntRouteParam(c, "tournamentId")
+	bracket, err := h.tournamentService.WithContext(c).GetBracket(tournamentId)
+	if err != nil {
+		abortWithTournamentError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, bracket)
+}
+
+func (h *TournamentHandler) recordResult(c *gin.Context) {
+	var req dto.BracketResultDto
+	if err := c.BindJSON(&req); err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	editedBy, err := currentuser.GetIdpUserId(c)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	tournamentId := util.GetIntRouteParam(c, "tournamentId")
+	bracketMatchId := util.GetIntRouteParam(c, "bracketMatchId")
+	bracket, err := h.tournamentService.WithContext(c).RecordResult(tournamentId, bracketMatchId, editedBy, req)
+	if err != nil {
+		abortWithTournamentError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, bracket)
+}
+
+func abortWithTournamentError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, result.ErrorNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "tournament not found"})
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "match not found"})
+	case errors.Is(err, domain.ErrEntryNotFound), errors.Is(err, domain.ErrBracketMatchNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrTournamentStarted),
+		errors.Is(err, domain.ErrTournamentNotStarted),
+		errors.Is(err, domain.ErrPlayerAlreadyEntered),
+		errors.Is(err, domain.ErrBracketMatchNotReady),
+		errors.Is(err, domain.ErrBracketMatchPlayed),
+		errors.Is(err, domain.ErrNotEnoughSlots):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	}
+}
@@ -1,6 +1,7 @@
 package service
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 
@@ -32,6 +33,14 @@ func NewActivityService(
 	}
 }
 
+func (s *ActivityService) WithContext(ctx context.Context) *ActivityService {
+	clone := *s
+	clone.db = s.db.WithContext(ctx)
+	clone.matchsvc = s.matchsvc.WithContext(ctx)
+	clone.playersvc = s.playersvc.WithContext(ctx)
+	return &clone
+}
+
 func (s *ActivityService) Get() []dto.ActivityDto {
 	activities := []domain.Activity{}
 	if err := s.db.Limit(50).Order("created_at desc").Find(&activities).Error; err != nil {
@@ -0,0 +1,187 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/samber/lo"
+	"github.com/tructn/racket/internal/domain"
+	"github.com/tructn/racket/internal/dto"
+	"github.com/tructn/racket/pkg/result"
+	"github.com/tructn/racket/pkg/scopes"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type AttendanceService struct {
+	db        *gorm.DB
+	logger    *zap.SugaredLogger
+	generator domain.Generator
+}
+
+func NewAttendanceService(db *gorm.DB, logger *zap.SugaredLogger) *AttendanceService {
+	return &AttendanceService{
+		db:        db,
+		logger:    logger,
+		generator: &domain.DefaultGenerator{},
+	}
+}
+
+func (s *AttendanceService) WithContext(ctx context.Context) *AttendanceService {
+	clone := *s
+	clone.db = s.db.WithContext(ctx)
+	return &clone
+}
+
+// GetCheckInCode returns the current code to show as a QR code at the venue
+func (s *AttendanceService) GetCheckInCode(matchId uint) (*dto.CheckInCodeDto, error) {
+	match := &domain.Match{}
+	if err := s.db.First(match, matchId).Error; err != nil {
+		return nil, err
+	}
+
+	if match.CheckInSecret == "" {
+		if err := match.EnsureCheckInSecret(s.generator); err != nil {
+			return nil, err
+		}
+
+		if err := s.db.Model(match).Update("check_in_secret", match.CheckInSecret).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	code, expiresAt := match.CheckInCode(time.Now())
+	return &dto.CheckInCodeDto{
+		MatchId:   match.ID,
+		Code:      code,
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+// CheckIn is called by a player scanning the QR code at the venue
+func (s *AttendanceService) CheckIn(playerId, matchId uint, code string) (*dto.AttendanceDto, error) {
+	match := &domain.Match{}
+	if err := s.db.First(match, matchId).Error; err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if !match.VerifyCheckInCode(code, now) {
+		return nil, domain.ErrInvalidCheckInCode
+	}
+
+	registration := &domain.Registration{}
+	err := s.db.
+		Where("match_id = ? AND player_id = ? AND is_waitlisted = false AND rsvp = ?", matchId, playerId, domain.RsvpGoing).
+		First(registration).Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, result.ErrorNotFound
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := registration.CheckIn(match, now); err != nil {
+		return nil, err
+	}
+
+	if err := s.db.Save(registration).Error; err != nil {
+		return nil, err
+	}
+
+	return toAttendanceDto(*registration), nil
+}
+
+// MarkAttendance lets the organizer record attendance from the roster
+func (s *AttendanceService) MarkAttendance(matchId uint, items []dto.AttendanceDto) ([]dto.AttendanceDto, error) {
+	var registrations []domain.Registration
+	if err := s.db.Where("match_id = ?", matchId).Find(&registrations).Error; err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		for _, item := range items {
+			attendance, err := domain.ParseAttendance(item.Attendance)
+			if err != nil {
+				return err
+			}
+
+			_, idx, found := lo.FindIndexOf(registrations, func(r domain.Registration) bool { return r.ID == item.RegistrationId })
+			if !found {
+				return result.ErrorNotFound
+			}
+
+			registrations[idx].MarkAttendance(attendance, now)
+			if err := tx.Save(&registrations[idx]).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return lo.Map(registrations, func(r domain.Registration, _ int) dto.AttendanceDto { return *toAttendanceDto(r) }), nil
+}
+
+// GetReliability counts attendance of played matches, all players when playerId is 0
+func (s *AttendanceService) GetReliability(playerId uint) ([]dto.PlayerReliabilityDto, error) {
+	rows := []dto.PlayerReliabilityDto{}
+	tenantId, _ := scopes.GetTenantId(s.db.Statement.Context)
+	err := s.db.Raw(`
+		SELECT
+			p.id AS player_id,
+			TRIM(CONCAT(p.first_name, ' ', p.last_name)) AS player_name,
+			COUNT(r.id) AS matches,
+			COUNT(r.id) FILTER (WHERE r.attendance = ?) AS attended,
+			COUNT(r.id) FILTER (WHERE r.attendance = ?) AS late,
+			COUNT(r.id) FILTER (WHERE r.attendance = ?) AS no_shows
+		FROM registrations r
+		JOIN matches m ON m.id = r.match_id AND m.deleted_at IS NULL
+		JOIN players p ON p.id = r.player_id AND p.deleted_at IS NULL
+		WHERE r.deleted_at IS NULL
+			AND r.tenant_id = ?
+			AND r.is_waitlisted = false
+			AND r.rsvp = ?
+			AND m.state IN ?
+			AND (? = 0 OR p.id = ?)
+		GROUP BY p.id
+		ORDER BY player_name
+	`,
+		domain.AttendanceAttended,
+		domain.AttendanceLate,
+		domain.AttendanceNoShow,
+		tenantId,
+		domain.RsvpGoing,
+		[]domain.MatchState{domain.MatchPlayed, domain.MatchFinalized},
+		playerId,
+		playerId,
+	).Scan(&rows).Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range rows {
+		recorded := rows[i].Attended + rows[i].Late + rows[i].NoShows
+		if recorded > 0 {
+			rows[i].Reliability = float64(rows[i].Attended+rows[i].Late) / float64(recorded)
+		}
+	}
+
+	return rows, nil
+}
+
+func toAttendanceDto(r domain.Registration) *dto.AttendanceDto {
+	return &dto.AttendanceDto{
+		RegistrationId: r.ID,
+		Attendance:     r.Attendance,
+		CheckedInAt:    r.CheckedInAt,
+	}
+}
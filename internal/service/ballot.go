@@ -0,0 +1,249 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/samber/lo"
+	"github.com/tructn/racket/internal/domain"
+	"github.com/tructn/racket/internal/dto"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type BallotService struct {
+	db                *gorm.DB
+	logger            *zap.SugaredLogger
+	attendanceService *AttendanceService
+}
+
+func NewBallotService(db *gorm.DB, logger *zap.SugaredLogger, attendanceService *AttendanceService) *BallotService {
+	return &BallotService{
+		db:                db,
+		logger:            logger,
+		attendanceService: attendanceService,
+	}
+}
+
+func (s *BallotService) WithContext(ctx context.Context) *BallotService {
+	clone := *s
+	clone.db = s.db.WithContext(ctx)
+	clone.attendanceService = s.attendanceService.WithContext(ctx)
+	return &clone
+}
+
+// SetBallot collects registrations until the draw time instead of confirming them first-come-first-served
+func (s *BallotService) SetBallot(matchId uint, req dto.BallotRequestDto) error {
+	match := domain.Match{}
+	if err := s.db.First(&match, matchId).Error; err != nil {
+		return err
+	}
+
+	if err := match.SetBallot(req.DrawAt); err != nil {
+		return err
+	}
+
+	return s.db.Model(&match).Select("ballot_draw_at").Updates(&match).Error
+}
+
+// Get shows the entrant count of a pending ballot, or the seed, inputs and outcome of the draw
+func (s *BallotService) Get(matchId uint) (*dto.BallotDto, error) {
+	match := domain.Match{}
+	if err := s.db.Preload("Registrations").First(&match, matchId).Error; err != nil {
+		return nil, err
+	}
+
+	if match.BallotDrawAt == nil {
+		return nil, domain.ErrNoBallot
+	}
+
+	res := &dto.BallotDto{
+		MatchId:      match.ID,
+		DrawAt:       match.BallotDrawAt,
+		DrawnAt:      match.BallotDrawnAt,
+		EntrantCount: len(match.GetBallotEntrants()),
+		Entries:      []dto.BallotEntryDto{},
+	}
+
+	draw := domain.BallotDraw{}
+	err := s.db.Where("match_id = ?", matchId).First(&draw).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return res, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	var players []domain.Player
+	playerIds := lo.Map(draw.Entries, func(e domain.BallotEntry, _ int) uint { return e.PlayerId })
+	if err := s.db.Unscoped().Where("id IN ?", playerIds).Find(&players).Error; err != nil {
+		return nil, err
+	}
+
+	names := lo.SliceToMap(players, func(p domain.Player) (uint, string) {
+		return p.ID, strings.TrimSpace(fmt.Sprintf("%s %s", p.FirstName, p.LastName))
+	})
+
+	res.EntrantCount = len(draw.Entries)
+	res.Seed = &draw.Seed
+	res.Seats = draw.Seats
+	res.IsVerified = draw.Verify()
+	res.Entries = lo.Map(draw.Entries, func(e domain.BallotEntry, _ int) dto.BallotEntryDto {
+		return dto.BallotEntryDto{
+			RegistrationId: e.RegistrationId,
+			PlayerId:       e.PlayerId,
+			PlayerName:     names[e.PlayerId],
+			Seats:          e.Seats,
+			IsMember:       e.IsMember,
+			RecentSessions: e.RecentSessions,
+			Reliability:    e.Reliability,
+			Weight:         e.Weight,
+			Position:       e.Position,
+			Won:            e.Won,
+		}
+	})
+	return res, nil
+}
+
+// Draw runs the lottery of the match once its draw time has passed
+func (s *BallotService) Draw(matchId uint) (*dto.BallotDto, error) {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		match := domain.Match{}
+		if err := tx.
+			Preload("Registrations").
+			Preload("CourtBookings").
+			Preload("SportCenter").
+			First(&match, matchId).Error; err != nil {
+			return err
+		}
+		return s.draw(tx, &match, time.Now())
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return s.Get(matchId)
+}
+
+// DrawDue runs every lottery whose draw time has passed, it is run regularly by the SchedulerService
+func (s *BallotService) DrawDue() (*dto.BallotDrawResultDto, error) {
+	now := time.Now()
+	var matches []domain.Match
+	if err := s.db.
+		Preload("Registrations").
+		Preload("CourtBookings").
+		Preload("SportCenter").
+		Scopes(MatchStateScope(nil)).
+		Where("ballot_draw_at <= ? AND ballot_drawn_at IS NULL", now).
+		Find(&matches).Error; err != nil {
+		return nil, err
+	}
+
+	res := &dto.BallotDrawResultDto{DrawnMatchIds: []uint{}}
+	for i := range matches {
+		if err := s.db.Transaction(func(tx *gorm.DB) error { return s.draw(tx, &matches[i], now) }); err != nil {
+			return nil, err
+		}
+		res.DrawnMatchIds = append(res.DrawnMatchIds, matches[i].ID)
+	}
+
+	return res, nil
+}
+
+func (s *BallotService) draw(tx *gorm.DB, match *domain.Match, now time.Time) error {
+	entries, err := s.buildEntries(tx, match.GetBallotEntrants(), now)
+	if err != nil {
+		return err
+	}
+
+	seed, err := domain.NewBallotSeed()
+	if err != nil {
+		return err
+	}
+
+	draw, err := match.DrawBallot(seed, entries, now)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Create(draw).Error; err != nil {
+		return err
+	}
+
+	if err := tx.Model(match).Update("ballot_drawn_at", match.BallotDrawnAt).Error; err != nil {
+		return err
+	}
+
+	for _, e := range draw.Entries {
+		reg, _ := lo.Find(match.Registrations, func(r domain.Registration) bool { return r.ID == e.RegistrationId })
+		if err := tx.Model(&domain.Registration{}).Where("id = ?", reg.ID).Updates(map[string]any{
+			"is_waitlisted":   reg.IsWaitlisted,
+			"ballot_position": reg.BallotPosition,
+		}).Error; err != nil {
+			return err
+		}
+	}
+
+	session := fmt.Sprintf("%s on %s", match.SportCenter.Name, match.Start.Format("02/01/2006 15:04"))
+	winners := lo.Filter(draw.Entries, func(e domain.BallotEntry, _ int) bool { return e.Won })
+	losers := lo.Reject(draw.Entries, func(e domain.BallotEntry, _ int) bool { return e.Won })
+	if err := notifyPlayers(tx, lo.Map(winners, func(e domain.BallotEntry, _ int) uint { return e.PlayerId }), &match.ID,
+		"You got a spot", fmt.Sprintf("You were drawn in the ballot for %s, you are playing", session)); err != nil {
+		return err
+	}
+
+	return notifyPlayers(tx, lo.Map(losers, func(e domain.BallotEntry, _ int) uint { return e.PlayerId }), &match.ID,
+		"You are on the waitlist", fmt.Sprintf("You were not drawn in the ballot for %s, you are on the waitlist in draw order", session))
+}
+
+// buildEntries weighs every entrant by membership, sessions played recently and reliability
+func (s *BallotService) buildEntries(tx *gorm.DB, entrants []domain.Registration, now time.Time) ([]domain.BallotEntry, error) {
+	playerIds := lo.Map(entrants, func(r domain.Registration, _ int) uint { return r.PlayerId })
+
+	var players []domain.Player
+	if err := tx.Where("id IN ?", playerIds).Find(&players).Error; err != nil {
+		return nil, err
+	}
+
+	type recentSessions struct {
+		PlayerId uint
+		Sessions int
+	}
+	var recent []recentSessions
+	if err := tx.Raw(`
+		SELECT r.player_id, COUNT(r.id) AS sessions
+		FROM registrations r
+		JOIN matches m ON m.id = r.match_id AND m.deleted_at IS NULL
+		WHERE r.deleted_at IS NULL
+			AND r.is_waitlisted = false
+			AND r.rsvp = ?
+			AND m.state <> ?
+			AND m.start >= ? AND m.start < ?
+			AND r.player_id IN ?
+		GROUP BY r.player_id
+	`, domain.RsvpGoing, domain.MatchCancelled, now.AddDate(0, 0, -domain.BallotRecentDays), now, playerIds).Scan(&recent).Error; err != nil {
+		return nil, err
+	}
+
+	reliability, err := s.attendanceService.GetReliability(0)
+	if err != nil {
+		return nil, err
+	}
+
+	isMember := lo.SliceToMap(players, func(p domain.Player) (uint, bool) { return p.ID, p.ExternalUserID != "" })
+	sessions := lo.SliceToMap(recent, func(r recentSessions) (uint, int) { return r.PlayerId, r.Sessions })
+	reliable := lo.SliceToMap(reliability, func(r dto.PlayerReliabilityDto) (uint, float64) {
+		// Players without recorded attendance are trusted
+		return r.PlayerId, lo.Ternary(r.Attended+r.Late+r.NoShows > 0, r.Reliability, 1)
+	})
+
+	return lo.Map(entrants, func(r domain.Registration, _ int) domain.BallotEntry {
+		score, ok := reliable[r.PlayerId]
+		return domain.NewBallotEntry(r, isMember[r.PlayerId], sessions[r.PlayerId], lo.Ternary(ok, score, 1))
+	}), nil
+}
@@ -0,0 +1,342 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/samber/lo"
+	"github.com/tructn/racket/internal/domain"
+	"github.com/tructn/racket/internal/dto"
+	"github.com/tructn/racket/pkg/ical"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const defaultImportMinutePerSection = 60
+
+var (
+	ErrUnsupportedImportFormat = errors.New("unsupported file format, expecting .ics or .csv")
+	// ErrInvalidImportFile wraps the errors of files or options that can not be read, the caller has to fix them
+	ErrInvalidImportFile = errors.New("invalid import file")
+)
+
+type importedMatch struct {
+	sportCenter *domain.SportCenter
+	match       *domain.Match
+}
+
+// BookingImportService turns sport center booking confirmations (ICS, CSV) into matches
+type BookingImportService struct {
+	db                          *gorm.DB
+	logger                      *zap.SugaredLogger
+	standingRegistrationService *StandingRegistrationService
+}
+
+func NewBookingImportService(
+	db *gorm.DB,
+	logger *zap.SugaredLogger,
+	standingRegistrationService *StandingRegistrationService,
+) *BookingImportService {
+	return &BookingImportService{
+		db:                          db,
+		logger:                      logger,
+		standingRegistrationService: standingRegistrationService,
+	}
+}
+
+func (s *BookingImportService) WithContext(ctx context.Context) *BookingImportService {
+	clone := *s
+	clone.db = s.db.WithContext(ctx)
+	clone.standingRegistrationService = s.standingRegistrationService.WithContext(ctx)
+	return &clone
+}
+
+// Preview parses the file and tells what would be created, nothing is written
+func (s *BookingImportService) Preview(filename string, r io.Reader, opts dto.BookingImportOptions) (*dto.BookingImportResultDto, error) {
+	bookings, err := s.parse(filename, r, opts)
+	if err != nil {
+		return nil, err
+	}
+	return s.process(s.db, bookings, opts, false)
+}
+
+// Import creates the missing sport centers and the non duplicated matches in one transaction,
+// the regulars are then registered to the new matches like for matches created by hand
+func (s *BookingImportService) Import(filename string, r io.Reader, opts dto.BookingImportOptions) (*dto.BookingImportResultDto, error) {
+	bookings, err := s.parse(filename, r, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var res *dto.BookingImportResultDto
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		res, err = s.process(tx, bookings, opts, true)
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	matchIds := lo.FilterMap(res.Items, func(item dto.BookingImportItemDto, _ int) (uint, bool) {
+		return lo.FromPtr(item.CreatedMatchId), item.CreatedMatchId != nil
+	})
+	if err := s.standingRegistrationService.Apply(matchIds); err != nil {
+		s.logger.Errorw("Failed to apply standing registrations to imported matches", "matchIds", matchIds, "error", err)
+	}
+
+	return res, nil
+}
+
+func (s *BookingImportService) process(
+	tx *gorm.DB,
+	bookings []domain.ParsedBooking,
+	opts dto.BookingImportOptions,
+	commit bool,
+) (*dto.BookingImportResultDto, error) {
+	var sportCenters []*domain.SportCenter
+	if err := tx.
+		Preload("PricingRules").
+		Preload("PriceHistory").
+		Preload("OpeningHours").
+		Preload("Closures").
+		Find(&sportCenters).Error; err != nil {
+		return nil, err
+	}
+
+	minutePerSection := opts.DefaultMinutePerSection
+	if minutePerSection == 0 {
+		minutePerSection = defaultImportMinutePerSection
+	}
+
+	res := &dto.BookingImportResultDto{
+		Committed:       commit,
+		Items:           []dto.BookingImportItemDto{},
+		NewSportCenters: []string{},
+	}
+	accepted := []importedMatch{}
+
+	for _, pb := range bookings {
+		if pb.Err != nil {
+			res.InvalidCount++
+			res.Items = append(res.Items, dto.BookingImportItemDto{
+				Reference:          pb.Reference,
+				ValidationErrorMsg: pb.Err.Error(),
+			})
+			continue
+		}
+
+		b := pb.Booking
+		item := dto.BookingImportItemDto{
+			Reference: b.Reference,
+			Venue:     b.Venue,
+			Start:     b.Start,
+			End:       b.End,
+			Court:     b.Court,
+		}
+
+		sc, found := lo.Find(sportCenters, func(sc *domain.SportCenter) bool { return sc.IsNamed(b.Venue) })
+		if !found {
+			sc = domain.NewSportCenter(b.Venue, b.Address, opts.DefaultCostPerSection, minutePerSection)
+			if err := sc.SetTimezone(opts.Timezone); err != nil {
+				return nil, fmt.Errorf("%w: %w", ErrInvalidImportFile, err)
+			}
+			if commit {
+				if err := tx.Create(sc).Error; err != nil {
+					return nil, err
+				}
+			}
+			sportCenters = append(sportCenters, sc)
+			res.NewSportCenters = append(res.NewSportCenters, sc.Name)
+		}
+
+		item.SportCenterId = sc.ID
+		item.SportCenterName = sc.Name
+		item.IsNewSportCenter = lo.Contains(res.NewSportCenters, sc.Name)
+
+		match := domain.NewMatch(b.Start, b.End, sc.ID, sc.GetPricing(b.Start, false), b.Court, nil)
+		item.Cost = match.Cost
+
+		duplicateId, isDuplicate, err := s.findDuplicate(tx, sc, b, accepted)
+		if err != nil {
+			return nil, err
+		}
+
+		if isDuplicate {
+			item.IsDuplicate = true
+			item.DuplicateMatchId = duplicateId
+			res.DuplicateCount++
+			res.Items = append(res.Items, item)
+			continue
+		}
+
+		if err := s.ensureCanCreate(tx, sc, match, accepted, opts); err != nil {
+			var closedErr *domain.ClosedError
+			var conflictErr *domain.ConflictError
+			if !errors.As(err, &closedErr) && !errors.As(err, &conflictErr) {
+				return nil, err
+			}
+
+			item.ValidationErrorMsg = err.Error()
+			res.InvalidCount++
+			res.Items = append(res.Items, item)
+			continue
+		}
+
+		if commit {
+			if err := tx.Create(match).Error; err != nil {
+				return nil, err
+			}
+			item.CreatedMatchId = &match.ID
+		}
+
+		accepted = append(accepted, importedMatch{sportCenter: sc, match: match})
+		res.NewCount++
+		res.TotalCost += match.Cost
+		res.Items = append(res.Items, item)
+	}
+
+	return res, nil
+}
+
+// ensureCanCreate applies the checks of matches created by hand, bookings on closed dates or
+// booking a court already taken by another match, or by a booking accepted earlier from the file, are refused unless allowed
+func (s *BookingImportService) ensureCanCreate(
+	tx *gorm.DB,
+	sc *domain.SportCenter,
+	match *domain.Match,
+	accepted []importedMatch,
+	opts dto.BookingImportOptions,
+) error {
+	if !opts.AllowClosed {
+		if err := sc.EnsureOpenFor(match); err != nil {
+			return err
+		}
+	}
+
+	if opts.AllowConflicts {
+		return nil
+	}
+
+	conflicts := match.FindConflicts(lo.FilterMap(accepted, func(am importedMatch, _ int) (domain.Match, bool) {
+		return *am.match, am.sportCenter == sc
+	}))
+
+	if sc.ID != 0 {
+		existing, err := findCourtConflicts(tx, match)
+		if err != nil {
+			return err
+		}
+		conflicts = append(conflicts, existing...)
+	}
+
+	if len(conflicts) > 0 {
+		return &domain.ConflictError{Kind: domain.CourtConflict, Matches: conflicts}
+	}
+
+	return nil
+}
+
+// findDuplicate looks for an existing match, or a booking accepted earlier from the same file.
+// Sport centers created by the import are not saved in preview mode, so the file is compared by sport center instance.
+func (s *BookingImportService) findDuplicate(
+	tx *gorm.DB,
+	sc *domain.SportCenter,
+	b *domain.Booking,
+	accepted []importedMatch,
+) (*uint, bool, error) {
+	for _, am := range accepted {
+		if am.sportCenter == sc && am.match.IsSameBooking(am.match.SportCenterId, b.Start, b.End, b.Court) {
+			return lo.Ternary(am.match.ID == 0, nil, &am.match.ID), true, nil
+		}
+	}
+
+	if sc.ID == 0 {
+		return nil, false, nil
+	}
+
+	var existing []domain.Match
+	if err := tx.
+		Preload("CourtBookings").
+		Where("sport_center_id = ? AND start = ? AND \"end\" = ?", sc.ID, b.Start, b.End).
+		Find(&existing).Error; err != nil {
+		return nil, false, err
+	}
+
+	for _, m := range existing {
+		if m.IsSameBooking(sc.ID, b.Start, b.End, b.Court) {
+			return &m.ID, true, nil
+		}
+	}
+
+	return nil, false, nil
+}
+
+func (s *BookingImportService) parse(filename string, r io.Reader, opts dto.BookingImportOptions) ([]domain.ParsedBooking, error) {
+	loc := time.UTC
+	if opts.Timezone != "" {
+		l, err := time.LoadLocation(opts.Timezone)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid timezone %s: %w", ErrInvalidImportFile, opts.Timezone, err)
+		}
+		loc = l
+	}
+
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	ext := strings.ToLower(filepath.Ext(filename))
+	isCalendar := bytes.HasPrefix(bytes.TrimSpace(content), []byte("BEGIN:VCALENDAR"))
+
+	switch {
+	case ext == ".ics" || isCalendar:
+		return parseIcsBookings(content, loc)
+	case ext == ".csv" || ext == "":
+		bookings, err := domain.ParseCsvBookings(content, loc)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidImportFile, err)
+		}
+		return bookings, nil
+	default:
+		return nil, ErrUnsupportedImportFormat
+	}
+}
+
+func parseIcsBookings(content []byte, loc *time.Location) ([]domain.ParsedBooking, error) {
+	events, err := ical.Parse(bytes.NewReader(content), loc)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidImportFile, err)
+	}
+
+	result := []domain.ParsedBooking{}
+	for _, ev := range events {
+		if ev.Status == ical.StatusCancelled {
+			continue
+		}
+
+		// Sport centers put "Venue name, address" in the location, fallback to the summary otherwise
+		venue, address := ev.Summary, ""
+		if ev.Location != "" {
+			parts := strings.SplitN(ev.Location, ",", 2)
+			venue = parts[0]
+			if len(parts) > 1 {
+				address = parts[1]
+			}
+		}
+
+		court := domain.ExtractCourt(ev.Summary, ev.Location, ev.Description)
+		booking, err := domain.NewBooking(ev.UID, venue, address, court, ev.Start, ev.End)
+		result = append(result, domain.ParsedBooking{Reference: ev.UID, Booking: booking, Err: err})
+	}
+
+	return result, nil
+}
@@ -0,0 +1,271 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/samber/lo"
+	"github.com/tructn/racket/internal/domain"
+	"github.com/tructn/racket/pkg/ical"
+	"github.com/tructn/racket/pkg/result"
+	"github.com/tructn/racket/pkg/scopes"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// Matches older than this are dropped from the player feed to keep it small
+const calendarHistoryDays = 30
+
+type CalendarService struct {
+	db     *gorm.DB
+	logger *zap.SugaredLogger
+}
+
+func NewCalendarService(db *gorm.DB, logger *zap.SugaredLogger) *CalendarService {
+	return &CalendarService{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (s *CalendarService) WithContext(ctx context.Context) *CalendarService {
+	clone := *s
+	clone.db = s.db.WithContext(ctx)
+	return &clone
+}
+
+func (s *CalendarService) GetOrCreatePlayerFeed(playerId uint) (*domain.CalendarFeed, error) {
+	feed := &domain.CalendarFeed{}
+	err := s.db.Where("player_id = ?", playerId).First(feed).Error
+	if err == nil {
+		return feed, nil
+	}
+
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	feed, err = domain.NewPlayerCalendarFeed(playerId, &domain.DefaultGenerator{})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.db.Create(feed).Error; err != nil {
+		return nil, err
+	}
+
+	return feed, nil
+}
+
+// GetOrCreateTeamFeed returns the team feed, only team owner or team members are allowed to see it
+func (s *CalendarService) GetOrCreateTeamFeed(teamId, playerId uint, idpUserId string) (*domain.CalendarFeed, error) {
+	if err := s.ensureTeamAccess(teamId, playerId, idpUserId); err != nil {
+		return nil, err
+	}
+
+	feed := &domain.CalendarFeed{}
+	err := s.db.Where("team_id = ?", teamId).First(feed).Error
+	if err == nil {
+		return feed, nil
+	}
+
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	feed, err = domain.NewTeamCalendarFeed(teamId, &domain.DefaultGenerator{})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.db.Create(feed).Error; err != nil {
+		return nil, err
+	}
+
+	return feed, nil
+}
+
+// RevokePlayerFeed invalidates the current token, a new one is issued on next request
+func (s *CalendarService) RevokePlayerFeed(playerId uint) error {
+	return s.db.Unscoped().Where("player_id = ?", playerId).Delete(&domain.CalendarFeed{}).Error
+}
+
+func (s *CalendarService) RevokeTeamFeed(teamId, playerId uint, idpUserId string) error {
+	if err := s.ensureTeamAccess(teamId, playerId, idpUserId); err != nil {
+		return err
+	}
+	return s.db.Unscoped().Where("team_id = ?", teamId).Delete(&domain.CalendarFeed{}).Error
+}
+
+// RenderFeed resolves the feed token and renders the matching calendar
+func (s *CalendarService) RenderFeed(token string) (string, error) {
+	feed := &domain.CalendarFeed{}
+	err := s.db.Where("token = ?", token).First(feed).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", result.ErrorNotFound
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	// Calendar clients have no tenant, the feed is rendered in the tenant of the token
+	s = s.WithContext(scopes.WithTenant(s.db.Statement.Context, feed.TenantId))
+	if feed.IsTeamFeed() {
+		return s.RenderTeamCalendar(*feed.TeamId)
+	}
+
+	return s.RenderPlayerCalendar(*feed.PlayerId)
+}
+
+// RenderPlayerCalendar renders the matches the player is going to or may go to.
+// Deleted matches and matches the player withdrew from are kept in the feed as cancelled events
+// so that subscribers remove them.
+func (s *CalendarService) RenderPlayerCalendar(playerId uint) (string, error) {
+	var matches []domain.Match
+	err := s.baseMatchQuery().
+		Where("matches.start >= ?", time.Now().UTC().AddDate(0, 0, -calendarHistoryDays)).
+		Where(`EXISTS (
+			SELECT 1 FROM registrations r
+			WHERE r.match_id = matches.id
+				AND r.player_id = ?
+				AND r.rsvp IN ?
+		)`, playerId, []domain.RsvpStatus{domain.RsvpGoing, domain.RsvpMaybe, domain.RsvpNotGoing}).
+		Find(&matches).Error
+
+	if err != nil {
+		return "", err
+	}
+
+	var registrations []domain.Registration
+	matchIds := lo.Map(matches, func(m domain.Match, _ int) uint { return m.ID })
+	if err := s.db.Unscoped().Where("player_id = ? AND match_id IN ?", playerId, matchIds).Find(&registrations).Error; err != nil {
+		return "", err
+	}
+
+	byMatch := lo.GroupBy(registrations, func(r domain.Registration) uint { return r.MatchId })
+	cal := &ical.Calendar{
+		Name: "Racket - My matches",
+		Events: lo.Map(matches, func(m domain.Match, _ int) ical.Event {
+			return buildPlayerMatchEvent(m, byMatch[m.ID])
+		}),
+	}
+
+	return cal.Render(), nil
+}
+
+// RenderTeamCalendar renders the upcoming matches for the team members, matches are dropped once they end.
+// Drafts and private matches the team is not invited to are not published.
+func (s *CalendarService) RenderTeamCalendar(teamId uint) (string, error) {
+	team := &domain.Team{}
+	if err := s.db.First(team, teamId).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", result.ErrorNotFound
+		}
+		return "", err
+	}
+
+	var matches []domain.Match
+	if err := s.baseMatchQuery().
+		Preload("Invitations", "deleted_at IS NULL").
+		Where(`matches."end" > ?`, time.Now().UTC()).
+		Where("matches.state <> ?", domain.MatchDraft).
+		Find(&matches).Error; err != nil {
+		return "", err
+	}
+	matches = lo.Filter(matches, func(m domain.Match, _ int) bool { return m.IsVisibleToTeam(teamId) })
+
+	cal := &ical.Calendar{
+		Name: fmt.Sprintf("Racket - %s", team.Name),
+		Events: lo.Map(matches, func(m domain.Match, _ int) ical.Event {
+			return buildMatchEvent(m, m.CalcFullSessionCost())
+		}),
+	}
+
+	return cal.Render(), nil
+}
+
+func (s *CalendarService) baseMatchQuery() *gorm.DB {
+	return s.db.
+		Unscoped().
+		Preload("SportCenter").
+		Preload("AdditionalCosts", "deleted_at IS NULL").
+		Preload("Registrations", "deleted_at IS NULL").
+		Order("matches.start ASC")
+}
+
+func (s *CalendarService) ensureTeamAccess(teamId, playerId uint, idpUserId string) error {
+	var hasAccess bool
+	err := s.db.Raw(`
+		SELECT EXISTS (SELECT 1 FROM teams t WHERE t.id = ? AND t.owner_id = ? AND t.deleted_at IS NULL)
+			OR EXISTS (SELECT 1 FROM team_members tm WHERE tm.team_id = ? AND tm.player_id = ?)
+	`, teamId, idpUserId, teamId, playerId).Scan(&hasAccess).Error
+
+	if err != nil {
+		return err
+	}
+
+	if !hasAccess {
+		return result.ErrorNotFound
+	}
+
+	return nil
+}
+
+// buildPlayerMatchEvent cancels the event once the player withdrew, registrations include the deleted ones
+func buildPlayerMatchEvent(m domain.Match, registrations []domain.Registration) ical.Event {
+	reg, attending, withdrawnAt := domain.GetFeedAttendance(registrations)
+	if !attending {
+		ev := buildMatchEvent(m, m.CalcFullSessionCost())
+		ev.Status = ical.StatusCancelled
+		if withdrawnAt.After(ev.LastModified) {
+			ev.LastModified = withdrawnAt
+		}
+		return ev
+	}
+
+	if !reg.IsConfirmed() {
+		return buildMatchEvent(m, m.CalcFullSessionCost())
+	}
+	return buildMatchEvent(m, m.CalcIndividualCost(reg))
+}
+
+func buildMatchEvent(m domain.Match, estimatedCost float64) ical.Event {
+	lastModified := m.UpdatedAt
+	status := ical.StatusConfirmed
+	if m.IsCancelled() {
+		status = ical.StatusCancelled
+	}
+
+	if m.DeletedAt.Valid {
+		status = ical.StatusCancelled
+		if m.DeletedAt.Time.After(lastModified) {
+			lastModified = m.DeletedAt.Time
+		}
+	}
+
+	location := strings.TrimSpace(strings.Join(lo.Compact([]string{m.SportCenter.Name, m.SportCenter.Location}), ", "))
+
+	description := []string{}
+	if m.Court != "" {
+		description = append(description, fmt.Sprintf("Court: %s", m.Court))
+	}
+	description = append(description,
+		fmt.Sprintf("Players: %d", m.CalcPlayerCount()),
+		fmt.Sprintf("Estimated cost: %.2f", estimatedCost),
+	)
+
+	return ical.Event{
+		UID:          m.CalendarUID(),
+		Start:        m.Start,
+		End:          m.End,
+		Summary:      fmt.Sprintf("Badminton @ %s", m.SportCenter.Name),
+		Location:     location,
+		Description:  strings.Join(description, "\n"),
+		Status:       status,
+		LastModified: lastModified,
+	}
+}
@@ -0,0 +1,307 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/samber/lo"
+	"github.com/tructn/racket/internal/domain"
+	"github.com/tructn/racket/internal/dto"
+	"github.com/tructn/racket/pkg/result"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type GameService struct {
+	db            *gorm.DB
+	logger        *zap.SugaredLogger
+	ratingService *RatingService
+}
+
+func NewGameService(db *gorm.DB, logger *zap.SugaredLogger, ratingService *RatingService) *GameService {
+	return &GameService{
+		db:            db,
+		logger:        logger,
+		ratingService: ratingService,
+	}
+}
+
+func (s *GameService) WithContext(ctx context.Context) *GameService {
+	clone := *s
+	clone.db = s.db.WithContext(ctx)
+	clone.ratingService = s.ratingService.WithContext(ctx)
+	return &clone
+}
+
+func (s *GameService) GetGames(matchId uint) ([]dto.GameDto, error) {
+	var games []domain.Game
+	if err := s.db.
+		Preload("Players").
+		Preload("Sets", orderSetsByNumber).
+		Where("match_id = ?", matchId).
+		Order("id").
+		Find(&games).Error; err != nil {
+		return nil, err
+	}
+
+	return s.toGameDtos(games)
+}
+
+// CreateGame records the result of a game, editedBy is the user who entered it
+func (s *GameService) CreateGame(matchId uint, editedBy string, req dto.GameRequestDto) (*dto.GameDto, error) {
+	game, err := domain.NewGame(matchId, lo.FromPtr(req.Court), toScoringFormat(req.Format))
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := s.applyResult(tx, game, req); err != nil {
+			return err
+		}
+
+		game.Revise(editedBy)
+		if err := tx.Create(game).Error; err != nil {
+			return err
+		}
+
+		return s.ratingService.RecomputeFrom(tx, game.MatchId, game.ID)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return s.toGameDto(*game)
+}
+
+// UpdateGame corrects a result, the previous versions stay in the history
+func (s *GameService) UpdateGame(matchId, gameId uint, editedBy string, req dto.GameRequestDto) (*dto.GameDto, error) {
+	game, err := s.getGame(s.db, matchId, gameId)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Format != nil {
+		updated, err := domain.NewGame(matchId, game.Court, toScoringFormat(req.Format))
+		if err != nil {
+			return nil, err
+		}
+		game.Format = updated.Format
+	}
+
+	if req.Court != nil {
+		game.Court = *req.Court
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := s.applyResult(tx, game, req); err != nil {
+			return err
+		}
+
+		if err := tx.Where("game_id = ?", game.ID).Delete(&domain.GamePlayer{}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Where("game_id = ?", game.ID).Delete(&domain.GameSet{}).Error; err != nil {
+			return err
+		}
+
+		revision := game.Revise(editedBy)
+		if err := tx.Create(&revision).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Omit("History").Session(&gorm.Session{FullSaveAssociations: true}).Save(game).Error; err != nil {
+			return err
+		}
+
+		return s.ratingService.RecomputeFrom(tx, game.MatchId, game.ID)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return s.toGameDto(*game)
+}
+
+func (s *GameService) DeleteGame(matchId, gameId uint) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		game, err := s.getGame(tx, matchId, gameId)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Delete(game).Error; err != nil {
+			return err
+		}
+
+		return s.ratingService.RecomputeFrom(tx, game.MatchId, game.ID)
+	})
+}
+
+func (s *GameService) GetHistory(matchId, gameId uint) ([]dto.GameRevisionDto, error) {
+	if _, err := s.getGame(s.db, matchId, gameId); err != nil {
+		return nil, err
+	}
+
+	var revisions []domain.GameRevision
+	if err := s.db.Where("game_id = ?", gameId).Order("id").Find(&revisions).Error; err != nil {
+		return nil, err
+	}
+
+	return lo.Map(revisions, func(r domain.GameRevision, _ int) dto.GameRevisionDto {
+		return dto.GameRevisionDto{
+			EditedBy: r.EditedBy,
+			EditedAt: r.CreatedAt,
+			Players:  r.Players,
+			Scores:   r.Scores,
+			Winner:   r.Winner,
+		}
+	}), nil
+}
+
+// GetPlayerStats lists every player with recorded games by win rate, the rank is the rating rank
+func (s *GameService) GetPlayerStats() ([]dto.PlayerStatsDto, error) {
+	stats, err := s.calcStats(s.db)
+	if err != nil {
+		return nil, err
+	}
+
+	players, err := s.getPlayers(lo.Map(stats, func(st domain.PlayerGameStats, _ int) uint { return st.PlayerId }))
+	if err != nil {
+		return nil, err
+	}
+
+	ranks, err := s.ratingService.GetRanks()
+	if err != nil {
+		return nil, err
+	}
+
+	return lo.Map(stats, func(st domain.PlayerGameStats, _ int) dto.PlayerStatsDto {
+		p := players[st.PlayerId]
+		return dto.PlayerStatsDto{
+			PlayerId:      st.PlayerId,
+			PlayerName:    fmt.Sprintf("%s %s", p.FirstName, p.LastName),
+			Rank:          ranks[st.PlayerId],
+			Played:        st.Played,
+			Won:           st.Won,
+			WinRate:       st.WinRate(),
+			PointsFor:     st.PointsFor,
+			PointsAgainst: st.PointsAgainst,
+		}
+	}), nil
+}
+
+func (s *GameService) applyResult(tx *gorm.DB, game *domain.Game, req dto.GameRequestDto) error {
+	if err := game.SetPlayers(req.SideA, req.SideB); err != nil {
+		return err
+	}
+
+	match := &domain.Match{}
+	if err := tx.Preload("Registrations.Guests").First(match, game.MatchId).Error; err != nil {
+		return err
+	}
+
+	if err := match.EnsurePlayersInMatch(game); err != nil {
+		return err
+	}
+
+	return game.RecordScores(lo.Map(req.Sets, func(set dto.SetScoreDto, _ int) [2]int { return [2]int{set.A, set.B} }))
+}
+
+func (s *GameService) calcStats(tx *gorm.DB) ([]domain.PlayerGameStats, error) {
+	var games []domain.Game
+	if err := tx.
+		Preload("Players").
+		Preload("Sets").
+		Joins("JOIN matches ON matches.id = games.match_id AND matches.deleted_at IS NULL").
+		Scopes(MatchStateScope(nil)).
+		Find(&games).Error; err != nil {
+		return nil, err
+	}
+
+	return domain.CalcPlayerGameStats(games), nil
+}
+
+func (s *GameService) getGame(tx *gorm.DB, matchId, gameId uint) (*domain.Game, error) {
+	game := &domain.Game{}
+	err := tx.
+		Preload("Players").
+		Preload("Sets", orderSetsByNumber).
+		Where("match_id = ?", matchId).
+		First(game, gameId).Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, result.ErrorNotFound
+	}
+	return game, err
+}
+
+func (s *GameService) getPlayers(ids []uint) (map[uint]domain.Player, error) {
+	var players []domain.Player
+	if err := s.db.Where("id IN ?", lo.Uniq(ids)).Find(&players).Error; err != nil {
+		return nil, err
+	}
+	return lo.KeyBy(players, func(p domain.Player) uint { return p.ID }), nil
+}
+
+func (s *GameService) toGameDto(game domain.Game) (*dto.GameDto, error) {
+	games, err := s.toGameDtos([]domain.Game{game})
+	if err != nil {
+		return nil, err
+	}
+	return &games[0], nil
+}
+
+func (s *GameService) toGameDtos(games []domain.Game) ([]dto.GameDto, error) {
+	players, err := s.getPlayers(lo.FlatMap(games, func(g domain.Game, _ int) []uint {
+		return lo.Map(g.Players, func(p domain.GamePlayer, _ int) uint { return p.PlayerId })
+	}))
+	if err != nil {
+		return nil, err
+	}
+
+	summaries := func(ids []uint) []dto.PlayerSummaryDto {
+		return lo.Map(ids, func(id uint, _ int) dto.PlayerSummaryDto {
+			return dto.PlayerSummaryDto{PlayerId: id, FirstName: players[id].FirstName, LastName: players[id].LastName}
+		})
+	}
+
+	return lo.Map(games, func(g domain.Game, _ int) dto.GameDto {
+		return dto.GameDto{
+			Id:      g.ID,
+			MatchId: g.MatchId,
+			Court:   g.Court,
+			Format: dto.ScoringFormatDto{
+				Points: g.Format.Points,
+				WinBy:  g.Format.WinBy,
+				Cap:    g.Format.Cap,
+				BestOf: g.Format.BestOf,
+			},
+			SideA:     summaries(g.GetSide(domain.SideA)),
+			SideB:     summaries(g.GetSide(domain.SideB)),
+			Sets:      lo.Map(g.Sets, func(set domain.GameSet, _ int) dto.SetScoreDto { return dto.SetScoreDto{A: set.ScoreA, B: set.ScoreB} }),
+			Winner:    g.Winner,
+			UpdatedAt: g.UpdatedAt,
+		}
+	}), nil
+}
+
+func orderSetsByNumber(db *gorm.DB) *gorm.DB {
+	return db.Order("number")
+}
+
+func toScoringFormat(format *dto.ScoringFormatDto) domain.ScoringFormat {
+	if format == nil {
+		return domain.ScoringFormat{}
+	}
+
+	return domain.ScoringFormat{
+		Points: format.Points,
+		WinBy:  format.WinBy,
+		Cap:    format.Cap,
+		BestOf: format.BestOf,
+	}
+}
@@ -0,0 +1,354 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/samber/lo"
+	"github.com/tructn/racket/internal/domain"
+	"github.com/tructn/racket/internal/dto"
+	"github.com/tructn/racket/pkg/result"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type LadderService struct {
+	db            *gorm.DB
+	logger        *zap.SugaredLogger
+	ratingService *RatingService
+}
+
+func NewLadderService(db *gorm.DB, logger *zap.SugaredLogger, ratingService *RatingService) *LadderService {
+	return &LadderService{
+		db:            db,
+		logger:        logger,
+		ratingService: ratingService,
+	}
+}
+
+func (s *LadderService) WithContext(ctx context.Context) *LadderService {
+	clone := *s
+	clone.db = s.db.WithContext(ctx)
+	clone.ratingService = s.ratingService.WithContext(ctx)
+	return &clone
+}
+
+func (s *LadderService) GetAll() ([]dto.LadderSummaryDto, error) {
+	var ladders []domain.Ladder
+	if err := s.db.Preload("Rungs").Order("name").Find(&ladders).Error; err != nil {
+		return nil, err
+	}
+
+	return lo.Map(ladders, func(l domain.Ladder, _ int) dto.LadderSummaryDto {
+		return dto.LadderSummaryDto{Id: l.ID, Name: l.Name, PlayerCount: len(l.Rungs)}
+	}), nil
+}
+
+func (s *LadderService) Create(req dto.LadderRequestDto) (*dto.LadderDto, error) {
+	ladder, err := domain.NewLadder(req.Name, req.ChallengeRange, req.ResponseDays, req.InactivityDays, req.InactivityDrop)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ladder.SetScoring(toScoringFormat(req.Scoring)); err != nil {
+		return nil, err
+	}
+
+	if err := ladder.SetPlayDays(req.PlayDays); err != nil {
+		return nil, err
+	}
+
+	if err := s.db.Create(ladder).Error; err != nil {
+		return nil, err
+	}
+
+	return s.toLadderDto(ladder)
+}
+
+func (s *LadderService) Get(id uint) (*dto.LadderDto, error) {
+	return s.update(id, func(tx *gorm.DB, ladder *domain.Ladder, now time.Time) error { return nil })
+}
+
+// GetHistory lists every challenge and inactivity penalty of the ladder, the latest first
+func (s *LadderService) GetHistory(id uint) (*dto.LadderHistoryDto, error) {
+	ladder, err := s.refresh(id, func(tx *gorm.DB, ladder *domain.Ladder, now time.Time) error { return nil })
+	if err != nil {
+		return nil, err
+	}
+
+	playerIds := lo.Map(ladder.Penalties, func(p domain.LadderPenalty, _ int) uint { return p.PlayerId })
+	for _, c := range ladder.Challenges {
+		playerIds = append(playerIds, c.ChallengerId, c.DefenderId)
+	}
+
+	players, err := s.getPlayers(playerIds)
+	if err != nil {
+		return nil, err
+	}
+
+	challenges := lo.Map(ladder.Challenges, func(c domain.LadderChallenge, _ int) dto.LadderChallengeDto { return toLadderChallengeDto(c, players) })
+	sort.SliceStable(challenges, func(i, j int) bool { return challenges[i].CreatedAt.After(challenges[j].CreatedAt) })
+
+	penalties := lo.Map(ladder.Penalties, func(p domain.LadderPenalty, _ int) dto.LadderPenaltyDto {
+		return dto.LadderPenaltyDto{
+			Player:       toPlayerSummaryDto(p.PlayerId, players),
+			FromPosition: p.FromPosition,
+			ToPosition:   p.ToPosition,
+			CreatedAt:    p.CreatedAt,
+		}
+	})
+	sort.SliceStable(penalties, func(i, j int) bool { return penalties[i].CreatedAt.After(penalties[j].CreatedAt) })
+
+	return &dto.LadderHistoryDto{Challenges: challenges, Penalties: penalties}, nil
+}
+
+func (s *LadderService) Join(id, playerId uint) (*dto.LadderDto, error) {
+	return s.update(id, func(tx *gorm.DB, ladder *domain.Ladder, now time.Time) error {
+		_, err := ladder.Join(playerId, now)
+		return err
+	})
+}
+
+func (s *LadderService) Leave(id, playerId uint) (*dto.LadderDto, error) {
+	return s.update(id, func(tx *gorm.DB, ladder *domain.Ladder, now time.Time) error {
+		if err := ladder.Leave(playerId, now); err != nil {
+			return err
+		}
+		return tx.Where("ladder_id = ? AND player_id = ?", id, playerId).Delete(&domain.LadderRung{}).Error
+	})
+}
+
+// Challenge is made by the current player, the challenged player is notified to respond before the deadline
+func (s *LadderService) Challenge(id, challengerId uint, req dto.ChallengeRequestDto) (*dto.LadderDto, error) {
+	return s.update(id, func(tx *gorm.DB, ladder *domain.Ladder, now time.Time) error {
+		challenge, err := ladder.Challenge(challengerId, req.DefenderId, now)
+		if err != nil {
+			return err
+		}
+
+		if req.MatchId != nil {
+			if err := s.ensureSession(tx, *req.MatchId); err != nil {
+				return err
+			}
+			challenge.MatchId = req.MatchId
+			challenge.Court = req.Court
+		}
+
+		challenger, err := s.getPlayers([]uint{challengerId})
+		if err != nil {
+			return err
+		}
+
+		return notifyPlayers(tx, []uint{req.DefenderId}, challenge.MatchId, "Ladder challenge", fmt.Sprintf(
+			"%s challenged you on the %s ladder, please respond by %s",
+			challenger[challengerId].FirstName, ladder.Name, challenge.RespondBy.Format("02 Jan 15:04"),
+		))
+	})
+}
+
+// Respond is made by the challenged player, declining forfeits the challenge and accepting starts the play deadline
+func (s *LadderService) Respond(id, challengeId, playerId uint, req dto.ChallengeResponseDto) (*dto.LadderDto, error) {
+	return s.update(id, func(tx *gorm.DB, ladder *domain.Ladder, now time.Time) error {
+		challenge, err := ladder.Respond(challengeId, playerId, req.Accept, now)
+		if err != nil {
+			return err
+		}
+
+		message := fmt.Sprintf("Your challenge on the %s ladder was declined, you take the position", ladder.Name)
+		if req.Accept {
+			message = fmt.Sprintf("Your challenge on the %s ladder was accepted, play it before %s", ladder.Name, challenge.PlayBy.Format("02 Jan 15:04"))
+		}
+		return notifyPlayers(tx, []uint{challenge.ChallengerId}, challenge.MatchId, "Ladder challenge", message)
+	})
+}
+
+func (s *LadderService) Schedule(id, challengeId uint, req dto.ScheduleChallengeDto) (*dto.LadderDto, error) {
+	return s.update(id, func(tx *gorm.DB, ladder *domain.Ladder, now time.Time) error {
+		if err := s.ensureSession(tx, req.MatchId); err != nil {
+			return err
+		}
+
+		_, err := ladder.Schedule(challengeId, req.MatchId, req.Court)
+		return err
+	})
+}
+
+// RecordResult swaps the positions when the challenger wins, the result is also recorded
+// as a game of the session when the challenge was played at one so ratings include it
+func (s *LadderService) RecordResult(id, challengeId uint, editedBy string, req dto.ChallengeResultDto) (*dto.LadderDto, error) {
+	scores := lo.Map(req.Sets, func(set dto.SetScoreDto, _ int) [2]int { return [2]int{set.A, set.B} })
+
+	return s.update(id, func(tx *gorm.DB, ladder *domain.Ladder, now time.Time) error {
+		challenge, err := ladder.RecordResult(challengeId, scores, now)
+		if err != nil {
+			return err
+		}
+
+		if challenge.MatchId == nil {
+			return nil
+		}
+
+		game, err := domain.NewGame(*challenge.MatchId, challenge.Court, ladder.Scoring)
+		if err != nil {
+			return err
+		}
+
+		if err := game.SetPlayers([]uint{challenge.ChallengerId}, []uint{challenge.DefenderId}); err != nil {
+			return err
+		}
+
+		if err := game.RecordScores(scores); err != nil {
+			return err
+		}
+
+		game.Revise(editedBy)
+		if err := tx.Create(game).Error; err != nil {
+			return err
+		}
+
+		return s.ratingService.RecomputeFrom(tx, game.MatchId, game.ID)
+	})
+}
+
+func (s *LadderService) update(id uint, change func(tx *gorm.DB, ladder *domain.Ladder, now time.Time) error) (*dto.LadderDto, error) {
+	ladder, err := s.refresh(id, change)
+	if err != nil {
+		return nil, err
+	}
+	return s.toLadderDto(ladder)
+}
+
+// refresh loads the ladder, forfeits overdue challenges and applies inactivity penalties before the change,
+// there is no background job so this is what keeps the ladder up to date
+func (s *LadderService) refresh(id uint, change func(tx *gorm.DB, ladder *domain.Ladder, now time.Time) error) (*domain.Ladder, error) {
+	var ladder *domain.Ladder
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		ladder, err = s.getLadder(tx, id)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		ladder.Refresh(now)
+		if err := change(tx, ladder, now); err != nil {
+			return err
+		}
+
+		return s.saveLadder(tx, ladder)
+	})
+
+	return ladder, err
+}
+
+func (s *LadderService) saveLadder(tx *gorm.DB, ladder *domain.Ladder) error {
+	for i := range ladder.Rungs {
+		if err := tx.Save(&ladder.Rungs[i]).Error; err != nil {
+			return err
+		}
+	}
+
+	for i := range ladder.Challenges {
+		if err := tx.Save(&ladder.Challenges[i]).Error; err != nil {
+			return err
+		}
+	}
+
+	for i := range ladder.Penalties {
+		if ladder.Penalties[i].ID != 0 {
+			continue
+		}
+
+		if err := tx.Create(&ladder.Penalties[i]).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ensureSession checks the match session exists and is not cancelled
+func (s *LadderService) ensureSession(tx *gorm.DB, matchId uint) error {
+	return tx.Scopes(MatchStateScope(nil)).Select("id").First(&domain.Match{}, matchId).Error
+}
+
+func (s *LadderService) getLadder(tx *gorm.DB, id uint) (*domain.Ladder, error) {
+	ladder := &domain.Ladder{}
+	err := tx.
+		Preload("Rungs").
+		Preload("Challenges", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+		Preload("Penalties").
+		First(ladder, id).Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, result.ErrorNotFound
+	}
+	return ladder, err
+}
+
+func (s *LadderService) getPlayers(ids []uint) (map[uint]domain.Player, error) {
+	var players []domain.Player
+	if err := s.db.Where("id IN ?", lo.Uniq(ids)).Find(&players).Error; err != nil {
+		return nil, err
+	}
+	return lo.KeyBy(players, func(p domain.Player) uint { return p.ID }), nil
+}
+
+func (s *LadderService) toLadderDto(ladder *domain.Ladder) (*dto.LadderDto, error) {
+	open := lo.Filter(ladder.Challenges, func(c domain.LadderChallenge, _ int) bool { return c.IsOpen() })
+	playerIds := lo.Map(ladder.Rungs, func(r domain.LadderRung, _ int) uint { return r.PlayerId })
+	players, err := s.getPlayers(playerIds)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.LadderDto{
+		Id:             ladder.ID,
+		Name:           ladder.Name,
+		ChallengeRange: ladder.ChallengeRange,
+		ResponseDays:   ladder.ResponseDays,
+		PlayDays:       ladder.PlayDays,
+		InactivityDays: ladder.InactivityDays,
+		InactivityDrop: ladder.InactivityDrop,
+		Scoring: dto.ScoringFormatDto{
+			Points: ladder.Scoring.Points,
+			WinBy:  ladder.Scoring.WinBy,
+			Cap:    ladder.Scoring.Cap,
+			BestOf: ladder.Scoring.BestOf,
+		},
+		Rungs: lo.Map(ladder.SortedRungs(), func(r domain.LadderRung, _ int) dto.LadderRungDto {
+			return dto.LadderRungDto{
+				Position:     r.Position,
+				Player:       toPlayerSummaryDto(r.PlayerId, players),
+				LastActiveAt: r.LastActiveAt,
+			}
+		}),
+		OpenChallenges: lo.Map(open, func(c domain.LadderChallenge, _ int) dto.LadderChallengeDto { return toLadderChallengeDto(c, players) }),
+	}, nil
+}
+
+func toLadderChallengeDto(c domain.LadderChallenge, players map[uint]domain.Player) dto.LadderChallengeDto {
+	return dto.LadderChallengeDto{
+		Id:                 c.ID,
+		Challenger:         toPlayerSummaryDto(c.ChallengerId, players),
+		Defender:           toPlayerSummaryDto(c.DefenderId, players),
+		ChallengerPosition: c.ChallengerPosition,
+		DefenderPosition:   c.DefenderPosition,
+		State:              c.State,
+		RespondBy:          c.RespondBy,
+		PlayBy:             c.PlayBy,
+		MatchId:            c.MatchId,
+		Court:              c.Court,
+		Sets:               lo.Map(c.Sets, func(set [2]int, _ int) dto.SetScoreDto { return dto.SetScoreDto{A: set[0], B: set[1]} }),
+		WinnerId:           c.WinnerId,
+		CreatedAt:          c.CreatedAt,
+		ResolvedAt:         c.ResolvedAt,
+	}
+}
+
+func toPlayerSummaryDto(id uint, players map[uint]domain.Player) dto.PlayerSummaryDto {
+	return dto.PlayerSummaryDto{PlayerId: id, FirstName: players[id].FirstName, LastName: players[id].LastName}
+}
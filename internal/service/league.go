@@ -0,0 +1,331 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/samber/lo"
+	"github.com/tructn/racket/internal/domain"
+	"github.com/tructn/racket/internal/dto"
+	"github.com/tructn/racket/pkg/result"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+var ErrTeamNotFound = errors.New("team not found")
+
+type LeagueService struct {
+	db     *gorm.DB
+	logger *zap.SugaredLogger
+}
+
+func NewLeagueService(db *gorm.DB, logger *zap.SugaredLogger) *LeagueService {
+	return &LeagueService{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (s *LeagueService) WithContext(ctx context.Context) *LeagueService {
+	clone := *s
+	clone.db = s.db.WithContext(ctx)
+	return &clone
+}
+
+func (s *LeagueService) GetSeasons() ([]dto.SeasonDto, error) {
+	var seasons []domain.Season
+	if err := s.db.Preload("Leagues").Order("starts_on DESC").Find(&seasons).Error; err != nil {
+		return nil, err
+	}
+
+	return lo.Map(seasons, func(season domain.Season, _ int) dto.SeasonDto { return toSeasonDto(season) }), nil
+}
+
+func (s *LeagueService) CreateSeason(req dto.SeasonRequestDto) (*dto.SeasonDto, error) {
+	season, err := domain.NewSeason(req.Name, req.StartsOn, req.EndsOn)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.db.Create(season).Error; err != nil {
+		return nil, err
+	}
+
+	seasonDto := toSeasonDto(*season)
+	return &seasonDto, nil
+}
+
+// CreateLeague enters permanent teams in a league of the season, event teams of a single match can not play
+func (s *LeagueService) CreateLeague(seasonId uint, req dto.LeagueRequestDto) (*dto.LeagueDto, error) {
+	if err := s.db.First(&domain.Season{}, seasonId).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, result.ErrorNotFound
+		}
+		return nil, err
+	}
+
+	league, err := domain.NewLeague(seasonId, req.Name, toPointsRules(req.Points), req.Tiebreakers, req.HomeAndAway)
+	if err != nil {
+		return nil, err
+	}
+
+	var teams []domain.Team
+	if err := s.db.Where("id IN ? AND match_id IS NULL", lo.Uniq(req.TeamIds)).Order("id").Find(&teams).Error; err != nil {
+		return nil, err
+	}
+
+	if len(teams) != len(lo.Uniq(req.TeamIds)) {
+		return nil, ErrTeamNotFound
+	}
+
+	league.Teams = teams
+	if err := s.db.Omit("Teams.*").Create(league).Error; err != nil {
+		return nil, err
+	}
+
+	return s.GetLeague(league.ID)
+}
+
+func (s *LeagueService) GetLeague(id uint) (*dto.LeagueDto, error) {
+	league, err := s.getLeague(s.db, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.toLeagueDto(league)
+}
+
+// GenerateFixtures replaces the fixtures of the league with a new round robin
+func (s *LeagueService) GenerateFixtures(id uint, req dto.GenerateFixturesDto) (*dto.LeagueDto, error) {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		league, err := s.getLeague(tx, id)
+		if err != nil {
+			return err
+		}
+
+		fixtures, err := league.GenerateFixtures()
+		if err != nil {
+			return err
+		}
+
+		if err := s.ensureSessions(tx, req.MatchIds...); err != nil {
+			return err
+		}
+
+		for i := range fixtures {
+			if round := fixtures[i].Round; round <= len(req.MatchIds) {
+				fixtures[i].MatchId = &req.MatchIds[round-1]
+			}
+		}
+
+		if err := tx.Where("league_id = ?", id).Delete(&domain.Fixture{}).Error; err != nil {
+			return err
+		}
+
+		return tx.Create(&fixtures).Error
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetLeague(id)
+}
+
+func (s *LeagueService) RecordResult(id, fixtureId uint, req dto.FixtureResultDto) (*dto.LeagueDto, error) {
+	return s.updateFixture(id, fixtureId, func(league *domain.League, f *domain.Fixture) error {
+		return f.RecordResult(req.HomeRubbers, req.AwayRubbers, league.Points)
+	})
+}
+
+func (s *LeagueService) Postpone(id, fixtureId uint, req dto.PostponeFixtureDto) (*dto.LeagueDto, error) {
+	return s.updateFixture(id, fixtureId, func(_ *domain.League, f *domain.Fixture) error {
+		return f.Postpone(req.Reason)
+	})
+}
+
+func (s *LeagueService) Reschedule(id, fixtureId uint, req dto.RescheduleFixtureDto) (*dto.LeagueDto, error) {
+	if err := s.ensureSessions(s.db, req.MatchId); err != nil {
+		return nil, err
+	}
+
+	return s.updateFixture(id, fixtureId, func(_ *domain.League, f *domain.Fixture) error {
+		return f.Reschedule(req.MatchId)
+	})
+}
+
+func (s *LeagueService) GetStandings(id uint) ([]dto.StandingDto, error) {
+	league, err := s.getLeague(s.db, id)
+	if err != nil {
+		return nil, err
+	}
+
+	teams := lo.KeyBy(league.Teams, func(t domain.Team) uint { return t.ID })
+	return lo.Map(league.CalcStandings(), func(st domain.Standing, _ int) dto.StandingDto {
+		return dto.StandingDto{
+			Position:         st.Position,
+			TeamId:           st.TeamId,
+			TeamName:         teams[st.TeamId].Name,
+			Played:           st.Played,
+			Won:              st.Won,
+			Drawn:            st.Drawn,
+			Lost:             st.Lost,
+			RubbersFor:       st.RubbersFor,
+			RubbersAgainst:   st.RubbersAgainst,
+			RubberDifference: st.RubbersFor - st.RubbersAgainst,
+			Points:           st.Points,
+		}
+	}), nil
+}
+
+// ExportStandings renders the standings as csv so they can be shared or printed
+func (s *LeagueService) ExportStandings(id uint) ([]byte, error) {
+	standings, err := s.GetStandings(id)
+	if err != nil {
+		return nil, err
+	}
+
+	buf := &bytes.Buffer{}
+	writer := csv.NewWriter(buf)
+	rows := [][]string{{"Position", "Team", "Played", "Won", "Drawn", "Lost", "Rubbers for", "Rubbers against", "Rubber difference", "Points"}}
+	for _, st := range standings {
+		rows = append(rows, append([]string{strconv.Itoa(st.Position), st.TeamName}, lo.Map(
+			[]int{st.Played, st.Won, st.Drawn, st.Lost, st.RubbersFor, st.RubbersAgainst, st.RubberDifference, st.Points},
+			func(v int, _ int) string { return strconv.Itoa(v) },
+		)...))
+	}
+
+	if err := writer.WriteAll(rows); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (s *LeagueService) updateFixture(id, fixtureId uint, update func(*domain.League, *domain.Fixture) error) (*dto.LeagueDto, error) {
+	league, err := s.getLeague(s.db, id)
+	if err != nil {
+		return nil, err
+	}
+
+	fixture, found := lo.Find(league.Fixtures, func(f domain.Fixture) bool { return f.ID == fixtureId })
+	if !found {
+		return nil, domain.ErrFixtureNotFound
+	}
+
+	if err := update(league, &fixture); err != nil {
+		return nil, err
+	}
+
+	if err := s.db.Save(&fixture).Error; err != nil {
+		return nil, err
+	}
+
+	return s.GetLeague(id)
+}
+
+// ensureSessions checks the match sessions exist and are not cancelled
+func (s *LeagueService) ensureSessions(tx *gorm.DB, matchIds ...uint) error {
+	if len(matchIds) == 0 {
+		return nil
+	}
+
+	var count int64
+	if err := tx.Model(&domain.Match{}).Scopes(MatchStateScope(nil)).Where("id IN ?", lo.Uniq(matchIds)).Count(&count).Error; err != nil {
+		return err
+	}
+
+	if int(count) != len(lo.Uniq(matchIds)) {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (s *LeagueService) getLeague(tx *gorm.DB, id uint) (*domain.League, error) {
+	league := &domain.League{}
+	err := tx.
+		Preload("Teams", func(db *gorm.DB) *gorm.DB { return db.Order("teams.id") }).
+		Preload("Fixtures", func(db *gorm.DB) *gorm.DB { return db.Order("round, id") }).
+		First(league, id).Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, result.ErrorNotFound
+	}
+	return league, err
+}
+
+func (s *LeagueService) toLeagueDto(league *domain.League) (*dto.LeagueDto, error) {
+	var matches []domain.Match
+	matchIds := lo.Uniq(lo.FilterMap(league.Fixtures, func(f domain.Fixture, _ int) (uint, bool) { return lo.FromPtr(f.MatchId), f.MatchId != nil }))
+	if err := s.db.Where("id IN ?", matchIds).Find(&matches).Error; err != nil {
+		return nil, err
+	}
+
+	starts := lo.SliceToMap(matches, func(m domain.Match) (uint, time.Time) { return m.ID, m.Start })
+	teams := lo.KeyBy(league.Teams, func(t domain.Team) uint { return t.ID })
+	fixtures := lo.Map(league.Fixtures, func(f domain.Fixture, _ int) dto.FixtureDto {
+		fixture := dto.FixtureDto{
+			Id:                   f.ID,
+			Round:                f.Round,
+			HomeTeamId:           f.HomeTeamId,
+			HomeTeamName:         teams[f.HomeTeamId].Name,
+			AwayTeamId:           f.AwayTeamId,
+			AwayTeamName:         teams[f.AwayTeamId].Name,
+			MatchId:              f.MatchId,
+			State:                f.State,
+			HomeRubbers:          f.HomeRubbers,
+			AwayRubbers:          f.AwayRubbers,
+			PostponedReason:      f.PostponedReason,
+			PostponedFromMatchId: f.PostponedFromMatchId,
+		}
+		if start, found := starts[lo.FromPtr(f.MatchId)]; found {
+			fixture.MatchStart = &start
+		}
+		return fixture
+	})
+	sort.SliceStable(fixtures, func(i, j int) bool { return fixtures[i].Round < fixtures[j].Round })
+
+	return &dto.LeagueDto{
+		Id:       league.ID,
+		SeasonId: league.SeasonId,
+		Name:     league.Name,
+		Points: dto.PointsRulesDto{
+			Win:       league.Points.Win,
+			Draw:      league.Points.Draw,
+			Loss:      league.Points.Loss,
+			RubberWin: league.Points.RubberWin,
+			Rubbers:   league.Points.Rubbers,
+		},
+		Tiebreakers: league.Tiebreakers,
+		HomeAndAway: league.HomeAndAway,
+		Teams:       lo.Map(league.Teams, func(t domain.Team, _ int) dto.LeagueTeamDto { return dto.LeagueTeamDto{Id: t.ID, Name: t.Name} }),
+		Fixtures:    fixtures,
+	}, nil
+}
+
+func toSeasonDto(season domain.Season) dto.SeasonDto {
+	return dto.SeasonDto{
+		Id:       season.ID,
+		Name:     season.Name,
+		StartsOn: season.StartsOn,
+		EndsOn:   season.EndsOn,
+		Leagues:  lo.Map(season.Leagues, func(l domain.League, _ int) dto.LeagueSummaryDto { return dto.LeagueSummaryDto{Id: l.ID, Name: l.Name} }),
+	}
+}
+
+func toPointsRules(points *dto.PointsRulesDto) domain.PointsRules {
+	if points == nil {
+		return domain.PointsRules{}
+	}
+
+	return domain.PointsRules{
+		Win:       points.Win,
+		Draw:      points.Draw,
+		Loss:      points.Loss,
+		RubberWin: points.RubberWin,
+		Rubbers:   points.Rubbers,
+	}
+}
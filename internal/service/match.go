@@ -1,13 +1,30 @@
 package service
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
 	"github.com/samber/lo"
 	"github.com/tructn/racket/internal/domain"
 	"github.com/tructn/racket/internal/dto"
+	"github.com/tructn/racket/pkg/result"
+	"github.com/tructn/racket/pkg/scopes"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 )
 
+// PlayerVisibleMatchStates excludes matches being prepared and cancelled ones
+var PlayerVisibleMatchStates = []domain.MatchState{
+	domain.MatchOpen,
+	domain.MatchRegistrationClosed,
+	domain.MatchPlayed,
+	domain.MatchFinalized,
+}
+
 type MatchService struct {
 	db     *gorm.DB
 	logger *zap.SugaredLogger
@@ -20,6 +37,12 @@ func NewMatchService(db *gorm.DB, logger *zap.SugaredLogger) *MatchService {
 	}
 }
 
+func (s *MatchService) WithContext(ctx context.Context) *MatchService {
+	clone := *s
+	clone.db = s.db.WithContext(ctx)
+	return &clone
+}
+
 func (s *MatchService) GetMatchSummary(matchId uint) (*dto.MatchSummaryDto, error) {
 	match := &domain.Match{}
 	err := s.db.Model(&domain.Match{}).
@@ -39,116 +62,859 @@ func (s *MatchService) GetMatchSummary(matchId uint) (*dto.MatchSummaryDto, erro
 	}, nil
 }
 
-func (s *MatchService) GetTodayMatches() []dto.MatchDto {
+func (s *MatchService) GetTodayMatches(states []domain.MatchState) []dto.MatchDto {
 	var matches []domain.Match
 	s.db.
+		Scopes(MatchStateScope(states)).
 		Preload("SportCenter", func(db *gorm.DB) *gorm.DB {
 			return db.Select("id, name, cost_per_section, minute_per_section")
 		}).
 		Preload("AdditionalCosts", func(db *gorm.DB) *gorm.DB {
 			return db.Select("amount, match_id")
 		}).
+		Preload("Registrations", maybeRegistrations).
+		Preload("CourtBookings").
 		Where("start::date = CURRENT_DATE::date").
 		Order("start DESC").
 		Find(&matches)
 
-	// Optionally, parallelize the mapping if necessary
-	result := lo.Map(matches, func(m domain.Match, _ int) dto.MatchDto {
-		// Calculate AdditionalCost
-		additionalCost := lo.SumBy(m.AdditionalCosts, func(ac domain.AdditionalCost) float64 {
-			return ac.Amount
-		})
-
-		return dto.MatchDto{
-			MatchId:          m.ID,
-			Start:            m.Start,
-			End:              m.End,
-			SportCenterId:    m.SportCenterId,
-			SportCenterName:  m.SportCenter.Name,
-			CostPerSection:   m.SportCenter.CostPerSection,
-			MinutePerSection: m.SportCenter.MinutePerSection,
-			Cost:             m.Cost,
-			AdditionalCost:   additionalCost,
-			Court:            m.Court,
-			CustomSection:    m.CustomSection,
-		}
-	})
-
-	return result
+	return lo.Map(matches, func(m domain.Match, _ int) dto.MatchDto { return ToMatchDto(m) })
 }
 
-func (s *MatchService) GetFutureMatches() []dto.MatchDto {
+func (s *MatchService) GetFutureMatches(states []domain.MatchState) []dto.MatchDto {
 	var matches []domain.Match
 	s.db.
+		Scopes(MatchStateScope(states)).
 		Preload("SportCenter", func(db *gorm.DB) *gorm.DB {
 			return db.Select("id, name, cost_per_section, minute_per_section")
 		}).
 		Preload("AdditionalCosts", func(db *gorm.DB) *gorm.DB {
 			return db.Select("amount, match_id")
 		}).
+		Preload("Registrations", maybeRegistrations).
+		Preload("CourtBookings").
 		Where("start::date > CURRENT_DATE::date").
 		Order("start DESC").
 		Find(&matches)
 
-	// Optionally, parallelize the mapping if necessary
-	result := lo.Map(matches, func(m domain.Match, _ int) dto.MatchDto {
-		// Calculate AdditionalCost
-		additionalCost := lo.SumBy(m.AdditionalCosts, func(ac domain.AdditionalCost) float64 {
-			return ac.Amount
-		})
-
-		return dto.MatchDto{
-			MatchId:          m.ID,
-			Start:            m.Start,
-			End:              m.End,
-			SportCenterId:    m.SportCenterId,
-			SportCenterName:  m.SportCenter.Name,
-			CostPerSection:   m.SportCenter.CostPerSection,
-			MinutePerSection: m.SportCenter.MinutePerSection,
-			Cost:             m.Cost,
-			AdditionalCost:   additionalCost,
-			Court:            m.Court,
-			CustomSection:    m.CustomSection,
-		}
-	})
-
-	return result
+	return lo.Map(matches, func(m domain.Match, _ int) dto.MatchDto { return ToMatchDto(m) })
 }
 
-func (s *MatchService) GetArchivedMatches() []dto.MatchDto {
+func (s *MatchService) GetArchivedMatches(states []domain.MatchState) []dto.MatchDto {
 	var matches []domain.Match
 	s.db.
+		Scopes(MatchStateScope(states)).
 		Preload("SportCenter", func(db *gorm.DB) *gorm.DB {
 			return db.Select("id, name, cost_per_section, minute_per_section")
 		}).
 		Preload("AdditionalCosts", func(db *gorm.DB) *gorm.DB {
 			return db.Select("amount, match_id")
 		}).
+		Preload("Registrations", maybeRegistrations).
+		Preload("CourtBookings").
 		Where("start < CURRENT_DATE").
 		Order("start DESC").
 		Find(&matches)
 
-	// Optionally, parallelize the mapping if necessary
-	result := lo.Map(matches, func(m domain.Match, _ int) dto.MatchDto {
-		// Calculate AdditionalCost
-		additionalCost := lo.SumBy(m.AdditionalCosts, func(ac domain.AdditionalCost) float64 {
-			return ac.Amount
+	return lo.Map(matches, func(m domain.Match, _ int) dto.MatchDto { return ToMatchDto(m) })
+}
+
+// maybeRegistrations only loads the undecided players, match listings show how many there are
+func maybeRegistrations(db *gorm.DB) *gorm.DB {
+	return db.Select("id, match_id, rsvp").Where("rsvp = ?", domain.RsvpMaybe)
+}
+
+// ParseMatchStates reads a comma separated state filter such as "open,played"
+func ParseMatchStates(value string) ([]domain.MatchState, error) {
+	states := []domain.MatchState{}
+	for _, v := range strings.Split(value, ",") {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+
+		state, err := domain.ParseMatchState(v)
+		if err != nil {
+			return nil, err
+		}
+		states = append(states, state)
+	}
+	return states, nil
+}
+
+// MatchStateScope filters matches by state, cancelled matches are hidden when no state is given
+func MatchStateScope(states []domain.MatchState) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if len(states) == 0 {
+			return db.Where("matches.state <> ?", domain.MatchCancelled)
+		}
+		return db.Where("matches.state IN ?", states)
+	}
+}
+
+// ChangeState moves the match through its lifecycle, cancelling runs the cancellation side effects
+func (s *MatchService) ChangeState(matchId uint, state domain.MatchState, reason string) error {
+	if state == domain.MatchCancelled {
+		return s.CancelMatch(matchId, reason)
+	}
+
+	match := domain.Match{}
+	if err := s.db.First(&match, matchId).Error; err != nil {
+		return err
+	}
+
+	if err := match.TransitionTo(state); err != nil {
+		return err
+	}
+
+	return s.db.Model(&match).Update("state", match.State).Error
+}
+
+func (s *MatchService) ChangeCostSplit(matchId uint, courtCost, additionalCost domain.CostSplit) error {
+	match := domain.Match{}
+	if err := s.db.First(&match, matchId).Error; err != nil {
+		return err
+	}
+
+	if err := match.SetCostSplit(courtCost, additionalCost); err != nil {
+		return err
+	}
+
+	return s.db.Model(&match).Select("cost_split", "additional_cost_split").Updates(&match).Error
+}
+
+// ChangeVisibility hides the match from players who are not invited, players already on the roster keep it
+func (s *MatchService) ChangeVisibility(matchId uint, visibility domain.MatchVisibility) error {
+	match := domain.Match{}
+	if err := s.db.First(&match, matchId).Error; err != nil {
+		return err
+	}
+
+	return s.db.Model(&match).Update("visibility", visibility).Error
+}
+
+func (s *MatchService) GetInvitations(matchId uint) ([]dto.MatchInvitationDto, error) {
+	match := domain.Match{}
+	if err := s.db.Preload("Invitations").First(&match, matchId).Error; err != nil {
+		return nil, err
+	}
+
+	var players []domain.Player
+	playerIds := lo.FilterMap(match.Invitations, func(i domain.MatchInvitation, _ int) (uint, bool) { return lo.FromPtr(i.PlayerId), i.PlayerId != nil })
+	if err := s.db.Where("id IN ?", playerIds).Find(&players).Error; err != nil {
+		return nil, err
+	}
+
+	var teams []domain.Team
+	teamIds := lo.FilterMap(match.Invitations, func(i domain.MatchInvitation, _ int) (uint, bool) { return lo.FromPtr(i.TeamId), i.TeamId != nil })
+	if err := s.db.Where("id IN ?", teamIds).Find(&teams).Error; err != nil {
+		return nil, err
+	}
+
+	playerNames := lo.SliceToMap(players, func(p domain.Player) (uint, string) {
+		return p.ID, strings.TrimSpace(fmt.Sprintf("%s %s", p.FirstName, p.LastName))
+	})
+	teamNames := lo.SliceToMap(teams, func(t domain.Team) (uint, string) { return t.ID, t.Name })
+
+	return lo.Map(match.Invitations, func(i domain.MatchInvitation, _ int) dto.MatchInvitationDto {
+		return dto.MatchInvitationDto{
+			Id:         i.ID,
+			PlayerId:   i.PlayerId,
+			PlayerName: playerNames[lo.FromPtr(i.PlayerId)],
+			TeamId:     i.TeamId,
+			TeamName:   teamNames[lo.FromPtr(i.TeamId)],
+		}
+	}), nil
+}
+
+// Invite lets the players and the members of the teams see and register for the match, newly invited players are notified.
+// Invited players are also listed on the match as invited until they answer.
+func (s *MatchService) Invite(matchId uint, req dto.MatchInvitationRequestDto) ([]dto.MatchInvitationDto, error) {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		match := domain.Match{}
+		if err := tx.Preload("Invitations").Preload("Registrations").Preload("SportCenter").First(&match, matchId).Error; err != nil {
+			return err
+		}
+
+		var playerCount int64
+		if err := tx.Model(&domain.Player{}).Where("id IN ?", lo.Uniq(req.PlayerIds)).Count(&playerCount).Error; err != nil {
+			return err
+		}
+
+		var teams []domain.Team
+		if err := tx.Preload("Members").Where("id IN ? AND match_id IS NULL", lo.Uniq(req.TeamIds)).Find(&teams).Error; err != nil {
+			return err
+		}
+
+		if int(playerCount) != len(lo.Uniq(req.PlayerIds)) || len(teams) != len(lo.Uniq(req.TeamIds)) {
+			return result.ErrorNotFound
+		}
+
+		invitations := lo.Map(lo.Uniq(req.PlayerIds), func(id uint, _ int) domain.MatchInvitation { return *domain.NewPlayerInvitation(matchId, id) })
+		invitations = append(invitations, lo.Map(teams, func(t domain.Team, _ int) domain.MatchInvitation { return *domain.NewTeamInvitation(matchId, t.ID) })...)
+
+		var invited []domain.MatchInvitation
+		for _, invitation := range invitations {
+			added, err := match.Invite(invitation)
+			if err != nil {
+				return err
+			}
+
+			if added {
+				invited = append(invited, invitation)
+			}
+		}
+
+		if len(invited) == 0 {
+			return nil
+		}
+
+		if err := tx.Create(&invited).Error; err != nil {
+			return err
+		}
+
+		playerIds := lo.FilterMap(invited, func(i domain.MatchInvitation, _ int) (uint, bool) { return lo.FromPtr(i.PlayerId), i.PlayerId != nil })
+		if registrations := match.NewInvitations(playerIds); len(registrations) > 0 {
+			if err := tx.Omit("Guests").Create(&registrations).Error; err != nil {
+				return err
+			}
+		}
+
+		for _, team := range teams {
+			if lo.ContainsBy(invited, func(i domain.MatchInvitation) bool { return lo.FromPtr(i.TeamId) == team.ID }) {
+				playerIds = append(playerIds, lo.Map(team.Members, func(p domain.Player, _ int) uint { return p.ID })...)
+			}
+		}
+
+		message := fmt.Sprintf("You are invited to the session at %s on %s", match.SportCenter.Name, match.Start.Format("02/01/2006 15:04"))
+		return notifyPlayers(tx, playerIds, &match.ID, "You are invited", message)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetInvitations(matchId)
+}
+
+// RemoveInvitation hides a private match from the invitee again, a player who has not answered yet is taken off the match,
+// registrations already made are kept
+func (s *MatchService) RemoveInvitation(matchId, invitationId uint) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		invitation := domain.MatchInvitation{}
+		err := tx.Where("match_id = ?", matchId).First(&invitation, invitationId).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return domain.ErrInvitationNotFound
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Delete(&invitation).Error; err != nil {
+			return err
+		}
+
+		if invitation.PlayerId == nil {
+			return nil
+		}
+
+		return tx.
+			Where("match_id = ? AND player_id = ? AND rsvp = ?", matchId, *invitation.PlayerId, domain.RsvpInvited).
+			Delete(&domain.Registration{}).Error
+	})
+}
+
+// FilterVisibleMatches drops the private matches the player is not invited to
+func (s *MatchService) FilterVisibleMatches(matches []domain.Match, playerId uint) ([]domain.Match, error) {
+	return filterVisibleMatches(s.db, matches, playerId)
+}
+
+// filterVisibleMatches expects the registrations and invitations of the matches to be loaded
+func filterVisibleMatches(db *gorm.DB, matches []domain.Match, playerId uint) ([]domain.Match, error) {
+	teamIds, err := getPlayerTeamIds(db, playerId)
+	if err != nil {
+		return nil, err
+	}
+
+	return lo.Filter(matches, func(m domain.Match, _ int) bool { return m.IsVisibleTo(playerId, teamIds) }), nil
+}
+
+// getPlayerTeamIds leaves out event teams, they only exist for one match and grant no access to others
+func getPlayerTeamIds(db *gorm.DB, playerId uint) ([]uint, error) {
+	var teamIds []uint
+	err := db.Raw(`
+		SELECT tm.team_id
+		FROM team_members tm
+		JOIN teams t ON t.id = tm.team_id AND t.deleted_at IS NULL AND t.match_id IS NULL
+		WHERE tm.player_id = ?
+	`, playerId).Scan(&teamIds).Error
+	return teamIds, err
+}
+
+// CancelMatch keeps the match and its registrations for history, refunds what players paid
+// from their wallet or were marked as paid for it, notifies them and logs the cancellation
+func (s *MatchService) CancelMatch(matchId uint, reason string) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		match := domain.Match{}
+		if err := tx.
+			Preload("SportCenter").
+			Preload("Registrations").
+			Preload("AdditionalCosts").
+			First(&match, matchId).Error; err != nil {
+			return err
+		}
+
+		if err := match.Cancel(reason); err != nil {
+			return err
+		}
+
+		if err := tx.Model(&match).Select("state", "cancelled_reason").Updates(&match).Error; err != nil {
+			return err
+		}
+
+		description := fmt.Sprintf("%s on %s cancelled", match.SportCenter.Name, match.Start.Format("02/01/2006"))
+
+		refunded, err := s.refundMatch(tx, &match, fmt.Sprintf("Refund: %s", description))
+		if err != nil {
+			return err
+		}
+
+		message := description
+		if reason != "" {
+			message = fmt.Sprintf("%s: %s", description, reason)
+		}
+
+		playerIds := lo.Map(match.Registrations, func(r domain.Registration, _ int) uint { return r.PlayerId })
+		if err := notifyPlayers(tx, playerIds, &match.ID, "Match cancelled", message); err != nil {
+			return err
+		}
+
+		data := struct {
+			MatchId     uint      `json:"matchId"`
+			SportCenter string    `json:"sportCenter"`
+			Start       time.Time `json:"start"`
+			Reason      string    `json:"reason"`
+			Players     int       `json:"players"`
+			Refunded    float64   `json:"refunded"`
+		}{
+			MatchId:     match.ID,
+			SportCenter: match.SportCenter.Name,
+			Start:       match.Start,
+			Reason:      reason,
+			Players:     len(playerIds),
+			Refunded:    refunded,
+		}
+
+		payload, err := json.Marshal(data)
+		if err != nil {
+			return err
+		}
+
+		ac, err := domain.CreateActivityLog(domain.MatchDeleted, message, string(payload))
+		if err != nil {
+			return err
+		}
+
+		return tx.Create(ac).Error
+	})
+}
+
+// refundMatch credits the confirmed players marked as paid to their oldest wallet, a wallet is opened for players without one.
+// Payments are recorded on registrations, so refunds are derived from them.
+func (s *MatchService) refundMatch(tx *gorm.DB, match *domain.Match, description string) (float64, error) {
+	total := 0.0
+	for playerId, amount := range match.CalcPaidRefunds() {
+		wallet := domain.NewWallet(playerId, "Refunds")
+		if err := tx.Where("owner_id = ?", playerId).Order("id").Limit(1).Find(wallet).Error; err != nil {
+			return 0, err
+		}
+
+		if err := wallet.CreditForMatch(match.ID, amount, description); err != nil {
+			return 0, err
+		}
+
+		if err := tx.Save(wallet).Error; err != nil {
+			return 0, err
+		}
+		total += amount
+	}
+
+	return total, nil
+}
+
+// FindCourtConflicts returns the matches booking the same court of the same sport center at the same time
+func (s *MatchService) FindCourtConflicts(match *domain.Match) ([]domain.Match, error) {
+	return findCourtConflicts(s.db, match)
+}
+
+// findCourtConflicts compares the court bookings of the candidates, not only their session times
+func findCourtConflicts(db *gorm.DB, match *domain.Match) ([]domain.Match, error) {
+	var candidates []domain.Match
+	err := db.
+		Preload("SportCenter").
+		Preload("CourtBookings").
+		Scopes(MatchStateScope(nil)).
+		Where("sport_center_id = ? AND id <> ? AND start < ? AND \"end\" > ?", match.SportCenterId, match.ID, match.End, match.Start).
+		Find(&candidates).Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return match.FindConflicts(candidates), nil
+}
+
+// EnsureNoCourtConflict fails with a domain.ConflictError unless conflicts are explicitly allowed
+func (s *MatchService) EnsureNoCourtConflict(match *domain.Match, allowConflicts bool) error {
+	if allowConflicts {
+		return nil
+	}
+
+	conflicts, err := s.FindCourtConflicts(match)
+	if err != nil {
+		return err
+	}
+
+	if len(conflicts) > 0 {
+		return &domain.ConflictError{Kind: domain.CourtConflict, Matches: conflicts}
+	}
+
+	return nil
+}
+
+// EnsureSportCenterOpen fails with a domain.ClosedError when a court is booked outside
+// opening hours or on a closed date, unless explicitly allowed
+func (s *MatchService) EnsureSportCenterOpen(match *domain.Match, allowClosed bool) error {
+	if allowClosed {
+		return nil
+	}
+
+	sc := domain.SportCenter{}
+	if err := s.db.
+		Preload("OpeningHours").
+		Preload("Closures").
+		First(&sc, match.SportCenterId).Error; err != nil {
+		return err
+	}
+
+	return sc.EnsureOpenFor(match)
+}
+
+// getBookingSportCenter loads the sport center with what is needed to price and check a booking
+func (s *MatchService) getBookingSportCenter(id uint) (*domain.SportCenter, error) {
+	sc := &domain.SportCenter{}
+	err := s.db.
+		Preload("PricingRules").
+		Preload("PriceHistory").
+		Preload("OpeningHours").
+		Preload("Closures").
+		First(sc, id).Error
+	return sc, err
+}
+
+// CloneMatch copies the match a week later priced for its new date,
+// closed dates and court conflicts fail unless explicitly allowed
+func (s *MatchService) CloneMatch(matchId uint, allowClosed, allowConflicts bool) (*domain.Match, error) {
+	match := domain.Match{}
+	if err := s.db.Preload("CourtBookings").Preload("Invitations").First(&match, matchId).Error; err != nil {
+		return nil, err
+	}
+
+	sc, err := s.getBookingSportCenter(match.SportCenterId)
+	if err != nil {
+		return nil, err
+	}
+
+	clone := match.Clone()
+	clone.Reprice(sc)
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if !allowClosed {
+			if err := sc.EnsureOpenFor(&clone); err != nil {
+				return err
+			}
+		}
+
+		if !allowConflicts {
+			conflicts, err := findCourtConflicts(tx, &clone)
+			if err != nil {
+				return err
+			}
+
+			if len(conflicts) > 0 {
+				return &domain.ConflictError{Kind: domain.CourtConflict, Matches: conflicts}
+			}
+		}
+
+		return tx.Create(&clone).Error
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &clone, nil
+}
+
+// GenerateSeries repeats the match weekly priced for each date,
+// closed dates and dates with a court conflict are skipped and reported
+func (s *MatchService) GenerateSeries(matchId uint, req dto.MatchSeriesDto) (*dto.MatchSeriesResultDto, error) {
+	if req.Weeks < 1 || req.Weeks > 52 {
+		return nil, errors.New("weeks must be between 1 and 52")
+	}
+
+	match := domain.Match{}
+	if err := s.db.Preload("CourtBookings").Preload("Invitations").First(&match, matchId).Error; err != nil {
+		return nil, err
+	}
+
+	sc, err := s.getBookingSportCenter(match.SportCenterId)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &dto.MatchSeriesResultDto{
+		CreatedMatchIds: []uint{},
+		SkippedDates:    []dto.SkippedMatchDate{},
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		for week := 1; week <= req.Weeks; week++ {
+			clone := match.RepeatWeeksLater(week)
+			clone.Reprice(sc)
+
+			if !req.AllowClosed {
+				var closedErr *domain.ClosedError
+				if err := sc.EnsureOpenFor(&clone); errors.As(err, &closedErr) {
+					res.SkippedDates = append(res.SkippedDates, dto.SkippedMatchDate{
+						Start:  clone.Start,
+						Reason: closedErr.Reason,
+					})
+					continue
+				} else if err != nil {
+					return err
+				}
+			}
+
+			if !req.AllowConflicts {
+				// Read within the transaction so that the occurrences created so far are taken into account
+				conflicts, err := findCourtConflicts(tx, &clone)
+				if err != nil {
+					return err
+				}
+
+				if len(conflicts) > 0 {
+					conflictErr := &domain.ConflictError{Kind: domain.CourtConflict, Matches: conflicts}
+					res.SkippedDates = append(res.SkippedDates, dto.SkippedMatchDate{
+						Start:  clone.Start,
+						Reason: conflictErr.Error(),
+					})
+					continue
+				}
+			}
+
+			if err := tx.Create(&clone).Error; err != nil {
+				return err
+			}
+			res.CreatedMatchIds = append(res.CreatedMatchIds, clone.ID)
+		}
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// GetConflictReport lists the existing double bookings of courts and players for matches from the given date
+func (s *MatchService) GetConflictReport(from time.Time) (*dto.ConflictReportDto, error) {
+	var matches []domain.Match
+	if err := s.db.
+		Preload("SportCenter").
+		Preload("CourtBookings").
+		Scopes(MatchStateScope(nil)).
+		Where("start >= ?", from).
+		Order("start ASC").
+		Find(&matches).Error; err != nil {
+		return nil, err
+	}
+
+	report := &dto.ConflictReportDto{
+		CourtConflicts:  []dto.CourtConflictReportDto{},
+		PlayerConflicts: []dto.PlayerConflictReportDto{},
+	}
+
+	for i := range matches {
+		for j := i + 1; j < len(matches); j++ {
+			a, b := matches[i], matches[j]
+			if a.ConflictsWith(&b) {
+				report.CourtConflicts = append(report.CourtConflicts, dto.CourtConflictReportDto{
+					SportCenterId:   a.SportCenterId,
+					SportCenterName: a.SportCenter.Name,
+					Matches:         []dto.MatchConflictDto{ToMatchConflictDto(a), ToMatchConflictDto(b)},
+				})
+			}
+		}
+	}
+
+	var rows []struct {
+		PlayerId   uint
+		PlayerName string
+		MatchId    uint
+		OtherId    uint
+	}
+	tenantId, _ := scopes.GetTenantId(s.db.Statement.Context)
+	if err := s.db.Raw(`
+		SELECT
+			p.id AS player_id,
+			TRIM(CONCAT(p.first_name, ' ', p.last_name)) AS player_name,
+			m1.id AS match_id,
+			m2.id AS other_id
+		FROM registrations r1
+		JOIN registrations r2 ON r2.player_id = r1.player_id AND r2.match_id > r1.match_id AND r2.rsvp = r1.rsvp AND r2.deleted_at IS NULL
+		JOIN matches m1 ON m1.id = r1.match_id AND m1.deleted_at IS NULL
+		JOIN matches m2 ON m2.id = r2.match_id AND m2.deleted_at IS NULL
+		JOIN players p ON p.id = r1.player_id AND p.deleted_at IS NULL
+		WHERE r1.deleted_at IS NULL
+			AND r1.tenant_id = ?
+			AND r1.rsvp = ?
+			AND m1.start >= ?
+			AND m1.start < m2."end"
+			AND m2.start < m1."end"
+		ORDER BY player_name, m1.start
+	`, tenantId, domain.RsvpGoing, from).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	matchById := lo.KeyBy(matches, func(m domain.Match) uint { return m.ID })
+	for _, row := range rows {
+		a, okA := matchById[row.MatchId]
+		b, okB := matchById[row.OtherId]
+		if !okA || !okB {
+			continue
+		}
+		report.PlayerConflicts = append(report.PlayerConflicts, dto.PlayerConflictReportDto{
+			PlayerId:   row.PlayerId,
+			PlayerName: row.PlayerName,
+			Matches:    []dto.MatchConflictDto{ToMatchConflictDto(a), ToMatchConflictDto(b)},
 		})
+	}
+
+	return report, nil
+}
+
+func ToMatchConflictDto(m domain.Match) dto.MatchConflictDto {
+	return dto.MatchConflictDto{
+		MatchId:         m.ID,
+		Start:           m.Start,
+		End:             m.End,
+		SportCenterId:   m.SportCenterId,
+		SportCenterName: m.SportCenter.Name,
+		Court:           m.Court,
+	}
+}
+
+func ToConflictErrorDto(err *domain.ConflictError) dto.ConflictErrorDto {
+	return dto.ConflictErrorDto{
+		Error:     err.Error(),
+		Kind:      err.Kind,
+		Conflicts: lo.Map(err.Matches, func(m domain.Match, _ int) dto.MatchConflictDto { return ToMatchConflictDto(m) }),
+	}
+}
+
+// ToMatchDto maps the fields every match listing shows, the sport center, additional costs,
+// court bookings and the registrations counted must be loaded
+func ToMatchDto(m domain.Match) dto.MatchDto {
+	return dto.MatchDto{
+		MatchId:              m.ID,
+		Start:                m.Start,
+		End:                  m.End,
+		SportCenterId:        m.SportCenterId,
+		SportCenterName:      m.SportCenter.Name,
+		CostPerSection:       m.SportCenter.CostPerSection,
+		MinutePerSection:     m.SportCenter.MinutePerSection,
+		Cost:                 m.Cost,
+		AdditionalCost:       m.CalcAdditionalCost(),
+		Court:                m.Court,
+		CustomSection:        m.CustomSection,
+		MaybeCount:           m.CalcMaybeCount(),
+		Capacity:             m.CalcCapacity(),
+		CourtBookings:        ToCourtBookingDtos(m.CourtBookings),
+		State:                m.GetState(),
+		Visibility:           m.GetVisibility(),
+		RegistrationOpensAt:  m.RegistrationOpensAt,
+		RegistrationClosesAt: m.RegistrationClosesAt,
+		CancellationDeadline: m.CancellationDeadline,
+		LateCancellationFee:  m.LateCancellationFee,
+		BallotDrawAt:         m.BallotDrawAt,
+	}
+}
+
+func ToCourtBookingDtos(bookings []domain.CourtBooking) []dto.CourtBookingDto {
+	return lo.Map(bookings, func(b domain.CourtBooking, _ int) dto.CourtBookingDto {
+		return dto.CourtBookingDto{
+			Id:               b.ID,
+			Court:            b.Court,
+			Start:            b.Start,
+			End:              b.End,
+			CostPerSection:   b.CostPerSection,
+			MinutePerSection: b.MinutePerSection,
+			CustomSection:    b.CustomSection,
+			Cost:             b.Cost,
+			Capacity:         b.Capacity,
+			MemberRate:       b.MemberRate,
+			CostItems:        ToCostItemDtos(b.CostItems),
+			PriceSource:      string(b.PriceSource),
+		}
+	})
+}
+
+func ToCostItemDtos(items []domain.CostItem) []dto.CostItemDto {
+	return lo.Map(items, func(item domain.CostItem, _ int) dto.CostItemDto {
+		return dto.CostItemDto{
+			RuleId:         item.RuleId,
+			Description:    item.Description,
+			Start:          item.Start,
+			End:            item.End,
+			Minutes:        item.Minutes,
+			CostPerSection: item.CostPerSection,
+			Sections:       item.Sections,
+			Cost:           item.Cost,
+		}
+	})
+}
+
+// BuildCourtBookings prices the requested courts with the sport center pricing rules,
+// a rate given explicitly on a court overrides them. Pricing rules and price history must be loaded.
+func (s *MatchService) BuildCourtBookings(items []dto.CourtBookingDto, sportCenter *domain.SportCenter) ([]domain.CourtBooking, error) {
+	bookings := []domain.CourtBooking{}
+	for _, item := range items {
+		pricing := sportCenter.GetPricing(item.Start, item.MemberRate)
+		if item.MinutePerSection > 0 {
+			pricing.MinutePerSection = item.MinutePerSection
+		}
+
+		booking, err := domain.NewCourtBooking(item.Court, item.Start, item.End, pricing, item.CustomSection)
+		if err != nil {
+			return nil, err
+		}
+
+		if item.CostPerSection > 0 {
+			booking.ApplyCustomRate(pricing, item.CostPerSection)
+		}
+		booking.SetCapacity(item.Capacity)
+		bookings = append(bookings, *booking)
+	}
+	return bookings, nil
+}
 
-		return dto.MatchDto{
-			MatchId:          m.ID,
-			Start:            m.Start,
-			End:              m.End,
-			SportCenterId:    m.SportCenterId,
-			SportCenterName:  m.SportCenter.Name,
-			CostPerSection:   m.SportCenter.CostPerSection,
-			MinutePerSection: m.SportCenter.MinutePerSection,
-			Cost:             m.Cost,
-			AdditionalCost:   additionalCost,
-			Court:            m.Court,
-			CustomSection:    m.CustomSection,
+// SaveMatch saves the match and replaces its court bookings
+func (s *MatchService) SaveMatch(match *domain.Match) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if match.ID != 0 {
+			if err := tx.Unscoped().Where("match_id = ?", match.ID).Delete(&domain.CourtBooking{}).Error; err != nil {
+				return err
+			}
 		}
+
+		return tx.Save(match).Error
 	})
+}
 
-	return result
+func (s *MatchService) GetRoster(matchId uint) (*dto.MatchRosterDto, error) {
+	match := &domain.Match{}
+	if err := s.db.
+		Preload("CourtBookings").
+		Preload("Registrations").
+		First(match, matchId).Error; err != nil {
+		return nil, err
+	}
+
+	registrations := []dto.RegistrationOverviewDto{}
+	if err := s.db.Raw(`
+		SELECT
+			pl.id AS player_id,
+			TRIM(CONCAT(pl.first_name, ' ', pl.last_name)) AS player_name,
+			pl.email,
+			re.id AS registration_id,
+			re.match_id,
+			re.is_paid,
+			re.total_player_paid_for,
+			re.rsvp,
+			re.is_waitlisted,
+			re.attendance,
+			re.checked_in_at
+		FROM registrations re
+		JOIN players pl ON pl.id = re.player_id AND pl.deleted_at IS NULL
+		WHERE re.deleted_at IS NULL AND re.match_id = ?
+		ORDER BY re.created_at ASC
+	`, matchId).Scan(&registrations).Error; err != nil {
+		return nil, err
+	}
+
+	var guests []domain.Guest
+	if err := s.db.
+		Joins("JOIN registrations r ON r.id = guests.registration_id AND r.deleted_at IS NULL").
+		Where("r.match_id = ?", matchId).
+		Order("guests.id").
+		Find(&guests).Error; err != nil {
+		return nil, err
+	}
+
+	for i := range registrations {
+		registrations[i].Guests = []dto.GuestDto{}
+		for _, g := range guests {
+			if g.RegistrationId == registrations[i].RegistrationId {
+				registrations[i].Guests = append(registrations[i].Guests, *ToGuestDto(g))
+			}
+		}
+	}
+
+	return &dto.MatchRosterDto{
+		MatchId:       match.ID,
+		Start:         match.Start,
+		End:           match.End,
+		Capacity:      match.CalcCapacity(),
+		PlayerCount:   match.CalcPlayerCount(),
+		MaybeCount:    match.CalcMaybeCount(),
+		Courts:        ToCourtBookingDtos(match.CourtBookings),
+		Registrations: registrations,
+	}, nil
+}
+
+func (s *MatchService) GetCostBreakdown(matchId uint) (*dto.MatchCostBreakdownDto, error) {
+	match := &domain.Match{}
+	if err := s.db.
+		Preload("CourtBookings").
+		Preload("AdditionalCosts").
+		Preload("Registrations").
+		First(match, matchId).Error; err != nil {
+		return nil, err
+	}
+
+	additionalCost := match.CalcAdditionalCost()
+
+	return &dto.MatchCostBreakdownDto{
+		MatchId:             match.ID,
+		Courts:              ToCourtBookingDtos(match.CourtBookings),
+		CourtCost:           match.Cost,
+		AdditionalCost:      additionalCost,
+		TotalCost:           match.Cost + additionalCost,
+		PlayerCount:         match.CalcPlayerCount(),
+		IndividualCost:      match.CalcFullSessionCost(),
+		CostSplit:           match.CostSplit,
+		AdditionalCostSplit: match.AdditionalCostSplit,
+		Registrations: lo.FilterMap(match.Registrations, func(r domain.Registration, _ int) (dto.RegistrationCostDto, bool) {
+			return dto.RegistrationCostDto{
+				RegistrationId:     r.ID,
+				PlayerId:           r.PlayerId,
+				TotalPlayerPaidFor: r.TotalPlayerPaidFor,
+				PlayedMinutes:      r.PlayedMinutes(match),
+				Amount:             match.CalcIndividualCost(r),
+			}, r.IsConfirmed()
+		}),
+	}, nil
 }
@@ -0,0 +1,160 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tructn/racket/internal/db/dbtest"
+	"github.com/tructn/racket/internal/domain"
+	"github.com/tructn/racket/internal/dto"
+	"github.com/tructn/racket/pkg/scopes"
+	"go.uber.org/zap"
+)
+
+func booking(court string, start time.Time, hours int) domain.CourtBooking {
+	return domain.CourtBooking{Court: court, Start: start, End: start.Add(time.Duration(hours) * time.Hour)}
+}
+
+func TestFindCourtConflictsComparesCourtBookings(t *testing.T) {
+	db := dbtest.OpenTenant(t)
+	svc := NewMatchService(db, zap.NewNop().Sugar())
+
+	sc := domain.NewSportCenter("Center", "Somewhere", 10, 60)
+	require.NoError(t, db.Create(sc).Error)
+
+	start := time.Date(2024, 6, 5, 18, 0, 0, 0, time.UTC)
+	existing, err := domain.NewMatchWithCourtBookings(sc.ID, []domain.CourtBooking{
+		booking("1", start, 1),
+		booking("2", start.Add(time.Hour), 1),
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.Create(existing).Error)
+
+	// Both sessions run from 18:00 to 20:00 but each court is booked by one of them at a time
+	swapped, err := domain.NewMatchWithCourtBookings(sc.ID, []domain.CourtBooking{
+		booking("2", start, 1),
+		booking("1", start.Add(time.Hour), 1),
+	})
+	require.NoError(t, err)
+
+	conflicts, err := svc.FindCourtConflicts(swapped)
+	require.NoError(t, err)
+	assert.Empty(t, conflicts)
+
+	overlapping, err := domain.NewMatchWithCourtBookings(sc.ID, []domain.CourtBooking{booking("1", start.Add(30*time.Minute), 1)})
+	require.NoError(t, err)
+
+	conflicts, err = svc.FindCourtConflicts(overlapping)
+	require.NoError(t, err)
+	require.Len(t, conflicts, 1)
+	assert.Equal(t, existing.ID, conflicts[0].ID)
+}
+
+func TestGenerateSeriesSkipsClosedAndConflictingDates(t *testing.T) {
+	db := dbtest.OpenTenant(t)
+	svc := NewMatchService(db, zap.NewNop().Sugar())
+
+	start := time.Date(2030, 6, 5, 18, 0, 0, 0, time.UTC)
+	sc := domain.NewSportCenter("Center", "Somewhere", 10, 60)
+	closure, err := domain.NewClosure(start.AddDate(0, 0, 7), start.AddDate(0, 0, 8), "Maintenance")
+	require.NoError(t, err)
+	sc.Closures = []domain.Closure{*closure}
+	require.NoError(t, db.Create(sc).Error)
+
+	match := domain.NewMatch(start, start.Add(2*time.Hour), sc.ID, sc.GetPricing(start, false), "1", nil)
+	require.NoError(t, db.Create(match).Error)
+
+	taken := start.AddDate(0, 0, 14)
+	other := domain.NewMatch(taken, taken.Add(time.Hour), sc.ID, sc.GetPricing(taken, false), "1", nil)
+	require.NoError(t, db.Create(other).Error)
+
+	res, err := svc.GenerateSeries(match.ID, dto.MatchSeriesDto{Weeks: 3})
+	require.NoError(t, err)
+	assert.Len(t, res.CreatedMatchIds, 1)
+	require.Len(t, res.SkippedDates, 2)
+	assert.Equal(t, "Maintenance", res.SkippedDates[0].Reason)
+	assert.True(t, taken.Equal(res.SkippedDates[1].Start))
+
+	// Running the series again conflicts with the occurrence created above unless conflicts are allowed
+	res, err = svc.GenerateSeries(match.ID, dto.MatchSeriesDto{Weeks: 3})
+	require.NoError(t, err)
+	assert.Empty(t, res.CreatedMatchIds)
+
+	res, err = svc.GenerateSeries(match.ID, dto.MatchSeriesDto{Weeks: 3, AllowClosed: true, AllowConflicts: true})
+	require.NoError(t, err)
+	assert.Len(t, res.CreatedMatchIds, 3)
+	assert.Empty(t, res.SkippedDates)
+}
+
+func TestCloneMatchRejectsConflictsUnlessAllowed(t *testing.T) {
+	db := dbtest.OpenTenant(t)
+	svc := NewMatchService(db, zap.NewNop().Sugar())
+
+	start := time.Date(2030, 6, 5, 18, 0, 0, 0, time.UTC)
+	sc := domain.NewSportCenter("Center", "Somewhere", 10, 60)
+	require.NoError(t, db.Create(sc).Error)
+
+	match := domain.NewMatch(start, start.Add(2*time.Hour), sc.ID, sc.GetPricing(start, false), "1", nil)
+	require.NoError(t, db.Create(match).Error)
+
+	clone, err := svc.CloneMatch(match.ID, false, false)
+	require.NoError(t, err)
+	assert.True(t, start.AddDate(0, 0, 7).Equal(clone.Start))
+
+	var conflictErr *domain.ConflictError
+	_, err = svc.CloneMatch(match.ID, false, false)
+	require.ErrorAs(t, err, &conflictErr)
+	assert.Equal(t, clone.ID, conflictErr.Matches[0].ID)
+
+	_, err = svc.CloneMatch(match.ID, false, true)
+	require.NoError(t, err)
+
+	var count int64
+	require.NoError(t, db.Model(&domain.Match{}).Count(&count).Error)
+	assert.Equal(t, int64(3), count)
+}
+
+func TestGetConflictReportComparesCourtBookingsOfTheTenant(t *testing.T) {
+	db := dbtest.Open(t)
+	svc := NewMatchService(db, zap.NewNop().Sugar())
+	ctx := scopes.WithTenant(context.Background(), 1)
+
+	sc := domain.NewSportCenter("Center", "Somewhere", 10, 60)
+	require.NoError(t, db.WithContext(ctx).Create(sc).Error)
+
+	start := time.Date(2030, 6, 5, 18, 0, 0, 0, time.UTC)
+	newMatch := func(bookings ...domain.CourtBooking) *domain.Match {
+		m, err := domain.NewMatchWithCourtBookings(sc.ID, bookings)
+		require.NoError(t, err)
+		return m
+	}
+
+	// Both sessions run from 18:00 to 20:00 but each court is booked by one of them at a time
+	first := newMatch(booking("1", start, 1), booking("2", start.Add(time.Hour), 1))
+	second := newMatch(booking("2", start, 1), booking("1", start.Add(time.Hour), 1))
+	require.NoError(t, db.WithContext(ctx).Create([]*domain.Match{first, second}).Error)
+
+	report, err := svc.WithContext(ctx).GetConflictReport(start.Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Empty(t, report.CourtConflicts)
+
+	// A double booking in another tenant is not reported
+	other := newMatch(booking("1", start, 2))
+	require.NoError(t, db.WithContext(scopes.WithTenant(context.Background(), 2)).Create(other).Error)
+
+	report, err = svc.WithContext(ctx).GetConflictReport(start.Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Empty(t, report.CourtConflicts)
+
+	third := newMatch(booking("1", start, 1))
+	require.NoError(t, db.WithContext(ctx).Create(third).Error)
+
+	report, err = svc.WithContext(ctx).GetConflictReport(start.Add(-time.Hour))
+	require.NoError(t, err)
+	require.Len(t, report.CourtConflicts, 1)
+	assert.Equal(t, first.ID, report.CourtConflicts[0].Matches[0].MatchId)
+	assert.Equal(t, third.ID, report.CourtConflicts[0].Matches[1].MatchId)
+}
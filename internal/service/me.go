@@ -1,7 +1,9 @@
 package service
 
 import (
+	"context"
 	"errors"
+	"time"
 
 	"github.com/samber/lo"
 	"github.com/tructn/racket/internal/domain"
@@ -18,6 +20,12 @@ func NewMeService(db *gorm.DB) *MeService {
 	return &MeService{db: db}
 }
 
+func (s *MeService) WithContext(ctx context.Context) *MeService {
+	clone := *s
+	clone.db = s.db.WithContext(ctx)
+	return &clone
+}
+
 func (s *MeService) GetMyWallet(playerId uint) (*dto.WalletDto, error) {
 	var wallet domain.Wallet
 	err := s.db.Where("owner_id = ?", playerId).First(&wallet).Error
@@ -36,38 +44,84 @@ func (s *MeService) GetMyWallet(playerId uint) (*dto.WalletDto, error) {
 	}, nil
 }
 
+// GetMyUpcommingMatches lists the matches the player can register for, private ones only when invited
 func (s *MeService) GetMyUpcommingMatches(playerId uint) ([]dto.MatchDto, error) {
 	var matches []domain.Match
 	s.db.
 		Preload("SportCenter").
 		Preload("AdditionalCosts").
 		Preload("Registrations").
+		Preload("CourtBookings").
+		Preload("Invitations").
+		Scopes(MatchStateScope(PlayerVisibleMatchStates)).
 		Where("start::date >= CURRENT_DATE::date").
 		Order("start ASC").
 		Find(&matches)
 
+	matches, err := filterVisibleMatches(s.db, matches, playerId)
+	if err != nil {
+		return nil, err
+	}
+
 	result := lo.Map(matches, func(m domain.Match, _ int) dto.MatchDto {
-		isRegistered := lo.ContainsBy(m.Registrations, func(reg domain.Registration) bool {
+		reg, isRegistered := lo.Find(m.Registrations, func(reg domain.Registration) bool {
 			return reg.PlayerId == playerId
 		})
+		individualCost := lo.Ternary(isRegistered && reg.IsConfirmed(), m.CalcIndividualCost(reg), m.CalcFullSessionCost())
 
-		return dto.MatchDto{
-			MatchId:          m.ID,
-			Start:            m.Start,
-			End:              m.End,
-			SportCenterId:    m.SportCenterId,
-			SportCenterName:  m.SportCenter.Name,
-			CostPerSection:   m.SportCenter.CostPerSection,
-			MinutePerSection: m.SportCenter.MinutePerSection,
-			Cost:             m.Cost,
-			AdditionalCost:   m.CalcAdditionalCost(),
-			Court:            m.Court,
-			PlayerCount:      m.CalcPlayerCount(),
-			RegistrationIds:  lo.Map(m.Registrations, func(reg domain.Registration, _ int) uint { return reg.ID }),
-			IndividualCost:   m.CalcIndividualCost(),
-			IsRegistered:     isRegistered,
+		// Players registered as regulars can opt out freely until registration closes
+		var optOutDeadline *time.Time
+		if isRegistered && reg.StandingRegistrationId != nil {
+			optOutDeadline = lo.ToPtr(m.GetOptOutDeadline())
 		}
+
+		res := ToMatchDto(m)
+		res.PlayerCount = m.CalcPlayerCount()
+		res.RegistrationIds = lo.FilterMap(m.Registrations, func(reg domain.Registration, _ int) (uint, bool) { return reg.ID, reg.IsGoing() })
+		res.IndividualCost = individualCost
+		res.IsRegistered = isRegistered && reg.IsGoing()
+		res.Rsvp = reg.Rsvp
+		res.OptOutDeadline = optOutDeadline
+		return res
 	})
 
 	return result, nil
 }
+
+func (s *MeService) GetMyNotifications(playerId uint) ([]dto.NotificationDto, error) {
+	var notifications []domain.Notification
+	if err := s.db.
+		Where("player_id = ?", playerId).
+		Order("created_at DESC").
+		Limit(100).
+		Find(&notifications).Error; err != nil {
+		return nil, err
+	}
+
+	return lo.Map(notifications, func(n domain.Notification, _ int) dto.NotificationDto {
+		return dto.NotificationDto{
+			Id:        n.ID,
+			MatchId:   n.MatchId,
+			Title:     n.Title,
+			Message:   n.Message,
+			ReadAt:    n.ReadAt,
+			CreatedAt: n.CreatedAt,
+		}
+	}), nil
+}
+
+func (s *MeService) MarkNotificationRead(playerId, notificationId uint) error {
+	notification := domain.Notification{}
+	err := s.db.Where("player_id = ?", playerId).First(&notification, notificationId).Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return result.ErrorNotFound
+	}
+
+	if err != nil {
+		return err
+	}
+
+	notification.MarkRead(time.Now())
+	return s.db.Save(&notification).Error
+}
@@ -0,0 +1,25 @@
+package service
+
+import (
+	"github.com/samber/lo"
+	"github.com/tructn/racket/internal/domain"
+	"gorm.io/gorm"
+)
+
+// notifyPlayers creates an in-app notification for each player within the caller transaction
+func notifyPlayers(tx *gorm.DB, playerIds []uint, matchId *uint, title, message string) error {
+	notifications := []domain.Notification{}
+	for _, playerId := range lo.Uniq(playerIds) {
+		n, err := domain.NewNotification(playerId, matchId, title, message)
+		if err != nil {
+			return err
+		}
+		notifications = append(notifications, *n)
+	}
+
+	if len(notifications) == 0 {
+		return nil
+	}
+
+	return tx.Create(&notifications).Error
+}
@@ -1,6 +1,13 @@
 package service
 
 import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/samber/lo"
+	"github.com/tructn/racket/internal/domain"
 	"github.com/tructn/racket/internal/dto"
 	"gorm.io/gorm"
 )
@@ -15,95 +22,192 @@ func NewPaymentService(db *gorm.DB) *PaymentService {
 	}
 }
 
+func (s *PaymentService) WithContext(ctx context.Context) *PaymentService {
+	clone := *s
+	clone.db = s.db.WithContext(ctx)
+	return &clone
+}
+
 func (s *PaymentService) GetOutstandingPaymentReportForAdmin() ([]dto.AdminOutstandingPaymentReportDto, error) {
-	sql := `
-	WITH cte_match_costs AS (
-		SELECT 
-			m.id AS match_id, 
-			m.sport_center_id AS sport_center_id,
-			-- (Base cost + Total additional cost) / Total players paid for
-			(m.cost + COALESCE(acx.additional_cost, 0)) / SUM(r.total_player_paid_for) AS individual_cost
-		FROM matches m
-		JOIN registrations r ON m.id = r.match_id
-		-- Getting SUM of additional cost to add with base cost
-		LEFT JOIN LATERAL (
-			SELECT ac.match_id, SUM(ac.amount) AS additional_cost
-			FROM additional_costs ac
-			GROUP BY ac.match_id
-		) acx ON acx.match_id = m.id
-		WHERE 
-			m.deleted_at is null
-			AND r.deleted_at is null
-		GROUP BY m.id, m.sport_center_id, acx.additional_cost
-	)
-	SELECT
-		p.id AS player_id, 
-		CONCAT(p.first_name,' ', p.last_name) AS player_name,
-		p.email,
-		COUNT(cte.match_id) AS match_count,
-		SUM(cte.individual_cost * r.total_player_paid_for) AS unpaid_amount
-	FROM registrations r
-	JOIN cte_match_costs cte ON cte.match_id = r.match_id
-	JOIN sport_centers sc ON cte.sport_center_id = sc.id
-	JOIN players p ON r.player_id = p.id
-	WHERE 
-		r.is_paid = false
-		AND r.deleted_at is null
-		AND p.deleted_at is null
-	GROUP BY p.id
-	ORDER by player_name
-	`
-	result := []dto.AdminOutstandingPaymentReportDto{}
-	if err := s.db.Raw(sql).Scan(&result).Error; err != nil {
+	items, err := s.GetOutstandingPaymentReportForAnonymous()
+	if err != nil {
 		return nil, err
 	}
+
+	grouped := lo.GroupBy(items, func(item dto.AnonymousOutstandingPaymentReportDto) uint { return item.PlayerId })
+	result := lo.MapToSlice(grouped, func(_ uint, items []dto.AnonymousOutstandingPaymentReportDto) dto.AdminOutstandingPaymentReportDto {
+		isEntryFee := func(item dto.AnonymousOutstandingPaymentReportDto, _ int) bool { return item.TournamentId != 0 }
+		isLateFee := func(item dto.AnonymousOutstandingPaymentReportDto, _ int) bool {
+			return item.LateCancellationFeeId != 0
+		}
+		amount := func(item dto.AnonymousOutstandingPaymentReportDto) float64 { return item.Amount }
+
+		// Matches are counted once, entry fees and late cancellation fees are reported on their own
+		entryFees := lo.Filter(items, isEntryFee)
+		matchIds := lo.FilterMap(lo.Reject(lo.Reject(items, isEntryFee), isLateFee), func(item dto.AnonymousOutstandingPaymentReportDto, _ int) (uint, bool) {
+			return item.MatchId, item.MatchId != 0
+		})
+		return dto.AdminOutstandingPaymentReportDto{
+			PlayerId:             items[0].PlayerId,
+			PlayerName:           items[0].PlayerName,
+			Email:                items[0].PlayerEmail,
+			MatchCount:           uint(len(lo.Uniq(matchIds))),
+			UnpaidAmount:         lo.SumBy(items, amount),
+			LateCancellationFees: lo.SumBy(lo.Filter(items, isLateFee), amount),
+			EntryFeeCount:        uint(len(entryFees)),
+			EntryFees:            lo.SumBy(entryFees, amount),
+		}
+	})
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].PlayerName < result[j].PlayerName
+	})
 	return result, nil
 }
 
+// GetOutstandingPaymentReportForAnonymous lists every unpaid registration with the amount owed,
+// the amount depends on the cost split of the match so it is priced by the match itself.
+// Unpaid tournament entry fees and late cancellation fees are owed the same way.
 func (s *PaymentService) GetOutstandingPaymentReportForAnonymous() ([]dto.AnonymousOutstandingPaymentReportDto, error) {
-	sql := `with 
-		cte_addtional_costs as (
-			select ac.match_id, sum(ac.amount) as total
-			from public.additional_costs ac 
-			group by ac.match_id
-		),
-		cte_player_counts as (
-			select 
-				m.id as match_id, 
-				m."cost" as match_cost, 
-				ac.total as match_additional_cost, 
-				m."start" as match_date,  
-				SUM(r.total_player_paid_for) as match_player_count
-			from public.registrations r
-			join public.matches m  on r.match_id = m.id
-			left join cte_addtional_costs ac on ac.match_id = m.id
-			where m.deleted_at is null 
-				and r.deleted_at is null
-			group by 
-				m.id, 
-				m."cost", 
-				m.start, 
-				ac.total
-		)
-		select 
-			p.id as player_id,
-			r.total_player_paid_for, 
-			CONCAT(p.first_name, ' ', p.last_name) as player_name, 
-			p.email as player_email,
-			pc.match_id, 
-			pc.match_date, 
-			pc.match_cost, 
-			pc.match_additional_cost,
-			pc.match_player_count
-		from public.registrations r
-		join public.players p on p.id = r.player_id
-		join cte_player_counts pc on pc.match_id  = r.match_id
-		where r.is_paid = false AND r.deleted_at is null
-	`
+	var matches []domain.Match
+	err := s.db.
+		Preload("Registrations", "is_waitlisted = false AND rsvp = ?", domain.RsvpGoing).
+		Preload("AdditionalCosts").
+		Scopes(MatchStateScope(nil)).
+		Where(`EXISTS (
+			SELECT 1 FROM registrations r
+			WHERE r.match_id = matches.id AND r.is_paid = false AND r.is_waitlisted = false AND r.rsvp = ? AND r.deleted_at IS NULL
+		)`, domain.RsvpGoing).
+		Find(&matches).Error
 
-	result := []dto.AnonymousOutstandingPaymentReportDto{}
-	if err := s.db.Raw(sql).Scan(&result).Error; err != nil {
+	if err != nil {
+		return nil, err
+	}
+
+	fees, err := s.getUnpaidEntryFees()
+	if err != nil {
 		return nil, err
 	}
+
+	lateFees, err := s.getUnpaidLateCancellationFees()
+	if err != nil {
+		return nil, err
+	}
+
+	var players []domain.Player
+	playerIds := lo.FlatMap(matches, func(m domain.Match, _ int) []uint {
+		return lo.Map(m.Registrations, func(r domain.Registration, _ int) uint { return r.PlayerId })
+	})
+	playerIds = append(playerIds, lo.Map(fees, func(f unpaidEntryFee, _ int) uint { return f.PlayerId })...)
+	playerIds = append(playerIds, lo.Map(lateFees, func(f unpaidLateCancellationFee, _ int) uint { return f.PlayerId })...)
+	if err := s.db.Where("id IN ?", lo.Uniq(playerIds)).Find(&players).Error; err != nil {
+		return nil, err
+	}
+	playersById := lo.KeyBy(players, func(p domain.Player) uint { return p.ID })
+
+	result := []dto.AnonymousOutstandingPaymentReportDto{}
+	for i := range matches {
+		m := &matches[i]
+		for _, r := range m.Registrations {
+			player, found := playersById[r.PlayerId]
+			if r.IsPaid || !found {
+				continue
+			}
+
+			result = append(result, dto.AnonymousOutstandingPaymentReportDto{
+				PlayerId:            player.ID,
+				TotalPlayerPaidFor:  r.TotalPlayerPaidFor,
+				PlayerName:          fmt.Sprintf("%s %s", player.FirstName, player.LastName),
+				PlayerEmail:         player.Email,
+				MatchId:             m.ID,
+				MatchDate:           m.Start,
+				MatchCost:           m.Cost,
+				MatchAdditionalCost: m.CalcAdditionalCost(),
+				MatchPlayerCount:    uint(m.CalcPlayerCount()),
+				PlayedMinutes:       r.PlayedMinutes(m),
+				Amount:              m.CalcIndividualCost(r),
+			})
+		}
+	}
+
+	for _, f := range fees {
+		player, found := playersById[f.PlayerId]
+		if !found {
+			continue
+		}
+
+		result = append(result, dto.AnonymousOutstandingPaymentReportDto{
+			PlayerId:           player.ID,
+			TotalPlayerPaidFor: 1,
+			PlayerName:         fmt.Sprintf("%s %s", player.FirstName, player.LastName),
+			PlayerEmail:        player.Email,
+			TournamentId:       f.TournamentId,
+			MatchDate:          f.CreatedAt,
+			MatchCost:          f.EntryFee,
+			MatchPlayerCount:   1,
+			Amount:             f.EntryFee,
+		})
+	}
+
+	for _, f := range lateFees {
+		player, found := playersById[f.PlayerId]
+		if !found {
+			continue
+		}
+
+		result = append(result, dto.AnonymousOutstandingPaymentReportDto{
+			PlayerId:              player.ID,
+			TotalPlayerPaidFor:    1,
+			PlayerName:            fmt.Sprintf("%s %s", player.FirstName, player.LastName),
+			PlayerEmail:           player.Email,
+			MatchId:               f.MatchId,
+			LateCancellationFeeId: f.Id,
+			MatchDate:             f.Start,
+			Amount:                f.Amount,
+		})
+	}
+
 	return result, nil
 }
+
+type unpaidLateCancellationFee struct {
+	Id       uint
+	PlayerId uint
+	MatchId  uint
+	Start    time.Time
+	Amount   float64
+}
+
+// getUnpaidLateCancellationFees lists the fees owed for leaving matches after the cancellation deadline
+func (s *PaymentService) getUnpaidLateCancellationFees() ([]unpaidLateCancellationFee, error) {
+	fees := []unpaidLateCancellationFee{}
+	err := s.db.
+		Model(&domain.LateCancellationFee{}).
+		Select("late_cancellation_fees.id, late_cancellation_fees.player_id, late_cancellation_fees.match_id, matches.start, late_cancellation_fees.amount").
+		Joins("JOIN matches ON matches.id = late_cancellation_fees.match_id AND matches.deleted_at IS NULL").
+		Where("late_cancellation_fees.is_paid = false").
+		Scan(&fees).Error
+
+	return fees, err
+}
+
+type unpaidEntryFee struct {
+	PlayerId     uint
+	TournamentId uint
+	EntryFee     float64
+	CreatedAt    time.Time
+}
+
+// getUnpaidEntryFees lists the tournament entry fees owed by every player of an entry
+func (s *PaymentService) getUnpaidEntryFees() ([]unpaidEntryFee, error) {
+	fees := []unpaidEntryFee{}
+	err := s.db.
+		Model(&domain.TournamentEntryPlayer{}).
+		Select("tournament_entry_players.player_id, tournaments.id AS tournament_id, tournaments.entry_fee, tournament_entries.created_at").
+		Joins("JOIN tournament_entries ON tournament_entries.id = tournament_entry_players.entry_id AND tournament_entries.deleted_at IS NULL").
+		Joins("JOIN tournaments ON tournaments.id = tournament_entries.tournament_id AND tournaments.deleted_at IS NULL").
+		Where("tournament_entry_players.is_paid = false AND tournaments.entry_fee > 0").
+		Scan(&fees).Error
+
+	return fees, err
+}
@@ -1,9 +1,13 @@
 package service
 
 import (
+	"context"
+
 	"github.com/tructn/racket/internal/dto"
 )
 
 type PlayerService interface {
 	GetPlayerSummary(playerId uint) (*dto.PlayerSummaryDto, error)
+	// WithContext scopes the queries to the tenant of ctx
+	WithContext(ctx context.Context) PlayerService
 }
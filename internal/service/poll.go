@@ -0,0 +1,333 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/samber/lo"
+	"github.com/tructn/racket/internal/domain"
+	"github.com/tructn/racket/internal/dto"
+	"github.com/tructn/racket/pkg/result"
+	"github.com/tructn/racket/pkg/scopes"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+var ErrSportCenterRequired = errors.New("sport center is required to book the slot")
+
+type PollService struct {
+	db                          *gorm.DB
+	logger                      *zap.SugaredLogger
+	matchService                *MatchService
+	standingRegistrationService *StandingRegistrationService
+}
+
+func NewPollService(
+	db *gorm.DB,
+	logger *zap.SugaredLogger,
+	matchService *MatchService,
+	standingRegistrationService *StandingRegistrationService,
+) *PollService {
+	return &PollService{
+		db:                          db,
+		logger:                      logger,
+		matchService:                matchService,
+		standingRegistrationService: standingRegistrationService,
+	}
+}
+
+func (s *PollService) WithContext(ctx context.Context) *PollService {
+	clone := *s
+	clone.db = s.db.WithContext(ctx)
+	clone.matchService = s.matchService.WithContext(ctx)
+	clone.standingRegistrationService = s.standingRegistrationService.WithContext(ctx)
+	return &clone
+}
+
+func (s *PollService) GetAll() ([]dto.PollSummaryDto, error) {
+	var polls []domain.Poll
+	if err := s.db.Preload("Responses").Order("created_at DESC").Find(&polls).Error; err != nil {
+		return nil, err
+	}
+
+	return lo.Map(polls, func(p domain.Poll, _ int) dto.PollSummaryDto {
+		return dto.PollSummaryDto{
+			Id:            p.ID,
+			Title:         p.Title,
+			State:         p.State,
+			ClosesAt:      p.ClosesAt,
+			ResponseCount: len(p.Responses),
+			MatchId:       p.MatchId,
+		}
+	}), nil
+}
+
+func (s *PollService) Create(req dto.CreatePollDto) (*dto.PollDto, error) {
+	slots := lo.Map(req.Slots, func(slot dto.PollSlotRequestDto, _ int) domain.PollSlot {
+		return domain.PollSlot{Start: slot.Start, End: slot.End, SportCenterId: slot.SportCenterId, Court: strings.TrimSpace(slot.Court)}
+	})
+
+	poll, err := domain.NewPoll(req.Title, req.Description, slots, req.ClosesAt, &domain.DefaultGenerator{})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.db.Create(poll).Error; err != nil {
+		return nil, err
+	}
+
+	return s.toPollDto(poll, true)
+}
+
+func (s *PollService) Get(id uint) (*dto.PollDto, error) {
+	poll, err := s.getPoll(s.db, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.toPollDto(poll, true)
+}
+
+// GetByShareCode is the public view of the poll, the share code is the credential
+func (s *PollService) GetByShareCode(shareCode string) (*dto.PollDto, error) {
+	poll, err := s.getPollByShareCode(s.db, shareCode)
+	if err != nil {
+		return nil, err
+	}
+	return s.WithContext(scopes.WithTenant(s.db.Statement.Context, poll.TenantId)).toPollDto(poll, false)
+}
+
+// Respond records the answers of a signed in player under the player's name
+func (s *PollService) Respond(id, playerId uint, req dto.PollResponseRequestDto) (*dto.PollDto, error) {
+	player := &domain.Player{}
+	if err := s.db.First(player, playerId).Error; err != nil {
+		return nil, err
+	}
+
+	name := strings.TrimSpace(fmt.Sprintf("%s %s", player.FirstName, player.LastName))
+	poll, _, err := s.respond(
+		func(tx *gorm.DB) (*domain.Poll, error) { return s.getPoll(tx, id) },
+		func(poll *domain.Poll) (*domain.PollResponse, error) {
+			return poll.Respond(playerId, name, req.Answers, time.Now())
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.toPollDto(poll, true)
+}
+
+// RespondAnonymously records the answers given through the share code link, the respondent is only known by name.
+// The response token is returned with the first answers, it is required to change them.
+func (s *PollService) RespondAnonymously(shareCode string, req dto.PollResponseRequestDto) (*dto.PollDto, error) {
+	poll, err := s.getPollByShareCode(s.db, shareCode)
+	if err != nil {
+		return nil, err
+	}
+
+	// Respondents have no tenant, the response belongs to the tenant of the poll
+	s = s.WithContext(scopes.WithTenant(s.db.Statement.Context, poll.TenantId))
+	poll, response, err := s.respond(
+		func(tx *gorm.DB) (*domain.Poll, error) { return s.getPollByShareCode(tx, shareCode) },
+		func(poll *domain.Poll) (*domain.PollResponse, error) {
+			return poll.RespondAnonymously(req.Name, req.ResponseToken, req.Answers, time.Now(), &domain.DefaultGenerator{})
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := s.toPollDto(poll, false)
+	if err != nil {
+		return nil, err
+	}
+
+	res.ResponseToken = response.Token
+	return res, nil
+}
+
+func (s *PollService) Close(id uint) (*dto.PollDto, error) {
+	poll, err := s.getPoll(s.db, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := poll.Close(); err != nil {
+		return nil, err
+	}
+
+	if err := s.db.Omit("Slots", "Responses").Save(poll).Error; err != nil {
+		return nil, err
+	}
+
+	return s.toPollDto(poll, true)
+}
+
+// Book turns a slot into a match, everyone who answered yes is registered and notified
+func (s *PollService) Book(id uint, req dto.BookPollDto) (*dto.PollDto, error) {
+	poll, err := s.getPoll(s.db, id)
+	if err != nil {
+		return nil, err
+	}
+
+	slot := poll.BestSlot()
+	if req.SlotId != nil {
+		slot = lo.FindOrElse(lo.ToSlicePtr(poll.Slots), nil, func(slot *domain.PollSlot) bool { return slot.ID == *req.SlotId })
+	}
+
+	if slot == nil {
+		return nil, domain.ErrPollSlotNotFound
+	}
+
+	sportCenterId := lo.Ternary(req.SportCenterId != nil, req.SportCenterId, slot.SportCenterId)
+	if sportCenterId == nil {
+		return nil, ErrSportCenterRequired
+	}
+
+	sc := domain.SportCenter{}
+	if err := s.db.Preload("PricingRules").Preload("PriceHistory").First(&sc, *sportCenterId).Error; err != nil {
+		return nil, err
+	}
+
+	court := lo.Ternary(strings.TrimSpace(req.Court) != "", strings.TrimSpace(req.Court), slot.Court)
+	match := domain.NewMatch(slot.Start, slot.End, sc.ID, sc.GetPricing(slot.Start, false), court, nil)
+	if err := poll.Book(slot.ID, match); err != nil {
+		return nil, err
+	}
+
+	if err := s.matchService.EnsureSportCenterOpen(match, req.AllowClosed); err != nil {
+		return nil, err
+	}
+
+	if err := s.matchService.EnsureNoCourtConflict(match, req.AllowConflicts); err != nil {
+		return nil, err
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(match).Error; err != nil {
+			return err
+		}
+
+		poll.MatchId = &match.ID
+		if err := tx.Omit("Slots", "Responses").Save(poll).Error; err != nil {
+			return err
+		}
+
+		playerIds := lo.Map(match.Registrations, func(r domain.Registration, _ int) uint { return r.PlayerId })
+		return notifyPlayers(tx, playerIds, &match.ID, poll.Title, fmt.Sprintf(
+			"You are registered for %s, the date you said you could make",
+			match.Start.Format("Mon 02 Jan 15:04"),
+		))
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.standingRegistrationService.Apply([]uint{match.ID}); err != nil {
+		s.logger.Errorw("Failed to apply standing registrations", "matchId", match.ID, "error", err)
+	}
+
+	return s.toPollDto(poll, true)
+}
+
+func (s *PollService) respond(
+	getPoll func(tx *gorm.DB) (*domain.Poll, error),
+	respond func(poll *domain.Poll) (*domain.PollResponse, error),
+) (*domain.Poll, *domain.PollResponse, error) {
+	var poll *domain.Poll
+	var response *domain.PollResponse
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		poll, err = getPoll(tx)
+		if err != nil {
+			return err
+		}
+
+		response, err = respond(poll)
+		if err != nil {
+			return err
+		}
+
+		return tx.Save(response).Error
+	})
+
+	return poll, response, err
+}
+
+func (s *PollService) getPoll(tx *gorm.DB, id uint) (*domain.Poll, error) {
+	poll := &domain.Poll{}
+	err := tx.
+		Preload("Slots", func(db *gorm.DB) *gorm.DB { return db.Order("start") }).
+		Preload("Responses").
+		First(poll, id).Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, result.ErrorNotFound
+	}
+	return poll, err
+}
+
+func (s *PollService) getPollByShareCode(tx *gorm.DB, shareCode string) (*domain.Poll, error) {
+	poll := &domain.Poll{}
+	err := tx.Select("id").Where("share_code = ?", shareCode).First(poll).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, result.ErrorNotFound
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return s.getPoll(tx, poll.ID)
+}
+
+// toPollDto leaves the share code out of the public view
+func (s *PollService) toPollDto(poll *domain.Poll, withShareCode bool) (*dto.PollDto, error) {
+	var sportCenters []domain.SportCenter
+	sportCenterIds := lo.Uniq(lo.FilterMap(poll.Slots, func(slot domain.PollSlot, _ int) (uint, bool) {
+		return lo.FromPtr(slot.SportCenterId), slot.SportCenterId != nil
+	}))
+	if err := s.db.Where("id IN ?", sportCenterIds).Find(&sportCenters).Error; err != nil {
+		return nil, err
+	}
+
+	names := lo.SliceToMap(sportCenters, func(sc domain.SportCenter) (uint, string) { return sc.ID, sc.Name })
+	results := lo.KeyBy(poll.Results(), func(r domain.PollSlotResult) uint { return r.SlotId })
+	respondents := func(responses []domain.PollResponse) []dto.PollRespondentDto {
+		return lo.Map(responses, func(r domain.PollResponse, _ int) dto.PollRespondentDto {
+			return dto.PollRespondentDto{PlayerId: r.PlayerId, Name: r.Name}
+		})
+	}
+
+	return &dto.PollDto{
+		Id:           poll.ID,
+		Title:        poll.Title,
+		Description:  poll.Description,
+		State:        poll.State,
+		ClosesAt:     poll.ClosesAt,
+		ShareCode:    lo.Ternary(withShareCode, poll.ShareCode, ""),
+		BookedSlotId: poll.BookedSlotId,
+		MatchId:      poll.MatchId,
+		Slots: lo.Map(poll.Slots, func(slot domain.PollSlot, _ int) dto.PollSlotDto {
+			res := results[slot.ID]
+			return dto.PollSlotDto{
+				Id:              slot.ID,
+				Start:           slot.Start,
+				End:             slot.End,
+				SportCenterId:   slot.SportCenterId,
+				SportCenterName: names[lo.FromPtr(slot.SportCenterId)],
+				Court:           slot.Court,
+				YesCount:        len(res.Yes),
+				MaybeCount:      len(res.Maybe),
+				NoCount:         len(res.No),
+				Yes:             respondents(res.Yes),
+				Maybe:           respondents(res.Maybe),
+				No:              respondents(res.No),
+			}
+		}),
+	}, nil
+}
@@ -0,0 +1,253 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/samber/lo"
+	"github.com/tructn/racket/internal/domain"
+	"github.com/tructn/racket/internal/dto"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type RatingService struct {
+	db     *gorm.DB
+	logger *zap.SugaredLogger
+}
+
+func NewRatingService(db *gorm.DB, logger *zap.SugaredLogger) *RatingService {
+	return &RatingService{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (s *RatingService) WithContext(ctx context.Context) *RatingService {
+	clone := *s
+	clone.db = s.db.WithContext(ctx)
+	return &clone
+}
+
+// Recompute replays the whole game history, it is only needed after correcting results outside of the API
+func (s *RatingService) Recompute(tx *gorm.DB) error {
+	games, err := s.getRatedGames(tx)
+	if err != nil {
+		return err
+	}
+
+	ratings, changes := domain.CalcRatings(games)
+
+	if err := tx.Session(&gorm.Session{AllowGlobalUpdate: true}).Unscoped().Delete(&domain.RatingChange{}).Error; err != nil {
+		return err
+	}
+
+	if err := tx.Session(&gorm.Session{AllowGlobalUpdate: true}).Unscoped().Delete(&domain.PlayerRating{}).Error; err != nil {
+		return err
+	}
+
+	if err := s.saveRatings(tx, ratings, changes); err != nil {
+		return err
+	}
+
+	s.logger.Infof("recomputed %d ratings from %d games", len(ratings), len(games))
+	return nil
+}
+
+// RecomputeFrom is run after a game of the match is recorded, corrected or deleted. Only the games rated
+// from that game on are replayed, on top of the ratings their players had before it.
+func (s *RatingService) RecomputeFrom(tx *gorm.DB, matchId, gameId uint) error {
+	match := domain.Match{}
+	if err := tx.Unscoped().Select("id", "start").First(&match, matchId).Error; err != nil {
+		return err
+	}
+	from := match.Start
+
+	games, err := s.getRatedGames(tx, "matches.start >= ?", from)
+	if err != nil {
+		return err
+	}
+	games = lo.Reject(games, func(g domain.RatedGame, _ int) bool { return g.IsBefore(from, gameId) })
+
+	after := "played_at > ? OR (played_at = ? AND game_id >= ?)"
+	var replaced []domain.RatingChange
+	if err := tx.Where(after, from, from, gameId).Find(&replaced).Error; err != nil {
+		return err
+	}
+
+	playerIds := lo.Map(replaced, func(c domain.RatingChange, _ int) uint { return c.PlayerId })
+	for _, g := range games {
+		playerIds = append(playerIds, g.Game.GetSide(domain.SideA)...)
+		playerIds = append(playerIds, g.Game.GetSide(domain.SideB)...)
+	}
+	playerIds = lo.Uniq(playerIds)
+	if len(playerIds) == 0 {
+		return nil
+	}
+
+	var history []domain.RatingChange
+	if err := tx.
+		Where("player_id IN ?", playerIds).
+		Where("NOT ("+after+")", from, from, gameId).
+		Order("played_at, game_id").
+		Find(&history).Error; err != nil {
+		return err
+	}
+
+	start := lo.FilterMap(playerIds, func(id uint, _ int) (domain.PlayerRating, bool) {
+		r := domain.RestoreRating(id, history)
+		return r, r.Games > 0
+	})
+	ratings, changes := domain.CalcRatingsFrom(start, games)
+
+	if err := tx.Unscoped().Where(after, from, from, gameId).Delete(&domain.RatingChange{}).Error; err != nil {
+		return err
+	}
+
+	if err := tx.Unscoped().Where("player_id IN ?", playerIds).Delete(&domain.PlayerRating{}).Error; err != nil {
+		return err
+	}
+
+	if err := s.saveRatings(tx, ratings, changes); err != nil {
+		return err
+	}
+
+	s.logger.Infof("recomputed %d ratings from %d games", len(ratings), len(games))
+	return nil
+}
+
+// RecomputeAll is run by an admin after correcting results outside of the API
+func (s *RatingService) RecomputeAll() error {
+	return s.db.Transaction(s.Recompute)
+}
+
+// getRatedGames loads the games of the matches that count, with the time they were played
+func (s *RatingService) getRatedGames(tx *gorm.DB, conds ...any) ([]domain.RatedGame, error) {
+	var games []domain.Game
+	query := tx.
+		Preload("Players").
+		Joins("JOIN matches ON matches.id = games.match_id AND matches.deleted_at IS NULL").
+		Scopes(MatchStateScope(nil))
+	if len(conds) > 0 {
+		query = query.Where(conds[0], conds[1:]...)
+	}
+
+	if err := query.Find(&games).Error; err != nil {
+		return nil, err
+	}
+
+	var matches []domain.Match
+	matchIds := lo.Uniq(lo.Map(games, func(g domain.Game, _ int) uint { return g.MatchId }))
+	if err := tx.Select("id", "start").Where("id IN ?", matchIds).Find(&matches).Error; err != nil {
+		return nil, err
+	}
+	starts := lo.SliceToMap(matches, func(m domain.Match) (uint, time.Time) { return m.ID, m.Start })
+
+	return lo.Map(games, func(g domain.Game, _ int) domain.RatedGame {
+		return domain.RatedGame{Game: g, PlayedAt: starts[g.MatchId]}
+	}), nil
+}
+
+func (s *RatingService) saveRatings(tx *gorm.DB, ratings []domain.PlayerRating, changes []domain.RatingChange) error {
+	if len(ratings) > 0 {
+		if err := tx.CreateInBatches(ratings, 100).Error; err != nil {
+			return err
+		}
+	}
+
+	if len(changes) > 0 {
+		return tx.CreateInBatches(changes, 100).Error
+	}
+	return nil
+}
+
+// GetRanks is the position of the established players on the leaderboard, it is computed when read
+// because ratings decay while players are away
+func (s *RatingService) GetRanks() (map[uint]uint, error) {
+	var ratings []domain.PlayerRating
+	if err := s.db.Find(&ratings).Error; err != nil {
+		return nil, err
+	}
+
+	ranks := map[uint]uint{}
+	for i, r := range domain.RankRatings(ratings, time.Now()) {
+		ranks[r.PlayerId] = uint(i + 1)
+	}
+	return ranks, nil
+}
+
+// GetLeaderboard lists ranked players first, provisional players follow by rating
+func (s *RatingService) GetLeaderboard() ([]dto.LeaderboardEntryDto, error) {
+	var ratings []domain.PlayerRating
+	if err := s.db.Find(&ratings).Error; err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	ranked := domain.RankRatings(ratings, now)
+	provisional := domain.SortRatings(lo.Filter(ratings, func(r domain.PlayerRating, _ int) bool { return r.IsProvisional() }), now)
+
+	players, err := s.getPlayers(lo.Map(ratings, func(r domain.PlayerRating, _ int) uint { return r.PlayerId }))
+	if err != nil {
+		return nil, err
+	}
+
+	entry := func(r domain.PlayerRating, rank int) dto.LeaderboardEntryDto {
+		p := players[r.PlayerId]
+		return dto.LeaderboardEntryDto{
+			Rank:         uint(rank),
+			PlayerId:     r.PlayerId,
+			PlayerName:   fmt.Sprintf("%s %s", p.FirstName, p.LastName),
+			Rating:       r.CurrentRating(now),
+			Games:        r.Games,
+			Provisional:  r.IsProvisional(),
+			LastPlayedAt: r.LastPlayedAt,
+		}
+	}
+
+	return append(
+		lo.Map(ranked, func(r domain.PlayerRating, i int) dto.LeaderboardEntryDto { return entry(r, i+1) }),
+		lo.Map(provisional, func(r domain.PlayerRating, _ int) dto.LeaderboardEntryDto { return entry(r, 0) })...,
+	), nil
+}
+
+func (s *RatingService) GetHistory(playerId uint) ([]dto.RatingChangeDto, error) {
+	var changes []domain.RatingChange
+	if err := s.db.Where("player_id = ?", playerId).Order("played_at, game_id").Find(&changes).Error; err != nil {
+		return nil, err
+	}
+
+	return lo.Map(changes, func(c domain.RatingChange, _ int) dto.RatingChangeDto {
+		return dto.RatingChangeDto{
+			GameId:   c.GameId,
+			MatchId:  c.MatchId,
+			PlayedAt: c.PlayedAt,
+			Before:   c.Before,
+			After:    c.After,
+		}
+	}), nil
+}
+
+// GetRatings returns the current rating of the players, players without games have the initial rating
+func (s *RatingService) GetRatings(playerIds []uint) (map[uint]float64, error) {
+	var ratings []domain.PlayerRating
+	if err := s.db.Where("player_id IN ?", playerIds).Find(&ratings).Error; err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	result := lo.SliceToMap(playerIds, func(id uint) (uint, float64) { return id, domain.InitialRating })
+	for _, r := range ratings {
+		result[r.PlayerId] = r.CurrentRating(now)
+	}
+	return result, nil
+}
+
+func (s *RatingService) getPlayers(ids []uint) (map[uint]domain.Player, error) {
+	var players []domain.Player
+	if err := s.db.Where("id IN ?", lo.Uniq(ids)).Find(&players).Error; err != nil {
+		return nil, err
+	}
+	return lo.KeyBy(players, func(p domain.Player) uint { return p.ID }), nil
+}
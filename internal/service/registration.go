@@ -1,10 +1,18 @@
 package service
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"strings"
+	"time"
 
+	"github.com/samber/lo"
 	"github.com/tructn/racket/internal/domain"
+	"github.com/tructn/racket/internal/dto"
+	"github.com/tructn/racket/pkg/scopes"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type RegistrationService struct {
@@ -15,42 +23,426 @@ func NewRegistrationService(db *gorm.DB) *RegistrationService {
 	return &RegistrationService{db: db}
 }
 
-func (s *RegistrationService) RegisterMatch(playerId uint, matchId uint) error {
-	var isExist bool
-	if err := s.db.Model(&domain.Registration{}).
-		Where("player_id = ? AND match_id = ?", playerId, matchId).
-		Select("COUNT(1) > 0").
-		Scan(&isExist).Error; err != nil {
+func (s *RegistrationService) WithContext(ctx context.Context) *RegistrationService {
+	clone := *s
+	clone.db = s.db.WithContext(ctx)
+	return &clone
+}
+
+// RegisterOptions lifts the rules admins may override when registering a player,
+// capacity, the waitlist and a pending ballot always apply
+type RegisterOptions struct {
+	AllowConflicts bool
+	// AllowClosed ignores the registration window, the match must still accept changes
+	AllowClosed bool
+	// AllowPrivate registers players who are not invited to a private match
+	AllowPrivate bool
+}
+
+// RegisterMatch registers the player, a player can not be registered to overlapping matches unless AllowConflicts is set.
+// The player is waitlisted when the booked courts are full. An invited or undecided player is now going.
+func (s *RegistrationService) RegisterMatch(playerId uint, matchId uint, opts RegisterOptions) (*domain.Registration, error) {
+	var registration *domain.Registration
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		registration, err = s.withTx(tx).registerMatch(playerId, matchId, opts)
 		return err
+	})
+
+	if err != nil {
+		return nil, err
 	}
-	if isExist {
-		return fmt.Errorf("player already registered for this match")
+
+	return registration, nil
+}
+
+// registerMatch holds the lock on the match while counting the free spots so that concurrent registrations
+// can not take the same spot
+func (s *RegistrationService) registerMatch(playerId uint, matchId uint, opts RegisterOptions) (*domain.Registration, error) {
+	match, err := getMatchForUpdate(s.db, matchId)
+	if err != nil {
+		return nil, err
+	}
+
+	registration := &domain.Registration{}
+	err = s.db.Where("player_id = ? AND match_id = ?", playerId, matchId).First(registration).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		registration = domain.NewRegistration(playerId, matchId)
+	} else if err != nil {
+		return nil, err
+	} else if registration.IsGoing() {
+		return nil, domain.ErrAlreadyRegistered
+	}
+
+	if !opts.AllowPrivate {
+		if err := s.ensureVisible(match, playerId); err != nil {
+			return nil, err
+		}
+	}
+
+	if !opts.AllowClosed {
+		if err := match.EnsureCanRegister(time.Now()); err != nil {
+			return nil, err
+		}
+	} else if !match.AcceptsChanges() {
+		return nil, domain.ErrRegistrationClosed
+	}
+
+	if !opts.AllowConflicts {
+		if err := s.ensureNoPlayerConflict(playerId, matchId); err != nil {
+			return nil, err
+		}
+	}
+
+	registration.ChangeRsvp(domain.RsvpGoing)
+	registration.IsWaitlisted = match.ShouldWaitlist(registration.TotalPlayerPaidFor)
+	if registration.IsWaitlisted {
+		priority, err := s.getWaitlistPriority(playerId)
+		if err != nil {
+			return nil, err
+		}
+		registration.WaitlistPriority = priority
+	}
+
+	if err := s.db.Omit("Guests").Save(registration).Error; err != nil {
+		return nil, err
+	}
+
+	return registration, nil
+}
+
+func (s *RegistrationService) withTx(tx *gorm.DB) *RegistrationService {
+	clone := *s
+	clone.db = tx
+	return &clone
+}
+
+// getMatchForUpdate locks the match row until the transaction ends, the registrations are loaded once the lock is held
+func getMatchForUpdate(tx *gorm.DB, matchId uint) (*domain.Match, error) {
+	match := &domain.Match{}
+	if err := tx.
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Preload("Registrations").
+		Preload("CourtBookings").
+		Preload("SportCenter").
+		Preload("Invitations").
+		First(match, matchId).Error; err != nil {
+		return nil, err
+	}
+	return match, nil
+}
+
+// EnsureMatchVisible refuses players who are not invited to a private match
+func (s *RegistrationService) EnsureMatchVisible(playerId, matchId uint) error {
+	match := &domain.Match{}
+	if err := s.db.Preload("Registrations").Preload("Invitations").First(match, matchId).Error; err != nil {
+		return err
+	}
+	return s.ensureVisible(match, playerId)
+}
+
+// ensureVisible expects the registrations and invitations of the match to be loaded
+func (s *RegistrationService) ensureVisible(match *domain.Match, playerId uint) error {
+	if !match.IsPrivate() {
+		return nil
+	}
+
+	teamIds, err := getPlayerTeamIds(s.db, playerId)
+	if err != nil {
+		return err
+	}
+	return match.EnsureVisibleTo(playerId, teamIds)
+}
+
+// getWaitlistPriority lowers the priority of players with repeated recent no-shows
+func (s *RegistrationService) getWaitlistPriority(playerId uint) (int, error) {
+	settings := domain.Settings{}
+	if err := s.db.Limit(1).Find(&settings).Error; err != nil {
+		return 0, err
+	}
+
+	policy := settings.GetNoShowPolicy()
+	if !policy.IsEnabled() {
+		return 0, nil
+	}
+
+	var noShows int64
+	if err := s.db.Model(&domain.Registration{}).
+		Joins("JOIN matches ON matches.id = registrations.match_id").
+		Where("registrations.player_id = ? AND registrations.attendance = ? AND matches.start >= ?", playerId, domain.AttendanceNoShow, policy.Since(time.Now())).
+		Count(&noShows).Error; err != nil {
+		return 0, err
+	}
+
+	return policy.WaitlistPriority(int(noShows)), nil
+}
+
+// UnregisterMatch removes the player from the match and promotes the waitlist.
+// Leaving after the cancellation deadline is charged unless someone from the waitlist takes the spot.
+func (s *RegistrationService) UnregisterMatch(playerId uint, matchId uint) (*dto.UnregisterResultDto, error) {
+	return s.withdraw(playerId, matchId, checkUnregister, removeRegistration)
+}
+
+// OptOut removes a registration made by a standing registration, it is free until registration closes
+func (s *RegistrationService) OptOut(playerId uint, matchId uint) (*dto.UnregisterResultDto, error) {
+	return s.withdraw(playerId, matchId, func(match *domain.Match, registration domain.Registration) (bool, error) {
+		return false, match.CheckOptOut(registration, time.Now())
+	}, removeRegistration)
+}
+
+// SetRsvp records whether the player is going, may go or is not going. Going registers the player as RegisterMatch does,
+// stepping back from going frees the spot as UnregisterMatch does but keeps the answer on the roster.
+func (s *RegistrationService) SetRsvp(playerId uint, req dto.RsvpDto) (*dto.RsvpResultDto, error) {
+	rsvp, err := domain.ParseRsvp(req.Rsvp)
+	if err != nil {
+		return nil, err
+	}
+
+	if rsvp == domain.RsvpGoing {
+		registration, err := s.RegisterMatch(playerId, req.MatchId, RegisterOptions{AllowConflicts: req.AllowConflicts})
+		if err != nil {
+			return nil, err
+		}
+		return &dto.RsvpResultDto{RegistrationId: registration.ID, Rsvp: registration.Rsvp, IsWaitlisted: registration.IsWaitlisted}, nil
+	}
+
+	registration := &domain.Registration{}
+	err = s.db.Where("player_id = ? AND match_id = ?", playerId, req.MatchId).First(registration).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return s.answerWithoutRegistration(playerId, req.MatchId, rsvp)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := s.withdraw(playerId, req.MatchId, checkUnregister, func(tx *gorm.DB, registration *domain.Registration) error {
+		registration.ChangeRsvp(rsvp)
+		return tx.Omit("Guests").Save(registration).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.RsvpResultDto{
+		RegistrationId:       registration.ID,
+		Rsvp:                 rsvp,
+		ReplacedFromWaitlist: res.ReplacedFromWaitlist,
+		LateCancellationFee:  res.LateCancellationFee,
+	}, nil
+}
+
+// SendRsvpReminders asks invited and undecided players to decide before registration closes,
+// it is run regularly by the SchedulerService and reminds every registration once
+func (s *RegistrationService) SendRsvpReminders() (*dto.RsvpReminderResultDto, error) {
+	now := time.Now()
+	var matches []domain.Match
+	if err := s.db.
+		Preload("Registrations").
+		Preload("SportCenter").
+		Scopes(MatchStateScope([]domain.MatchState{domain.MatchOpen})).
+		Where("registration_closes_at > ? AND registration_closes_at <= ?", now, now.Add(domain.RsvpReminderWindow)).
+		Find(&matches).Error; err != nil {
+		return nil, err
+	}
+
+	res := &dto.RsvpReminderResultDto{}
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		for _, match := range matches {
+			reminders := match.GetRsvpReminders(now)
+			if len(reminders) == 0 {
+				continue
+			}
+
+			ids := lo.Map(reminders, func(r domain.Registration, _ int) uint { return r.ID })
+			if err := tx.Model(&domain.Registration{}).Where("id IN ?", ids).Update("rsvp_reminded_at", now).Error; err != nil {
+				return err
+			}
+
+			message := fmt.Sprintf(
+				"Registration for %s on %s closes at %s, please let us know if you are going",
+				match.SportCenter.Name, match.Start.Format("02/01/2006 15:04"), match.RegistrationClosesAt.Format("02/01/2006 15:04"),
+			)
+			playerIds := lo.Map(reminders, func(r domain.Registration, _ int) uint { return r.PlayerId })
+			if err := notifyPlayers(tx, playerIds, &match.ID, "Are you going?", message); err != nil {
+				return err
+			}
+			res.Reminded += len(reminders)
+		}
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// answerWithoutRegistration lists a player who has not registered yet as undecided or not going
+func (s *RegistrationService) answerWithoutRegistration(playerId, matchId uint, rsvp domain.RsvpStatus) (*dto.RsvpResultDto, error) {
+	match := &domain.Match{}
+	if err := s.db.Preload("Invitations").First(match, matchId).Error; err != nil {
+		return nil, err
+	}
+
+	if err := s.ensureVisible(match, playerId); err != nil {
+		return nil, err
+	}
+
+	if err := match.EnsureCanRegister(time.Now()); err != nil {
+		return nil, err
 	}
 
 	registration := domain.NewRegistration(playerId, matchId)
+	registration.ChangeRsvp(rsvp)
 	if err := s.db.Create(registration).Error; err != nil {
+		return nil, err
+	}
+
+	return &dto.RsvpResultDto{RegistrationId: registration.ID, Rsvp: rsvp}, nil
+}
+
+// checkUnregister only holds confirmed players to the cancellation deadline
+func checkUnregister(match *domain.Match, registration domain.Registration) (bool, error) {
+	isLate, err := match.CheckUnregister(time.Now())
+	if err != nil && registration.IsConfirmed() {
+		return false, err
+	}
+	return isLate, nil
+}
+
+func removeRegistration(tx *gorm.DB, registration *domain.Registration) error {
+	if err := tx.Delete(&domain.Registration{}, registration.ID).Error; err != nil {
 		return err
 	}
+	return tx.Where("registration_id = ?", registration.ID).Delete(&domain.Guest{}).Error
+}
 
-	return nil
+// withdraw frees the spot of the player and promotes the waitlist.
+// Leaving late is charged unless someone from the waitlist takes the spot.
+func (s *RegistrationService) withdraw(
+	playerId uint,
+	matchId uint,
+	check func(match *domain.Match, registration domain.Registration) (bool, error),
+	remove func(tx *gorm.DB, registration *domain.Registration) error,
+) (*dto.UnregisterResultDto, error) {
+	res := &dto.UnregisterResultDto{}
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		match, err := getMatchForUpdate(tx, matchId)
+		if err != nil {
+			return err
+		}
+
+		registration, found := lo.Find(match.Registrations, func(r domain.Registration) bool { return r.PlayerId == playerId })
+		if !found {
+			return fmt.Errorf("no registration found for this match")
+		}
+
+		wasConfirmed := registration.IsConfirmed()
+		isLate, err := check(match, registration)
+		if err != nil {
+			return err
+		}
+
+		if err := remove(tx, &registration); err != nil {
+			return err
+		}
+
+		if !wasConfirmed {
+			return nil
+		}
+
+		match.Registrations = lo.Filter(match.Registrations, func(r domain.Registration, _ int) bool { return r.ID != registration.ID })
+		promoted, err := s.promoteWaitlist(tx, match)
+		if err != nil {
+			return err
+		}
+		res.ReplacedFromWaitlist = len(promoted) > 0
+
+		if isLate && !res.ReplacedFromWaitlist && *match.LateCancellationFee > 0 {
+			fee, err := domain.NewLateCancellationFee(playerId, matchId, *match.LateCancellationFee)
+			if err != nil {
+				return err
+			}
+
+			if err := tx.Create(fee).Error; err != nil {
+				return err
+			}
+			res.LateCancellationFee = fee.Amount
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
 }
 
-func (s *RegistrationService) UnregisterMatch(playerId uint, matchId uint) error {
-	result := s.db.
-		Where("player_id = ? AND match_id = ?", playerId, matchId).
-		Delete(&domain.Registration{})
+// PromoteWaitlist confirms waitlisted players of the match while spots are free
+func (s *RegistrationService) PromoteWaitlist(tx *gorm.DB, matchId uint) error {
+	match, err := getMatchForUpdate(tx, matchId)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.promoteWaitlist(tx, match)
+	return err
+}
 
-	if result.Error != nil {
-		return result.Error
+func (s *RegistrationService) promoteWaitlist(tx *gorm.DB, match *domain.Match) ([]domain.Registration, error) {
+	promoted := match.PromoteWaitlist()
+	for _, r := range promoted {
+		if err := tx.Model(&domain.Registration{}).Where("id = ?", r.ID).Update("is_waitlisted", false).Error; err != nil {
+			return nil, err
+		}
 	}
 
-	if result.RowsAffected == 0 {
-		return fmt.Errorf("no registration found for this match")
+	message := fmt.Sprintf("A spot opened up at %s on %s, you are now playing", match.SportCenter.Name, match.Start.Format("02/01/2006 15:04"))
+	playerIds := lo.Map(promoted, func(r domain.Registration, _ int) uint { return r.PlayerId })
+	if err := notifyPlayers(tx, playerIds, &match.ID, "You are off the waitlist", message); err != nil {
+		return nil, err
 	}
 
-	return nil
+	return promoted, nil
+}
+
+func (s *RegistrationService) GetUnpaidLateCancellationFees() ([]dto.LateCancellationFeeDto, error) {
+	result := []dto.LateCancellationFeeDto{}
+	tenantId, _ := scopes.GetTenantId(s.db.Statement.Context)
+	err := s.db.Raw(`
+		SELECT
+			f.id,
+			f.player_id,
+			TRIM(CONCAT(p.first_name, ' ', p.last_name)) AS player_name,
+			f.match_id,
+			m.start AS match_start,
+			f.amount,
+			f.is_paid
+		FROM late_cancellation_fees f
+		JOIN players p ON p.id = f.player_id
+		JOIN matches m ON m.id = f.match_id
+		WHERE f.deleted_at IS NULL AND f.is_paid = false AND f.tenant_id = ?
+		ORDER BY m.start DESC
+	`, tenantId).Scan(&result).Error
+
+	return result, err
+}
+
+func (s *RegistrationService) MarkLateCancellationFeePaid(feeId uint) error {
+	fee := &domain.LateCancellationFee{}
+	if err := s.db.First(fee, feeId).Error; err != nil {
+		return err
+	}
+
+	fee.MarkPaid()
+	return s.db.Save(fee).Error
 }
 
+// UpdateTotalPlayerPaidFor changes how many players the registration pays for, spots are checked like for guests
 func (s *RegistrationService) UpdateTotalPlayerPaidFor(registrationId uint, count uint) error {
 	if count < 1 {
 		count = 1
@@ -61,10 +453,211 @@ func (s *RegistrationService) UpdateTotalPlayerPaidFor(registrationId uint, coun
 		return err
 	}
 
-	registration.UpdatePlayerPaidForCount(count)
-	if err := s.db.Save(registration).Error; err != nil {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		match, err := s.getMatchWithGuests(tx, registration.MatchId)
+		if err != nil {
+			return err
+		}
+
+		reg, err := match.ChangePlayerPaidForCount(registrationId, count)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Model(&domain.Registration{}).
+			Where("id = ?", registrationId).
+			Update("total_player_paid_for", reg.TotalPlayerPaidFor).
+			Error; err != nil {
+			return err
+		}
+
+		// Paying for fewer players frees spots for the waitlist
+		return s.PromoteWaitlist(tx, registration.MatchId)
+	})
+}
+
+// SetPlayedTime records a partial attendance, it only matters for matches split by player-minutes
+func (s *RegistrationService) SetPlayedTime(registrationId uint, from, to *time.Time) error {
+	registration := &domain.Registration{}
+	if err := s.db.First(registration, registrationId).Error; err != nil {
+		return err
+	}
+
+	match := &domain.Match{}
+	if err := s.db.First(match, registration.MatchId).Error; err != nil {
+		return err
+	}
+
+	if err := registration.SetPlayedTime(match, from, to); err != nil {
+		return err
+	}
+
+	return s.db.Model(registration).Select("played_from", "played_to").Updates(registration).Error
+}
+
+// AddGuest names a +1 of the registration, the guest name defaults to the linked player name
+func (s *RegistrationService) AddGuest(registrationId uint, req dto.GuestDto) (*dto.GuestDto, error) {
+	registration := &domain.Registration{}
+	if err := s.db.First(registration, registrationId).Error; err != nil {
+		return nil, err
+	}
+
+	if req.PlayerId != nil && req.Name == "" {
+		player := &domain.Player{}
+		if err := s.db.First(player, *req.PlayerId).Error; err != nil {
+			return nil, err
+		}
+		req.Name = fmt.Sprintf("%s %s", player.FirstName, player.LastName)
+	}
+
+	guest, err := domain.NewGuest(req.Name, req.PlayerId)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		match, err := s.getMatchWithGuests(tx, registration.MatchId)
+		if err != nil {
+			return err
+		}
+
+		host, err := match.AddGuest(registrationId, guest)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Create(guest).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&domain.Registration{}).
+			Where("id = ?", registrationId).
+			Update("total_player_paid_for", host.TotalPlayerPaidFor).
+			Error
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return ToGuestDto(*guest), nil
+}
+
+func (s *RegistrationService) RemoveGuest(registrationId, guestId uint) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		registration := &domain.Registration{}
+		if err := tx.Preload("Guests").First(registration, registrationId).Error; err != nil {
+			return err
+		}
+
+		if _, err := registration.RemoveGuest(guestId); err != nil {
+			return err
+		}
+
+		if err := tx.Delete(&domain.Guest{}, guestId).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(registration).Update("total_player_paid_for", registration.TotalPlayerPaidFor).Error; err != nil {
+			return err
+		}
+
+		return s.PromoteWaitlist(tx, registration.MatchId)
+	})
+}
+
+// ConvertGuest gives the guest a registration of their own, a player is created for guests not linked to one
+func (s *RegistrationService) ConvertGuest(registrationId, guestId uint) (*domain.Registration, error) {
+	var registration *domain.Registration
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		host := &domain.Registration{}
+		if err := tx.First(host, registrationId).Error; err != nil {
+			return err
+		}
+
+		match, err := s.getMatchWithGuests(tx, host.MatchId)
+		if err != nil {
+			return err
+		}
+
+		guest := &domain.Guest{}
+		if err := tx.Where("registration_id = ?", registrationId).First(guest, guestId).Error; err != nil {
+			return err
+		}
+
+		if guest.PlayerId == nil {
+			firstName, lastName, _ := strings.Cut(guest.Name, " ")
+			player := domain.NewPlayer("", "", firstName, lastName)
+			if err := tx.Create(player).Error; err != nil {
+				return err
+			}
+			guest.PlayerId = &player.ID
+		}
+
+		registration, err = match.ConvertGuest(guestId, *guest.PlayerId)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Create(registration).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Delete(guest).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(host).Update("total_player_paid_for", host.TotalPlayerPaidFor-1).Error
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return registration, nil
+}
+
+func (s *RegistrationService) getMatchWithGuests(tx *gorm.DB, matchId uint) (*domain.Match, error) {
+	match := &domain.Match{}
+	if err := tx.
+		Preload("Registrations.Guests").
+		Preload("CourtBookings").
+		First(match, matchId).Error; err != nil {
+		return nil, err
+	}
+	return match, nil
+}
+
+func ToGuestDto(g domain.Guest) *dto.GuestDto {
+	return &dto.GuestDto{
+		Id:             g.ID,
+		RegistrationId: g.RegistrationId,
+		Name:           g.Name,
+		PlayerId:       g.PlayerId,
+	}
+}
+
+func (s *RegistrationService) ensureNoPlayerConflict(playerId uint, matchId uint) error {
+	match := &domain.Match{}
+	if err := s.db.First(match, matchId).Error; err != nil {
+		return err
+	}
+
+	var registeredMatches []domain.Match
+	err := s.db.
+		Preload("SportCenter").
+		Joins("JOIN registrations r ON r.match_id = matches.id AND r.deleted_at IS NULL").
+		Scopes(MatchStateScope(nil)).
+		Where("r.player_id = ? AND r.rsvp = ? AND matches.start < ? AND matches.\"end\" > ?", playerId, domain.RsvpGoing, match.End, match.Start).
+		Find(&registeredMatches).Error
+
+	if err != nil {
 		return err
 	}
 
+	if overlaps := match.FindOverlaps(registeredMatches); len(overlaps) > 0 {
+		return &domain.ConflictError{Kind: domain.PlayerConflict, Matches: overlaps}
+	}
+
 	return nil
 }
@@ -0,0 +1,57 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tructn/racket/internal/db/dbtest"
+	"github.com/tructn/racket/internal/domain"
+	"gorm.io/gorm"
+)
+
+// recordLockedTables collects the tables queried with SELECT ... FOR UPDATE, sqlite drops the clause itself
+func recordLockedTables(t *testing.T, db *gorm.DB) *[]string {
+	locked := []string{}
+	require.NoError(t, db.Callback().Query().Before("gorm:query").Register("test:locking", func(tx *gorm.DB) {
+		if _, ok := tx.Statement.Clauses["FOR"]; ok {
+			locked = append(locked, tx.Statement.Table)
+		}
+	}))
+	return &locked
+}
+
+func TestRegisterMatchLocksTheMatchWhileTakingASpot(t *testing.T) {
+	db := dbtest.OpenTenant(t)
+	svc := NewRegistrationService(db)
+
+	start := time.Now().Add(48 * time.Hour)
+	first, second := &domain.Player{FirstName: "First"}, &domain.Player{FirstName: "Second"}
+	require.NoError(t, db.Create([]*domain.Player{first, second}).Error)
+	match := &domain.Match{
+		Start:         start,
+		End:           start.Add(time.Hour),
+		State:         domain.MatchOpen,
+		CourtBookings: []domain.CourtBooking{{Court: "1", Start: start, End: start.Add(time.Hour), Capacity: 1}},
+	}
+	require.NoError(t, db.Create(match).Error)
+
+	locked := recordLockedTables(t, db)
+
+	reg, err := svc.RegisterMatch(first.ID, match.ID, RegisterOptions{})
+	require.NoError(t, err)
+	assert.False(t, reg.IsWaitlisted)
+
+	reg, err = svc.RegisterMatch(second.ID, match.ID, RegisterOptions{})
+	require.NoError(t, err)
+	assert.True(t, reg.IsWaitlisted)
+	assert.Equal(t, []string{"matches", "matches"}, *locked)
+
+	_, err = svc.UnregisterMatch(first.ID, match.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"matches", "matches", "matches"}, *locked)
+
+	require.NoError(t, db.First(reg, reg.ID).Error)
+	assert.False(t, reg.IsWaitlisted)
+}
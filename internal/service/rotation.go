@@ -0,0 +1,230 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/samber/lo"
+	"github.com/tructn/racket/internal/domain"
+	"github.com/tructn/racket/internal/dto"
+	"github.com/tructn/racket/pkg/result"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// DefaultRoundMinutes is the length of a doubles round when the number of rounds is not given
+const DefaultRoundMinutes = 15
+
+type RotationService struct {
+	db            *gorm.DB
+	logger        *zap.SugaredLogger
+	ratingService *RatingService
+}
+
+func NewRotationService(db *gorm.DB, logger *zap.SugaredLogger, ratingService *RatingService) *RotationService {
+	return &RotationService{
+		db:            db,
+		logger:        logger,
+		ratingService: ratingService,
+	}
+}
+
+func (s *RotationService) WithContext(ctx context.Context) *RotationService {
+	clone := *s
+	clone.db = s.db.WithContext(ctx)
+	clone.ratingService = s.ratingService.WithContext(ctx)
+	return &clone
+}
+
+// Generate plans the doubles rounds of the match for the checked-in players, it replaces any previous schedule
+func (s *RotationService) Generate(matchId uint, req dto.GenerateRotationDto) (*dto.RotationDto, error) {
+	match := &domain.Match{}
+	if err := s.db.
+		Preload("CourtBookings").
+		Preload("Registrations.Guests").
+		First(match, matchId).Error; err != nil {
+		return nil, err
+	}
+
+	players, err := s.getCheckedInPlayers(match)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := domain.RotationOptions{
+		Courts:       req.Courts,
+		Rounds:       req.Rounds,
+		Seed:         lo.FromPtrOr(req.Seed, time.Now().UnixNano()),
+		BalanceSkill: req.BalanceSkill,
+		Mixed:        req.Mixed,
+		FixedPairs:   req.FixedPairs,
+	}
+	if opts.Courts == 0 {
+		opts.Courts = max(len(match.CourtBookings), len(domain.SplitCourts(match.Court)), 1)
+	}
+	if opts.Rounds == 0 {
+		opts.Rounds = max(int(match.End.Sub(match.Start).Minutes())/DefaultRoundMinutes, 1)
+	}
+
+	rotation, err := domain.GenerateRotation(players, opts)
+	if err != nil {
+		return nil, err
+	}
+	rotation.MatchId = matchId
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := s.deleteRotation(tx, matchId); err != nil {
+			return err
+		}
+		return tx.Create(rotation).Error
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return s.toRotationDto(rotation)
+}
+
+func (s *RotationService) Get(matchId uint) (*dto.RotationDto, error) {
+	rotation, err := s.getRotation(matchId)
+	if err != nil {
+		return nil, err
+	}
+	return s.toRotationDto(rotation)
+}
+
+// Advance moves the session to the next round so it can be displayed courtside
+func (s *RotationService) Advance(matchId uint) (*dto.RotationDto, error) {
+	rotation, err := s.getRotation(matchId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := rotation.Advance(); err != nil {
+		return nil, err
+	}
+
+	if err := s.db.Model(rotation).Update("current_round", rotation.CurrentRound).Error; err != nil {
+		return nil, err
+	}
+
+	return s.toRotationDto(rotation)
+}
+
+// getCheckedInPlayers takes the players who checked in and the guests they brought who are known players
+func (s *RotationService) getCheckedInPlayers(match *domain.Match) ([]domain.RotationPlayer, error) {
+	playerIds := []uint{}
+	for _, r := range match.Registrations {
+		if !r.IsConfirmed() || (r.Attendance != domain.AttendanceAttended && r.Attendance != domain.AttendanceLate) {
+			continue
+		}
+
+		playerIds = append(playerIds, r.PlayerId)
+		for _, g := range r.Guests {
+			if g.PlayerId != nil {
+				playerIds = append(playerIds, *g.PlayerId)
+			}
+		}
+	}
+
+	var players []domain.Player
+	if err := s.db.Where("id IN ?", lo.Uniq(playerIds)).Order("id").Find(&players).Error; err != nil {
+		return nil, err
+	}
+
+	ratings, err := s.ratingService.GetRatings(lo.Map(players, func(p domain.Player, _ int) uint { return p.ID }))
+	if err != nil {
+		return nil, err
+	}
+
+	return lo.Map(players, func(p domain.Player, _ int) domain.RotationPlayer {
+		return domain.RotationPlayer{PlayerId: p.ID, Rating: ratings[p.ID], Gender: p.Gender}
+	}), nil
+}
+
+func (s *RotationService) getRotation(matchId uint) (*domain.Rotation, error) {
+	rotation := &domain.Rotation{}
+	err := s.db.
+		Preload("Rounds.Games").
+		Preload("Rounds.SitOuts").
+		Where("match_id = ?", matchId).
+		First(rotation).Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, result.ErrorNotFound
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(rotation.Rounds, func(i, j int) bool { return rotation.Rounds[i].Number < rotation.Rounds[j].Number })
+	return rotation, nil
+}
+
+func (s *RotationService) deleteRotation(tx *gorm.DB, matchId uint) error {
+	roundIds := tx.Model(&domain.RotationRound{}).
+		Select("rotation_rounds.id").
+		Joins("JOIN rotations ON rotations.id = rotation_rounds.rotation_id").
+		Where("rotations.match_id = ?", matchId)
+
+	if err := tx.Where("round_id IN (?)", roundIds).Delete(&domain.RotationGame{}).Error; err != nil {
+		return err
+	}
+
+	if err := tx.Where("round_id IN (?)", roundIds).Delete(&domain.RotationSitOut{}).Error; err != nil {
+		return err
+	}
+
+	rotationIds := tx.Model(&domain.Rotation{}).Select("id").Where("match_id = ?", matchId)
+	if err := tx.Where("rotation_id IN (?)", rotationIds).Delete(&domain.RotationRound{}).Error; err != nil {
+		return err
+	}
+
+	return tx.Where("match_id = ?", matchId).Delete(&domain.Rotation{}).Error
+}
+
+func (s *RotationService) toRotationDto(rotation *domain.Rotation) (*dto.RotationDto, error) {
+	var players []domain.Player
+	playerIds := lo.FlatMap(rotation.Rounds, func(r domain.RotationRound, _ int) []uint {
+		ids := lo.Map(r.SitOuts, func(so domain.RotationSitOut, _ int) uint { return so.PlayerId })
+		for _, g := range r.Games {
+			ids = append(ids, g.TeamA1, g.TeamA2, g.TeamB1, g.TeamB2)
+		}
+		return ids
+	})
+	if err := s.db.Where("id IN ?", lo.Uniq(playerIds)).Find(&players).Error; err != nil {
+		return nil, err
+	}
+
+	byId := lo.KeyBy(players, func(p domain.Player) uint { return p.ID })
+	summary := func(id uint) dto.PlayerSummaryDto {
+		p := byId[id]
+		return dto.PlayerSummaryDto{PlayerId: id, FirstName: p.FirstName, LastName: p.LastName}
+	}
+
+	return &dto.RotationDto{
+		Id:           rotation.ID,
+		MatchId:      rotation.MatchId,
+		Seed:         rotation.Seed,
+		Courts:       rotation.Courts,
+		CurrentRound: rotation.CurrentRound,
+		Rounds: lo.Map(rotation.Rounds, func(r domain.RotationRound, _ int) dto.RotationRoundDto {
+			sort.Slice(r.Games, func(i, j int) bool { return r.Games[i].Court < r.Games[j].Court })
+			return dto.RotationRoundDto{
+				Number: r.Number,
+				Games: lo.Map(r.Games, func(g domain.RotationGame, _ int) dto.RotationGameDto {
+					return dto.RotationGameDto{
+						Court: g.Court,
+						TeamA: []dto.PlayerSummaryDto{summary(g.TeamA1), summary(g.TeamA2)},
+						TeamB: []dto.PlayerSummaryDto{summary(g.TeamB1), summary(g.TeamB2)},
+					}
+				}),
+				SitOuts: lo.Map(r.SitOuts, func(so domain.RotationSitOut, _ int) dto.PlayerSummaryDto { return summary(so.PlayerId) }),
+			}
+		}),
+	}, nil
+}
@@ -0,0 +1,90 @@
+package service
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/tructn/racket/internal/domain"
+	"github.com/tructn/racket/pkg/scopes"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// defaultSchedulerInterval keeps draws and reminders within minutes of their due time,
+// it can be changed with the SCHEDULER_INTERVAL environment variable, e.g. "1m"
+const defaultSchedulerInterval = 5 * time.Minute
+
+// SchedulerService runs the jobs that are due at a given time rather than on a request:
+// ballot draws and rsvp reminders, for every tenant
+type SchedulerService struct {
+	db                  *gorm.DB
+	logger              *zap.SugaredLogger
+	ballotService       *BallotService
+	registrationService *RegistrationService
+}
+
+func NewSchedulerService(
+	db *gorm.DB,
+	logger *zap.SugaredLogger,
+	ballotService *BallotService,
+	registrationService *RegistrationService,
+) *SchedulerService {
+	return &SchedulerService{
+		db:                  db,
+		logger:              logger,
+		ballotService:       ballotService,
+		registrationService: registrationService,
+	}
+}
+
+// Run runs the jobs at every interval until the context is done
+func (s *SchedulerService) Run(ctx context.Context) {
+	interval := defaultSchedulerInterval
+	if value := os.Getenv("SCHEDULER_INTERVAL"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed <= 0 {
+			s.logger.Warnw("Invalid scheduler interval, using the default", "value", value, "default", interval)
+		} else {
+			interval = parsed
+		}
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		s.RunOnce(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce runs every job for every tenant, a failing tenant does not stop the others
+func (s *SchedulerService) RunOnce(ctx context.Context) {
+	var tenants []domain.Tenant
+	if err := s.db.WithContext(ctx).Find(&tenants).Error; err != nil {
+		s.logger.Errorw("Failed to load tenants for scheduled jobs", "error", err)
+		return
+	}
+
+	for _, tenant := range tenants {
+		tenantCtx := scopes.WithTenant(ctx, tenant.ID)
+
+		if res, err := s.ballotService.WithContext(tenantCtx).DrawDue(); err != nil {
+			s.logger.Errorw("Failed to draw due ballots", "tenantId", tenant.ID, "error", err)
+		} else if len(res.DrawnMatchIds) > 0 {
+			s.logger.Infow("Drew due ballots", "tenantId", tenant.ID, "matchIds", res.DrawnMatchIds)
+		}
+
+		if res, err := s.registrationService.WithContext(tenantCtx).SendRsvpReminders(); err != nil {
+			s.logger.Errorw("Failed to send rsvp reminders", "tenantId", tenant.ID, "error", err)
+		} else if res.Reminded > 0 {
+			s.logger.Infow("Sent rsvp reminders", "tenantId", tenant.ID, "reminded", res.Reminded)
+		}
+	}
+}
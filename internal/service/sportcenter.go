@@ -1,7 +1,12 @@
 package service
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/samber/lo"
 	"github.com/tructn/racket/internal/domain"
@@ -22,20 +27,29 @@ func NewSportCenterService(db *gorm.DB, logger *zap.SugaredLogger) *SportCenterS
 	}
 }
 
+func (s *SportCenterService) WithContext(ctx context.Context) *SportCenterService {
+	clone := *s
+	clone.db = s.db.WithContext(ctx)
+	return &clone
+}
+
+// GetAll lists the sport centers with the price in effect now
 func (s *SportCenterService) GetAll() ([]dto.SportCenterDto, error) {
-	sportCenters, err := s.getSportCenters()
-	if err != nil {
+	var sportCenters []domain.SportCenter
+	if err := s.db.Preload("PriceHistory").Order("name ASC").Find(&sportCenters).Error; err != nil {
 		return nil, err
 	}
-	s.logger.Debug(sportCenters)
 
+	now := time.Now()
 	result := lo.Map(sportCenters, func(item domain.SportCenter, _ int) dto.SportCenterDto {
+		costPerSection, minutePerSection := item.PriceAt(now)
 		return dto.SportCenterDto{
 			ID:               item.ID,
 			Name:             item.Name,
 			Location:         item.Location,
-			CostPerSection:   item.CostPerSection,
-			MinutePerSection: item.MinutePerSection,
+			CostPerSection:   costPerSection,
+			MinutePerSection: minutePerSection,
+			Timezone:         item.Timezone,
 		}
 	})
 	return result, nil
@@ -46,33 +60,47 @@ func (s *SportCenterService) Create(
 	location string,
 	costPerSection float64,
 	minutePerSection uint,
+	timezone string,
 ) error {
 	center := domain.NewSportCenter(name, location, costPerSection, minutePerSection)
+	if err := center.SetTimezone(timezone); err != nil {
+		return err
+	}
 	err := s.db.Create(center).Error
 	return err
 }
 
+// Update records a price change effective now when the rate changes, so that past costs keep their price
 func (s *SportCenterService) Update(
 	id,
 	name,
 	location string,
 	costPerSection float64,
 	minutePerSection uint,
+	timezone string,
 ) error {
 	entity := domain.SportCenter{}
 
-	if err := s.db.Find(&entity, id).Error; err != nil {
+	if err := s.db.Preload("PriceHistory").Find(&entity, id).Error; err != nil {
 		return err
 	}
 
 	entity.Name = name
 	entity.Location = location
-	entity.CostPerSection = costPerSection
-	entity.MinutePerSection = minutePerSection
+	if err := entity.SetTimezone(timezone); err != nil {
+		return err
+	}
 
-	err := s.db.Save(&entity).Error
+	now := time.Now()
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if currentCost, currentMinute := entity.PriceAt(now); currentCost != costPerSection || currentMinute != minutePerSection {
+			if err := s.changePrice(tx, &entity, costPerSection, minutePerSection, now); err != nil {
+				return err
+			}
+		}
 
-	return err
+		return tx.Omit("PriceHistory").Save(&entity).Error
+	})
 }
 
 func (s *SportCenterService) GetOptions() ([]dto.SelectOption, error) {
@@ -96,3 +124,436 @@ func (s *SportCenterService) getSportCenters() ([]domain.SportCenter, error) {
 	err := s.db.Order("name ASC").Find(&sportCenters).Error
 	return sportCenters, err
 }
+
+func (s *SportCenterService) GetPricingRules(id uint) ([]dto.PricingRuleDto, error) {
+	var rules []domain.PricingRule
+	if err := s.db.
+		Where("sport_center_id = ?", id).
+		Order("priority DESC, start_minute ASC").
+		Find(&rules).Error; err != nil {
+		return nil, err
+	}
+
+	return lo.Map(rules, func(r domain.PricingRule, _ int) dto.PricingRuleDto {
+		return dto.PricingRuleDto{
+			Id:             r.ID,
+			Name:           r.Name,
+			Weekdays:       lo.Map(r.GetWeekdays(), func(d time.Weekday, _ int) int { return int(d) }),
+			StartTime:      domain.FormatTimeOfDay(r.StartMinute),
+			EndTime:        domain.FormatTimeOfDay(r.EndMinute),
+			CostPerSection: r.CostPerSection,
+			Priority:       r.Priority,
+			MemberOnly:     r.MemberOnly,
+		}
+	}), nil
+}
+
+// ReplacePricingRules replaces the whole rule set of the sport center,
+// existing match costs are not changed
+func (s *SportCenterService) ReplacePricingRules(id uint, items []dto.PricingRuleDto) error {
+	entity := domain.SportCenter{}
+	if err := s.db.First(&entity, id).Error; err != nil {
+		return err
+	}
+
+	rules := []domain.PricingRule{}
+	for _, item := range items {
+		startMinute, err := domain.ParseTimeOfDay(item.StartTime)
+		if err != nil {
+			return err
+		}
+
+		endMinute, err := domain.ParseTimeOfDay(item.EndTime)
+		if err != nil {
+			return err
+		}
+
+		weekdays := lo.Map(item.Weekdays, func(d int, _ int) time.Weekday { return time.Weekday(d) })
+		rule, err := domain.NewPricingRule(item.Name, weekdays, startMinute, endMinute, item.CostPerSection, item.Priority, item.MemberOnly)
+		if err != nil {
+			return err
+		}
+		rules = append(rules, *rule)
+	}
+
+	if err := entity.ReplacePricingRules(rules); err != nil {
+		return err
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("sport_center_id = ?", id).Delete(&domain.PricingRule{}).Error; err != nil {
+			return err
+		}
+
+		if len(entity.PricingRules) == 0 {
+			return nil
+		}
+
+		return tx.Create(&entity.PricingRules).Error
+	})
+}
+
+// Quote prices a booking with the current rules without creating anything
+func (s *SportCenterService) Quote(id uint, req dto.PricingQuoteRequestDto) (*dto.PricingQuoteDto, error) {
+	if err := domain.CheckBookingDuration(req.Start, req.End); err != nil {
+		return nil, err
+	}
+
+	entity := domain.SportCenter{}
+	if err := s.db.Preload("PricingRules").Preload("PriceHistory").First(&entity, id).Error; err != nil {
+		return nil, err
+	}
+
+	items, cost := entity.GetPricing(req.Start, req.MemberRate).Price(req.Start, req.End, req.CustomSection)
+
+	return &dto.PricingQuoteDto{
+		Items: ToCostItemDtos(items),
+		Cost:  cost,
+	}, nil
+}
+
+func (s *SportCenterService) GetPriceHistory(id uint) ([]dto.SportCenterPriceDto, error) {
+	var prices []domain.SportCenterPrice
+	if err := s.db.
+		Where("sport_center_id = ?", id).
+		Order("effective_from DESC").
+		Find(&prices).Error; err != nil {
+		return nil, err
+	}
+
+	return lo.Map(prices, func(p domain.SportCenterPrice, _ int) dto.SportCenterPriceDto {
+		return dto.SportCenterPriceDto{
+			Id:               p.ID,
+			CostPerSection:   p.CostPerSection,
+			MinutePerSection: p.MinutePerSection,
+			EffectiveFrom:    p.EffectiveFrom,
+		}
+	}), nil
+}
+
+// ChangePrice schedules a new price, existing matches keep their cost until recalculated
+func (s *SportCenterService) ChangePrice(id uint, req dto.ChangeSportCenterPriceDto) error {
+	entity := domain.SportCenter{}
+	if err := s.db.Preload("PriceHistory").First(&entity, id).Error; err != nil {
+		return err
+	}
+
+	effectiveFrom := time.Now()
+	if req.EffectiveFrom != nil {
+		effectiveFrom = *req.EffectiveFrom
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := s.changePrice(tx, &entity, req.CostPerSection, req.MinutePerSection, effectiveFrom); err != nil {
+			return err
+		}
+
+		return tx.Omit("PriceHistory").Save(&entity).Error
+	})
+}
+
+func (s *SportCenterService) changePrice(
+	tx *gorm.DB,
+	entity *domain.SportCenter,
+	costPerSection float64,
+	minutePerSection uint,
+	effectiveFrom time.Time,
+) error {
+	oldCost, oldMinute := entity.PriceAt(effectiveFrom)
+	if _, err := entity.ChangePrice(costPerSection, minutePerSection, effectiveFrom); err != nil {
+		return err
+	}
+
+	// Saves the new version, and the initial one for centers created before the history existed
+	newPrices := lo.Filter(entity.PriceHistory, func(p domain.SportCenterPrice, _ int) bool { return p.ID == 0 })
+	if err := tx.Create(&newPrices).Error; err != nil {
+		return err
+	}
+
+	data := struct {
+		SportCenterId       uint      `json:"sportCenterId"`
+		SportCenter         string    `json:"sportCenter"`
+		OldCostPerSection   float64   `json:"oldCostPerSection"`
+		NewCostPerSection   float64   `json:"newCostPerSection"`
+		OldMinutePerSection uint      `json:"oldMinutePerSection"`
+		NewMinutePerSection uint      `json:"newMinutePerSection"`
+		EffectiveFrom       time.Time `json:"effectiveFrom"`
+	}{
+		SportCenterId:       entity.ID,
+		SportCenter:         entity.Name,
+		OldCostPerSection:   oldCost,
+		NewCostPerSection:   costPerSection,
+		OldMinutePerSection: oldMinute,
+		NewMinutePerSection: minutePerSection,
+		EffectiveFrom:       effectiveFrom,
+	}
+
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	ac, err := domain.CreateActivityLog(
+		domain.SportCenterPriceChanged,
+		fmt.Sprintf("%s price changed from %.2f to %.2f per %d minutes from %s",
+			entity.Name, oldCost, costPerSection, minutePerSection, effectiveFrom.Format("02/01/2006")),
+		string(payload),
+	)
+	if err != nil {
+		return err
+	}
+
+	return tx.Create(ac).Error
+}
+
+// PreviewRecalculation tells how future matches costs would change with the current prices, nothing is written
+func (s *SportCenterService) PreviewRecalculation(id uint) (*dto.PriceRecalculationDto, error) {
+	return s.recalculate(s.db, id, false)
+}
+
+// ApplyRecalculation reprices all future matches of the sport center which are not finalized yet
+func (s *SportCenterService) ApplyRecalculation(id uint) (*dto.PriceRecalculationDto, error) {
+	var res *dto.PriceRecalculationDto
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		res, err = s.recalculate(tx, id, true)
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (s *SportCenterService) recalculate(tx *gorm.DB, id uint, commit bool) (*dto.PriceRecalculationDto, error) {
+	sc := domain.SportCenter{}
+	if err := tx.Preload("PricingRules").Preload("PriceHistory").First(&sc, id).Error; err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	var matches []domain.Match
+	if err := tx.
+		Preload("CourtBookings").
+		Preload("AdditionalCosts").
+		Preload("Registrations").
+		Scopes(MatchStateScope(nil)).
+		Where("sport_center_id = ? AND start > ?", id, now).
+		Order("start ASC").
+		Find(&matches).Error; err != nil {
+		return nil, err
+	}
+
+	playerNames, err := s.getPlayerNames(tx, matches)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &dto.PriceRecalculationDto{
+		SportCenterId:    id,
+		Applied:          commit,
+		Matches:          []dto.MatchRecalculationDto{},
+		FinalizedMatches: []uint{},
+		ManualMatches:    []uint{},
+	}
+
+	for i := range matches {
+		m := &matches[i]
+		if m.IsFinalized(now) {
+			res.FinalizedMatches = append(res.FinalizedMatches, m.ID)
+			continue
+		}
+
+		oldCost, oldIndividualCost := m.Cost, m.CalcFullSessionCost()
+		// Waitlisted and undecided players pay nothing, they are not told about cost changes
+		confirmed := m.GetConfirmedRegistrations()
+		oldCosts := lo.Map(confirmed, func(r domain.Registration, _ int) float64 { return m.CalcIndividualCost(r) })
+		if !m.Reprice(&sc) {
+			res.ManualMatches = append(res.ManualMatches, m.ID)
+			continue
+		}
+		newIndividualCost := m.CalcFullSessionCost()
+
+		res.OldTotal += oldCost
+		res.NewTotal += m.Cost
+		if oldCost == m.Cost {
+			continue
+		}
+
+		res.Matches = append(res.Matches, dto.MatchRecalculationDto{
+			MatchId:           m.ID,
+			Start:             m.Start,
+			OldCost:           oldCost,
+			NewCost:           m.Cost,
+			OldIndividualCost: oldIndividualCost,
+			NewIndividualCost: newIndividualCost,
+			Players: lo.Map(confirmed, func(r domain.Registration, i int) dto.PlayerCostDiffDto {
+				newCost := m.CalcIndividualCost(r)
+				return dto.PlayerCostDiffDto{
+					PlayerId:   r.PlayerId,
+					PlayerName: playerNames[r.PlayerId],
+					OldCost:    oldCosts[i],
+					NewCost:    newCost,
+					Diff:       newCost - oldCosts[i],
+				}
+			}),
+		})
+
+		if commit {
+			if err := tx.Omit("CourtBookings", "AdditionalCosts", "Registrations", "SportCenter").Save(m).Error; err != nil {
+				return nil, err
+			}
+
+			for _, b := range m.CourtBookings {
+				if err := tx.Save(&b).Error; err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	return res, nil
+}
+
+func (s *SportCenterService) getPlayerNames(tx *gorm.DB, matches []domain.Match) (map[uint]string, error) {
+	playerIds := lo.Uniq(lo.FlatMap(matches, func(m domain.Match, _ int) []uint {
+		return lo.Map(m.GetConfirmedRegistrations(), func(r domain.Registration, _ int) uint { return r.PlayerId })
+	}))
+
+	names := map[uint]string{}
+	if len(playerIds) == 0 {
+		return names, nil
+	}
+
+	var players []domain.Player
+	if err := tx.Where("id IN ?", playerIds).Find(&players).Error; err != nil {
+		return nil, err
+	}
+
+	for _, p := range players {
+		names[p.ID] = strings.TrimSpace(fmt.Sprintf("%s %s", p.FirstName, p.LastName))
+	}
+	return names, nil
+}
+
+func (s *SportCenterService) GetOpeningHours(id uint) ([]dto.OpeningHoursDto, error) {
+	var hours []domain.OpeningHours
+	if err := s.db.
+		Where("sport_center_id = ?", id).
+		Order("weekday ASC, open_minute ASC").
+		Find(&hours).Error; err != nil {
+		return nil, err
+	}
+
+	return lo.Map(hours, func(h domain.OpeningHours, _ int) dto.OpeningHoursDto {
+		return dto.OpeningHoursDto{
+			Weekday:   int(h.Weekday),
+			OpenTime:  domain.FormatTimeOfDay(h.OpenMinute),
+			CloseTime: domain.FormatTimeOfDay(h.CloseMinute),
+		}
+	}), nil
+}
+
+// ReplaceOpeningHours replaces the weekly opening hours, an empty list means always open
+func (s *SportCenterService) ReplaceOpeningHours(id uint, items []dto.OpeningHoursDto) error {
+	entity := domain.SportCenter{}
+	if err := s.db.First(&entity, id).Error; err != nil {
+		return err
+	}
+
+	hours := []domain.OpeningHours{}
+	for _, item := range items {
+		openMinute, err := domain.ParseTimeOfDay(item.OpenTime)
+		if err != nil {
+			return err
+		}
+
+		closeMinute, err := domain.ParseTimeOfDay(item.CloseTime)
+		if err != nil {
+			return err
+		}
+
+		h, err := domain.NewOpeningHours(time.Weekday(item.Weekday), openMinute, closeMinute)
+		if err != nil {
+			return err
+		}
+		hours = append(hours, *h)
+	}
+
+	if err := entity.ReplaceOpeningHours(hours); err != nil {
+		return err
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("sport_center_id = ?", id).Delete(&domain.OpeningHours{}).Error; err != nil {
+			return err
+		}
+
+		if len(entity.OpeningHours) == 0 {
+			return nil
+		}
+
+		return tx.Create(&entity.OpeningHours).Error
+	})
+}
+
+func (s *SportCenterService) GetClosures(id uint) ([]dto.ClosureDto, error) {
+	entity := domain.SportCenter{}
+	if err := s.db.First(&entity, id).Error; err != nil {
+		return nil, err
+	}
+
+	var closures []domain.Closure
+	if err := s.db.
+		Where("sport_center_id = ?", id).
+		Order("\"from\" DESC").
+		Find(&closures).Error; err != nil {
+		return nil, err
+	}
+
+	loc := entity.GetLocation()
+	return lo.Map(closures, func(c domain.Closure, _ int) dto.ClosureDto {
+		return dto.ClosureDto{
+			Id:       c.ID,
+			FromDate: c.From.In(loc).Format(time.DateOnly),
+			ToDate:   c.To.In(loc).AddDate(0, 0, -1).Format(time.DateOnly),
+			Reason:   c.Reason,
+		}
+	}), nil
+}
+
+// AddClosure closes the sport center for whole local days
+func (s *SportCenterService) AddClosure(id uint, item dto.ClosureDto) error {
+	entity := domain.SportCenter{}
+	if err := s.db.First(&entity, id).Error; err != nil {
+		return err
+	}
+
+	loc := entity.GetLocation()
+	from, err := time.ParseInLocation(time.DateOnly, item.FromDate, loc)
+	if err != nil {
+		return fmt.Errorf("invalid from date %s", item.FromDate)
+	}
+
+	toDate := lo.Ternary(item.ToDate == "", item.FromDate, item.ToDate)
+	to, err := time.ParseInLocation(time.DateOnly, toDate, loc)
+	if err != nil {
+		return fmt.Errorf("invalid to date %s", toDate)
+	}
+
+	closure, err := domain.NewClosure(from, to.AddDate(0, 0, 1), item.Reason)
+	if err != nil {
+		return err
+	}
+	closure.SportCenterId = entity.ID
+
+	return s.db.Create(closure).Error
+}
+
+func (s *SportCenterService) DeleteClosure(id, closureId uint) error {
+	return s.db.
+		Where("sport_center_id = ?", id).
+		Delete(&domain.Closure{}, closureId).Error
+}
@@ -0,0 +1,239 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/samber/lo"
+	"github.com/tructn/racket/internal/domain"
+	"github.com/tructn/racket/internal/dto"
+	"github.com/tructn/racket/pkg/result"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type StandingRegistrationService struct {
+	db                  *gorm.DB
+	logger              *zap.SugaredLogger
+	registrationService *RegistrationService
+}
+
+func NewStandingRegistrationService(
+	db *gorm.DB,
+	logger *zap.SugaredLogger,
+	registrationService *RegistrationService,
+) *StandingRegistrationService {
+	return &StandingRegistrationService{
+		db:                  db,
+		logger:              logger,
+		registrationService: registrationService,
+	}
+}
+
+func (s *StandingRegistrationService) WithContext(ctx context.Context) *StandingRegistrationService {
+	clone := *s
+	clone.db = s.db.WithContext(ctx)
+	clone.registrationService = s.registrationService.WithContext(ctx)
+	return &clone
+}
+
+func (s *StandingRegistrationService) GetMine(playerId uint) ([]dto.StandingRegistrationDto, error) {
+	var standings []domain.StandingRegistration
+	if err := s.db.Where("player_id = ?", playerId).Order("id").Find(&standings).Error; err != nil {
+		return nil, err
+	}
+
+	var sportCenters []domain.SportCenter
+	sportCenterIds := lo.Uniq(lo.FilterMap(standings, func(sr domain.StandingRegistration, _ int) (uint, bool) {
+		return lo.FromPtr(sr.SportCenterId), sr.SportCenterId != nil
+	}))
+	if err := s.db.Where("id IN ?", sportCenterIds).Find(&sportCenters).Error; err != nil {
+		return nil, err
+	}
+
+	names := lo.SliceToMap(sportCenters, func(sc domain.SportCenter) (uint, string) { return sc.ID, sc.Name })
+	return lo.Map(standings, func(sr domain.StandingRegistration, _ int) dto.StandingRegistrationDto {
+		return toStandingRegistrationDto(sr, names[lo.FromPtr(sr.SportCenterId)])
+	}), nil
+}
+
+// Create follows the series of the given match, or the weekday and start minute at the sport center
+func (s *StandingRegistrationService) Create(playerId uint, req dto.StandingRegistrationRequestDto) (*dto.StandingRegistrationDto, error) {
+	var standing *domain.StandingRegistration
+	sportCenterName := ""
+	if req.SeriesId != nil {
+		match := domain.Match{}
+		if err := s.db.First(&match, *req.SeriesId).Error; err != nil {
+			return nil, err
+		}
+		standing = domain.NewSeriesStandingRegistration(playerId, *match.GetSeriesId())
+	} else {
+		if req.Weekday == nil {
+			return nil, errors.New("a series or a weekday is required")
+		}
+
+		sc := domain.SportCenter{}
+		if err := s.db.First(&sc, req.SportCenterId).Error; err != nil {
+			return nil, err
+		}
+
+		var err error
+		if standing, err = domain.NewWeeklyStandingRegistration(playerId, sc.ID, *req.Weekday, req.StartMinute); err != nil {
+			return nil, err
+		}
+		sportCenterName = sc.Name
+	}
+
+	standing.Pause(req.PausedUntil)
+	if err := s.db.Create(standing).Error; err != nil {
+		return nil, err
+	}
+
+	res := toStandingRegistrationDto(*standing, sportCenterName)
+	return &res, nil
+}
+
+// Pause skips new matches starting before the date, a nil date resumes the standing registration
+func (s *StandingRegistrationService) Pause(playerId, id uint, req dto.PauseStandingRegistrationDto) error {
+	standing, err := s.getStanding(playerId, id)
+	if err != nil {
+		return err
+	}
+
+	standing.Pause(req.PausedUntil)
+	return s.db.Model(standing).Select("paused_until").Updates(standing).Error
+}
+
+// Delete stops future automatic registrations, the matches the player is already registered to are kept
+func (s *StandingRegistrationService) Delete(playerId, id uint) error {
+	standing, err := s.getStanding(playerId, id)
+	if err != nil {
+		return err
+	}
+
+	return s.db.Delete(standing).Error
+}
+
+func (s *StandingRegistrationService) OptOut(playerId, matchId uint) (*dto.UnregisterResultDto, error) {
+	return s.registrationService.OptOut(playerId, matchId)
+}
+
+// Apply registers the regular players to newly created matches and notifies them.
+// Players the match is hidden from or who already play elsewhere at that time are skipped,
+// players over the capacity are waitlisted.
+func (s *StandingRegistrationService) Apply(matchIds []uint) error {
+	if len(matchIds) == 0 {
+		return nil
+	}
+
+	var standings []domain.StandingRegistration
+	if err := s.db.Order("id").Find(&standings).Error; err != nil {
+		return err
+	}
+
+	if len(standings) == 0 {
+		return nil
+	}
+
+	var matches []domain.Match
+	if err := s.db.
+		Preload("SportCenter").
+		Preload("Registrations").
+		Preload("CourtBookings").
+		Preload("Invitations").
+		Where("id IN ?", matchIds).
+		Order("start").
+		Find(&matches).Error; err != nil {
+		return err
+	}
+
+	now := time.Now()
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		for i := range matches {
+			match := &matches[i]
+			if !match.AcceptsRegistrations() || !now.Before(match.GetOptOutDeadline()) {
+				continue
+			}
+
+			registered, err := s.applyToMatch(tx, match, standings)
+			if err != nil {
+				return err
+			}
+
+			message := fmt.Sprintf(
+				"You are registered for %s on %s as a regular, opt out before %s if you can not make it",
+				match.SportCenter.Name, match.Start.Format("02/01/2006 15:04"), match.GetOptOutDeadline().Format("02/01/2006 15:04"),
+			)
+			if err := notifyPlayers(tx, registered, &match.ID, "You are registered", message); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (s *StandingRegistrationService) applyToMatch(tx *gorm.DB, match *domain.Match, standings []domain.StandingRegistration) ([]uint, error) {
+	loc := match.SportCenter.GetLocation()
+	registered := []uint{}
+	for _, standing := range standings {
+		isRegistered := lo.ContainsBy(match.Registrations, func(r domain.Registration) bool { return r.PlayerId == standing.PlayerId })
+		if !standing.Matches(match, loc) || isRegistered {
+			continue
+		}
+
+		if err := s.registrationService.ensureVisible(match, standing.PlayerId); errors.Is(err, domain.ErrMatchNotVisible) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		var conflictErr *domain.ConflictError
+		if err := s.registrationService.ensureNoPlayerConflict(standing.PlayerId, match.ID); errors.As(err, &conflictErr) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		registration := domain.NewRegistration(standing.PlayerId, match.ID)
+		registration.StandingRegistrationId = &standing.ID
+		registration.IsWaitlisted = match.ShouldWaitlist(registration.TotalPlayerPaidFor)
+		if registration.IsWaitlisted {
+			priority, err := s.registrationService.getWaitlistPriority(standing.PlayerId)
+			if err != nil {
+				return nil, err
+			}
+			registration.WaitlistPriority = priority
+		}
+
+		if err := tx.Create(registration).Error; err != nil {
+			return nil, err
+		}
+
+		match.Registrations = append(match.Registrations, *registration)
+		registered = append(registered, standing.PlayerId)
+	}
+	return registered, nil
+}
+
+func (s *StandingRegistrationService) getStanding(playerId, id uint) (*domain.StandingRegistration, error) {
+	standing := &domain.StandingRegistration{}
+	err := s.db.Where("player_id = ?", playerId).First(standing, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, result.ErrorNotFound
+	}
+	return standing, err
+}
+
+func toStandingRegistrationDto(sr domain.StandingRegistration, sportCenterName string) dto.StandingRegistrationDto {
+	return dto.StandingRegistrationDto{
+		Id:              sr.ID,
+		SeriesId:        sr.SeriesId,
+		SportCenterId:   sr.SportCenterId,
+		SportCenterName: sportCenterName,
+		Weekday:         sr.Weekday,
+		StartMinute:     sr.StartMinute,
+		PausedUntil:     sr.PausedUntil,
+	}
+}
@@ -18,6 +18,12 @@ func NewTeamService(db *gorm.DB) *TeamService {
 	return &TeamService{db: db}
 }
 
+func (s *TeamService) WithContext(ctx context.Context) *TeamService {
+	clone := *s
+	clone.db = s.db.WithContext(ctx)
+	return &clone
+}
+
 func (s *TeamService) CreateTeam(req dto.CreateTeamRequest, userId string) (*domain.Team, error) {
 	team := domain.NewTeam(req.Name, req.Description, userId)
 
@@ -32,6 +38,7 @@ func (s *TeamService) GetTeams(ctx context.Context) ([]domain.Team, error) {
 	var teams []domain.Team
 	if err := s.db.
 		Scopes(scopes.UserScope(ctx)).
+		Where("match_id IS NULL").
 		Preload("Members").
 		Find(&teams).Error; err != nil {
 		return nil, err
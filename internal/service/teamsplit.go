@@ -0,0 +1,202 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/samber/lo"
+	"github.com/tructn/racket/internal/domain"
+	"github.com/tructn/racket/internal/dto"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+var ErrPlayerNotInRoster = errors.New("every player must be in the match roster and in one team only")
+
+type TeamSplitService struct {
+	db            *gorm.DB
+	logger        *zap.SugaredLogger
+	ratingService *RatingService
+}
+
+func NewTeamSplitService(db *gorm.DB, logger *zap.SugaredLogger, ratingService *RatingService) *TeamSplitService {
+	return &TeamSplitService{
+		db:            db,
+		logger:        logger,
+		ratingService: ratingService,
+	}
+}
+
+func (s *TeamSplitService) WithContext(ctx context.Context) *TeamSplitService {
+	clone := *s
+	clone.db = s.db.WithContext(ctx)
+	clone.ratingService = s.ratingService.WithContext(ctx)
+	return &clone
+}
+
+// Suggest returns the most balanced splits of the match roster, nothing is saved until one is applied
+func (s *TeamSplitService) Suggest(matchId uint, req dto.TeamSplitRequestDto) ([]dto.TeamSplitDto, error) {
+	players, err := s.getRoster(matchId)
+	if err != nil {
+		return nil, err
+	}
+
+	seed := lo.FromPtrOr(req.Seed, time.Now().UnixNano())
+	splits, err := domain.SplitTeams(players, domain.TeamSplitOptions{
+		Teams:        req.Teams,
+		Candidates:   req.Candidates,
+		Seed:         seed,
+		GenderQuota:  req.GenderQuota,
+		LevelQuota:   req.LevelQuota,
+		KeepTogether: req.KeepTogether,
+		KeepApart:    req.KeepApart,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	summary, err := s.getPlayerSummaries(lo.Map(players, func(p domain.TeamSplitPlayer, _ int) uint { return p.PlayerId }))
+	if err != nil {
+		return nil, err
+	}
+
+	return lo.Map(splits, func(split domain.TeamSplit, _ int) dto.TeamSplitDto {
+		return dto.TeamSplitDto{
+			Seed:         seed,
+			RatingSpread: split.RatingSpread,
+			Score:        split.Score,
+			Teams: lo.Map(split.Teams, func(team []uint, i int) dto.TeamSplitMembersDto {
+				return dto.TeamSplitMembersDto{
+					Rating:  split.TeamRatings[i],
+					Players: lo.Map(team, func(id uint, _ int) dto.PlayerSummaryDto { return summary(id) }),
+				}
+			}),
+		}
+	}), nil
+}
+
+// Apply saves the chosen split as the event teams of the match, replacing the previous ones
+func (s *TeamSplitService) Apply(matchId uint, ownerId string, req dto.ApplyTeamSplitDto) ([]dto.EventTeamDto, error) {
+	players, err := s.getRoster(matchId)
+	if err != nil {
+		return nil, err
+	}
+
+	roster := lo.Map(players, func(p domain.TeamSplitPlayer, _ int) uint { return p.PlayerId })
+	assigned := lo.Flatten(req.Teams)
+	if len(lo.Uniq(assigned)) != len(assigned) || !lo.Every(roster, assigned) {
+		return nil, ErrPlayerNotInRoster
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := s.deleteEventTeams(tx, matchId); err != nil {
+			return err
+		}
+
+		for i, members := range req.Teams {
+			name := fmt.Sprintf("Team %d", i+1)
+			if i < len(req.Names) && req.Names[i] != "" {
+				name = req.Names[i]
+			}
+
+			team := domain.NewEventTeam(name, ownerId, matchId)
+			if err := tx.Create(team).Error; err != nil {
+				return err
+			}
+
+			for _, playerId := range members {
+				if err := tx.Create(&domain.TeamMember{TeamID: team.ID, PlayerID: playerId}).Error; err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetEventTeams(matchId)
+}
+
+func (s *TeamSplitService) GetEventTeams(matchId uint) ([]dto.EventTeamDto, error) {
+	var teams []domain.Team
+	if err := s.db.
+		Preload("Members").
+		Where("match_id = ?", matchId).
+		Order("id").
+		Find(&teams).Error; err != nil {
+		return nil, err
+	}
+
+	return lo.Map(teams, func(t domain.Team, _ int) dto.EventTeamDto {
+		return dto.EventTeamDto{
+			Id:   t.ID,
+			Name: t.Name,
+			Players: lo.Map(t.Members, func(p domain.Player, _ int) dto.PlayerSummaryDto {
+				return dto.PlayerSummaryDto{PlayerId: p.ID, FirstName: p.FirstName, LastName: p.LastName, Gender: p.Gender}
+			}),
+		}
+	}), nil
+}
+
+// getRoster takes the confirmed players and the guests they brought who are known players
+func (s *TeamSplitService) getRoster(matchId uint) ([]domain.TeamSplitPlayer, error) {
+	match := &domain.Match{}
+	if err := s.db.Preload("Registrations.Guests").First(match, matchId).Error; err != nil {
+		return nil, err
+	}
+
+	playerIds := []uint{}
+	for _, r := range match.Registrations {
+		if !r.IsConfirmed() {
+			continue
+		}
+
+		playerIds = append(playerIds, r.PlayerId)
+		for _, g := range r.Guests {
+			if g.PlayerId != nil {
+				playerIds = append(playerIds, *g.PlayerId)
+			}
+		}
+	}
+
+	var players []domain.Player
+	if err := s.db.Where("id IN ?", lo.Uniq(playerIds)).Order("id").Find(&players).Error; err != nil {
+		return nil, err
+	}
+
+	ratings, err := s.ratingService.GetRatings(lo.Map(players, func(p domain.Player, _ int) uint { return p.ID }))
+	if err != nil {
+		return nil, err
+	}
+
+	return lo.Map(players, func(p domain.Player, _ int) domain.TeamSplitPlayer {
+		return domain.TeamSplitPlayer{PlayerId: p.ID, Rating: ratings[p.ID], Gender: p.Gender}
+	}), nil
+}
+
+func (s *TeamSplitService) getPlayerSummaries(playerIds []uint) (func(uint) dto.PlayerSummaryDto, error) {
+	var players []domain.Player
+	if err := s.db.Where("id IN ?", playerIds).Find(&players).Error; err != nil {
+		return nil, err
+	}
+
+	byId := lo.KeyBy(players, func(p domain.Player) uint { return p.ID })
+	return func(id uint) dto.PlayerSummaryDto {
+		p := byId[id]
+		return dto.PlayerSummaryDto{PlayerId: id, FirstName: p.FirstName, LastName: p.LastName, Gender: p.Gender}
+	}, nil
+}
+
+func (s *TeamSplitService) deleteEventTeams(tx *gorm.DB, matchId uint) error {
+	teamIds := tx.Model(&domain.Team{}).Select("id").Where("match_id = ?", matchId)
+	if err := tx.Where("team_id IN (?)", teamIds).Delete(&domain.TeamMember{}).Error; err != nil {
+		return err
+	}
+
+	return tx.Where("match_id = ?", matchId).Delete(&domain.Team{}).Error
+}
@@ -0,0 +1,167 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/tructn/racket/internal/domain"
+	"github.com/tructn/racket/internal/dto"
+	"github.com/tructn/racket/pkg/result"
+	"github.com/tructn/racket/pkg/scopes"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// TenantService manages the memberships of users, it works across tenants so it is never scoped to one
+type TenantService struct {
+	db     *gorm.DB
+	logger *zap.SugaredLogger
+}
+
+func NewTenantService(db *gorm.DB, logger *zap.SugaredLogger) *TenantService {
+	return &TenantService{
+		db:     db.WithContext(scopes.AllTenants(context.Background())),
+		logger: logger,
+	}
+}
+
+// GetMine lists the tenants the user is a member of
+func (s *TenantService) GetMine(idpUserId string) ([]dto.TenantDto, error) {
+	tenants := []dto.TenantDto{}
+	err := s.db.
+		Model(&domain.Player{}).
+		Select("tenants.id, tenants.name, tenants.slug, players.id AS player_id").
+		Joins("JOIN tenants ON tenants.id = players.tenant_id").
+		Where("players.external_user_id = ?", idpUserId).
+		Order("tenants.name").
+		Scan(&tenants).Error
+
+	return tenants, err
+}
+
+// Create starts a new club or group, the user joins it with the profile of their existing player
+func (s *TenantService) Create(idpUserId string, req dto.CreateTenantDto) (*dto.TenantDto, error) {
+	account, err := s.getAccount("external_user_id = ?", idpUserId)
+	if err != nil {
+		return nil, err
+	}
+
+	tenant, err := domain.NewTenant(req.Name, strings.ToLower(strings.TrimSpace(req.Slug)), idpUserId)
+	if err != nil {
+		return nil, err
+	}
+
+	var member *domain.Player
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		var taken int64
+		if err := tx.Model(&domain.Tenant{}).Where("slug = ?", tenant.Slug).Count(&taken).Error; err != nil {
+			return err
+		}
+
+		if taken > 0 {
+			return domain.ErrTenantSlugTaken
+		}
+
+		if err := tx.Create(tenant).Error; err != nil {
+			return err
+		}
+
+		if member, err = tenant.NewMember(*account); err != nil {
+			return err
+		}
+
+		member.Role = domain.TenantRoleAdmin
+		return tx.Create(member).Error
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.TenantDto{
+		Id:       tenant.ID,
+		Name:     tenant.Name,
+		Slug:     tenant.Slug,
+		PlayerId: member.ID,
+	}, nil
+}
+
+// AddMember gives the account registered with the email a player in the tenant, with a balance of its own.
+// A player the tenant created for the same email without an account is linked instead so that its history is kept.
+// Only the admins of the tenant can add members.
+func (s *TenantService) AddMember(idpUserId string, hasIdpAdminRole bool, tenantId uint, req dto.AddTenantMemberDto) (*domain.Player, error) {
+	tenant := &domain.Tenant{}
+	if err := s.db.First(tenant, tenantId).Error; err != nil {
+		return nil, err
+	}
+
+	manager, err := s.getMember(idpUserId, tenantId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tenant.EnsureCanManageMembers(*manager, hasIdpAdminRole); err != nil {
+		return nil, err
+	}
+
+	account, err := s.getAccount("LOWER(email) = LOWER(?)", strings.TrimSpace(req.Email))
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.getMember(account.ExternalUserID, tenantId); err == nil {
+		return nil, domain.ErrAlreadyMember
+	} else if !errors.Is(err, domain.ErrNotTenantMember) {
+		return nil, err
+	}
+
+	var member *domain.Player
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		guest := &domain.Player{}
+		err := tx.
+			Where("tenant_id = ? AND external_user_id = '' AND LOWER(email) = LOWER(?)", tenantId, account.Email).
+			First(guest).Error
+		if err == nil {
+			member = guest
+			return tx.Model(member).Update("external_user_id", account.ExternalUserID).Error
+		}
+
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		if member, err = tenant.NewMember(*account); err != nil {
+			return err
+		}
+
+		return tx.Create(member).Error
+	})
+
+	if err != nil {
+		s.logger.Errorw("Failed to add tenant member", "tenantId", tenantId, "error", err)
+		return nil, err
+	}
+
+	return member, nil
+}
+
+// getMember finds the player of the user in the tenant
+func (s *TenantService) getMember(idpUserId string, tenantId uint) (*domain.Player, error) {
+	member := &domain.Player{}
+	err := s.db.Where("external_user_id = ? AND tenant_id = ?", idpUserId, tenantId).First(member).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, domain.ErrNotTenantMember
+	}
+	return member, err
+}
+
+// getAccount finds a player linked to an IdP account in any tenant, the oldest one holds the profile
+func (s *TenantService) getAccount(query string, args ...any) (*domain.Player, error) {
+	account := &domain.Player{}
+	err := s.db.Where("external_user_id <> ''").Where(query, args...).Order("id").First(account).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, result.ErrorNotFound
+	}
+	return account, err
+}
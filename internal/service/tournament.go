@@ -0,0 +1,471 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/samber/lo"
+	"github.com/tructn/racket/internal/domain"
+	"github.com/tructn/racket/internal/dto"
+	"github.com/tructn/racket/pkg/result"
+	"github.com/tructn/racket/pkg/scopes"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type TournamentService struct {
+	db            *gorm.DB
+	logger        *zap.SugaredLogger
+	ratingService *RatingService
+}
+
+func NewTournamentService(db *gorm.DB, logger *zap.SugaredLogger, ratingService *RatingService) *TournamentService {
+	return &TournamentService{
+		db:            db,
+		logger:        logger,
+		ratingService: ratingService,
+	}
+}
+
+func (s *TournamentService) WithContext(ctx context.Context) *TournamentService {
+	clone := *s
+	clone.db = s.db.WithContext(ctx)
+	clone.ratingService = s.ratingService.WithContext(ctx)
+	return &clone
+}
+
+func (s *TournamentService) GetAll() ([]dto.TournamentDto, error) {
+	var tournaments []domain.Tournament
+	if err := s.db.
+		Preload("Entries.Players").
+		Preload("Sessions").
+		Order("created_at DESC").
+		Find(&tournaments).Error; err != nil {
+		return nil, err
+	}
+
+	return s.toTournamentDtos(tournaments)
+}
+
+func (s *TournamentService) Get(id uint) (*dto.TournamentDto, error) {
+	tournament, err := s.getTournament(s.db, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.toTournamentDto(tournament)
+}
+
+func (s *TournamentService) Create(req dto.CreateTournamentDto) (*dto.TournamentDto, error) {
+	tournament, err := domain.NewTournament(req.Name, req.Format, req.EntryType, req.EntryFee, &domain.DefaultGenerator{})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tournament.SetScoring(toScoringFormat(req.Scoring)); err != nil {
+		return nil, err
+	}
+
+	if req.Format == domain.FormatGroupsKnockout {
+		if err := tournament.SetGroups(req.GroupCount, req.QualifiersPerGroup); err != nil {
+			return nil, err
+		}
+	}
+
+	if req.SlotMinutes > 0 {
+		tournament.SlotMinutes = req.SlotMinutes
+	}
+
+	if err := s.db.Create(tournament).Error; err != nil {
+		return nil, err
+	}
+
+	return s.toTournamentDto(tournament)
+}
+
+// AddEntry enters players, the entry fee is owed by every player of the entry until it is paid
+func (s *TournamentService) AddEntry(id uint, req dto.TournamentEntryRequestDto) (*dto.TournamentDto, error) {
+	tournament, err := s.getTournament(s.db, id)
+	if err != nil {
+		return nil, err
+	}
+
+	players, err := s.getPlayers(req.PlayerIds)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(players) != len(lo.Uniq(req.PlayerIds)) {
+		return nil, errors.New("player not found")
+	}
+
+	entry, err := tournament.AddEntry(req.Name, req.PlayerIds)
+	if err != nil {
+		return nil, err
+	}
+
+	if entry.Name == "" {
+		entry.Name = strings.Join(lo.Map(req.PlayerIds, func(id uint, _ int) string {
+			return fmt.Sprintf("%s %s", players[id].FirstName, players[id].LastName)
+		}), " & ")
+	}
+
+	if err := s.db.Create(entry).Error; err != nil {
+		return nil, err
+	}
+
+	return s.toTournamentDto(tournament)
+}
+
+func (s *TournamentService) RemoveEntry(id, entryId uint) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		tournament, err := s.getTournament(tx, id)
+		if err != nil {
+			return err
+		}
+
+		entry, err := tournament.RemoveEntry(entryId)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Where("entry_id = ?", entry.ID).Delete(&domain.TournamentEntryPlayer{}).Error; err != nil {
+			return err
+		}
+
+		return tx.Delete(entry).Error
+	})
+}
+
+// SetSessions chooses the match sessions whose courts the bracket is played on
+func (s *TournamentService) SetSessions(id uint, req dto.TournamentSessionsDto) (*dto.TournamentDto, error) {
+	tournament, err := s.getTournament(s.db, id)
+	if err != nil {
+		return nil, err
+	}
+
+	var sessions []domain.Match
+	if err := s.db.Scopes(MatchStateScope(nil)).Where("id IN ?", lo.Uniq(req.MatchIds)).Find(&sessions).Error; err != nil {
+		return nil, err
+	}
+
+	if len(sessions) != len(lo.Uniq(req.MatchIds)) {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	if err := s.db.Model(tournament).Association("Sessions").Replace(sessions); err != nil {
+		return nil, err
+	}
+
+	return s.Get(id)
+}
+
+// Draw seeds the entries from their current ratings and generates the bracket
+func (s *TournamentService) Draw(id uint) (*dto.BracketDto, error) {
+	var tournament *domain.Tournament
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		tournament, err = s.getTournament(tx, id)
+		if err != nil {
+			return err
+		}
+
+		ratings, err := s.ratingService.GetRatings(lo.FlatMap(tournament.Entries, func(e domain.TournamentEntry, _ int) []uint {
+			return e.PlayerIds()
+		}))
+		if err != nil {
+			return err
+		}
+
+		tournament.SeedEntries(ratings)
+		if err := tournament.GenerateBracket(); err != nil {
+			return err
+		}
+
+		return s.saveBracket(tx, tournament)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return s.toBracketDto(tournament), nil
+}
+
+// Schedule plans the remaining bracket matches on the courts of the tournament sessions
+func (s *TournamentService) Schedule(id uint) (*dto.BracketDto, error) {
+	var tournament *domain.Tournament
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		tournament, err = s.getTournament(tx, id)
+		if err != nil {
+			return err
+		}
+
+		if err := tournament.Schedule(); err != nil {
+			return err
+		}
+
+		return s.saveBracket(tx, tournament)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return s.toBracketDto(tournament), nil
+}
+
+// RecordResult advances the bracket, a match played during a session is also kept as a game of the session
+// so that it counts for the ratings and the player stats
+func (s *TournamentService) RecordResult(id, bracketMatchId uint, editedBy string, req dto.BracketResultDto) (*dto.BracketDto, error) {
+	scores := lo.Map(req.Sets, func(set dto.SetScoreDto, _ int) [2]int { return [2]int{set.A, set.B} })
+
+	var tournament *domain.Tournament
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		tournament, err = s.getTournament(tx, id)
+		if err != nil {
+			return err
+		}
+
+		m, err := tournament.RecordResult(bracketMatchId, scores)
+		if err != nil {
+			return err
+		}
+
+		if err := s.saveBracket(tx, tournament); err != nil {
+			return err
+		}
+
+		if m.SessionId == nil {
+			return nil
+		}
+
+		return s.recordGame(tx, tournament, m, editedBy, scores)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return s.toBracketDto(tournament), nil
+}
+
+func (s *TournamentService) GetBracket(id uint) (*dto.BracketDto, error) {
+	tournament, err := s.getTournament(s.db, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.toBracketDto(tournament), nil
+}
+
+// GetBracketByShareCode is the public view of the bracket, the share code is the credential
+func (s *TournamentService) GetBracketByShareCode(shareCode string) (*dto.BracketDto, error) {
+	tournament := &domain.Tournament{}
+	err := s.db.Select("id", "tenant_id").Where("share_code = ?", shareCode).First(tournament).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, result.ErrorNotFound
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	// Spectators have no tenant, the bracket is read in the tenant of the share code
+	return s.WithContext(scopes.WithTenant(s.db.Statement.Context, tournament.TenantId)).GetBracket(tournament.ID)
+}
+
+func (s *TournamentService) MarkEntryFeePaid(id, entryId, playerId uint) error {
+	res := s.db.
+		Model(&domain.TournamentEntryPlayer{}).
+		Where("entry_id = ? AND player_id = ?", entryId, playerId).
+		Where("entry_id IN (?)", s.db.Model(&domain.TournamentEntry{}).Select("id").Where("tournament_id = ?", id)).
+		Update("is_paid", true)
+
+	if res.Error != nil {
+		return res.Error
+	}
+
+	if res.RowsAffected == 0 {
+		return domain.ErrEntryNotFound
+	}
+	return nil
+}
+
+func (s *TournamentService) recordGame(tx *gorm.DB, tournament *domain.Tournament, m *domain.BracketMatch, editedBy string, scores [][2]int) error {
+	entries := lo.KeyBy(tournament.Entries, func(e domain.TournamentEntry) uint { return e.ID })
+	game, err := domain.NewGame(*m.SessionId, m.Court, tournament.Scoring)
+	if err != nil {
+		return err
+	}
+
+	if err := game.SetPlayers(entries[*m.EntryAId].PlayerIds(), entries[*m.EntryBId].PlayerIds()); err != nil {
+		return err
+	}
+
+	if err := game.RecordScores(scores); err != nil {
+		return err
+	}
+
+	game.Revise(editedBy)
+	if err := tx.Create(game).Error; err != nil {
+		return err
+	}
+
+	return s.ratingService.RecomputeFrom(tx, game.MatchId, game.ID)
+}
+
+func (s *TournamentService) saveBracket(tx *gorm.DB, tournament *domain.Tournament) error {
+	for i := range tournament.Entries {
+		if err := tx.Omit("Players").Save(&tournament.Entries[i]).Error; err != nil {
+			return err
+		}
+	}
+
+	for i := range tournament.BracketMatches {
+		tournament.BracketMatches[i].TournamentId = tournament.ID
+		if err := tx.Save(&tournament.BracketMatches[i]).Error; err != nil {
+			return err
+		}
+	}
+
+	return tx.Omit("Sessions", "Entries", "BracketMatches").Save(tournament).Error
+}
+
+func (s *TournamentService) getTournament(tx *gorm.DB, id uint) (*domain.Tournament, error) {
+	tournament := &domain.Tournament{}
+	err := tx.
+		Preload("Entries.Players").
+		Preload("BracketMatches", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+		Preload("Sessions.CourtBookings").
+		First(tournament, id).Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, result.ErrorNotFound
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(tournament.Entries, func(i, j int) bool { return tournament.Entries[i].ID < tournament.Entries[j].ID })
+	return tournament, nil
+}
+
+func (s *TournamentService) getPlayers(ids []uint) (map[uint]domain.Player, error) {
+	var players []domain.Player
+	if err := s.db.Where("id IN ?", lo.Uniq(ids)).Find(&players).Error; err != nil {
+		return nil, err
+	}
+	return lo.KeyBy(players, func(p domain.Player) uint { return p.ID }), nil
+}
+
+func (s *TournamentService) toTournamentDto(tournament *domain.Tournament) (*dto.TournamentDto, error) {
+	tournaments, err := s.toTournamentDtos([]domain.Tournament{*tournament})
+	if err != nil {
+		return nil, err
+	}
+	return &tournaments[0], nil
+}
+
+func (s *TournamentService) toTournamentDtos(tournaments []domain.Tournament) ([]dto.TournamentDto, error) {
+	players, err := s.getPlayers(lo.FlatMap(tournaments, func(t domain.Tournament, _ int) []uint {
+		return lo.FlatMap(t.Entries, func(e domain.TournamentEntry, _ int) []uint { return e.PlayerIds() })
+	}))
+	if err != nil {
+		return nil, err
+	}
+
+	return lo.Map(tournaments, func(t domain.Tournament, _ int) dto.TournamentDto {
+		return dto.TournamentDto{
+			Id:        t.ID,
+			Name:      t.Name,
+			Format:    t.Format,
+			EntryType: t.EntryType,
+			EntryFee:  t.EntryFee,
+			Scoring: dto.ScoringFormatDto{
+				Points: t.Scoring.Points,
+				WinBy:  t.Scoring.WinBy,
+				Cap:    t.Scoring.Cap,
+				BestOf: t.Scoring.BestOf,
+			},
+			GroupCount:         t.GroupCount,
+			QualifiersPerGroup: t.QualifiersPerGroup,
+			SlotMinutes:        t.SlotMinutes,
+			State:              t.State,
+			ShareCode:          t.ShareCode,
+			WinnerEntryId:      t.WinnerEntryId,
+			SessionIds:         lo.Map(t.Sessions, func(m domain.Match, _ int) uint { return m.ID }),
+			Entries: lo.Map(t.Entries, func(e domain.TournamentEntry, _ int) dto.TournamentEntryDto {
+				return dto.TournamentEntryDto{
+					Id:     e.ID,
+					Name:   e.Name,
+					Seed:   e.Seed,
+					Rating: e.Rating,
+					Group:  e.Group,
+					Players: lo.Map(e.Players, func(p domain.TournamentEntryPlayer, _ int) dto.TournamentEntryPlayerDto {
+						return dto.TournamentEntryPlayerDto{
+							PlayerId:  p.PlayerId,
+							FirstName: players[p.PlayerId].FirstName,
+							LastName:  players[p.PlayerId].LastName,
+							IsPaid:    p.IsPaid,
+						}
+					}),
+				}
+			}),
+		}
+	}), nil
+}
+
+func (s *TournamentService) toBracketDto(tournament *domain.Tournament) *dto.BracketDto {
+	entries := append([]domain.TournamentEntry{}, tournament.Entries...)
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Seed < entries[j].Seed })
+
+	return &dto.BracketDto{
+		TournamentId:  tournament.ID,
+		Name:          tournament.Name,
+		Format:        tournament.Format,
+		State:         tournament.State,
+		WinnerEntryId: tournament.WinnerEntryId,
+		Entries: lo.Map(entries, func(e domain.TournamentEntry, _ int) dto.BracketEntryDto {
+			return dto.BracketEntryDto{Id: e.ID, Name: e.Name, Seed: e.Seed, Group: e.Group}
+		}),
+		Matches: lo.Map(tournament.BracketMatches, func(m domain.BracketMatch, _ int) dto.BracketMatchDto {
+			return dto.BracketMatchDto{
+				Id:            m.ID,
+				Code:          m.Code,
+				Stage:         m.Stage,
+				Group:         m.Group,
+				Round:         m.Round,
+				Position:      m.Position,
+				EntryAId:      m.EntryAId,
+				EntryBId:      m.EntryBId,
+				WinnerId:      m.WinnerId,
+				IsFinished:    m.IsFinished,
+				IsBye:         m.IsBye,
+				Sets:          lo.Map(m.Sets, func(set [2]int, _ int) dto.SetScoreDto { return dto.SetScoreDto{A: set[0], B: set[1]} }),
+				NextCode:      m.NextCode,
+				LoserNextCode: m.LoserNextCode,
+				SessionId:     m.SessionId,
+				Court:         m.Court,
+				StartAt:       m.StartAt,
+			}
+		}),
+		Standings: lo.Map(tournament.GroupStandings(), func(gs domain.GroupStanding, _ int) dto.GroupStandingDto {
+			return dto.GroupStandingDto{
+				EntryId:       gs.EntryId,
+				Group:         gs.Group,
+				Rank:          gs.Rank,
+				Played:        gs.Played,
+				Won:           gs.Won,
+				SetsFor:       gs.SetsFor,
+				SetsAgainst:   gs.SetsAgainst,
+				PointsFor:     gs.PointsFor,
+				PointsAgainst: gs.PointsAgainst,
+			}
+		}),
+	}
+}
@@ -1,6 +1,8 @@
 package service
 
 import (
+	"context"
+
 	"github.com/tructn/racket/internal/domain"
 	"github.com/tructn/racket/pkg/auth0"
 	"gorm.io/gorm"
@@ -14,6 +16,12 @@ func NewUserService(db *gorm.DB) *UserService {
 	return &UserService{db: db}
 }
 
+func (s *UserService) WithContext(ctx context.Context) *UserService {
+	clone := *s
+	clone.db = s.db.WithContext(ctx)
+	return &clone
+}
+
 // Users should be sync via Auth0 hook API
 // This function is used to sync users from Auth0 manually
 // This will create player profile
@@ -16,11 +16,21 @@ import (
 	"github.com/tructn/racket/internal/di"
 	"github.com/tructn/racket/internal/feature/wallet"
 	"github.com/tructn/racket/internal/handler"
+	"github.com/tructn/racket/internal/service"
 	"github.com/tructn/racket/pkg/middleware"
+	"gorm.io/gorm"
 )
 
 func main() {
 	godotenv.Load(".env")
+
+	if len(os.Args) > 1 {
+		if err := runCommand(os.Args[1:]); err != nil {
+			log.Fatalln(err)
+		}
+		return
+	}
+
 	PORT := os.Getenv("PORT")
 
 	if PORT == "" {
@@ -54,8 +64,16 @@ func main() {
 		anonymousHandler.UseRouter(anonymousApi)
 	})
 
-	api := router.Group("/api/v1")
-	api.Use(middleware.AuthRequired())
+	authApi := router.Group("/api/v1")
+	authApi.Use(middleware.AuthRequired())
+	reg.Invoke(func(tenantHandler *handler.TenantHandler) {
+		tenantHandler.UseRouter(authApi)
+	})
+
+	api := authApi.Group("")
+	reg.Invoke(func(db *gorm.DB) {
+		api.Use(middleware.TenantRequired(db))
+	})
 
 	reg.Invoke(func(handler *handler.MatchHandler) {
 		api.POST("/matches", handler.Create)
@@ -66,11 +84,20 @@ func main() {
 		api.GET("/upcoming-matches", handler.GetUpcomingMatches)
 		api.GET("/matches/:matchId/registrations", handler.GetRegistrationsByMatch)
 		api.GET("/matches/:matchId/cost", handler.GetCost)
+		api.GET("/matches/:matchId/cost-breakdown", handler.GetCostBreakdown)
+		api.GET("/matches/:matchId/roster", handler.GetRoster)
 		api.POST("/matches/:matchId/clone", handler.Clone)
+		api.POST("/matches/:matchId/series", handler.CreateSeries)
 		api.GET("/matches/:matchId/additional-costs", handler.GetAdditionalCost)
 		api.PUT("/matches/:matchId", handler.UpdateMatch)
 		api.PUT("/matches/:matchId/costs", handler.UpdateCost)
 		api.PUT("/matches/:matchId/additional-costs", handler.CreateAdditionalCost)
+		api.PUT("/matches/:matchId/state", handler.ChangeState)
+		api.PUT("/matches/:matchId/cost-split", handler.ChangeCostSplit)
+		api.PUT("/matches/:matchId/visibility", handler.ChangeVisibility)
+		api.GET("/matches/:matchId/invitations", handler.GetInvitations)
+		api.POST("/matches/:matchId/invitations", handler.Invite)
+		api.DELETE("/matches/:matchId/invitations/:invitationId", handler.RemoveInvitation)
 		api.DELETE("/matches/:matchId", handler.Delete)
 	})
 
@@ -88,11 +115,25 @@ func main() {
 		api.GET("/sportcenters/options", handler.GetOptions)
 		api.POST("/sportcenters", handler.Create)
 		api.PUT("/sportcenters/:sportCenterId", handler.Update)
+		api.GET("/sportcenters/:sportCenterId/pricing-rules", handler.GetPricingRules)
+		api.PUT("/sportcenters/:sportCenterId/pricing-rules", handler.UpdatePricingRules)
+		api.POST("/sportcenters/:sportCenterId/pricing-quote", handler.QuotePrice)
+		api.GET("/sportcenters/:sportCenterId/prices", handler.GetPriceHistory)
+		api.POST("/sportcenters/:sportCenterId/prices", handler.ChangePrice)
+		api.GET("/sportcenters/:sportCenterId/recalculation", handler.PreviewRecalculation)
+		api.POST("/sportcenters/:sportCenterId/recalculation", handler.ApplyRecalculation)
+		api.GET("/sportcenters/:sportCenterId/opening-hours", handler.GetOpeningHours)
+		api.PUT("/sportcenters/:sportCenterId/opening-hours", handler.UpdateOpeningHours)
+		api.GET("/sportcenters/:sportCenterId/closures", handler.GetClosures)
+		api.POST("/sportcenters/:sportCenterId/closures", handler.AddClosure)
+		api.DELETE("/sportcenters/:sportCenterId/closures/:closureId", handler.DeleteClosure)
 	})
 
 	reg.Invoke(func(handler *handler.SettingsHandler) {
 		api.GET("/settings/message-template", handler.GetMessageTemplate)
 		api.POST("/settings/message-template", handler.CreateMessageTemplate)
+		api.GET("/settings/no-show-policy", handler.GetNoShowPolicy)
+		api.PUT("/settings/no-show-policy", handler.UpdateNoShowPolicy)
 	})
 
 	reg.Invoke(func(handler *handler.ActivityHandler) {
@@ -112,6 +153,19 @@ func main() {
 		meHandler *handler.MeHandler,
 		reportHandler *handler.ReportHandler,
 		walletHandler *wallet.WalletHandler,
+		calendarHandler *handler.CalendarHandler,
+		bookingImportHandler *handler.BookingImportHandler,
+		attendanceHandler *handler.AttendanceHandler,
+		rotationHandler *handler.RotationHandler,
+		gameHandler *handler.GameHandler,
+		ratingHandler *handler.RatingHandler,
+		teamSplitHandler *handler.TeamSplitHandler,
+		tournamentHandler *handler.TournamentHandler,
+		leagueHandler *handler.LeagueHandler,
+		ladderHandler *handler.LadderHandler,
+		pollHandler *handler.PollHandler,
+		standingRegistrationHandler *handler.StandingRegistrationHandler,
+		ballotHandler *handler.BallotHandler,
 	) {
 		registrationHandler.UseRouter(api)
 		teamHandler.UseRouter(api)
@@ -119,6 +173,19 @@ func main() {
 		meHandler.UseRouter(api)
 		reportHandler.UseRouter(api)
 		walletHandler.UseRouter(api)
+		calendarHandler.UseRouter(api)
+		bookingImportHandler.UseRouter(api)
+		attendanceHandler.UseRouter(api)
+		rotationHandler.UseRouter(api)
+		gameHandler.UseRouter(api)
+		ratingHandler.UseRouter(api)
+		teamSplitHandler.UseRouter(api)
+		tournamentHandler.UseRouter(api)
+		leagueHandler.UseRouter(api)
+		ladderHandler.UseRouter(api)
+		pollHandler.UseRouter(api)
+		standingRegistrationHandler.UseRouter(api)
+		ballotHandler.UseRouter(api)
 	})
 
 	server := &http.Server{
@@ -126,6 +193,13 @@ func main() {
 		Handler: router.Handler(),
 	}
 
+	// Ballot draws and rsvp reminders are due at a given time, they run in the background until shutdown
+	schedulerCtx, stopScheduler := context.WithCancel(context.Background())
+	defer stopScheduler()
+	reg.Invoke(func(scheduler *service.SchedulerService) {
+		go scheduler.Run(schedulerCtx)
+	})
+
 	// Serve HTTP server in goroutine
 	go func() {
 		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
@@ -147,6 +221,7 @@ func main() {
 	// Block until signal is receveid
 	<-quit
 	log.Println("Shutdown Server ...")
+	stopScheduler()
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -2,16 +2,20 @@ package auth0
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
 	"strings"
+	"sync"
 
 	"github.com/joho/godotenv"
 )
 
 var (
+	configOnce sync.Once
+
 	AUTH0_DOMAIN        string
 	AUTH0_CLIENT_ID     string
 	AUTH0_CLIENT_SECRET string
@@ -29,27 +33,35 @@ type Auth0User struct {
 	LoginsCount int    `json:"logins_count"`
 }
 
-func init() {
-	godotenv.Load(".env")
+// loadConfig reads the management API credentials on first use so that packages importing auth0 can be tested without them
+func loadConfig() error {
+	configOnce.Do(func() {
+		godotenv.Load(".env")
 
-	AUTH0_DOMAIN = os.Getenv("AUTH0_DOMAIN")
-	AUTH0_CLIENT_ID = os.Getenv("AUTH0_CLIENT_ID")
-	AUTH0_CLIENT_SECRET = os.Getenv("AUTH0_CLIENT_SECRET")
+		AUTH0_DOMAIN = os.Getenv("AUTH0_DOMAIN")
+		AUTH0_CLIENT_ID = os.Getenv("AUTH0_CLIENT_ID")
+		AUTH0_CLIENT_SECRET = os.Getenv("AUTH0_CLIENT_SECRET")
+	})
 
 	if AUTH0_DOMAIN == "" {
-		panic("AUTH0_DOMAIN is not set")
+		return errors.New("AUTH0_DOMAIN is not set")
 	}
 
 	if AUTH0_CLIENT_ID == "" {
-		panic("AUTH0_CLIENT_ID is not set")
+		return errors.New("AUTH0_CLIENT_ID is not set")
 	}
 
 	if AUTH0_CLIENT_SECRET == "" {
-		panic("AUTH0_CLIENT_SECRET is not set")
+		return errors.New("AUTH0_CLIENT_SECRET is not set")
 	}
+
+	return nil
 }
 
 func GetAuth0Users() ([]Auth0User, error) {
+	if err := loadConfig(); err != nil {
+		return nil, err
+	}
 
 	token, err := getAuth0AccessToken(AUTH0_DOMAIN, AUTH0_CLIENT_ID, AUTH0_CLIENT_SECRET)
 
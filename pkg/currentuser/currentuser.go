@@ -38,7 +38,7 @@ func GetIdpUserRoles(c *gin.Context) ([]string, error) {
 		return []string{}, fmt.Errorf("user not found in context")
 	}
 
-	roles, ok := user.(map[string]interface{})["https://auth.tn.co.uk/roles"].([]string)
+	roles, ok := user.([]string)
 	if !ok {
 		return []string{}, fmt.Errorf("invalid user roles in claims")
 	}
@@ -52,8 +52,9 @@ func GetPlayerId(c *gin.Context, db *gorm.DB) (uint, error) {
 		return 0, err
 	}
 
+	// Players are scoped to the tenant of the request, a user has a player per tenant
 	var playerId uint
-	if err := db.Model(&domain.Player{}).Where("external_user_id = ?", idpUserId).Select("id").First(&playerId).Error; err != nil {
+	if err := db.WithContext(c).Model(&domain.Player{}).Where("external_user_id = ?", idpUserId).Select("id").First(&playerId).Error; err != nil {
 		return 0, err
 	}
 
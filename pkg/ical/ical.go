@@ -0,0 +1,110 @@
+package ical
+
+import (
+	"strings"
+	"time"
+)
+
+const (
+	StatusConfirmed = "CONFIRMED"
+	StatusCancelled = "CANCELLED"
+
+	dateTimeFormat = "20060102T150405Z"
+	maxLineLength  = 75
+)
+
+// Event leaves SEQUENCE out, it is a revision counter and subscribers of a published feed
+// pick up changes from LAST-MODIFIED instead
+type Event struct {
+	UID          string
+	Start        time.Time
+	End          time.Time
+	Summary      string
+	Location     string
+	Description  string
+	Status       string
+	LastModified time.Time
+}
+
+type Calendar struct {
+	Name   string
+	Events []Event
+}
+
+// Render writes the calendar in RFC 5545 format, ready to be served as text/calendar.
+func (cal *Calendar) Render() string {
+	var sb strings.Builder
+
+	writeLine(&sb, "BEGIN:VCALENDAR")
+	writeLine(&sb, "VERSION:2.0")
+	writeLine(&sb, "PRODID:-//racket//calendar//EN")
+	writeLine(&sb, "CALSCALE:GREGORIAN")
+	writeLine(&sb, "METHOD:PUBLISH")
+	if cal.Name != "" {
+		writeLine(&sb, "X-WR-CALNAME:"+escapeText(cal.Name))
+	}
+
+	for _, ev := range cal.Events {
+		status := ev.Status
+		if status == "" {
+			status = StatusConfirmed
+		}
+
+		writeLine(&sb, "BEGIN:VEVENT")
+		writeLine(&sb, "UID:"+ev.UID)
+		writeLine(&sb, "DTSTAMP:"+formatTime(ev.LastModified))
+		writeLine(&sb, "LAST-MODIFIED:"+formatTime(ev.LastModified))
+		writeLine(&sb, "DTSTART:"+formatTime(ev.Start))
+		writeLine(&sb, "DTEND:"+formatTime(ev.End))
+		writeLine(&sb, "SUMMARY:"+escapeText(ev.Summary))
+		if ev.Location != "" {
+			writeLine(&sb, "LOCATION:"+escapeText(ev.Location))
+		}
+		if ev.Description != "" {
+			writeLine(&sb, "DESCRIPTION:"+escapeText(ev.Description))
+		}
+		writeLine(&sb, "STATUS:"+status)
+		writeLine(&sb, "END:VEVENT")
+	}
+
+	writeLine(&sb, "END:VCALENDAR")
+	return sb.String()
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(dateTimeFormat)
+}
+
+func escapeText(s string) string {
+	r := strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+	)
+	return r.Replace(s)
+}
+
+// writeLine folds content lines longer than 75 octets as required by the spec
+func writeLine(sb *strings.Builder, line string) {
+	limit := maxLineLength
+	for len(line) > limit {
+		cut := limit
+		// do not split a multi-byte rune
+		for cut > 0 && !isRuneStart(line[cut]) {
+			cut--
+		}
+		sb.WriteString(line[:cut])
+		sb.WriteString("\r\n ")
+		line = line[cut:]
+		// continuation lines start with a space which counts towards the limit
+		limit = maxLineLength - 1
+	}
+	sb.WriteString(line)
+	sb.WriteString("\r\n")
+}
+
+func isRuneStart(b byte) bool {
+	return b&0xC0 != 0x80
+}
@@ -0,0 +1,64 @@
+package ical
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEscapeText(t *testing.T) {
+	assert.Equal(t, `Court 1\, 2\; back\\side\nupstairs\nlift`, escapeText("Court 1, 2; back\\side\r\nupstairs\nlift"))
+}
+
+func TestWriteLineFolds(t *testing.T) {
+	var sb strings.Builder
+	line := "DESCRIPTION:" + strings.Repeat("a", 200)
+	writeLine(&sb, line)
+
+	folded := strings.Split(strings.TrimSuffix(sb.String(), "\r\n"), "\r\n")
+	assert.Len(t, folded[0], maxLineLength)
+	for _, l := range folded[1:] {
+		assert.True(t, strings.HasPrefix(l, " "))
+		assert.LessOrEqual(t, len(l), maxLineLength)
+	}
+
+	unfolded := strings.ReplaceAll(strings.TrimSuffix(sb.String(), "\r\n"), "\r\n ", "")
+	assert.Equal(t, line, unfolded)
+}
+
+func TestWriteLineKeepsRunes(t *testing.T) {
+	var sb strings.Builder
+	line := "SUMMARY:" + strings.Repeat("é", 60)
+	writeLine(&sb, line)
+
+	for _, l := range strings.Split(strings.TrimSuffix(sb.String(), "\r\n"), "\r\n") {
+		assert.LessOrEqual(t, len(l), maxLineLength)
+		assert.True(t, isRuneStart(strings.TrimPrefix(l, " ")[0]))
+	}
+	assert.Equal(t, line, strings.ReplaceAll(strings.TrimSuffix(sb.String(), "\r\n"), "\r\n ", ""))
+}
+
+func TestRender(t *testing.T) {
+	start := time.Date(2024, 6, 4, 18, 0, 0, 0, time.UTC)
+	cal := &Calendar{
+		Name: "Racket, my matches",
+		Events: []Event{{
+			UID:          "match-1@racket",
+			Start:        start,
+			End:          start.Add(2 * time.Hour),
+			Summary:      "Badminton",
+			Status:       StatusCancelled,
+			LastModified: start.Add(-time.Hour),
+		}},
+	}
+
+	out := cal.Render()
+	assert.Contains(t, out, "X-WR-CALNAME:Racket\\, my matches\r\n")
+	assert.Contains(t, out, "DTSTART:20240604T180000Z\r\n")
+	assert.Contains(t, out, "LAST-MODIFIED:20240604T170000Z\r\n")
+	assert.Contains(t, out, "STATUS:CANCELLED\r\n")
+	assert.NotContains(t, out, "SEQUENCE")
+	assert.NotContains(t, out, "LOCATION")
+}
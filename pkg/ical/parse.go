@@ -0,0 +1,149 @@
+package ical
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+const (
+	localDateTimeFormat = "20060102T150405"
+	dateFormat          = "20060102"
+)
+
+// Parse reads the VEVENT components of an RFC 5545 calendar.
+// Times without an explicit zone (floating times) or with an unknown TZID are read in defaultLoc.
+func Parse(r io.Reader, defaultLoc *time.Location) ([]Event, error) {
+	if defaultLoc == nil {
+		defaultLoc = time.UTC
+	}
+
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		events  []Event
+		current *Event
+	)
+
+	for i, line := range lines {
+		name, params, value, ok := splitLine(line)
+		if !ok {
+			continue
+		}
+
+		switch name {
+		case "BEGIN":
+			if strings.EqualFold(value, "VEVENT") {
+				current = &Event{}
+			}
+			continue
+		case "END":
+			if strings.EqualFold(value, "VEVENT") && current != nil {
+				events = append(events, *current)
+				current = nil
+			}
+			continue
+		}
+
+		if current == nil {
+			continue
+		}
+
+		switch name {
+		case "UID":
+			current.UID = value
+		case "SUMMARY":
+			current.Summary = unescapeText(value)
+		case "LOCATION":
+			current.Location = unescapeText(value)
+		case "DESCRIPTION":
+			current.Description = unescapeText(value)
+		case "STATUS":
+			current.Status = strings.ToUpper(value)
+		case "DTSTART", "DTEND":
+			t, err := parseTime(value, params["TZID"], defaultLoc)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", i+1, err)
+			}
+			if name == "DTSTART" {
+				current.Start = t
+			} else {
+				current.End = t
+			}
+		}
+	}
+
+	if current != nil {
+		return nil, errors.New("unterminated VEVENT")
+	}
+
+	return events, nil
+}
+
+func unfold(r io.Reader) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines, scanner.Err()
+}
+
+func splitLine(line string) (string, map[string]string, string, bool) {
+	colon := strings.Index(line, ":")
+	if colon < 0 {
+		return "", nil, "", false
+	}
+
+	head, value := line[:colon], line[colon+1:]
+	parts := strings.Split(head, ";")
+	params := map[string]string{}
+	for _, p := range parts[1:] {
+		if kv := strings.SplitN(p, "=", 2); len(kv) == 2 {
+			params[strings.ToUpper(kv[0])] = strings.Trim(kv[1], `"`)
+		}
+	}
+
+	return strings.ToUpper(parts[0]), params, value, true
+}
+
+func parseTime(value, tzid string, defaultLoc *time.Location) (time.Time, error) {
+	if strings.HasSuffix(value, "Z") {
+		return time.Parse(dateTimeFormat, value)
+	}
+
+	loc := defaultLoc
+	if tzid != "" {
+		if l, err := time.LoadLocation(tzid); err == nil {
+			loc = l
+		}
+	}
+
+	if len(value) == len(dateFormat) {
+		return time.ParseInLocation(dateFormat, value, loc)
+	}
+
+	return time.ParseInLocation(localDateTimeFormat, value, loc)
+}
+
+func unescapeText(s string) string {
+	r := strings.NewReplacer(
+		`\n`, "\n",
+		`\N`, "\n",
+		`\,`, ",",
+		`\;`, ";",
+		`\\`, `\`,
+	)
+	return r.Replace(s)
+}
@@ -0,0 +1,79 @@
+package ical
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	london, _ := time.LoadLocation("Europe/London")
+	newYork, _ := time.LoadLocation("America/New_York")
+	content := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"BEGIN:VEVENT",
+		"UID:utc",
+		"SUMMARY:Badminton - Court 1",
+		"LOCATION:Sports Hall\\, High Street",
+		"DTSTART:20240501T180000Z",
+		"DTEND:20240501T200000Z",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:tzid",
+		"DTSTART;TZID=America/New_York:20240501T190000",
+		"DTEND;TZID=\"America/New_York\":20240501T210000",
+		"STATUS:cancelled",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:floating",
+		"DTSTART:20240501T190000",
+		"DTEND;TZID=Unknown/Zone:20240501T210000",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:all-day",
+		"DESCRIPTION:Tournament\\nall day",
+		"DTSTART;VALUE=DATE:20240501",
+		"DTEND;VALUE=DATE:20240502",
+		"END:VEVENT",
+		"END:VCALENDAR",
+	}, "\r\n")
+
+	events, err := Parse(strings.NewReader(content), london)
+	assert.Nil(t, err)
+	assert.Len(t, events, 4)
+
+	assert.Equal(t, "Badminton - Court 1", events[0].Summary)
+	assert.Equal(t, "Sports Hall, High Street", events[0].Location)
+	assert.True(t, time.Date(2024, 5, 1, 18, 0, 0, 0, time.UTC).Equal(events[0].Start))
+	assert.True(t, time.Date(2024, 5, 1, 20, 0, 0, 0, time.UTC).Equal(events[0].End))
+
+	assert.True(t, time.Date(2024, 5, 1, 19, 0, 0, 0, newYork).Equal(events[1].Start))
+	assert.True(t, time.Date(2024, 5, 1, 21, 0, 0, 0, newYork).Equal(events[1].End))
+	assert.Equal(t, StatusCancelled, events[1].Status)
+
+	// Floating times and unknown zones are read in the default location
+	assert.True(t, time.Date(2024, 5, 1, 19, 0, 0, 0, london).Equal(events[2].Start))
+	assert.True(t, time.Date(2024, 5, 1, 21, 0, 0, 0, london).Equal(events[2].End))
+
+	assert.Equal(t, "Tournament\nall day", events[3].Description)
+	assert.True(t, time.Date(2024, 5, 1, 0, 0, 0, 0, london).Equal(events[3].Start))
+	assert.True(t, time.Date(2024, 5, 2, 0, 0, 0, 0, london).Equal(events[3].End))
+}
+
+func TestParseUnfoldsLines(t *testing.T) {
+	content := "BEGIN:VEVENT\r\nSUMMARY:Badminton\r\n  - Court 2\r\nEND:VEVENT\r\n"
+	events, err := Parse(strings.NewReader(content), nil)
+	assert.Nil(t, err)
+	assert.Len(t, events, 1)
+	assert.Equal(t, "Badminton - Court 2", events[0].Summary)
+}
+
+func TestParseErrors(t *testing.T) {
+	_, err := Parse(strings.NewReader("BEGIN:VEVENT\r\nSUMMARY:Badminton\r\n"), nil)
+	assert.Error(t, err)
+
+	_, err = Parse(strings.NewReader("BEGIN:VEVENT\r\nDTSTART:tomorrow\r\nEND:VEVENT\r\n"), nil)
+	assert.Error(t, err)
+}
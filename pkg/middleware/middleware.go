@@ -13,6 +13,7 @@ import (
 	"github.com/auth0/go-jwt-middleware/v2/jwks"
 	"github.com/auth0/go-jwt-middleware/v2/validator"
 	"github.com/gin-gonic/gin"
+	"github.com/tructn/racket/internal/domain"
 )
 
 type CustomClaims struct {
@@ -44,6 +45,17 @@ func (c CustomClaims) IsAdmin() bool {
 	return false
 }
 
+// AdminRequired only lets the admins of the tenant of the request through, it must run after TenantRequired
+func AdminRequired() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !c.GetBool(domain.TenantAdminContextKey) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "admin role is required"})
+			return
+		}
+		c.Next()
+	}
+}
+
 func AuthRequired() gin.HandlerFunc {
 	domain := os.Getenv("AUTH0_DOMAIN")
 	audience := os.Getenv("AUTH0_AUDIENCE")
@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/tructn/racket/internal/domain"
+)
+
+func TestAdminRequired(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name     string
+		isAdmin  any
+		expected int
+	}{
+		{name: "Tenant admin", isAdmin: true, expected: http.StatusOK},
+		{name: "Member", isAdmin: false, expected: http.StatusForbidden},
+		{name: "No tenant", isAdmin: nil, expected: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.GET("/code", func(c *gin.Context) {
+				if tt.isAdmin != nil {
+					c.Set(domain.TenantAdminContextKey, tt.isAdmin)
+				}
+				c.Next()
+			}, AdminRequired(), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/code", nil))
+			assert.Equal(t, tt.expected, w.Code)
+		})
+	}
+}
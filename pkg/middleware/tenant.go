@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/samber/lo"
+	"github.com/tructn/racket/internal/domain"
+	"github.com/tructn/racket/pkg/currentuser"
+	"github.com/tructn/racket/pkg/scopes"
+	"gorm.io/gorm"
+)
+
+// TenantHeader selects the club or group of the request, members of a single tenant can leave it out
+const TenantHeader = "X-Tenant-Id"
+
+// TenantRequired resolves the tenant of the request from the memberships of the authenticated user,
+// the queries made with the gin context are then scoped to it and the membership decides whether
+// the user administers the tenant. It must run after AuthRequired.
+func TenantRequired(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		idpUserId, err := currentuser.GetIdpUserId(c)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+
+		var requested uint64
+		if header := c.GetHeader(TenantHeader); header != "" {
+			if requested, err = strconv.ParseUint(header, 10, 64); err != nil {
+				c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "invalid tenant"})
+				return
+			}
+		}
+
+		var members []domain.Player
+		if err := db.WithContext(scopes.AllTenants(c)).Where("external_user_id = ?", idpUserId).Find(&members).Error; err != nil {
+			c.AbortWithError(http.StatusInternalServerError, err)
+			return
+		}
+
+		memberships := lo.Uniq(lo.Map(members, func(p domain.Player, _ int) uint { return p.TenantId }))
+		tenantId, err := domain.ResolveTenant(uint(requested), memberships)
+		if errors.Is(err, domain.ErrTenantRequired) {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+
+		tenant := domain.Tenant{}
+		if err := db.First(&tenant, tenantId).Error; err != nil {
+			c.AbortWithError(http.StatusInternalServerError, err)
+			return
+		}
+
+		roles, _ := currentuser.GetIdpUserRoles(c)
+		member, _ := lo.Find(members, func(p domain.Player) bool { return p.TenantId == tenantId })
+
+		c.Set(domain.TenantContextKey, tenantId)
+		c.Set(domain.TenantAdminContextKey, tenant.IsAdmin(member, CustomClaims{Roles: roles}.IsAdmin()))
+		c.Next()
+	}
+}
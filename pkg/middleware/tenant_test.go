@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tructn/racket/internal/db/dbtest"
+	"github.com/tructn/racket/internal/domain"
+)
+
+func TestTenantRequiredDecidesAdminPerTenant(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := dbtest.Open(t)
+
+	club := &domain.Tenant{Name: "Club", Slug: "club"}
+	other := &domain.Tenant{Name: "Other", Slug: "other"}
+	require.NoError(t, db.Create([]*domain.Tenant{club, other}).Error)
+	require.NoError(t, db.Create([]*domain.Player{
+		{BaseModel: domain.BaseModel{TenantId: club.ID}, ExternalUserID: "auth0|jane", Role: domain.TenantRoleAdmin},
+		{BaseModel: domain.BaseModel{TenantId: other.ID}, ExternalUserID: "auth0|jane"},
+	}).Error)
+
+	request := func(tenantId uint) (int, bool) {
+		var isAdmin bool
+		router := gin.New()
+		router.GET("/", func(c *gin.Context) {
+			c.Set("idp_user_id", "auth0|jane")
+			c.Set("idp_user_roles", []string{"admin"})
+		}, TenantRequired(db), func(c *gin.Context) {
+			isAdmin = c.GetBool(domain.TenantAdminContextKey)
+			c.Status(http.StatusOK)
+		})
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(TenantHeader, fmt.Sprint(tenantId))
+		router.ServeHTTP(w, req)
+		return w.Code, isAdmin
+	}
+
+	code, isAdmin := request(club.ID)
+	require.Equal(t, http.StatusOK, code)
+	assert.True(t, isAdmin)
+
+	code, isAdmin = request(other.ID)
+	require.Equal(t, http.StatusOK, code)
+	assert.False(t, isAdmin)
+
+	code, _ = request(other.ID + 100)
+	assert.Equal(t, http.StatusForbidden, code)
+}
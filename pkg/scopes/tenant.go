@@ -0,0 +1,74 @@
+package scopes
+
+import (
+	"context"
+	"errors"
+
+	"github.com/tructn/racket/internal/domain"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrNoTenant fails statements on tenant entities run without a tenant, so that a missing tenant
+// does not expose the data of every tenant
+var ErrNoTenant = errors.New("tenant entities are queried without a tenant")
+
+type allTenantsKey struct{}
+
+// WithTenant scopes the queries run with the returned context to the tenant
+func WithTenant(ctx context.Context, tenantId uint) context.Context {
+	return context.WithValue(ctx, domain.TenantContextKey, tenantId)
+}
+
+// AllTenants lets the statements run with the returned context see every tenant, for migrations and
+// lookups that find the tenant of a share code, a token or a user. A tenant set later still scopes them.
+func AllTenants(ctx context.Context) context.Context {
+	return context.WithValue(ctx, allTenantsKey{}, true)
+}
+
+// GetTenantId returns the tenant of the context, raw SQL is not scoped and has to filter by it explicitly
+func GetTenantId(ctx context.Context) (uint, bool) {
+	tenantId, ok := ctx.Value(domain.TenantContextKey).(uint)
+	return tenantId, ok && tenantId > 0
+}
+
+// RegisterTenantScope filters the queries, updates and deletes of tenant entities by the tenant of the
+// statement context. Statements without a tenant fail with ErrNoTenant unless the context is AllTenants.
+func RegisterTenantScope(db *gorm.DB) error {
+	if err := db.Callback().Query().Before("gorm:query").Register("tenant:query", tenantScope); err != nil {
+		return err
+	}
+
+	if err := db.Callback().Row().Before("gorm:row").Register("tenant:row", tenantScope); err != nil {
+		return err
+	}
+
+	if err := db.Callback().Update().Before("gorm:update").Register("tenant:update", tenantScope); err != nil {
+		return err
+	}
+
+	return db.Callback().Delete().Before("gorm:delete").Register("tenant:delete", tenantScope)
+}
+
+func tenantScope(db *gorm.DB) {
+	if db.Statement.Schema == nil {
+		return
+	}
+
+	field := db.Statement.Schema.LookUpField("TenantId")
+	if field == nil {
+		return
+	}
+
+	tenantId, ok := GetTenantId(db.Statement.Context)
+	if !ok {
+		if allTenants, _ := db.Statement.Context.Value(allTenantsKey{}).(bool); !allTenants {
+			db.AddError(ErrNoTenant)
+		}
+		return
+	}
+
+	db.Statement.AddClause(clause.Where{Exprs: []clause.Expression{
+		clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: field.DBName}, Value: tenantId},
+	}})
+}
@@ -0,0 +1,76 @@
+package scopes_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tructn/racket/internal/db/dbtest"
+	"github.com/tructn/racket/internal/domain"
+	"github.com/tructn/racket/pkg/scopes"
+	"gorm.io/gorm"
+)
+
+func TestTenantScopeIsolatesTenants(t *testing.T) {
+	conn := dbtest.Open(t)
+	tenantA := conn.WithContext(scopes.WithTenant(context.Background(), 1))
+	tenantB := conn.WithContext(scopes.WithTenant(context.Background(), 2))
+
+	start := time.Date(2030, 6, 5, 18, 0, 0, 0, time.UTC)
+	newMatch := func() *domain.Match {
+		return &domain.Match{
+			Start:         start,
+			End:           start.Add(time.Hour),
+			CourtBookings: []domain.CourtBooking{{Court: "1", Start: start, End: start.Add(time.Hour)}},
+		}
+	}
+	matchA, matchB := newMatch(), newMatch()
+	require.NoError(t, tenantA.Create(matchA).Error)
+	require.NoError(t, tenantB.Create(matchB).Error)
+
+	var matches []domain.Match
+	require.NoError(t, tenantA.Preload("CourtBookings").Find(&matches).Error)
+	require.Len(t, matches, 1)
+	assert.Equal(t, matchA.ID, matches[0].ID)
+	assert.Len(t, matches[0].CourtBookings, 1)
+
+	var count int64
+	require.NoError(t, tenantA.Model(&domain.CourtBooking{}).Count(&count).Error)
+	assert.Equal(t, int64(1), count)
+
+	assert.ErrorIs(t, tenantA.First(&domain.Match{}, matchB.ID).Error, gorm.ErrRecordNotFound)
+
+	res := tenantA.Model(&domain.Match{}).Where("id = ?", matchB.ID).Update("court", "hijacked")
+	require.NoError(t, res.Error)
+	assert.Zero(t, res.RowsAffected)
+
+	res = tenantA.Delete(&domain.Match{}, matchB.ID)
+	require.NoError(t, res.Error)
+	assert.Zero(t, res.RowsAffected)
+	require.NoError(t, tenantB.First(&domain.Match{}, matchB.ID).Error)
+}
+
+func TestTenantScopeFailsWithoutTenant(t *testing.T) {
+	conn := dbtest.Open(t)
+	require.NoError(t, conn.WithContext(scopes.WithTenant(context.Background(), 1)).Create(&domain.Player{FirstName: "Anna"}).Error)
+	require.NoError(t, conn.WithContext(scopes.WithTenant(context.Background(), 2)).Create(&domain.Player{FirstName: "Ben"}).Error)
+
+	var players []domain.Player
+	assert.ErrorIs(t, conn.Find(&players).Error, scopes.ErrNoTenant)
+	assert.ErrorIs(t, conn.Model(&domain.Player{}).Where("first_name = ?", "Anna").Update("last_name", "Smith").Error, scopes.ErrNoTenant)
+	assert.ErrorIs(t, conn.Where("first_name = ?", "Anna").Delete(&domain.Player{}).Error, scopes.ErrNoTenant)
+
+	// Tenants themselves are not tenant entities
+	require.NoError(t, conn.Find(&[]domain.Tenant{}).Error)
+
+	allTenants := scopes.AllTenants(context.Background())
+	require.NoError(t, conn.WithContext(allTenants).Find(&players).Error)
+	assert.Len(t, players, 2)
+
+	// A tenant set on top of AllTenants still scopes the statement
+	require.NoError(t, conn.WithContext(scopes.WithTenant(allTenants, 2)).Find(&players).Error)
+	require.Len(t, players, 1)
+	assert.Equal(t, "Ben", players[0].FirstName)
+}